@@ -2,6 +2,7 @@ package main
 
 import (
 	"webook/internal/events"
+	"webook/internal/job"
 	"webook/pkg/saramax"
 
 	"github.com/gin-gonic/gin"
@@ -11,4 +12,5 @@ type App struct {
 	server       *gin.Engine
 	consumers    []events.Consumer
 	kafkaMonitor *saramax.MonitorMessage
+	scheduler    *job.Scheduler
 }
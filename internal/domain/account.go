@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// AccountExport 导出的个人数据
+type AccountExport struct {
+	User        User
+	Articles    []Article
+	Collections []CollectionItem
+}
+
+// CollectionItem 用户收藏的一个资源
+type CollectionItem struct {
+	Biz   string
+	BizId int64
+	// 收藏夹 ID
+	Cid   int64
+	Ctime time.Time
+}
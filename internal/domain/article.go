@@ -1,21 +1,60 @@
 package domain
 
-import "time"
+import (
+	"time"
+	"webook/pkg/markdown"
+)
 
 // article
 type Article struct {
 	Id      int64
 	Title   string
 	Content string
+	Format  ArticleFormat
+	// HTML 和 TOC 是发表的时候从 Markdown 渲染出来的，只有线上库有
+	HTML string
+	TOC  []TOCItem
+	// Tags 导入的时候从 front-matter 带过来的标签，只有制作库有
+	Tags []string
+	// Version 乐观锁，每次手动保存或者发表加一，自动保存不变
+	Version int64
 	LikeCnt int64
 	Author  Author
 	Status  ArticleStatus
 	Ctime   time.Time
 	Utime   time.Time
+	// Dtime 移到回收站的时间，零值是没有删除
+	Dtime time.Time
+}
+
+// ArticleCursor 作者文章列表的翻页位置，是上一页最后一篇的 (Utime, Id)。
+// 零值表示第一页
+type ArticleCursor struct {
+	Utime time.Time
+	Id    int64
+}
+
+func (c ArticleCursor) IsZero() bool {
+	return c.Id == 0
 }
 
+// Cursor 从这一篇往后翻页
+func (a Article) Cursor() ArticleCursor {
+	return ArticleCursor{Utime: a.Utime, Id: a.Id}
+}
+
+// Trashed 在回收站里面
+func (a Article) Trashed() bool {
+	return !a.Dtime.IsZero()
+}
+
+// Abstract Markdown 的摘要从去掉标记之后的纯文本里面取
 func (a Article) Abstract() string {
-	str := []rune(a.Content)
+	text := a.Content
+	if a.Format == ArticleFormatMarkdown {
+		text = markdown.PlainText(text)
+	}
+	str := []rune(text)
 	// 只取部分作为摘要
 	if len(str) > 128 {
 		str = str[:128]
@@ -38,9 +77,37 @@ const (
 	ArticleStatusPublished
 	// ArticleStatusPrivate 仅自己可见
 	ArticleStatusPrivate
+	// ArticleStatusPendingReview 发表的时候机审拿不准，等人工审核，只有自己可见
+	ArticleStatusPendingReview
+	// ArticleStatusRejected 人工审核没有通过，只有自己可见
+	ArticleStatusRejected
 )
 
 type Author struct {
 	Id   int64
 	Name string
 }
+
+// ArticleFormat 文章内容的格式
+type ArticleFormat uint8
+
+func (f ArticleFormat) ToUint8() uint8 {
+	return uint8(f)
+}
+
+func (f ArticleFormat) Valid() bool {
+	return f <= ArticleFormatMarkdown
+}
+
+const (
+	// ArticleFormatPlain 纯文本，原样展示
+	ArticleFormatPlain ArticleFormat = iota
+	ArticleFormatMarkdown
+)
+
+// TOCItem 目录里面的一项，Anchor 是渲染出来的 HTML 里面标题的 id
+type TOCItem struct {
+	Level  int
+	Title  string
+	Anchor string
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// ArticleImportJobStatus 导入任务的状态
+type ArticleImportJobStatus uint8
+
+const (
+	ArticleImportJobStatusUnknown ArticleImportJobStatus = iota
+	ArticleImportJobStatusRunning
+	ArticleImportJobStatusDone
+)
+
+// ArticleImportJob 从 ZIP 批量导入 Markdown 文章的后台任务
+type ArticleImportJob struct {
+	Id     int64
+	Uid    int64
+	Status ArticleImportJobStatus
+	// Total ZIP 里面 Markdown 文件的数量
+	Total int
+	// Files 已经处理完的文件，按照处理的顺序
+	Files []ArticleImportFile
+	Ctime time.Time
+	Utime time.Time
+}
+
+// Count 处于 status 状态的文件数量
+func (j ArticleImportJob) Count(status ArticleImportFileStatus) int {
+	cnt := 0
+	for _, f := range j.Files {
+		if f.Status == status {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+type ArticleImportFileStatus uint8
+
+const (
+	ArticleImportFileStatusUnknown ArticleImportFileStatus = iota
+	// ArticleImportFileStatusCreated 创建了草稿
+	ArticleImportFileStatusCreated
+	// ArticleImportFileStatusSkipped 同样内容的文件之前已经导入过了
+	ArticleImportFileStatusSkipped
+	ArticleImportFileStatusFailed
+)
+
+// ArticleImportFile 一个文件的导入结果
+type ArticleImportFile struct {
+	Name   string
+	Status ArticleImportFileStatus
+	// ArticleId 创建的草稿，跳过的时候是之前导入的那一篇
+	ArticleId int64
+	// Error 失败的原因，给作者看的
+	Error string
+}
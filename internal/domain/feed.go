@@ -0,0 +1,9 @@
+package domain
+
+// FeedFormat 订阅源的格式
+type FeedFormat string
+
+const (
+	FeedFormatRSS  FeedFormat = "rss"
+	FeedFormatAtom FeedFormat = "atom"
+)
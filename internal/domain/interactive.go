@@ -1,9 +1,27 @@
 package domain
 
+import "time"
+
+// 支持点赞、收藏、阅读的资源类型，对应互动数据里面的 biz
+const (
+	BizArticle = "article"
+	BizSeries  = "series"
+)
+
 type Interactive struct {
 	ReadCnt    int64
 	LikeCnt    int64
 	CollectCnt int64
-	Liked      bool
-	Collected  bool
+	// RewardCnt 被打赏的次数
+	RewardCnt int64
+	Liked     bool
+	Collected bool
+}
+
+// LikeItem 用户点赞过的一个资源
+type LikeItem struct {
+	Biz   string
+	BizId int64
+	// 点赞的时间
+	Ctime time.Time
 }
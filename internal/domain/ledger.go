@@ -0,0 +1,73 @@
+package domain
+
+import "time"
+
+// LedgerAccountType 积分账户的类型
+type LedgerAccountType uint8
+
+func (t LedgerAccountType) ToUint8() uint8 {
+	return uint8(t)
+}
+
+const (
+	LedgerAccountTypeUnknown = iota
+	// LedgerAccountTypeUser 用户的积分账户，余额不能是负数
+	LedgerAccountTypeUser
+	// LedgerAccountTypeTopUp 充值的来源，只有一个，Uid 是 0。
+	// 余额是负的，绝对值等于所有用户充值的总额
+	LedgerAccountTypeTopUp
+)
+
+// LedgerAccount 用类型和用户确定一个账户
+type LedgerAccount struct {
+	Type LedgerAccountType
+	Uid  int64
+}
+
+// UserLedgerAccount 用户自己的积分账户
+func UserLedgerAccount(uid int64) LedgerAccount {
+	return LedgerAccount{Type: LedgerAccountTypeUser, Uid: uid}
+}
+
+// LedgerTxnType 交易的类型
+type LedgerTxnType uint8
+
+func (t LedgerTxnType) ToUint8() uint8 {
+	return uint8(t)
+}
+
+const (
+	LedgerTxnTypeUnknown = iota
+	// LedgerTxnTypeTopUp 充值，从充值账户转到用户账户
+	LedgerTxnTypeTopUp
+	// LedgerTxnTypeReward 打赏，从读者转给作者
+	LedgerTxnTypeReward
+)
+
+// LedgerTxn 一笔交易，复式记账，From 减少多少 To 就增加多少
+type LedgerTxn struct {
+	Id int64
+	// Key 幂等键，同一个 Key 的交易只会记一次
+	Key    string
+	Type   LedgerTxnType
+	From   LedgerAccount
+	To     LedgerAccount
+	Amount int64
+	// Biz 和 BizId 是打赏的资源，充值没有
+	Biz   string
+	BizId int64
+	// Ref 外部的单号，比如充值的时候支付网关的交易号
+	Ref   string
+	Ctime time.Time
+}
+
+// LedgerEntry 账户的一条流水
+type LedgerEntry struct {
+	TxnId int64
+	Type  LedgerTxnType
+	// Amount 入账是正数，出账是负数
+	Amount int64
+	// Balance 这一笔之后的余额
+	Balance int64
+	Ctime   time.Time
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"net"
+	"time"
+)
+
+// LoginMethod 登录方式，第三方登录直接用提供商的名字
+type LoginMethod string
+
+const (
+	LoginMethodPassword LoginMethod = "password"
+	LoginMethodSMS      LoginMethod = "sms"
+	LoginMethodWechat   LoginMethod = "wechat"
+	// LoginMethodStepUp 异常登录二次验证通过，原来的登录方式在前一条记录里面
+	LoginMethodStepUp LoginMethod = "step_up"
+	// LoginMethodTOTP 两步验证，只记录验证码不对的情况
+	LoginMethodTOTP LoginMethod = "totp"
+)
+
+type LoginResult uint8
+
+const (
+	LoginResultUnknown LoginResult = iota
+	LoginResultSuccess
+	// LoginResultFailed 凭证不对
+	LoginResultFailed
+	// LoginResultStepUp 凭证对了，但是风险比较高，要求二次验证
+	LoginResultStepUp
+	// LoginResultLocked 失败次数太多，暂时不允许登录
+	LoginResultLocked
+)
+
+func (r LoginResult) ToUint8() uint8 {
+	return uint8(r)
+}
+
+// LoginRisk 登录的风险评估结果
+type LoginRisk uint8
+
+const (
+	LoginRiskNone LoginRisk = iota
+	// LoginRiskStepUp 需要二次验证
+	LoginRiskStepUp
+	// LoginRiskLocked 暂时锁定
+	LoginRiskLocked
+)
+
+// LoginLog 一次登录尝试
+type LoginLog struct {
+	Id int64
+	// 失败的时候不一定知道是哪个用户
+	Uid int64
+	// 登录时候输入的账号，邮箱或者手机号，第三方登录是外部 ID
+	Account   string
+	Method    LoginMethod
+	IP        string
+	UserAgent string
+	Result    LoginResult
+	Ctime     time.Time
+}
+
+// IPRange IP 所在的网段，IPv4 取前 24 位，IPv6 取前 48 位
+func (l LoginLog) IPRange() string {
+	ip := net.ParseIP(l.IP)
+	if ip == nil {
+		return l.IP
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,10 @@
+package domain
+
+// Media 上传的图片，按照内容寻址，同样的内容 Key 一定相同
+type Media struct {
+	Key         string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+// ModerationDecision 内容审核的结论
+type ModerationDecision uint8
+
+const (
+	ModerationApprove ModerationDecision = iota
+	// ModerationReview 机审拿不准，转人工审核
+	ModerationReview
+	ModerationReject
+)
+
+// ModerationResult 审核结果，Reasons 是给作者和审核员看的原因
+type ModerationResult struct {
+	Decision ModerationDecision
+	Reasons  []string
+}
+
+type ArticleReviewStatus uint8
+
+func (s ArticleReviewStatus) ToUint8() uint8 {
+	return uint8(s)
+}
+
+const (
+	ArticleReviewStatusUnknown ArticleReviewStatus = iota
+	ArticleReviewStatusPending
+	ArticleReviewStatusApproved
+	ArticleReviewStatusRejected
+	// ArticleReviewStatusCanceled 还没审核，作者就重新发表或者撤回了
+	ArticleReviewStatusCanceled
+)
+
+// ArticleReview 人工审核队列里面的一条任务
+type ArticleReview struct {
+	Id       int64
+	Aid      int64
+	AuthorId int64
+	Title    string
+	// 提交审核的内容，作者之后再修改草稿也不影响审核的版本
+	Content string
+	Format  ArticleFormat
+	// 机审转人工的原因
+	Reasons []string
+	Status  ArticleReviewStatus
+	// 审核员的 uid 和意见
+	Reviewer int64
+	Comment  string
+	Ctime    time.Time
+	Utime    time.Time
+}
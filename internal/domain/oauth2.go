@@ -0,0 +1,13 @@
+package domain
+
+// OAuth2Info 从第三方登录拿到的用户信息
+type OAuth2Info struct {
+	// 第三方的名字，比如 github
+	Provider string
+	// 用户在第三方的唯一标识，OIDC 里面就是 sub
+	ExternalId string
+	Email      string
+	// 第三方确认过邮箱是用户本人的
+	EmailVerified bool
+	Nickname      string
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// PrivacyScope 某件事允许谁来做，零值是所有人，没有设置过的用户就是全部公开
+type PrivacyScope uint8
+
+const (
+	PrivacyScopeEveryone PrivacyScope = iota
+	// PrivacyScopeNobody 只有自己
+	PrivacyScopeNobody
+)
+
+func (s PrivacyScope) ToUint8() uint8 {
+	return uint8(s)
+}
+
+func (s PrivacyScope) Valid() bool {
+	return s <= PrivacyScopeNobody
+}
+
+// PrivacySettings 用户的隐私设置
+type PrivacySettings struct {
+	Uid int64
+	// 谁能看我点赞过的内容
+	Likes PrivacyScope
+	// 谁能看我的收藏
+	Collections PrivacyScope
+}
+
+// PrivacyAction 别人对用户做的事情，用来判断有没有权限
+type PrivacyAction uint8
+
+const (
+	// PrivacyActionView 看用户的主页和文章，只受拉黑影响
+	PrivacyActionView PrivacyAction = iota
+	// PrivacyActionInteract 点赞收藏用户的文章，只受拉黑影响
+	PrivacyActionInteract
+	// PrivacyActionViewLikes 看用户点赞过的内容
+	PrivacyActionViewLikes
+	// PrivacyActionViewCollections 看用户的收藏
+	PrivacyActionViewCollections
+)
+
+// Scope 这件事情对应的设置，不受设置控制的返回所有人
+func (s PrivacySettings) Scope(action PrivacyAction) PrivacyScope {
+	switch action {
+	case PrivacyActionViewLikes:
+		return s.Likes
+	case PrivacyActionViewCollections:
+		return s.Collections
+	default:
+		return PrivacyScopeEveryone
+	}
+}
+
+// Block Uid 拉黑了 BlockedUid
+type Block struct {
+	Uid        int64
+	BlockedUid int64
+	Ctime      time.Time
+}
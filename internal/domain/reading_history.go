@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// ReadingProgressUnknown 只是打开了文章，还没有上报阅读进度
+const ReadingProgressUnknown = -1
+
+// ReadingHistory 用户读过的文章，同一篇只保留最后一次
+type ReadingHistory struct {
+	Uid int64
+	Aid int64
+	// Progress 滚动到的位置，0 到 1，小于 0 的时候是 ReadingProgressUnknown
+	Progress float64
+	// ProgressTime 上报进度的时间，没有进度的时候是零值
+	ProgressTime time.Time
+	// Utime 最后一次阅读的时间
+	Utime time.Time
+	// Article 列表的时候才有，文章已经看不到了的时候只有 Id
+	Article Article
+}
+
+// Cursor 从这一条往后翻页
+func (h ReadingHistory) Cursor() ArticleCursor {
+	return ArticleCursor{Utime: h.Utime, Id: h.Aid}
+}
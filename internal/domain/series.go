@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// Series 系列，作者把多篇文章按照顺序组织起来，例如分成好几篇的教程。
+// 一篇文章最多属于一个系列
+type Series struct {
+	Id          int64
+	AuthorId    int64
+	Title       string
+	Description string
+	// ArticleIds 按照阅读顺序排列
+	ArticleIds []int64
+	Ctime      time.Time
+	Utime      time.Time
+}
+
+// SeriesNav 文章在系列里面的上一篇和下一篇，只算已经发表的文章。
+// 没有上一篇或者下一篇的时候 Id 是 0
+type SeriesNav struct {
+	Series Series
+	Prev   Article
+	Next   Article
+}
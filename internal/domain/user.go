@@ -1,15 +1,22 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type User struct {
-	Id       int64
-	Email    string
-	Password string
-	Nickname string
+	Id    int64
+	Email string
+	// 邮箱是否已经验证过
+	EmailVerified bool
+	Password      string
+	Nickname      string
 	// YYYY-MM-DD
 	Birthday time.Time
 	AboutMe  string
+	// 头像，是 Media 的 Key，访问地址要由 MediaService 签名
+	Avatar string
 
 	Phone string
 
@@ -17,4 +24,57 @@ type User struct {
 	Ctime time.Time
 
 	WechatInfo WechatInfo
+	// OAuth2Providers 绑定了第三方账号的提供商，微信不在这里面
+	OAuth2Providers []string
+
+	// 两步验证，TOTPSecret 不为空但是 TOTPEnabled 为 false 说明还在绑定中
+	TOTPSecret  string
+	TOTPEnabled bool
+}
+
+// Identity 用户的登录方式
+type Identity string
+
+const (
+	IdentityPhone  Identity = "phone"
+	IdentityEmail  Identity = "email"
+	IdentityWechat Identity = "wechat"
+	// identityOAuth2Prefix 第三方账号是 oauth2:github 这种形式
+	identityOAuth2Prefix = "oauth2:"
+)
+
+// OAuth2Identity 用提供商的名字构造登录方式
+func OAuth2Identity(provider string) Identity {
+	return Identity(identityOAuth2Prefix + provider)
+}
+
+// OAuth2Provider 第三方账号的登录方式返回提供商的名字
+func (i Identity) OAuth2Provider() (string, bool) {
+	provider, ok := strings.CutPrefix(string(i), identityOAuth2Prefix)
+	return provider, ok && provider != ""
+}
+
+// Identities 用户可以用来登录的方式，邮箱要设置了密码才能登录
+func (u User) Identities() []Identity {
+	var res []Identity
+	if u.Phone != "" {
+		res = append(res, IdentityPhone)
+	}
+	if u.Email != "" && u.Password != "" {
+		res = append(res, IdentityEmail)
+	}
+	if u.WechatInfo.OpenId != "" {
+		res = append(res, IdentityWechat)
+	}
+	for _, p := range u.OAuth2Providers {
+		res = append(res, OAuth2Identity(p))
+	}
+	return res
+}
+
+// TOTPEnrollment 开启两步验证的时候返回给用户的信息
+type TOTPEnrollment struct {
+	Secret string
+	// otpauth:// 格式，前端转成二维码
+	URI string
 }
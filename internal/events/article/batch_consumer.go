@@ -45,8 +45,12 @@ func (i *InteractiveReadEventBatchConsumer) Consume(msg []*sarama.ConsumerMessag
 	bizs := make([]string, 0, len(events))
 	bizIds := make([]int64, 0, len(events))
 	for _, evt := range events {
-		bizs = append(bizs, "article")
-		bizIds = append(bizIds, evt.Aid)
+		if evt.Progress != nil {
+			continue
+		}
+		biz, bizId := evt.Target()
+		bizs = append(bizs, biz)
+		bizIds = append(bizIds, bizId)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
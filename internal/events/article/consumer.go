@@ -61,7 +61,12 @@ func (i *InteractiveReadEventConsumer) Start() error {
 // 真正的消费逻辑
 func (i *InteractiveReadEventConsumer) Consume(msg *sarama.ConsumerMessage,
 	event ReadEvent) error {
+	if event.Progress != nil {
+		// 上报阅读进度不算阅读数
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
-	return i.repo.IncrReadCnt(ctx, "article", event.Aid)
+	biz, bizId := event.Target()
+	return i.repo.IncrReadCnt(ctx, biz, bizId)
 }
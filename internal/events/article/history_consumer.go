@@ -0,0 +1,98 @@
+package article
+
+import (
+	"context"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/pkg/logger"
+	"webook/pkg/saramax"
+
+	"github.com/IBM/sarama"
+)
+
+// ReadingHistoryConsumer 把阅读事件批量写成阅读历史
+type ReadingHistoryConsumer struct {
+	repo   repository.ReadingHistoryRepository
+	client sarama.Client
+	l      logger.Logger
+	group  string
+}
+
+func NewReadingHistoryConsumer(repo repository.ReadingHistoryRepository, client sarama.Client, l logger.Logger) *ReadingHistoryConsumer {
+	return &ReadingHistoryConsumer{repo: repo, client: client, l: l, group: "reading_history"}
+}
+
+func (r *ReadingHistoryConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient(r.group, r.client)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		er := cg.Consume(context.Background(), []string{TopicReadEvent}, saramax.NewBatchHandler[ReadEvent](r.l, r.Consume))
+		if er != nil {
+			r.l.Error("退出消费", logger.Error(er))
+		}
+	}()
+	return nil
+}
+
+func (r *ReadingHistoryConsumer) Consume(msgs []*sarama.ConsumerMessage, events []ReadEvent) error {
+	hs := mergeReadEvents(msgs, events)
+	if len(hs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	return r.repo.Upsert(ctx, hs)
+}
+
+// mergeReadEvents 同一个人同一篇文章在一个批次里面只留一条，
+// 阅读时间取最晚的，进度取最晚上报的那一次
+func mergeReadEvents(msgs []*sarama.ConsumerMessage, events []ReadEvent) []domain.ReadingHistory {
+	type key struct {
+		uid int64
+		aid int64
+	}
+	// 反序列化失败的消息不在 events 里面，两边下标对不上，
+	// 所以只有长度一样的时候才用消息的时间兜底
+	useMsgTime := len(msgs) == len(events)
+	idx := make(map[key]int, len(events))
+	res := make([]domain.ReadingHistory, 0, len(events))
+	for i, evt := range events {
+		// 没登录的读者没有阅读历史，阅读历史也只记文章
+		biz, aid := evt.Target()
+		if evt.Uid <= 0 || biz != domain.BizArticle || aid <= 0 {
+			continue
+		}
+		ts := evt.Time
+		if ts == 0 && useMsgTime {
+			ts = msgs[i].Timestamp.UnixMilli()
+		}
+		if ts <= 0 {
+			ts = time.Now().UnixMilli()
+		}
+		k := key{uid: evt.Uid, aid: aid}
+		j, ok := idx[k]
+		if !ok {
+			j = len(res)
+			idx[k] = j
+			res = append(res, domain.ReadingHistory{
+				Uid:      evt.Uid,
+				Aid:      aid,
+				Progress: domain.ReadingProgressUnknown,
+			})
+		}
+		t := time.UnixMilli(ts)
+		if t.After(res[j].Utime) {
+			res[j].Utime = t
+		}
+		// 进度对应的时间和阅读时间分开算，写库的时候也按照进度的时间比较
+		if evt.Progress != nil && !t.Before(res[j].ProgressTime) {
+			res[j].Progress = *evt.Progress
+			res[j].ProgressTime = t
+		}
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package article
+
+import (
+	"testing"
+	"time"
+	"webook/internal/domain"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeReadEvents(t *testing.T) {
+	progress := func(p float64) *float64 {
+		return &p
+	}
+	msgTime := time.UnixMilli(5000)
+	testCases := []struct {
+		name   string
+		events []ReadEvent
+
+		want []domain.ReadingHistory
+	}{
+		{
+			name: "同一篇文章合并，进度取最晚上报的",
+			events: []ReadEvent{
+				{Uid: 1, Aid: 10, Time: 1000},
+				{Uid: 1, Aid: 10, Time: 3000, Progress: progress(0.8)},
+				// 乱序到达的旧进度不能覆盖新的
+				{Uid: 1, Aid: 10, Time: 2000, Progress: progress(0.3)},
+				{Uid: 1, Aid: 11, Time: 1500},
+			},
+			want: []domain.ReadingHistory{
+				{Uid: 1, Aid: 10, Progress: 0.8,
+					ProgressTime: time.UnixMilli(3000), Utime: time.UnixMilli(3000)},
+				{Uid: 1, Aid: 11, Progress: domain.ReadingProgressUnknown, Utime: time.UnixMilli(1500)},
+			},
+		},
+		{
+			name: "进度的时间和阅读时间分开",
+			events: []ReadEvent{
+				{Uid: 1, Aid: 10, Time: 2000, Progress: progress(0.3)},
+				{Uid: 1, Aid: 10, Time: 4000},
+			},
+			want: []domain.ReadingHistory{
+				{Uid: 1, Aid: 10, Progress: 0.3,
+					ProgressTime: time.UnixMilli(2000), Utime: time.UnixMilli(4000)},
+			},
+		},
+		{
+			name: "没有登录的和不是文章的跳过",
+			events: []ReadEvent{
+				{Uid: 0, Aid: 10, Time: 1000},
+				{Uid: 2, Biz: domain.BizSeries, BizId: 5, Time: 1000},
+				{Uid: 2, Aid: 10, Time: 1000},
+			},
+			want: []domain.ReadingHistory{
+				{Uid: 2, Aid: 10, Progress: domain.ReadingProgressUnknown, Utime: time.UnixMilli(1000)},
+			},
+		},
+		{
+			name: "老版本的消息用消息的时间",
+			events: []ReadEvent{
+				{Uid: 1, Aid: 10},
+			},
+			want: []domain.ReadingHistory{
+				{Uid: 1, Aid: 10, Progress: domain.ReadingProgressUnknown, Utime: msgTime},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msgs := make([]*sarama.ConsumerMessage, len(tc.events))
+			for i := range msgs {
+				msgs[i] = &sarama.ConsumerMessage{Timestamp: msgTime}
+			}
+			assert.Equal(t, tc.want, mergeReadEvents(msgs, tc.events))
+		})
+	}
+}
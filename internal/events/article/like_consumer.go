@@ -63,5 +63,6 @@ func (i *InteractiveLikeEventConsumer) Consume(msg *sarama.ConsumerMessage,
 	event LikeEvent) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
-	return i.repo.IncrLike(ctx, "article", event.Aid, event.Uid)
+	biz, bizId := event.Target()
+	return i.repo.IncrLike(ctx, biz, bizId, event.Uid)
 }
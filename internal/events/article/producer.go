@@ -3,11 +3,18 @@ package article
 import (
 	"encoding/json"
 	"github.com/IBM/sarama"
+	"webook/internal/domain"
 )
 
+// 名字是历史原因，现在所有类型的资源都走这几个 topic
 const TopicReadEvent = "article_read"
 const TopicLikeEvent = "article_like"
 
+// TopicUnLikeEvent 取消点赞以前也发到 article_like，消息内容和点赞一样，分不出来。
+// 升级的时候先停掉老版本，等 interactive 消费组在 article_like 上没有积压了再部署新版本。
+// 没消费完的老消息会被当成点赞，点赞是幂等的，计数和点赞状态还是一致的
+const TopicUnLikeEvent = "article_unlike"
+
 type Producer interface {
 	ProducerReadEvent(evt ReadEvent) error
 	ProducerLikeEvent(evt LikeEvent) error
@@ -15,18 +22,50 @@ type Producer interface {
 }
 
 type ReadEvent struct {
-	Aid int64
-	Uid int64
+	// Aid 老版本的消息只有文章，新的消息用 Biz 和 BizId
+	Aid   int64
+	Biz   string `json:",omitempty"`
+	BizId int64  `json:",omitempty"`
+	Uid   int64
+	// Progress 读者上报的阅读进度，0 到 1。
+	// 只有上报进度的事件才有，这种事件不算阅读数
+	Progress *float64 `json:",omitempty"`
+	// Time 阅读的时间，毫秒数。老版本的消息没有
+	Time int64 `json:",omitempty"`
 }
 
 type LikeEvent struct {
-	Aid int64
-	Uid int64
+	Aid   int64
+	Biz   string `json:",omitempty"`
+	BizId int64  `json:",omitempty"`
+	Uid   int64
 }
 
 type UnLikeEvent struct {
-	Aid int64
-	Uid int64
+	Aid   int64
+	Biz   string `json:",omitempty"`
+	BizId int64  `json:",omitempty"`
+	Uid   int64
+}
+
+func (e ReadEvent) Target() (string, int64) {
+	return target(e.Biz, e.BizId, e.Aid)
+}
+
+func (e LikeEvent) Target() (string, int64) {
+	return target(e.Biz, e.BizId, e.Aid)
+}
+
+func (e UnLikeEvent) Target() (string, int64) {
+	return target(e.Biz, e.BizId, e.Aid)
+}
+
+// target 没有 Biz 的是老版本的消息，都是文章
+func target(biz string, bizId, aid int64) (string, int64) {
+	if biz == "" {
+		return domain.BizArticle, aid
+	}
+	return biz, bizId
 }
 
 type SaramaSyncProducer struct {
@@ -75,7 +114,7 @@ func (s *SaramaSyncProducer) ProducerUnLikeEvent(evt UnLikeEvent) error {
 
 	// 发送消息
 	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
-		Topic: TopicLikeEvent,
+		Topic: TopicUnLikeEvent,
 		Value: sarama.StringEncoder(val),
 	})
 
@@ -48,7 +48,7 @@ func (i *InteractiveUnLikeEventConsumer) Start() error {
 
 	// 异步执行消费逻辑
 	go func() {
-		er := cg.Consume(context.Background(), []string{TopicLikeEvent}, saramax.NewHandler[UnLikeEvent](i.l, i.Consume, opts, "article_unlike_event"))
+		er := cg.Consume(context.Background(), []string{TopicUnLikeEvent}, saramax.NewHandler[UnLikeEvent](i.l, i.Consume, opts, "article_unlike_event"))
 
 		if er != nil {
 			i.l.Error("退出消费", logger.Error(er))
@@ -62,5 +62,6 @@ func (i *InteractiveUnLikeEventConsumer) Consume(msg *sarama.ConsumerMessage,
 	event UnLikeEvent) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
-	return i.repo.DecrLike(ctx, "article", event.Aid, event.Uid)
+	biz, bizId := event.Target()
+	return i.repo.DecrLike(ctx, biz, bizId, event.Uid)
 }
@@ -0,0 +1,45 @@
+package job
+
+import (
+	"context"
+	"time"
+	"webook/internal/service"
+	"webook/pkg/logger"
+)
+
+// FlushArticleAutosaveJob 把缓冲在 Redis 里面的自动保存内容刷到数据库
+type FlushArticleAutosaveJob struct {
+	svc service.ArticleService
+	l   logger.Logger
+	// 每一批刷新的数量
+	batchSize int
+}
+
+func NewFlushArticleAutosaveJob(svc service.ArticleService, l logger.Logger) *FlushArticleAutosaveJob {
+	return &FlushArticleAutosaveJob{
+		svc:       svc,
+		l:         l,
+		batchSize: 100,
+	}
+}
+
+func (j *FlushArticleAutosaveJob) Name() string {
+	return "flush_article_autosave"
+}
+
+func (j *FlushArticleAutosaveJob) Run(ctx context.Context) error {
+	// 执行过程中新写进来的留给下一次
+	before := time.Now()
+	for {
+		cnt, err := j.svc.FlushAutosave(ctx, before, j.batchSize)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			j.l.Debug("刷新自动保存的文章", logger.Int64("cnt", int64(cnt)))
+		}
+		if cnt < j.batchSize {
+			return nil
+		}
+	}
+}
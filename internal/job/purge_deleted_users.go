@@ -0,0 +1,47 @@
+package job
+
+import (
+	"context"
+	"time"
+	"webook/internal/service"
+	"webook/pkg/logger"
+)
+
+// PurgeDeletedUsersJob 彻底删除过了冷静期的注销账号
+type PurgeDeletedUsersJob struct {
+	svc service.AccountService
+	l   logger.Logger
+	// 注销之后多久彻底删除
+	gracePeriod time.Duration
+	// 每一批删除的数量
+	batchSize int
+}
+
+func NewPurgeDeletedUsersJob(svc service.AccountService, l logger.Logger) *PurgeDeletedUsersJob {
+	return &PurgeDeletedUsersJob{
+		svc:         svc,
+		l:           l,
+		gracePeriod: time.Hour * 24 * 30,
+		batchSize:   100,
+	}
+}
+
+func (j *PurgeDeletedUsersJob) Name() string {
+	return "purge_deleted_users"
+}
+
+func (j *PurgeDeletedUsersJob) Run(ctx context.Context) error {
+	before := time.Now().Add(-j.gracePeriod)
+	for {
+		cnt, err := j.svc.PurgeDeleted(ctx, before, j.batchSize)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			j.l.Info("彻底删除注销账号", logger.Int64("cnt", int64(cnt)))
+		}
+		if cnt < j.batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	"time"
+	"webook/internal/service"
+	"webook/pkg/logger"
+)
+
+// PurgeTrashedArticlesJob 彻底删除在回收站里面过期了的文章
+type PurgeTrashedArticlesJob struct {
+	svc service.ArticleService
+	l   logger.Logger
+	// 每一批删除的数量
+	batchSize int
+}
+
+func NewPurgeTrashedArticlesJob(svc service.ArticleService, l logger.Logger) *PurgeTrashedArticlesJob {
+	return &PurgeTrashedArticlesJob{
+		svc:       svc,
+		l:         l,
+		batchSize: 100,
+	}
+}
+
+func (j *PurgeTrashedArticlesJob) Name() string {
+	return "purge_trashed_articles"
+}
+
+func (j *PurgeTrashedArticlesJob) Run(ctx context.Context) error {
+	before := time.Now().Add(-service.ArticleTrashRetention)
+	for {
+		cnt, err := j.svc.PurgeTrash(ctx, before, j.batchSize)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			j.l.Info("彻底删除回收站里面的文章", logger.Int64("cnt", int64(cnt)))
+		}
+		if cnt < j.batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+	"webook/pkg/logger"
+)
+
+// Scheduler 简单的本地调度器，每个任务一个 goroutine，按照固定的间隔执行
+type Scheduler struct {
+	l       logger.Logger
+	entries []entry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type entry struct {
+	job      Job
+	interval time.Duration
+	// 每一次执行的超时时间
+	timeout time.Duration
+}
+
+func NewScheduler(l logger.Logger) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		l:      l,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register 要在 Start 之前调用
+func (s *Scheduler) Register(j Job, interval, timeout time.Duration) {
+	s.entries = append(s.entries, entry{
+		job:      j,
+		interval: interval,
+		timeout:  timeout,
+	})
+}
+
+func (s *Scheduler) Start() {
+	for _, e := range s.entries {
+		s.wg.Add(1)
+		go func(e entry) {
+			defer s.wg.Done()
+			s.loop(e)
+		}(e)
+	}
+}
+
+// Stop 等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(e entry) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(s.ctx, e.timeout)
+			err := e.job.Run(ctx)
+			cancel()
+			if err != nil {
+				s.l.Error("执行定时任务失败",
+					logger.String("job", e.job.Name()),
+					logger.Error(err))
+			}
+		}
+	}
+}
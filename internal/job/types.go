@@ -0,0 +1,9 @@
+package job
+
+import "context"
+
+// Job 定时任务，同一个任务可能在多个实例上同时执行，所以实现要保证幂等
+type Job interface {
+	Name() string
+	Run(ctx context.Context) error
+}
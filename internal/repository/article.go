@@ -2,21 +2,71 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/ecodeclub/ekit/slice"
+	"slices"
 	"time"
 	"webook/internal/domain"
 	"webook/internal/repository/cache"
 	"webook/internal/repository/dao"
 )
 
+var (
+	ErrArticleConflict = dao.ErrArticleVersionConflict
+	ErrArticleNotFound = dao.ErrArticleNotFound
+)
+
 type ArticleRepository interface {
 	Create(ctx context.Context, art domain.Article) (int64, error)
+	// Update 版本号不对的时候返回 ErrArticleConflict
 	Update(ctx context.Context, art domain.Article) error
 	Sync(ctx context.Context, art domain.Article) (int64, error)
+	// SyncPub 发表 art 的内容，草稿只修改状态，内容和版本号不变
+	SyncPub(ctx context.Context, art domain.Article) error
 	SyncStatus(ctx context.Context, uid int64, id int64, status domain.ArticleStatus) error
-	GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	// UpdateStatus 只修改制作库的状态，已经发表的版本不受影响
+	UpdateStatus(ctx context.Context, uid int64, id int64, status domain.ArticleStatus) error
+	// GetByAuthor 作者的文章列表，按照更新时间倒序。
+	// 第一页会走缓存，缓存里面的 Content 只有摘要
+	GetByAuthor(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
+	// ScanByAuthor 和 GetByAuthor 一样翻页，但是不走缓存，导出数据这种要完整内容的时候用
+	ScanByAuthor(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
 	GetById(ctx context.Context, id int64) (domain.Article, error)
 	GetPubById(ctx context.Context, id int64) (domain.Article, error)
+	// GetPubByAuthor 分页查询作者已经发表的文章，不包括仅自己可见的
+	GetPubByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	// CountPubByAuthor 作者已经发表的文章数量
+	CountPubByAuthor(ctx context.Context, uid int64) (int64, error)
+	// GetPubLatest 全站最新发表的文章，按照更新时间倒序
+	GetPubLatest(ctx context.Context, limit int) ([]domain.Article, error)
+	// ScanPub 按照 ID 顺序分页遍历全站已经发表的文章，只有 Id、Author.Id 和 Utime
+	ScanPub(ctx context.Context, offset int, limit int) ([]domain.Article, error)
+	// CountPub 全站已经发表的文章数量
+	CountPub(ctx context.Context) (int64, error)
+	TransferAuthor(ctx context.Context, from, to int64) error
+	// SyncStatusByAuthor 修改作者所有文章的状态
+	SyncStatusByAuthor(ctx context.Context, uid int64, status domain.ArticleStatus) error
+	// DeleteByAuthor 彻底删除作者所有的文章，返回被删除的文章 ID
+	DeleteByAuthor(ctx context.Context, uid int64) ([]int64, error)
+
+	// Trash 移到回收站，文章不存在或者已经在回收站里面返回 ErrArticleNotFound
+	Trash(ctx context.Context, uid int64, id int64) error
+	// Restore 恢复 since 之后移到回收站的文章，过期了返回 ErrArticleNotFound
+	Restore(ctx context.Context, uid int64, id int64, since time.Time, status domain.ArticleStatus) error
+	GetTrashByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	FindTrashedBefore(ctx context.Context, before time.Time, limit int) ([]int64, error)
+	DeleteTrashed(ctx context.Context, ids []int64) error
+
+	// Autosave 写到缓冲区，由 FlushAutosave 写回数据库
+	Autosave(ctx context.Context, art domain.Article) error
+	// GetAutosave 还没有写回数据库的自动保存内容
+	GetAutosave(ctx context.Context, id int64) (domain.Article, error)
+	// AutosaveIds 在 before 之前自动保存过、还没有写回数据库的文章
+	AutosaveIds(ctx context.Context, before time.Time, limit int) ([]int64, error)
+	// FlushAutosave 把一篇文章的自动保存内容写回数据库，不改变版本号。
+	// 版本冲突或者文章不存在的时候缓冲区直接丢掉，同时返回错误
+	FlushAutosave(ctx context.Context, id int64) error
 }
 
 type CachedArticleRepository struct {
@@ -24,7 +74,10 @@ type CachedArticleRepository struct {
 
 	userRepo UserRepository
 
-	cache cache.ArticleCache
+	cache         cache.ArticleCache
+	autosaveCache cache.ArticleAutosaveCache
+	// feedCache 线上库有变化的时候要让订阅源和 sitemap 失效
+	feedCache cache.FeedCache
 }
 
 func (c *CachedArticleRepository) GetPubById(ctx context.Context, id int64) (domain.Article, error) {
@@ -56,6 +109,44 @@ func (c *CachedArticleRepository) GetPubById(ctx context.Context, id int64) (dom
 	return res, nil
 }
 
+func (c *CachedArticleRepository) GetPubByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error) {
+	arts, err := c.dao.GetPubByAuthor(ctx, uid, uint8(domain.ArticleStatusPublished), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return c.pubToDomain(arts), nil
+}
+
+func (c *CachedArticleRepository) CountPubByAuthor(ctx context.Context, uid int64) (int64, error) {
+	return c.dao.CountPubByAuthor(ctx, uid, uint8(domain.ArticleStatusPublished))
+}
+
+func (c *CachedArticleRepository) GetPubLatest(ctx context.Context, limit int) ([]domain.Article, error) {
+	arts, err := c.dao.GetPubLatest(ctx, uint8(domain.ArticleStatusPublished), limit)
+	if err != nil {
+		return nil, err
+	}
+	return c.pubToDomain(arts), nil
+}
+
+func (c *CachedArticleRepository) ScanPub(ctx context.Context, offset int, limit int) ([]domain.Article, error) {
+	arts, err := c.dao.ScanPub(ctx, uint8(domain.ArticleStatusPublished), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return c.pubToDomain(arts), nil
+}
+
+func (c *CachedArticleRepository) CountPub(ctx context.Context) (int64, error) {
+	return c.dao.CountPub(ctx, uint8(domain.ArticleStatusPublished))
+}
+
+func (c *CachedArticleRepository) pubToDomain(arts []dao.PublishedArticle) []domain.Article {
+	return slice.Map[dao.PublishedArticle, domain.Article](arts, func(idx int, src dao.PublishedArticle) domain.Article {
+		return c.toDomain(dao.Article(src))
+	})
+}
+
 func (c *CachedArticleRepository) GetById(ctx context.Context, id int64) (domain.Article, error) {
 	res, err := c.cache.Get(ctx, id)
 	if err == nil {
@@ -75,39 +166,37 @@ func (c *CachedArticleRepository) GetById(ctx context.Context, id int64) (domain
 	return res, nil
 }
 
-func (c *CachedArticleRepository) GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error) {
+// firstPageSize 缓存作者列表的前这么多篇，第一页只要不超过这个数都可以走缓存
+const firstPageSize = 100
+
+func (c *CachedArticleRepository) GetByAuthor(ctx context.Context, uid int64,
+	cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
 	// 首先第一步要判断查不查缓存
-	// 事实上， limit <= 100 都可以查询缓存
-	if offset == 0 && limit == 100 {
-		//if offset == 0 && limit <= 100 {
-		res, err := c.cache.GetFirstPage(ctx, uid)
-		if err == nil {
-			return res, err
-		} else {
-			// 要考虑记录日志
-			// 缓存未命中，你是可以忽略的
-		}
+	if !cursor.IsZero() || limit > firstPageSize {
+		return c.ScanByAuthor(ctx, uid, cursor, limit)
 	}
-	arts, err := c.dao.GetByAuthor(ctx, uid, offset, limit)
+	res, err := c.cache.GetFirstPage(ctx, uid)
+	if err == nil {
+		// 缓存的是完整的前 firstPageSize 篇，少于 limit 说明作者一共就这么多
+		return res[:min(limit, len(res))], nil
+	}
+	// 缓存未命中，你是可以忽略的
+	// 不管要多少，都把完整的第一页查出来放进缓存
+	res, err = c.ScanByAuthor(ctx, uid, cursor, firstPageSize)
 	if err != nil {
 		return nil, err
 	}
-	res := slice.Map[dao.Article, domain.Article](arts, func(idx int, src dao.Article) domain.Article {
-		return c.toDomain(src)
-	})
 
+	// SetFirstPage 会把内容替换成摘要，不能和返回值共用
+	cached := slices.Clone(res)
 	go func() {
-
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
-
-		if offset == 0 && limit == 100 {
-			// 缓存回写失败，不一定是大问题，但有可能是大问题
-			err = c.cache.SetFirstPage(ctx, uid, res)
-			if err != nil {
-				// 记录日志
-				// 我需要监控这里
-			}
+		// 缓存回写失败，不一定是大问题，但有可能是大问题
+		err := c.cache.SetFirstPage(ctx, uid, cached)
+		if err != nil {
+			// 记录日志
+			// 我需要监控这里
 		}
 	}()
 
@@ -117,13 +206,32 @@ func (c *CachedArticleRepository) GetByAuthor(ctx context.Context, uid int64, of
 		c.preCache(ctx, res)
 	}()
 
-	return res, nil
+	return res[:min(limit, len(res))], nil
+}
+
+func (c *CachedArticleRepository) ScanByAuthor(ctx context.Context, uid int64,
+	cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
+	var utime int64
+	if !cursor.IsZero() {
+		utime = cursor.Utime.UnixMilli()
+	}
+	arts, err := c.dao.GetByAuthor(ctx, uid, utime, cursor.Id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.Article, domain.Article](arts, func(idx int, src dao.Article) domain.Article {
+		return c.toDomain(src)
+	}), nil
 }
 
 func (c *CachedArticleRepository) Sync(ctx context.Context, art domain.Article) (int64, error) {
 	id, err := c.dao.Sync(ctx, c.toEntity(art))
 	if err == nil {
-		er := c.cache.DelFirstPage(ctx, art.Author.Id)
+		er := c.delDraftCache(ctx, art.Author.Id, id)
+		if er != nil {
+			// 也要记录日志
+		}
+		er = c.feedCache.Invalidate(ctx, art.Author.Id)
 		if er != nil {
 			// 也要记录日志
 		}
@@ -155,7 +263,7 @@ func (c *CachedArticleRepository) Sync(ctx context.Context, art domain.Article)
 func (c *CachedArticleRepository) Update(ctx context.Context, art domain.Article) error {
 	err := c.dao.UpdateById(ctx, c.toEntity(art))
 	if err == nil {
-		er := c.cache.DelFirstPage(ctx, art.Author.Id)
+		er := c.delDraftCache(ctx, art.Author.Id, art.Id)
 		if er != nil {
 			// 也要记录日志
 		}
@@ -164,6 +272,65 @@ func (c *CachedArticleRepository) Update(ctx context.Context, art domain.Article
 
 }
 
+// delDraftCache 手动保存之后，列表、详情和自动保存的内容都过时了
+func (c *CachedArticleRepository) delDraftCache(ctx context.Context, uid int64, id int64) error {
+	err := c.cache.DelFirstPage(ctx, uid)
+	if err != nil {
+		return err
+	}
+	err = c.cache.Del(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.autosaveCache.Del(ctx, id)
+}
+
+func (c *CachedArticleRepository) Autosave(ctx context.Context, art domain.Article) error {
+	return c.autosaveCache.Set(ctx, art)
+}
+
+func (c *CachedArticleRepository) GetAutosave(ctx context.Context, id int64) (domain.Article, error) {
+	art, _, err := c.autosaveCache.Get(ctx, id)
+	return art, err
+}
+
+func (c *CachedArticleRepository) AutosaveIds(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	return c.autosaveCache.DirtyIds(ctx, before, limit)
+}
+
+func (c *CachedArticleRepository) FlushAutosave(ctx context.Context, id int64) error {
+	art, seq, err := c.autosaveCache.Get(ctx, id)
+	if err == cache.ErrKeyNotExist {
+		// 已经过期了，从待刷新的列表里面去掉
+		return c.autosaveCache.Del(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	err = c.dao.SaveDraft(ctx, c.toEntity(art))
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrArticleConflict), errors.Is(err, ErrArticleNotFound):
+		// 重试也没用，丢掉
+		er := c.autosaveCache.Clear(ctx, id, seq)
+		if er != nil {
+			return er
+		}
+		return err
+	default:
+		return err
+	}
+	err = c.autosaveCache.Clear(ctx, id, seq)
+	if err != nil {
+		return err
+	}
+	err = c.cache.Del(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.cache.DelFirstPage(ctx, art.Author.Id)
+}
+
 func (c *CachedArticleRepository) Create(ctx context.Context, art domain.Article) (int64, error) {
 	id, err := c.dao.Insert(ctx, c.toEntity(art))
 	if err == nil {
@@ -175,40 +342,204 @@ func (c *CachedArticleRepository) Create(ctx context.Context, art domain.Article
 	return id, err
 }
 
-func NewCachedArticleRepository(dao dao.ArticleDAO, userRepo UserRepository, cache cache.ArticleCache) ArticleRepository {
+func NewCachedArticleRepository(dao dao.ArticleDAO, userRepo UserRepository,
+	cache cache.ArticleCache, autosaveCache cache.ArticleAutosaveCache,
+	feedCache cache.FeedCache) ArticleRepository {
 	return &CachedArticleRepository{
-		dao:      dao,
-		userRepo: userRepo,
-		cache:    cache,
+		dao:           dao,
+		userRepo:      userRepo,
+		cache:         cache,
+		autosaveCache: autosaveCache,
+		feedCache:     feedCache,
 	}
 }
 
+func (c *CachedArticleRepository) TransferAuthor(ctx context.Context, from, to int64) error {
+	err := c.dao.TransferAuthor(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	// 两个人的列表都变了
+	er := c.cache.DelFirstPage(ctx, from)
+	if er != nil {
+		return er
+	}
+	er = c.cache.DelFirstPage(ctx, to)
+	if er != nil {
+		return er
+	}
+	return c.feedCache.Invalidate(ctx, from, to)
+}
+
+func (c *CachedArticleRepository) SyncStatusByAuthor(ctx context.Context, uid int64, status domain.ArticleStatus) error {
+	ids, err := c.dao.SyncStatusByAuthor(ctx, uid, status.ToUint8())
+	if err != nil {
+		return err
+	}
+	return c.delCache(ctx, uid, ids)
+}
+
+func (c *CachedArticleRepository) DeleteByAuthor(ctx context.Context, uid int64) ([]int64, error) {
+	ids, err := c.dao.DeleteByAuthor(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return ids, c.delCache(ctx, uid, ids)
+}
+
+func (c *CachedArticleRepository) Trash(ctx context.Context, uid int64, id int64) error {
+	err := c.dao.Trash(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	err = c.delDraftCache(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	err = c.cache.DelPub(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.feedCache.Invalidate(ctx, uid)
+}
+
+func (c *CachedArticleRepository) Restore(ctx context.Context, uid int64, id int64,
+	since time.Time, status domain.ArticleStatus) error {
+	err := c.dao.Restore(ctx, uid, id, since.UnixMilli(), status.ToUint8())
+	if err != nil {
+		return err
+	}
+	return c.delDraftCache(ctx, uid, id)
+}
+
+func (c *CachedArticleRepository) GetTrashByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error) {
+	arts, err := c.dao.GetTrashByAuthor(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.Article, domain.Article](arts, func(idx int, src dao.Article) domain.Article {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedArticleRepository) FindTrashedBefore(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	return c.dao.FindTrashedBefore(ctx, before.UnixMilli(), limit)
+}
+
+func (c *CachedArticleRepository) DeleteTrashed(ctx context.Context, ids []int64) error {
+	err := c.dao.DeleteTrashed(ctx, ids)
+	if err != nil {
+		return err
+	}
+	// 回收站里面的文章也可能被作者打开过
+	for _, id := range ids {
+		err = c.cache.Del(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delCache 作者的列表缓存、线上文章的缓存和订阅源都要删掉，不然还能被看到
+func (c *CachedArticleRepository) delCache(ctx context.Context, uid int64, ids []int64) error {
+	err := c.cache.DelFirstPage(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = c.cache.DelPub(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return c.feedCache.Invalidate(ctx, uid)
+}
+
+func (c *CachedArticleRepository) SyncPub(ctx context.Context, art domain.Article) error {
+	err := c.dao.SyncPub(ctx, c.toEntity(art))
+	if err != nil {
+		return err
+	}
+	// 自动保存的内容是作者自己的，不能删
+	err = c.cache.Del(ctx, art.Id)
+	if err != nil {
+		return err
+	}
+	return c.delCache(ctx, art.Author.Id, []int64{art.Id})
+}
+
 func (c *CachedArticleRepository) SyncStatus(ctx context.Context, uid int64, id int64, status domain.ArticleStatus) error {
 	err := c.dao.SyncStatus(ctx, uid, id, status.ToUint8())
-	if err == nil {
-		er := c.cache.DelFirstPage(ctx, uid)
-		if er != nil {
-			// 也要记录日志
-		}
+	if err != nil {
+		return err
 	}
-	return err
+	// 线上文章的缓存里面也有状态，审核通过或者撤回之后要删掉
+	return c.delCache(ctx, uid, []int64{id})
+}
+
+func (c *CachedArticleRepository) UpdateStatus(ctx context.Context, uid int64, id int64, status domain.ArticleStatus) error {
+	err := c.dao.UpdateStatus(ctx, uid, id, status.ToUint8())
+	if err != nil {
+		return err
+	}
+	err = c.cache.DelFirstPage(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return c.cache.Del(ctx, id)
 }
 
 func (c *CachedArticleRepository) toEntity(art domain.Article) dao.Article {
-	return dao.Article{
+	var toc string
+	if len(art.TOC) > 0 {
+		// 都是基本类型，不会出错
+		val, _ := json.Marshal(art.TOC)
+		toc = string(val)
+	}
+	var tags string
+	if len(art.Tags) > 0 {
+		val, _ := json.Marshal(art.Tags)
+		tags = string(val)
+	}
+	res := dao.Article{
 		Id:       art.Id,
 		Title:    art.Title,
 		Content:  art.Content,
+		Format:   art.Format.ToUint8(),
+		HTML:     art.HTML,
+		TOC:      toc,
+		Tags:     tags,
 		AuthorId: art.Author.Id,
+		Version:  art.Version,
 		Status:   art.Status.ToUint8(),
 	}
+	if !art.Ctime.IsZero() {
+		// 导入的文章保留原来的创建时间
+		res.Ctime = art.Ctime.UnixMilli()
+	}
+	return res
 }
 
 func (c *CachedArticleRepository) toDomain(art dao.Article) domain.Article {
-	return domain.Article{
+	var toc []domain.TOCItem
+	if art.TOC != "" {
+		// 坏数据就当没有目录
+		_ = json.Unmarshal([]byte(art.TOC), &toc)
+	}
+	var tags []string
+	if art.Tags != "" {
+		_ = json.Unmarshal([]byte(art.Tags), &tags)
+	}
+	res := domain.Article{
 		Id:      art.Id,
 		Title:   art.Title,
 		Content: art.Content,
+		Format:  domain.ArticleFormat(art.Format),
+		HTML:    art.HTML,
+		TOC:     toc,
+		Tags:    tags,
+		Version: art.Version,
 		Author: domain.Author{
 			Id: art.AuthorId,
 		},
@@ -216,6 +547,10 @@ func (c *CachedArticleRepository) toDomain(art dao.Article) domain.Article {
 		Utime:  time.UnixMilli(art.Utime),
 		Status: domain.ArticleStatus(art.Status),
 	}
+	if art.Dtime > 0 {
+		res.Dtime = time.UnixMilli(art.Dtime)
+	}
+	return res
 }
 
 func (c *CachedArticleRepository) preCache(ctx context.Context, arts []domain.Article) {
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/cache"
+	"webook/internal/repository/dao"
+)
+
+var (
+	ErrArticleImportJobNotFound = cache.ErrKeyNotExist
+	ErrArticleImportDuplicate   = dao.ErrArticleImportDuplicate
+)
+
+type ArticleImportRepository interface {
+	CreateJob(ctx context.Context, job domain.ArticleImportJob) (int64, error)
+	// GetJob 任务不存在或者已经过期了返回 ErrArticleImportJobNotFound
+	GetJob(ctx context.Context, id int64) (domain.ArticleImportJob, error)
+	AddFile(ctx context.Context, id int64, file domain.ArticleImportFile) error
+	SetJobStatus(ctx context.Context, id int64, status domain.ArticleImportJobStatus) error
+
+	// ClaimHash 占住作者的内容哈希，已经导入过了返回之前导入的文章 ID 和 ErrArticleImportDuplicate。
+	// staleBefore 之前占住但是一直没有确认的可以重新占住
+	ClaimHash(ctx context.Context, uid int64, hash string, staleBefore time.Time) (int64, error)
+	ConfirmHash(ctx context.Context, uid int64, hash string, aid int64) error
+	ReleaseHash(ctx context.Context, uid int64, hash string) error
+	// ReleaseArticles 文章彻底删除之后，同样内容的文件可以重新导入
+	ReleaseArticles(ctx context.Context, aids []int64) error
+}
+
+type CachedArticleImportRepository struct {
+	dao   dao.ArticleImportDAO
+	cache cache.ArticleImportCache
+}
+
+func NewCachedArticleImportRepository(dao dao.ArticleImportDAO, cache cache.ArticleImportCache) ArticleImportRepository {
+	return &CachedArticleImportRepository{
+		dao:   dao,
+		cache: cache,
+	}
+}
+
+func (repo *CachedArticleImportRepository) CreateJob(ctx context.Context, job domain.ArticleImportJob) (int64, error) {
+	return repo.cache.Create(ctx, job)
+}
+
+func (repo *CachedArticleImportRepository) GetJob(ctx context.Context, id int64) (domain.ArticleImportJob, error) {
+	return repo.cache.Get(ctx, id)
+}
+
+func (repo *CachedArticleImportRepository) AddFile(ctx context.Context, id int64, file domain.ArticleImportFile) error {
+	return repo.cache.AddFile(ctx, id, file)
+}
+
+func (repo *CachedArticleImportRepository) SetJobStatus(ctx context.Context, id int64, status domain.ArticleImportJobStatus) error {
+	return repo.cache.SetStatus(ctx, id, status)
+}
+
+func (repo *CachedArticleImportRepository) ClaimHash(ctx context.Context, uid int64, hash string, staleBefore time.Time) (int64, error) {
+	return repo.dao.Claim(ctx, uid, hash, staleBefore.UnixMilli())
+}
+
+func (repo *CachedArticleImportRepository) ConfirmHash(ctx context.Context, uid int64, hash string, aid int64) error {
+	return repo.dao.Confirm(ctx, uid, hash, aid)
+}
+
+func (repo *CachedArticleImportRepository) ReleaseHash(ctx context.Context, uid int64, hash string) error {
+	return repo.dao.Release(ctx, uid, hash)
+}
+
+func (repo *CachedArticleImportRepository) ReleaseArticles(ctx context.Context, aids []int64) error {
+	return repo.dao.ReleaseArticles(ctx, aids)
+}
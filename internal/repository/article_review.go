@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/dao"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+// ErrArticleReviewNotPending 审核任务不存在，或者已经处理过了
+var ErrArticleReviewNotPending = dao.ErrRecordNotFound
+
+type ArticleReviewRepository interface {
+	Create(ctx context.Context, r domain.ArticleReview) (int64, error)
+	FindById(ctx context.Context, id int64) (domain.ArticleReview, error)
+	FindPending(ctx context.Context, offset, limit int) ([]domain.ArticleReview, error)
+	Decide(ctx context.Context, id int64, status domain.ArticleReviewStatus, reviewer int64, comment string) error
+	// Reopen 把已经是 status 状态的任务放回待审核
+	Reopen(ctx context.Context, id int64, status domain.ArticleReviewStatus) error
+	CancelByArticle(ctx context.Context, aid int64) error
+}
+
+type articleReviewRepository struct {
+	dao dao.ArticleReviewDAO
+}
+
+func NewArticleReviewRepository(dao dao.ArticleReviewDAO) ArticleReviewRepository {
+	return &articleReviewRepository{
+		dao: dao,
+	}
+}
+
+func (repo *articleReviewRepository) Create(ctx context.Context, r domain.ArticleReview) (int64, error) {
+	entity, err := repo.toEntity(r)
+	if err != nil {
+		return 0, err
+	}
+	return repo.dao.Insert(ctx, entity)
+}
+
+func (repo *articleReviewRepository) FindById(ctx context.Context, id int64) (domain.ArticleReview, error) {
+	r, err := repo.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.ArticleReview{}, err
+	}
+	return repo.toDomain(r), nil
+}
+
+func (repo *articleReviewRepository) FindPending(ctx context.Context, offset, limit int) ([]domain.ArticleReview, error) {
+	rs, err := repo.dao.FindPending(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(rs, func(idx int, src dao.ArticleReview) domain.ArticleReview {
+		return repo.toDomain(src)
+	}), nil
+}
+
+func (repo *articleReviewRepository) Decide(ctx context.Context, id int64,
+	status domain.ArticleReviewStatus, reviewer int64, comment string) error {
+	return repo.dao.Decide(ctx, id, status.ToUint8(), reviewer, comment)
+}
+
+func (repo *articleReviewRepository) Reopen(ctx context.Context, id int64, status domain.ArticleReviewStatus) error {
+	return repo.dao.Reopen(ctx, id, status.ToUint8())
+}
+
+func (repo *articleReviewRepository) CancelByArticle(ctx context.Context, aid int64) error {
+	return repo.dao.CancelByArticle(ctx, aid)
+}
+
+func (repo *articleReviewRepository) toEntity(r domain.ArticleReview) (dao.ArticleReview, error) {
+	reasons, err := json.Marshal(r.Reasons)
+	if err != nil {
+		return dao.ArticleReview{}, err
+	}
+	return dao.ArticleReview{
+		Id:       r.Id,
+		Aid:      r.Aid,
+		AuthorId: r.AuthorId,
+		Title:    r.Title,
+		Content:  r.Content,
+		Format:   r.Format.ToUint8(),
+		Reasons:  string(reasons),
+		Status:   r.Status.ToUint8(),
+		Reviewer: r.Reviewer,
+		Comment:  r.Comment,
+	}, nil
+}
+
+func (repo *articleReviewRepository) toDomain(r dao.ArticleReview) domain.ArticleReview {
+	var reasons []string
+	// 解析失败也不影响审核，审核员可以直接看文章
+	_ = json.Unmarshal([]byte(r.Reasons), &reasons)
+	return domain.ArticleReview{
+		Id:       r.Id,
+		Aid:      r.Aid,
+		AuthorId: r.AuthorId,
+		Title:    r.Title,
+		Content:  r.Content,
+		Format:   domain.ArticleFormat(r.Format),
+		Reasons:  reasons,
+		Status:   domain.ArticleReviewStatus(r.Status),
+		Reviewer: r.Reviewer,
+		Comment:  r.Comment,
+		Ctime:    time.UnixMilli(r.Ctime),
+		Utime:    time.UnixMilli(r.Utime),
+	}
+}
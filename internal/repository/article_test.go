@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/cache"
+	cachemocks "webook/internal/repository/cache/mocks"
+	"webook/internal/repository/dao"
+	daomocks "webook/internal/repository/dao/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCachedArticleRepository_GetByAuthor(t *testing.T) {
+	arts := func(n int) []domain.Article {
+		res := make([]domain.Article, 0, n)
+		for i := n; i > 0; i-- {
+			res = append(res, domain.Article{Id: int64(i), Content: "完整的内容"})
+		}
+		return res
+	}
+	entities := func(n int) []dao.Article {
+		res := make([]dao.Article, 0, n)
+		for i := n; i > 0; i-- {
+			res = append(res, dao.Article{Id: int64(i), Content: "完整的内容", Utime: int64(i)})
+		}
+		return res
+	}
+	testCases := []struct {
+		name string
+		// 缓存回写完成的时候关闭
+		mock   func(ctrl *gomock.Controller, done chan struct{}) (dao.ArticleDAO, cache.ArticleCache)
+		cursor domain.ArticleCursor
+		limit  int
+
+		wantIds []int64
+	}{
+		{
+			name: "第一页命中缓存，比缓存的少",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.ArticleDAO, cache.ArticleCache) {
+				c := cachemocks.NewMockArticleCache(ctrl)
+				c.EXPECT().GetFirstPage(gomock.Any(), int64(123)).Return(arts(5), nil)
+				close(done)
+				return daomocks.NewMockArticleDAO(ctrl), c
+			},
+			limit:   3,
+			wantIds: []int64{5, 4, 3},
+		},
+		{
+			name: "第一页命中缓存，作者的文章不够一页",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.ArticleDAO, cache.ArticleCache) {
+				c := cachemocks.NewMockArticleCache(ctrl)
+				c.EXPECT().GetFirstPage(gomock.Any(), int64(123)).Return(arts(2), nil)
+				close(done)
+				return daomocks.NewMockArticleDAO(ctrl), c
+			},
+			limit:   10,
+			wantIds: []int64{2, 1},
+		},
+		{
+			name: "第一页没有命中缓存，查完整的第一页回写",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.ArticleDAO, cache.ArticleCache) {
+				c := cachemocks.NewMockArticleCache(ctrl)
+				c.EXPECT().GetFirstPage(gomock.Any(), int64(123)).Return(nil, cache.ErrKeyNotExist)
+				c.EXPECT().SetFirstPage(gomock.Any(), int64(123), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, uid int64, res []domain.Article) error {
+						defer close(done)
+						assert.Len(t, res, 4)
+						// 和真正的实现一样会改内容，不能影响返回值
+						for i := range res {
+							res[i].Content = "摘要"
+						}
+						return nil
+					})
+				c.EXPECT().Set(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+				d := daomocks.NewMockArticleDAO(ctrl)
+				d.EXPECT().GetByAuthor(gomock.Any(), int64(123), int64(0), int64(0), firstPageSize).
+					Return(entities(4), nil)
+				return d, c
+			},
+			limit:   2,
+			wantIds: []int64{4, 3},
+		},
+		{
+			name: "后面的页不走缓存",
+			mock: func(ctrl *gomock.Controller, done chan struct{}) (dao.ArticleDAO, cache.ArticleCache) {
+				d := daomocks.NewMockArticleDAO(ctrl)
+				d.EXPECT().GetByAuthor(gomock.Any(), int64(123), int64(1700000000000), int64(5), 2).
+					Return(entities(2), nil)
+				close(done)
+				return d, cachemocks.NewMockArticleCache(ctrl)
+			},
+			cursor:  domain.ArticleCursor{Utime: time.UnixMilli(1700000000000), Id: 5},
+			limit:   2,
+			wantIds: []int64{2, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			done := make(chan struct{})
+			d, c := tc.mock(ctrl, done)
+			repo := NewCachedArticleRepository(d, nil, c, nil, nil)
+			res, err := repo.GetByAuthor(context.Background(), 123, tc.cursor, tc.limit)
+			require.NoError(t, err)
+			<-done
+			ids := make([]int64, 0, len(res))
+			for _, art := range res {
+				ids = append(ids, art.Id)
+				assert.Equal(t, "完整的内容", art.Content)
+			}
+			assert.Equal(t, tc.wantIds, ids)
+		})
+	}
+}
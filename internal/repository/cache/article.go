@@ -15,8 +15,10 @@ type ArticleCache interface {
 	DelFirstPage(ctx context.Context, uid int64) error
 	Get(ctx context.Context, id int64) (domain.Article, error)
 	Set(ctx context.Context, art domain.Article) error
+	Del(ctx context.Context, id int64) error
 	GetPub(ctx context.Context, id int64) (domain.Article, error)
 	SetPub(ctx context.Context, art domain.Article) error
+	DelPub(ctx context.Context, id int64) error
 }
 
 type ArticleRedisCache struct {
@@ -47,6 +49,10 @@ func (a *ArticleRedisCache) SetPub(ctx context.Context, art domain.Article) erro
 	return a.client.Set(ctx, a.pubKey(art.Id), val, time.Minute*10).Err()
 }
 
+func (a *ArticleRedisCache) DelPub(ctx context.Context, id int64) error {
+	return a.client.Del(ctx, a.pubKey(id)).Err()
+}
+
 func (a *ArticleRedisCache) Get(ctx context.Context, id int64) (domain.Article, error) {
 	val, err := a.client.Get(ctx, a.key(id)).Bytes()
 	if err != nil {
@@ -66,6 +72,10 @@ func (a *ArticleRedisCache) Set(ctx context.Context, art domain.Article) error {
 	return a.client.Set(ctx, a.key(art.Id), val, time.Minute*10).Err()
 }
 
+func (a *ArticleRedisCache) Del(ctx context.Context, id int64) error {
+	return a.client.Del(ctx, a.key(id)).Err()
+}
+
 func (a *ArticleRedisCache) DelFirstPage(ctx context.Context, uid int64) error {
 	return a.client.Del(ctx, a.firstKey(uid)).Err()
 }
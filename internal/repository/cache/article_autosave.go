@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed lua/set_autosave.lua
+	luaSetAutosave string
+	//go:embed lua/clear_autosave.lua
+	luaClearAutosave string
+)
+
+// ArticleAutosaveCache 自动保存的缓冲区，定时刷到数据库
+type ArticleAutosaveCache interface {
+	// Set 覆盖之前自动保存的内容，同时标记为待刷新
+	Set(ctx context.Context, art domain.Article) error
+	// Get 返回自动保存的内容和序号，没有的时候返回 ErrKeyNotExist
+	Get(ctx context.Context, id int64) (domain.Article, int64, error)
+	// DirtyIds 在 before 之前自动保存过、还没有刷到数据库的文章
+	DirtyIds(ctx context.Context, before time.Time, limit int) ([]int64, error)
+	// Clear 刷到数据库之后清掉。seq 不一样说明又保存过了，这时候什么也不做
+	Clear(ctx context.Context, id int64, seq int64) error
+	// Del 不管有没有刷新都直接删掉，手动保存之后用
+	Del(ctx context.Context, id int64) error
+}
+
+type RedisArticleAutosaveCache struct {
+	cmd redis.Cmdable
+	// 作者一直不回来，缓冲区也不能一直留着
+	expiration time.Duration
+}
+
+func NewArticleAutosaveCache(cmd redis.Cmdable) ArticleAutosaveCache {
+	return &RedisArticleAutosaveCache{
+		cmd:        cmd,
+		expiration: time.Hour * 24 * 7,
+	}
+}
+
+func (c *RedisArticleAutosaveCache) Set(ctx context.Context, art domain.Article) error {
+	val, err := json.Marshal(art)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Eval(ctx, luaSetAutosave, []string{c.key(art.Id), c.dirtyKey()},
+		val, int64(c.expiration.Seconds()), art.Id, time.Now().UnixMilli()).Err()
+}
+
+func (c *RedisArticleAutosaveCache) Get(ctx context.Context, id int64) (domain.Article, int64, error) {
+	vals, err := c.cmd.HMGet(ctx, c.key(id), "data", "seq").Result()
+	if err != nil {
+		return domain.Article{}, 0, err
+	}
+	data, ok1 := vals[0].(string)
+	seqStr, ok2 := vals[1].(string)
+	if !ok1 || !ok2 {
+		return domain.Article{}, 0, ErrKeyNotExist
+	}
+	seq, err := strconv.ParseInt(seqStr, 10, 64)
+	if err != nil {
+		return domain.Article{}, 0, err
+	}
+	var art domain.Article
+	err = json.Unmarshal([]byte(data), &art)
+	return art, seq, err
+}
+
+func (c *RedisArticleAutosaveCache) DirtyIds(ctx context.Context, before time.Time, limit int) ([]int64, error) {
+	vals, err := c.cmd.ZRangeByScore(ctx, c.dirtyKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(before.UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(vals))
+	for _, val := range vals {
+		id, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (c *RedisArticleAutosaveCache) Clear(ctx context.Context, id int64, seq int64) error {
+	return c.cmd.Eval(ctx, luaClearAutosave, []string{c.key(id), c.dirtyKey()},
+		strconv.FormatInt(seq, 10), id).Err()
+}
+
+func (c *RedisArticleAutosaveCache) Del(ctx context.Context, id int64) error {
+	pipe := c.cmd.TxPipeline()
+	pipe.Del(ctx, c.key(id))
+	pipe.ZRem(ctx, c.dirtyKey(), id)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisArticleAutosaveCache) key(id int64) string {
+	return fmt.Sprintf("article:autosave:%d", id)
+}
+
+func (c *RedisArticleAutosaveCache) dirtyKey() string {
+	return "article:autosave:dirty"
+}
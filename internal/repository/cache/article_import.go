@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ArticleImportCache 导入任务的进度。任务的基本信息是一个 hash，
+// 每个文件的结果按照顺序追加到一个 list 里面，不用每处理一个文件都重写整个任务
+type ArticleImportCache interface {
+	// Create 返回任务 ID
+	Create(ctx context.Context, job domain.ArticleImportJob) (int64, error)
+	// Get 任务不存在或者已经过期了返回 ErrKeyNotExist
+	Get(ctx context.Context, id int64) (domain.ArticleImportJob, error)
+	AddFile(ctx context.Context, id int64, file domain.ArticleImportFile) error
+	SetStatus(ctx context.Context, id int64, status domain.ArticleImportJobStatus) error
+}
+
+type RedisArticleImportCache struct {
+	cmd redis.Cmdable
+	// 任务结束之后作者还要能看到结果
+	expiration time.Duration
+}
+
+func NewArticleImportCache(cmd redis.Cmdable) ArticleImportCache {
+	return &RedisArticleImportCache{
+		cmd:        cmd,
+		expiration: time.Hour * 24,
+	}
+}
+
+func (c *RedisArticleImportCache) Create(ctx context.Context, job domain.ArticleImportJob) (int64, error) {
+	id, err := c.cmd.Incr(ctx, c.idKey()).Result()
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UnixMilli()
+	key := c.key(id)
+	pipe := c.cmd.TxPipeline()
+	pipe.HSet(ctx, key,
+		"uid", job.Uid,
+		"status", uint8(job.Status),
+		"total", job.Total,
+		"ctime", now,
+		"utime", now)
+	pipe.Expire(ctx, key, c.expiration)
+	_, err = pipe.Exec(ctx)
+	return id, err
+}
+
+func (c *RedisArticleImportCache) Get(ctx context.Context, id int64) (domain.ArticleImportJob, error) {
+	pipe := c.cmd.Pipeline()
+	meta := pipe.HGetAll(ctx, c.key(id))
+	files := pipe.LRange(ctx, c.filesKey(id), 0, -1)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return domain.ArticleImportJob{}, err
+	}
+	vals := meta.Val()
+	if len(vals) == 0 {
+		return domain.ArticleImportJob{}, ErrKeyNotExist
+	}
+	uid, _ := strconv.ParseInt(vals["uid"], 10, 64)
+	status, _ := strconv.ParseUint(vals["status"], 10, 8)
+	total, _ := strconv.Atoi(vals["total"])
+	ctime, _ := strconv.ParseInt(vals["ctime"], 10, 64)
+	utime, _ := strconv.ParseInt(vals["utime"], 10, 64)
+	res := domain.ArticleImportJob{
+		Id:     id,
+		Uid:    uid,
+		Status: domain.ArticleImportJobStatus(status),
+		Total:  total,
+		Files:  make([]domain.ArticleImportFile, 0, len(files.Val())),
+		Ctime:  time.UnixMilli(ctime),
+		Utime:  time.UnixMilli(utime),
+	}
+	for _, val := range files.Val() {
+		var f domain.ArticleImportFile
+		err = json.Unmarshal([]byte(val), &f)
+		if err != nil {
+			return domain.ArticleImportJob{}, err
+		}
+		res.Files = append(res.Files, f)
+	}
+	return res, nil
+}
+
+func (c *RedisArticleImportCache) AddFile(ctx context.Context, id int64, file domain.ArticleImportFile) error {
+	val, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	pipe := c.cmd.TxPipeline()
+	pipe.RPush(ctx, c.filesKey(id), val)
+	pipe.Expire(ctx, c.filesKey(id), c.expiration)
+	pipe.HSet(ctx, c.key(id), "utime", time.Now().UnixMilli())
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisArticleImportCache) SetStatus(ctx context.Context, id int64, status domain.ArticleImportJobStatus) error {
+	pipe := c.cmd.TxPipeline()
+	pipe.HSet(ctx, c.key(id),
+		"status", uint8(status),
+		"utime", time.Now().UnixMilli())
+	pipe.Expire(ctx, c.key(id), c.expiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisArticleImportCache) idKey() string {
+	return "article:import:id"
+}
+
+func (c *RedisArticleImportCache) key(id int64) string {
+	return fmt.Sprintf("article:import:%d", id)
+}
+
+func (c *RedisArticleImportCache) filesKey(id int64) string {
+	return fmt.Sprintf("article:import:%d:files", id)
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FeedCache 生成好的订阅源和 sitemap。全站的、每个作者的、sitemap 各是一个 hash，
+// 失效的时候整个 hash 删掉，sitemap 不用知道一共有多少页
+type FeedCache interface {
+	// GetFeed uid 是 0 的时候是全站的订阅源
+	GetFeed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error)
+	SetFeed(ctx context.Context, uid int64, format domain.FeedFormat, data []byte) error
+	// GetSitemap page 是 0 的时候是 sitemap 索引
+	GetSitemap(ctx context.Context, page int) ([]byte, error)
+	SetSitemap(ctx context.Context, page int, data []byte) error
+	// Invalidate 这些作者在线上库的文章有变化，作者的、全站的订阅源和 sitemap 都失效
+	Invalidate(ctx context.Context, uids ...int64) error
+}
+
+type RedisFeedCache struct {
+	cmd        redis.Cmdable
+	expiration time.Duration
+}
+
+func NewFeedCache(cmd redis.Cmdable) FeedCache {
+	return &RedisFeedCache{
+		cmd: cmd,
+		// 线上库有变化的时候会主动失效，过期时间只是兜底，
+		// 例如作者改了昵称
+		expiration: time.Minute * 30,
+	}
+}
+
+func (c *RedisFeedCache) GetFeed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error) {
+	return c.get(ctx, c.feedKey(uid), string(format))
+}
+
+func (c *RedisFeedCache) SetFeed(ctx context.Context, uid int64, format domain.FeedFormat, data []byte) error {
+	return c.set(ctx, c.feedKey(uid), string(format), data)
+}
+
+func (c *RedisFeedCache) GetSitemap(ctx context.Context, page int) ([]byte, error) {
+	return c.get(ctx, c.sitemapKey(), strconv.Itoa(page))
+}
+
+func (c *RedisFeedCache) SetSitemap(ctx context.Context, page int, data []byte) error {
+	return c.set(ctx, c.sitemapKey(), strconv.Itoa(page), data)
+}
+
+func (c *RedisFeedCache) Invalidate(ctx context.Context, uids ...int64) error {
+	keys := make([]string, 0, len(uids)+2)
+	keys = append(keys, c.feedKey(0), c.sitemapKey())
+	for _, uid := range uids {
+		keys = append(keys, c.feedKey(uid))
+	}
+	return c.cmd.Del(ctx, keys...).Err()
+}
+
+func (c *RedisFeedCache) get(ctx context.Context, key, field string) ([]byte, error) {
+	data, err := c.cmd.HGet(ctx, key, field).Bytes()
+	if err == redis.Nil {
+		return nil, ErrKeyNotExist
+	}
+	return data, err
+}
+
+func (c *RedisFeedCache) set(ctx context.Context, key, field string, data []byte) error {
+	pipe := c.cmd.TxPipeline()
+	pipe.HSet(ctx, key, field, data)
+	pipe.Expire(ctx, key, c.expiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisFeedCache) feedKey(uid int64) string {
+	if uid == 0 {
+		return "feed:site"
+	}
+	return fmt.Sprintf("feed:author:%d", uid)
+}
+
+func (c *RedisFeedCache) sitemapKey() string {
+	return "sitemap"
+}
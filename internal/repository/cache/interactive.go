@@ -18,14 +18,22 @@ var (
 const fieldReadCnt = "read_cnt"
 const fieldLikeCnt = "like_cnt"
 const fieldCollectCnt = "collect_cnt"
+const fieldRewardCnt = "reward_cnt"
 
 type InteractiveCache interface {
 	IncrReadCntIfPresent(ctx context.Context, biz string, bizId int64) error
 	IncrLikeCntIfPresent(ctx context.Context, biz string, id int64) error
 	DecrLikeCntIfPresent(ctx context.Context, biz string, id int64) error
 	IncrCollectCntIfPresent(ctx context.Context, biz string, id int64) error
+	IncrRewardCntIfPresent(ctx context.Context, biz string, id int64) error
 	Get(ctx context.Context, biz string, id int64) (domain.Interactive, error)
+	// GetByIds 一次往返查多个资源，只返回缓存里面有的
+	GetByIds(ctx context.Context, biz string, ids []int64) (map[int64]domain.Interactive, error)
 	Set(ctx context.Context, biz string, id int64, res domain.Interactive) error
+	SetByIds(ctx context.Context, biz string, intrs map[int64]domain.Interactive) error
+	Del(ctx context.Context, biz string, id int64) error
+	// MarkRead 记下 uid 读过这个资源，一段时间之内已经记过的返回 false
+	MarkRead(ctx context.Context, biz string, id int64, uid int64) (bool, error)
 }
 
 type InteractiveRedisCache struct {
@@ -51,14 +59,69 @@ func (i *InteractiveRedisCache) Get(ctx context.Context, biz string, id int64) (
 	intr.CollectCnt, _ = strconv.ParseInt(res[fieldCollectCnt], 10, 64)
 	intr.LikeCnt, _ = strconv.ParseInt(res[fieldLikeCnt], 10, 64)
 	intr.ReadCnt, _ = strconv.ParseInt(res[fieldReadCnt], 10, 64)
+	intr.RewardCnt, _ = strconv.ParseInt(res[fieldRewardCnt], 10, 64)
 	return intr, nil
 }
 
+func (i *InteractiveRedisCache) GetByIds(ctx context.Context, biz string, ids []int64) (map[int64]domain.Interactive, error) {
+	pipe := i.client.Pipeline()
+	cmds := make([]*redis.SliceCmd, 0, len(ids))
+	for _, id := range ids {
+		cmds = append(cmds, pipe.HMGet(ctx, i.key(biz, id),
+			fieldReadCnt, fieldLikeCnt, fieldCollectCnt, fieldRewardCnt))
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]domain.Interactive, len(ids))
+	for idx, cmd := range cmds {
+		vals := cmd.Val()
+		hit := false
+		cnts := make([]int64, len(vals))
+		for j, val := range vals {
+			// 字段不存在的时候是 nil，比如加上打赏数之前写进去的缓存
+			str, ok := val.(string)
+			if !ok {
+				continue
+			}
+			hit = true
+			cnts[j], _ = strconv.ParseInt(str, 10, 64)
+		}
+		if !hit {
+			continue
+		}
+		res[ids[idx]] = domain.Interactive{
+			ReadCnt:    cnts[0],
+			LikeCnt:    cnts[1],
+			CollectCnt: cnts[2],
+			RewardCnt:  cnts[3],
+		}
+	}
+	return res, nil
+}
+
+func (i *InteractiveRedisCache) SetByIds(ctx context.Context, biz string, intrs map[int64]domain.Interactive) error {
+	pipe := i.client.Pipeline()
+	for id, res := range intrs {
+		key := i.key(biz, id)
+		pipe.HSet(ctx, key, fieldCollectCnt, res.CollectCnt,
+			fieldReadCnt, res.ReadCnt,
+			fieldLikeCnt, res.LikeCnt,
+			fieldRewardCnt, res.RewardCnt,
+		)
+		pipe.Expire(ctx, key, time.Minute*15)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 func (i *InteractiveRedisCache) Set(ctx context.Context, biz string, id int64, res domain.Interactive) error {
 	key := i.key(biz, id)
 	err := i.client.HSet(ctx, key, fieldCollectCnt, res.CollectCnt,
 		fieldReadCnt, res.ReadCnt,
 		fieldLikeCnt, res.LikeCnt,
+		fieldRewardCnt, res.RewardCnt,
 	).Err()
 	if err != nil {
 		return err
@@ -90,6 +153,22 @@ func (i *InteractiveRedisCache) IncrCollectCntIfPresent(ctx context.Context,
 	return i.client.Eval(ctx, luaIncrCnt, []string{key}, fieldCollectCnt, 1).Err()
 }
 
+func (i *InteractiveRedisCache) IncrRewardCntIfPresent(ctx context.Context,
+	biz string, id int64) error {
+	key := i.key(biz, id)
+	return i.client.Eval(ctx, luaIncrCnt, []string{key}, fieldRewardCnt, 1).Err()
+}
+
+func (i *InteractiveRedisCache) Del(ctx context.Context, biz string, id int64) error {
+	return i.client.Del(ctx, i.key(biz, id)).Err()
+}
+
+func (i *InteractiveRedisCache) MarkRead(ctx context.Context, biz string, id int64, uid int64) (bool, error) {
+	// 同一个用户 10 分钟之内反复阅读只算一次
+	return i.client.SetNX(ctx, fmt.Sprintf("interactive:read:%s:%d:%d", biz, id, uid),
+		1, time.Minute*10).Result()
+}
+
 func NewInteractiveRedisCache(client redis.Cmdable) InteractiveCache {
 	return &InteractiveRedisCache{client: client}
 }
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	//go:embed lua/set_state.lua
+	luaSetState string
+)
+
+// InteractiveStateKind 缓存的是哪一种状态
+type InteractiveStateKind string
+
+const (
+	InteractiveStateLiked     InteractiveStateKind = "liked"
+	InteractiveStateCollected InteractiveStateKind = "collected"
+)
+
+const (
+	stateTrue  = "1"
+	stateFalse = "0"
+)
+
+// InteractiveStateCache 缓存用户对资源的点赞、收藏状态。
+// 一个用户一个 hash，字段是 kind:biz:bizId，值是 1 或者 0，
+// 没点赞也缓存，字段不存在才表示不知道。
+type InteractiveStateCache interface {
+	// Get 只返回缓存里面有的
+	Get(ctx context.Context, kind InteractiveStateKind, biz string, ids []int64, uid int64) (map[int64]bool, error)
+	// Set 用户操作之后直接覆盖
+	Set(ctx context.Context, kind InteractiveStateKind, biz string, id int64, uid int64, val bool) error
+	// Fill 查完数据库回写，已经有的字段不覆盖
+	Fill(ctx context.Context, kind InteractiveStateKind, biz string, vals map[int64]bool, uid int64) error
+	// Del 删除用户所有的状态，合并、注销账号用
+	Del(ctx context.Context, uid int64) error
+}
+
+type InteractiveStateRedisCache struct {
+	client redis.Cmdable
+	vector *prometheus.CounterVec
+	// 单个用户最多缓存多少个状态，超过了就整个删掉重新缓存
+	limit      int
+	expiration time.Duration
+}
+
+func NewInteractiveStateRedisCache(client redis.Cmdable, opts prometheus.CounterOpts) InteractiveStateCache {
+	vector := prometheus.NewCounterVec(opts, []string{"kind", "result"})
+	if err := prometheus.Register(vector); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			panic(err)
+		}
+		vector = are.ExistingCollector.(*prometheus.CounterVec)
+	}
+	return &InteractiveStateRedisCache{
+		client:     client,
+		vector:     vector,
+		limit:      1000,
+		expiration: time.Minute * 30,
+	}
+}
+
+func (i *InteractiveStateRedisCache) Get(ctx context.Context, kind InteractiveStateKind,
+	biz string, ids []int64, uid int64) (map[int64]bool, error) {
+	fields := make([]string, 0, len(ids))
+	for _, id := range ids {
+		fields = append(fields, i.field(kind, biz, id))
+	}
+	vals, err := i.client.HMGet(ctx, i.key(uid), fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]bool, len(ids))
+	for idx, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		res[ids[idx]] = str == stateTrue
+	}
+	i.vector.WithLabelValues(string(kind), "hit").Add(float64(len(res)))
+	i.vector.WithLabelValues(string(kind), "miss").Add(float64(len(ids) - len(res)))
+	return res, nil
+}
+
+func (i *InteractiveStateRedisCache) Set(ctx context.Context, kind InteractiveStateKind,
+	biz string, id int64, uid int64, val bool) error {
+	return i.set(ctx, uid, true, i.field(kind, biz, id), i.state(val))
+}
+
+func (i *InteractiveStateRedisCache) Fill(ctx context.Context, kind InteractiveStateKind,
+	biz string, vals map[int64]bool, uid int64) error {
+	if len(vals) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(vals)*2)
+	for id, val := range vals {
+		args = append(args, i.field(kind, biz, id), i.state(val))
+	}
+	return i.set(ctx, uid, false, args...)
+}
+
+func (i *InteractiveStateRedisCache) Del(ctx context.Context, uid int64) error {
+	return i.client.Del(ctx, i.key(uid)).Err()
+}
+
+func (i *InteractiveStateRedisCache) set(ctx context.Context, uid int64, overwrite bool, pairs ...any) error {
+	flag := "0"
+	if overwrite {
+		flag = "1"
+	}
+	args := append([]any{flag, i.limit, int64(i.expiration.Seconds())}, pairs...)
+	return i.client.Eval(ctx, luaSetState, []string{i.key(uid)}, args...).Err()
+}
+
+func (i *InteractiveStateRedisCache) state(val bool) string {
+	if val {
+		return stateTrue
+	}
+	return stateFalse
+}
+
+func (i *InteractiveStateRedisCache) key(uid int64) string {
+	return fmt.Sprintf("interactive:state:%d", uid)
+}
+
+func (i *InteractiveStateRedisCache) field(kind InteractiveStateKind, biz string, id int64) string {
+	return fmt.Sprintf("%s:%s:%d", kind, biz, id)
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/login_lock.lua
+var luaLoginLock string
+
+// LoginLockCache 登录失败太多次之后的锁定，key 可以是账号也可以是 IP
+type LoginLockCache interface {
+	// Lock 锁定，返回锁定的时长。连续锁定的时候时长翻倍，最长不超过 max
+	Lock(ctx context.Context, key string, base, max time.Duration) (time.Duration, error)
+	// TTL 剩余的锁定时间，没有锁定返回 0
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Unlock 解除锁定，同时重置锁定时长
+	Unlock(ctx context.Context, key string) error
+}
+
+type RedisLoginLockCache struct {
+	cmd redis.Cmdable
+	// 多久没有再被锁定，锁定时长就从头开始算
+	resetAfter time.Duration
+}
+
+func NewLoginLockCache(cmd redis.Cmdable) LoginLockCache {
+	return &RedisLoginLockCache{
+		cmd:        cmd,
+		resetAfter: time.Hour * 24,
+	}
+}
+
+func (c *RedisLoginLockCache) Lock(ctx context.Context, key string, base, max time.Duration) (time.Duration, error) {
+	ttl, err := c.cmd.Eval(ctx, luaLoginLock, []string{c.key(key)},
+		base.Milliseconds(), max.Milliseconds(), c.resetAfter.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ttl) * time.Millisecond, nil
+}
+
+func (c *RedisLoginLockCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.cmd.PTTL(ctx, c.key(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// key 不存在的时候是负数
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (c *RedisLoginLockCache) Unlock(ctx context.Context, key string) error {
+	k := c.key(key)
+	return c.cmd.Del(ctx, k, k+":level").Err()
+}
+
+func (c *RedisLoginLockCache) key(key string) string {
+	return fmt.Sprintf("login_lock:%s", key)
+}
@@ -0,0 +1,14 @@
+local key = KEYS[1]
+local dirtyKey = KEYS[2]
+-- 刷新的时候读到的 seq
+local expected = ARGV[1]
+local id = ARGV[2]
+
+local seq = redis.call("hget", key, "seq")
+if seq ~= false and seq ~= expected then
+    -- 刷新的过程中又自动保存了，留着下次再刷
+    return 0
+end
+redis.call("del", key)
+redis.call("zrem", dirtyKey, id)
+return 1
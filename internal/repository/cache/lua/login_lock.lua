@@ -0,0 +1,21 @@
+-- 锁定的 key
+local key = KEYS[1]
+-- 记录第几次锁定
+local levelKey = key..":level"
+-- 第一次锁定的时长，毫秒
+local base = tonumber(ARGV[1])
+-- 最长锁定多久，毫秒
+local max = tonumber(ARGV[2])
+-- 多久没有再锁定，锁定时长就重新计算，毫秒
+local reset = tonumber(ARGV[3])
+
+local level = redis.call("incr", levelKey)
+redis.call("pexpire", levelKey, reset)
+-- 每锁定一次，时长翻倍
+local ttl = base * 2 ^ (level - 1)
+if ttl > max then
+    ttl = max
+end
+ttl = math.floor(ttl)
+redis.call("set", key, level, "px", ttl)
+return ttl
@@ -0,0 +1,16 @@
+-- 自动保存的内容，hash 里面有 data 和 seq 两个字段
+local key = KEYS[1]
+-- 待刷新的文章，zset，score 是自动保存的时间
+local dirtyKey = KEYS[2]
+local data = ARGV[1]
+-- 过期时间，秒
+local ttl = tonumber(ARGV[2])
+local id = ARGV[3]
+local now = tonumber(ARGV[4])
+
+redis.call("hset", key, "data", data)
+-- 每次保存 seq 加一，刷新的时候用来判断有没有被覆盖
+local seq = redis.call("hincrby", key, "seq", 1)
+redis.call("expire", key, ttl)
+redis.call("zadd", dirtyKey, now, id)
+return seq
@@ -0,0 +1,33 @@
+-- 用户点赞、收藏状态，一个用户一个 hash
+local key = KEYS[1]
+-- 1 表示覆盖已有的字段，点赞、取消点赞、收藏的时候用
+-- 0 表示只写没有的字段，查完数据库回写的时候用，免得旧数据把刚写进去的状态覆盖掉
+local overwrite = ARGV[1] == "1"
+-- 单个用户最多缓存多少个状态
+local limit = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local cnt = redis.call("HLEN", key)
+local written = false
+for i = 4, #ARGV, 2 do
+    local field = ARGV[i]
+    if redis.call("HEXISTS", key, field) == 1 then
+        if overwrite then
+            redis.call("HSET", key, field, ARGV[i + 1])
+            written = true
+        end
+    elseif cnt < limit then
+        redis.call("HSET", key, field, ARGV[i + 1])
+        cnt = cnt + 1
+        written = true
+    else
+        -- 满了就整个删掉，下次查的时候回到数据库重新缓存，
+        -- 不然过期时间一直在刷新，新的状态永远缓存不进来
+        redis.call("DEL", key)
+        return 0
+    end
+end
+-- 什么都没写的时候不刷新过期时间
+if written then
+    redis.call("EXPIRE", key, ttl)
+end
+return cnt
@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interactive_state.go
+//
+// Generated by this command:
+//
+//	mockgen -package=cachemocks -destination=./mocks/interactive_state.mock.go -source=./interactive_state.go
+//
+
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+	cache "webook/internal/repository/cache"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveStateCache is a mock of InteractiveStateCache interface.
+type MockInteractiveStateCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveStateCacheMockRecorder
+}
+
+// MockInteractiveStateCacheMockRecorder is the mock recorder for MockInteractiveStateCache.
+type MockInteractiveStateCacheMockRecorder struct {
+	mock *MockInteractiveStateCache
+}
+
+// NewMockInteractiveStateCache creates a new mock instance.
+func NewMockInteractiveStateCache(ctrl *gomock.Controller) *MockInteractiveStateCache {
+	mock := &MockInteractiveStateCache{ctrl: ctrl}
+	mock.recorder = &MockInteractiveStateCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveStateCache) EXPECT() *MockInteractiveStateCacheMockRecorder {
+	return m.recorder
+}
+
+// Del mocks base method.
+func (m *MockInteractiveStateCache) Del(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockInteractiveStateCacheMockRecorder) Del(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockInteractiveStateCache)(nil).Del), ctx, uid)
+}
+
+// Fill mocks base method.
+func (m *MockInteractiveStateCache) Fill(ctx context.Context, kind cache.InteractiveStateKind, biz string, vals map[int64]bool, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fill", ctx, kind, biz, vals, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Fill indicates an expected call of Fill.
+func (mr *MockInteractiveStateCacheMockRecorder) Fill(ctx, kind, biz, vals, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fill", reflect.TypeOf((*MockInteractiveStateCache)(nil).Fill), ctx, kind, biz, vals, uid)
+}
+
+// Get mocks base method.
+func (m *MockInteractiveStateCache) Get(ctx context.Context, kind cache.InteractiveStateKind, biz string, ids []int64, uid int64) (map[int64]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, kind, biz, ids, uid)
+	ret0, _ := ret[0].(map[int64]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockInteractiveStateCacheMockRecorder) Get(ctx, kind, biz, ids, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInteractiveStateCache)(nil).Get), ctx, kind, biz, ids, uid)
+}
+
+// Set mocks base method.
+func (m *MockInteractiveStateCache) Set(ctx context.Context, kind cache.InteractiveStateKind, biz string, id, uid int64, val bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, kind, biz, id, uid, val)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockInteractiveStateCacheMockRecorder) Set(ctx, kind, biz, id, uid, val any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockInteractiveStateCache)(nil).Set), ctx, kind, biz, id, uid, val)
+}
@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -package=cachemocks -destination=./mock/user.mock.go -source=./user.go
+//	mockgen -package=cachemocks -destination=./mocks/user.mock.go -source=./user.go
 //
 
 // Package cachemocks is a generated GoMock package.
@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 	domain "webook/internal/domain"
+	cache "webook/internal/repository/cache"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,7 +41,21 @@ func (m *MockUserCache) EXPECT() *MockUserCacheMockRecorder {
 	return m.recorder
 }
 
-// Get mock base method.
+// Del mocks base method.
+func (m *MockUserCache) Del(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockUserCacheMockRecorder) Del(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockUserCache)(nil).Del), ctx, uid)
+}
+
+// Get mocks base method.
 func (m *MockUserCache) Get(ctx context.Context, uid int64) (domain.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Get", ctx, uid)
@@ -55,7 +70,22 @@ func (mr *MockUserCacheMockRecorder) Get(ctx, uid any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserCache)(nil).Get), ctx, uid)
 }
 
-// Set mock base method.
+// GetDelEmailVerifyToken mocks base method.
+func (m *MockUserCache) GetDelEmailVerifyToken(ctx context.Context, token string) (cache.EmailVerifyToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDelEmailVerifyToken", ctx, token)
+	ret0, _ := ret[0].(cache.EmailVerifyToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDelEmailVerifyToken indicates an expected call of GetDelEmailVerifyToken.
+func (mr *MockUserCacheMockRecorder) GetDelEmailVerifyToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDelEmailVerifyToken", reflect.TypeOf((*MockUserCache)(nil).GetDelEmailVerifyToken), ctx, token)
+}
+
+// Set mocks base method.
 func (m *MockUserCache) Set(ctx context.Context, du domain.User) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Set", ctx, du)
@@ -68,3 +98,17 @@ func (mr *MockUserCacheMockRecorder) Set(ctx, du any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockUserCache)(nil).Set), ctx, du)
 }
+
+// SetEmailVerifyToken mocks base method.
+func (m *MockUserCache) SetEmailVerifyToken(ctx context.Context, token string, t cache.EmailVerifyToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailVerifyToken", ctx, token, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmailVerifyToken indicates an expected call of SetEmailVerifyToken.
+func (mr *MockUserCacheMockRecorder) SetEmailVerifyToken(ctx, token, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailVerifyToken", reflect.TypeOf((*MockUserCache)(nil).SetEmailVerifyToken), ctx, token, t)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"webook/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PrivacyCache 隐私设置和拉黑关系，看文章、点赞的时候都要查，所以要缓存
+type PrivacyCache interface {
+	GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error)
+	SetSettings(ctx context.Context, s domain.PrivacySettings) error
+	DelSettings(ctx context.Context, uid int64) error
+	// GetBlocked a 和 b 之间有没有拉黑关系，不分方向
+	GetBlocked(ctx context.Context, a, b int64) (bool, error)
+	SetBlocked(ctx context.Context, a, b int64, blocked bool) error
+	DelBlocked(ctx context.Context, a, b int64) error
+}
+
+type RedisPrivacyCache struct {
+	cmd        redis.Cmdable
+	expiration time.Duration
+}
+
+func NewPrivacyCache(cmd redis.Cmdable) PrivacyCache {
+	return &RedisPrivacyCache{
+		cmd:        cmd,
+		expiration: time.Minute * 15,
+	}
+}
+
+func (c *RedisPrivacyCache) GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error) {
+	data, err := c.cmd.Get(ctx, c.settingsKey(uid)).Bytes()
+	if err != nil {
+		return domain.PrivacySettings{}, err
+	}
+	var s domain.PrivacySettings
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
+
+func (c *RedisPrivacyCache) SetSettings(ctx context.Context, s domain.PrivacySettings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Set(ctx, c.settingsKey(s.Uid), data, c.expiration).Err()
+}
+
+func (c *RedisPrivacyCache) DelSettings(ctx context.Context, uid int64) error {
+	return c.cmd.Del(ctx, c.settingsKey(uid)).Err()
+}
+
+func (c *RedisPrivacyCache) GetBlocked(ctx context.Context, a, b int64) (bool, error) {
+	return c.cmd.Get(ctx, c.blockKey(a, b)).Bool()
+}
+
+func (c *RedisPrivacyCache) SetBlocked(ctx context.Context, a, b int64, blocked bool) error {
+	return c.cmd.Set(ctx, c.blockKey(a, b), blocked, c.expiration).Err()
+}
+
+func (c *RedisPrivacyCache) DelBlocked(ctx context.Context, a, b int64) error {
+	return c.cmd.Del(ctx, c.blockKey(a, b)).Err()
+}
+
+func (c *RedisPrivacyCache) settingsKey(uid int64) string {
+	return fmt.Sprintf("privacy:settings:%d", uid)
+}
+
+// blockKey 小的 uid 在前面，这样 a 拉黑 b 和 b 拉黑 a 是同一个 key
+func (c *RedisPrivacyCache) blockKey(a, b int64) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("privacy:block:%d:%d", a, b)
+}
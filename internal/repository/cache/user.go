@@ -12,18 +12,30 @@ import (
 type UserCache interface {
 	Get(ctx context.Context, uid int64) (domain.User, error)
 	Set(ctx context.Context, du domain.User) error
+	Del(ctx context.Context, uid int64) error
+	SetEmailVerifyToken(ctx context.Context, token string, t EmailVerifyToken) error
+	GetDelEmailVerifyToken(ctx context.Context, token string) (EmailVerifyToken, error)
+}
+
+// EmailVerifyToken 邮箱验证链接里面的 token 对应的数据
+type EmailVerifyToken struct {
+	Uid   int64
+	Email string
 }
 
 type RedisUserCache struct {
 	cmd        redis.Cmdable
 	expiration time.Duration
+	// 邮箱验证链接的有效期
+	verifyExpiration time.Duration
 }
 
 func NewUserCache(cmd redis.Cmdable) UserCache {
 	return &RedisUserCache{
 		cmd: cmd,
 		// 15分钟过期user数据
-		expiration: time.Minute * 15,
+		expiration:       time.Minute * 15,
+		verifyExpiration: time.Hour * 24,
 	}
 }
 
@@ -51,3 +63,32 @@ func (cache *RedisUserCache) Set(ctx context.Context, u domain.User) error {
 
 	return cache.cmd.Set(ctx, key, data, cache.expiration).Err()
 }
+
+func (cache *RedisUserCache) Del(ctx context.Context, uid int64) error {
+	return cache.cmd.Del(ctx, cache.key(uid)).Err()
+}
+
+func (cache *RedisUserCache) SetEmailVerifyToken(ctx context.Context, token string, t EmailVerifyToken) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return cache.cmd.Set(ctx, cache.verifyKey(token), data, cache.verifyExpiration).Err()
+}
+
+func (cache *RedisUserCache) GetDelEmailVerifyToken(ctx context.Context, token string) (EmailVerifyToken, error) {
+	data, err := cache.cmd.GetDel(ctx, cache.verifyKey(token)).Bytes()
+	if err == redis.Nil {
+		return EmailVerifyToken{}, ErrKeyNotExist
+	}
+	if err != nil {
+		return EmailVerifyToken{}, err
+	}
+	var t EmailVerifyToken
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+func (cache *RedisUserCache) verifyKey(token string) string {
+	return fmt.Sprintf("user:email_verify:%s", token)
+}
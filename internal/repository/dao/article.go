@@ -8,14 +8,55 @@ import (
 	"time"
 )
 
+var (
+	// ErrArticleVersionConflict 更新的时候带的版本号和数据库里面的不一样，说明在别的地方修改过了
+	ErrArticleVersionConflict = errors.New("文章版本冲突")
+	ErrArticleNotFound        = errors.New("ID 不对或者创作者不对")
+)
+
 type ArticleDAO interface {
 	Insert(ctx context.Context, art Article) (int64, error)
+	// UpdateById 只有版本号和 entity.Version 一样才会更新，更新之后版本号加一
 	UpdateById(ctx context.Context, entity Article) error
+	// SaveDraft 把自动保存的内容写回制作库，同样检查版本号，但是版本号不变
+	SaveDraft(ctx context.Context, entity Article) error
 	Sync(ctx context.Context, entity Article) (int64, error)
+	// SyncPub 把 entity 写到线上库，制作库只修改状态，审核通过的时候用
+	SyncPub(ctx context.Context, entity Article) error
 	SyncStatus(ctx context.Context, uid int64, id int64, status uint8) error
-	GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error)
+	// UpdateStatus 只修改制作库的状态，线上库不动
+	UpdateStatus(ctx context.Context, uid int64, id int64, status uint8) error
+	// GetByAuthor 按照 (utime, id) 倒序分页，utime 和 id 是上一页最后一条，都是 0 的时候查第一页
+	GetByAuthor(ctx context.Context, uid int64, utime int64, id int64, limit int) ([]Article, error)
 	GetById(ctx context.Context, id int64) (Article, error)
 	GetPubById(ctx context.Context, id int64) (PublishedArticle, error)
+	// GetPubByAuthor 分页查询作者线上库里面处于 status 状态的文章
+	GetPubByAuthor(ctx context.Context, uid int64, status uint8, offset int, limit int) ([]PublishedArticle, error)
+	// CountPubByAuthor 统计作者线上库里面处于 status 状态的文章数量
+	CountPubByAuthor(ctx context.Context, uid int64, status uint8) (int64, error)
+	// GetPubLatest 全站线上库里面处于 status 状态的最新的文章，按照 utime 倒序
+	GetPubLatest(ctx context.Context, status uint8, limit int) ([]PublishedArticle, error)
+	// ScanPub 按照 ID 顺序分页遍历线上库里面处于 status 状态的文章，只查 id、author_id 和 utime
+	ScanPub(ctx context.Context, status uint8, offset int, limit int) ([]PublishedArticle, error)
+	// CountPub 全站线上库里面处于 status 状态的文章数量
+	CountPub(ctx context.Context, status uint8) (int64, error)
+	// TransferAuthor 把 from 的文章（包括线上库）都转移给 to，合并账号用
+	TransferAuthor(ctx context.Context, from, to int64) error
+	// SyncStatusByAuthor 修改作者所有文章的状态（包括线上库），返回文章 ID
+	SyncStatusByAuthor(ctx context.Context, uid int64, status uint8) ([]int64, error)
+	// DeleteByAuthor 彻底删除作者所有的文章，返回文章 ID
+	DeleteByAuthor(ctx context.Context, uid int64) ([]int64, error)
+
+	// Trash 移到回收站，同时从线上库删除。回收站里面的文章不能修改
+	Trash(ctx context.Context, uid int64, id int64) error
+	// Restore 恢复 since 之后移到回收站的文章，恢复之后的状态是 status
+	Restore(ctx context.Context, uid int64, id int64, since int64, status uint8) error
+	// GetTrashByAuthor 作者的回收站，最近删除的在前面
+	GetTrashByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error)
+	// FindTrashedBefore before 之前移到回收站的文章 ID
+	FindTrashedBefore(ctx context.Context, before int64, limit int) ([]int64, error)
+	// DeleteTrashed 彻底删除回收站里面的文章，已经恢复了的不会删除
+	DeleteTrashed(ctx context.Context, ids []int64) error
 	//GetPubListByLikeCnt(ctx context.Context, limit int64) ([]PublishedArticle, error)
 }
 
@@ -60,6 +101,135 @@ func (a *ArticleGORMDAO) GetPubById(ctx context.Context, id int64) (PublishedArt
 	return res, err
 }
 
+func (a *ArticleGORMDAO) TransferAuthor(ctx context.Context, from, to int64) error {
+	now := time.Now().UnixMilli()
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Article{}).Where("author_id = ?", from).
+			Updates(map[string]any{
+				"author_id": to,
+				"utime":     now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&PublishedArticle{}).Where("author_id = ?", from).
+			Updates(map[string]any{
+				"author_id": to,
+				"utime":     now,
+			}).Error
+	})
+}
+
+func (a *ArticleGORMDAO) SyncStatusByAuthor(ctx context.Context, uid int64, status uint8) ([]int64, error) {
+	var ids []int64
+	now := time.Now().UnixMilli()
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Article{}).Where("author_id = ?", uid).Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Article{}).Where("author_id = ?", uid).
+			Updates(map[string]any{
+				"status": status,
+				"utime":  now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&PublishedArticle{}).Where("author_id = ?", uid).
+			Updates(map[string]any{
+				"status": status,
+				"utime":  now,
+			}).Error
+	})
+	return ids, err
+}
+
+func (a *ArticleGORMDAO) DeleteByAuthor(ctx context.Context, uid int64) ([]int64, error) {
+	var ids []int64
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Article{}).Where("author_id = ?", uid).Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("author_id = ?", uid).Delete(&Article{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("author_id = ?", uid).Delete(&PublishedArticle{}).Error
+	})
+	return ids, err
+}
+
+func (a *ArticleGORMDAO) Trash(ctx context.Context, uid int64, id int64) error {
+	now := time.Now().UnixMilli()
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Article{}).
+			Where("id = ? AND author_id = ? AND dtime = 0", id, uid).
+			Updates(map[string]any{
+				"dtime": now,
+				"utime": now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrArticleNotFound
+		}
+		return tx.Where("id = ?", id).Delete(&PublishedArticle{}).Error
+	})
+}
+
+func (a *ArticleGORMDAO) Restore(ctx context.Context, uid int64, id int64, since int64, status uint8) error {
+	res := a.db.WithContext(ctx).Model(&Article{}).
+		Where("id = ? AND author_id = ? AND dtime >= ? AND dtime > 0", id, uid, since).
+		Updates(map[string]any{
+			"dtime":  0,
+			"status": status,
+			"utime":  time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+func (a *ArticleGORMDAO) GetTrashByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error) {
+	var arts []Article
+	err := a.db.WithContext(ctx).
+		Where("author_id = ? AND dtime > 0", uid).
+		Offset(offset).Limit(limit).
+		Order("dtime DESC").
+		Find(&arts).Error
+	return arts, err
+}
+
+func (a *ArticleGORMDAO) FindTrashedBefore(ctx context.Context, before int64, limit int) ([]int64, error) {
+	var ids []int64
+	err := a.db.WithContext(ctx).Model(&Article{}).
+		Where("dtime > 0 AND dtime < ?", before).
+		Order("dtime ASC").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (a *ArticleGORMDAO) DeleteTrashed(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id IN ? AND dtime > 0", ids).Delete(&Article{}).Error
+		if err != nil {
+			return err
+		}
+		// 移到回收站的时候已经删过了，这里兜底
+		return tx.Where("id IN ?", ids).Delete(&PublishedArticle{}).Error
+	})
+}
+
 func (a *ArticleGORMDAO) GetById(ctx context.Context, id int64) (Article, error) {
 	var art Article
 	err := a.db.WithContext(ctx).
@@ -67,22 +237,73 @@ func (a *ArticleGORMDAO) GetById(ctx context.Context, id int64) (Article, error)
 	return art, err
 }
 
-func (a *ArticleGORMDAO) GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error) {
+func (a *ArticleGORMDAO) GetByAuthor(ctx context.Context, uid int64, utime int64, id int64, limit int) ([]Article, error) {
 	var arts []Article
+	query := a.db.WithContext(ctx).
+		Where("author_id = ? AND dtime = 0", uid)
+	if utime > 0 || id > 0 {
+		// 同一毫秒更新的文章用 id 区分，不会漏也不会重复
+		query = query.Where("utime < ? OR (utime = ? AND id < ?)", utime, utime, id)
+	}
+	err := query.Limit(limit).
+		// a ASC, B DESC
+		Order("utime DESC, id DESC").
+		Find(&arts).Error
+	return arts, err
+}
+
+func (a *ArticleGORMDAO) GetPubByAuthor(ctx context.Context, uid int64, status uint8, offset int, limit int) ([]PublishedArticle, error) {
+	var arts []PublishedArticle
 	err := a.db.WithContext(ctx).
-		Where("author_id = ?", uid).
+		Where("author_id = ? AND status = ?", uid, status).
 		Offset(offset).Limit(limit).
-		// a ASC, B DESC
 		Order("utime DESC").
 		Find(&arts).Error
 	return arts, err
 }
 
+func (a *ArticleGORMDAO) CountPubByAuthor(ctx context.Context, uid int64, status uint8) (int64, error) {
+	var cnt int64
+	err := a.db.WithContext(ctx).Model(&PublishedArticle{}).
+		Where("author_id = ? AND status = ?", uid, status).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+func (a *ArticleGORMDAO) GetPubLatest(ctx context.Context, status uint8, limit int) ([]PublishedArticle, error) {
+	var arts []PublishedArticle
+	err := a.db.WithContext(ctx).
+		Where("status = ?", status).
+		Limit(limit).
+		Order("utime DESC, id DESC").
+		Find(&arts).Error
+	return arts, err
+}
+
+func (a *ArticleGORMDAO) ScanPub(ctx context.Context, status uint8, offset int, limit int) ([]PublishedArticle, error) {
+	var arts []PublishedArticle
+	err := a.db.WithContext(ctx).
+		Select("id", "author_id", "utime").
+		Where("status = ?", status).
+		Offset(offset).Limit(limit).
+		Order("id ASC").
+		Find(&arts).Error
+	return arts, err
+}
+
+func (a *ArticleGORMDAO) CountPub(ctx context.Context, status uint8) (int64, error) {
+	var cnt int64
+	err := a.db.WithContext(ctx).Model(&PublishedArticle{}).
+		Where("status = ?", status).
+		Count(&cnt).Error
+	return cnt, err
+}
+
 func (a *ArticleGORMDAO) SyncStatus(ctx context.Context, uid int64, id int64, status uint8) error {
 	now := time.Now().UnixMilli()
 	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		res := tx.Model(&Article{}).
-			Where("id = ? and author_id = ?", uid, id).
+			Where("id = ? AND author_id = ? AND dtime = 0", id, uid).
 			Updates(map[string]any{
 				"utime":  now,
 				"status": status,
@@ -91,10 +312,10 @@ func (a *ArticleGORMDAO) SyncStatus(ctx context.Context, uid int64, id int64, st
 			return res.Error
 		}
 		if res.RowsAffected != 1 {
-			return errors.New("ID 不对或者创作者不对")
+			return ErrArticleNotFound
 		}
 		return tx.Model(&PublishedArticle{}).
-			Where("id = ?", uid).
+			Where("id = ?", id).
 			Updates(map[string]any{
 				"utime":  now,
 				"status": status,
@@ -102,6 +323,22 @@ func (a *ArticleGORMDAO) SyncStatus(ctx context.Context, uid int64, id int64, st
 	})
 }
 
+func (a *ArticleGORMDAO) UpdateStatus(ctx context.Context, uid int64, id int64, status uint8) error {
+	res := a.db.WithContext(ctx).Model(&Article{}).
+		Where("id = ? AND author_id = ? AND dtime = 0", id, uid).
+		Updates(map[string]any{
+			"utime":  time.Now().UnixMilli(),
+			"status": status,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected != 1 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
 func (a *ArticleGORMDAO) Sync(ctx context.Context, art Article) (int64, error) {
 	var id = art.Id
 	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -112,34 +349,62 @@ func (a *ArticleGORMDAO) Sync(ctx context.Context, art Article) (int64, error) {
 		if id > 0 {
 			err = dao.UpdateById(ctx, art)
 		} else {
-			id, err = dao.Insert(ctx, art)
+			id, err = dao.Insert(ctx, art.draft())
 		}
 		if err != nil {
 			return err
 		}
 		art.Id = id
-		now := time.Now().UnixMilli()
-		pubArt := PublishedArticle(art)
-		pubArt.Ctime = now
-		pubArt.Utime = now
-		err = tx.Clauses(clause.OnConflict{
-			// 对MySQL不起效，但是可以兼容别的方言
-			// INSERT xxx ON DUPLICATE KEY SET `title`=?
-			// 别的方言：
-			// sqlite INSERT XXX ON CONFLICT DO UPDATES WHERE
-			Columns: []clause.Column{{Name: "id"}},
-			DoUpdates: clause.Assignments(map[string]interface{}{
-				"title":   pubArt.Title,
-				"content": pubArt.Content,
-				"utime":   now,
-				"status":  pubArt.Status,
-			}),
-		}).Create(&pubArt).Error
-		return err
+		return upsertPub(tx, art, time.Now().UnixMilli())
 	})
 	return id, err
 }
 
+func (a *ArticleGORMDAO) SyncPub(ctx context.Context, art Article) error {
+	now := time.Now().UnixMilli()
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 制作库只改状态，内容和版本号都不动，作者后面保存的修改不受影响
+		res := tx.Model(&Article{}).
+			Where("id = ? AND author_id = ? AND dtime = 0", art.Id, art.AuthorId).
+			Updates(map[string]any{
+				"utime":  now,
+				"status": art.Status,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return ErrArticleNotFound
+		}
+		return upsertPub(tx, art, now)
+	})
+}
+
+func upsertPub(tx *gorm.DB, art Article, now int64) error {
+	pubArt := PublishedArticle(art)
+	pubArt.Ctime = now
+	pubArt.Utime = now
+	// 线上库不需要版本号和标签
+	pubArt.Version = 0
+	pubArt.Tags = ""
+	return tx.Clauses(clause.OnConflict{
+		// 对MySQL不起效，但是可以兼容别的方言
+		// INSERT xxx ON DUPLICATE KEY SET `title`=?
+		// 别的方言：
+		// sqlite INSERT XXX ON CONFLICT DO UPDATES WHERE
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"title":   pubArt.Title,
+			"content": pubArt.Content,
+			"format":  pubArt.Format,
+			"html":    pubArt.HTML,
+			"toc":     pubArt.TOC,
+			"utime":   now,
+			"status":  pubArt.Status,
+		}),
+	}).Create(&pubArt).Error
+}
+
 func (a *ArticleGORMDAO) SyncV1(ctx context.Context, art Article) (int64, error) {
 	tx := a.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
@@ -186,29 +451,58 @@ func (a *ArticleGORMDAO) SyncV1(ctx context.Context, art Article) (int64, error)
 }
 
 func (a *ArticleGORMDAO) UpdateById(ctx context.Context, art Article) error {
-	now := time.Now().UnixMilli()
-	res := a.db.WithContext(ctx).Model(&art).
-		Where("id = ? AND author_id = ?", art.Id, art.AuthorId).Updates(map[string]any{
+	return a.updateWithVersion(ctx, art, map[string]any{
 		"title":   art.Title,
 		"content": art.Content,
-		"utime":   now,
+		"format":  art.Format,
+		"utime":   time.Now().UnixMilli(),
 		"status":  art.Status,
+		"version": gorm.Expr("version + 1"),
+	})
+}
+
+func (a *ArticleGORMDAO) SaveDraft(ctx context.Context, art Article) error {
+	return a.updateWithVersion(ctx, art, map[string]any{
+		"title":   art.Title,
+		"content": art.Content,
+		"format":  art.Format,
+		"utime":   time.Now().UnixMilli(),
 	})
+}
+
+func (a *ArticleGORMDAO) updateWithVersion(ctx context.Context, art Article, updates map[string]any) error {
+	res := a.db.WithContext(ctx).Model(&Article{}).
+		Where("id = ? AND author_id = ? AND version = ? AND dtime = 0", art.Id, art.AuthorId, art.Version).
+		Updates(updates)
 	if res.Error != nil {
 		return res.Error
 	}
 	// 我怎么知道有没有更新数据？
 	if res.RowsAffected == 0 {
+		// 区分一下是版本不对，还是创作者不对
+		var cnt int64
+		err := a.db.WithContext(ctx).Model(&Article{}).
+			Where("id = ? AND author_id = ? AND dtime = 0", art.Id, art.AuthorId).
+			Count(&cnt).Error
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrArticleVersionConflict
+		}
 		// 创作者不对，说明有人在瞎搞
-		return errors.New("ID 不对或者创作者不对")
+		return ErrArticleNotFound
 	}
 	return nil
 }
 
 func (a *ArticleGORMDAO) Insert(ctx context.Context, art Article) (int64, error) {
 	now := time.Now().UnixMilli()
-	art.Ctime = now
+	if art.Ctime == 0 {
+		art.Ctime = now
+	}
 	art.Utime = now
+	art.Version = 1
 	err := a.db.WithContext(ctx).Create(&art).Error
 	return art.Id, err
 }
@@ -223,12 +517,31 @@ type Article struct {
 	Id      int64  `gorm:"primaryKey,autoIncrement" bson:"id,omitempty"`
 	Title   string `gorm:"type=varchar(4096)" bson:"title,omitempty"`
 	Content string `gorm:"type=BLOB" bson:"content,omitempty"`
-	// 我要根据创作者ID来查询
-	AuthorId int64 `gorm:"index" bson:"author_id,omitempty"`
-	Status   uint8 `bson:"status,omitempty"`
-	Ctime    int64 `bson:"ctime,omitempty"`
+	// 下面三个不能 omitempty，不然线上库 $set 的时候旧的值覆盖不掉
+	Format uint8 `bson:"format"`
+	// HTML 发表的时候渲染好的内容，只有线上库有
+	HTML string `gorm:"type=BLOB" bson:"html"`
+	// TOC 目录，JSON
+	TOC string `gorm:"type=BLOB" bson:"toc"`
+	// Tags 标签，JSON，只有制作库用，只在创建的时候写入
+	Tags string `gorm:"type:varchar(1024)" bson:"tags,omitempty"`
+	// 我要根据创作者ID来查询，作者的列表按照 utime 倒序翻页
+	AuthorId int64 `gorm:"index;index:idx_author_utime,priority:1" bson:"author_id,omitempty"`
+	// Version 乐观锁，只有制作库用
+	Version int64 `bson:"version,omitempty"`
+	// Dtime 移到回收站的时间，0 是没有删除，只有制作库用
+	Dtime  int64 `gorm:"index" bson:"dtime,omitempty"`
+	Status uint8 `bson:"status,omitempty"`
+	Ctime  int64 `bson:"ctime,omitempty"`
 	// 更新时间
-	Utime int64 `bson:"utime,omitempty"`
+	Utime int64 `gorm:"index:idx_author_utime,priority:2" bson:"utime,omitempty"`
+}
+
+// draft 制作库里面不存渲染的结果
+func (art Article) draft() Article {
+	art.HTML = ""
+	art.TOC = ""
+	return art
 }
 
 type PublishedArticle Article
@@ -0,0 +1,108 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ErrArticleImportDuplicate 同样内容的文件已经导入过了，或者正在导入
+var ErrArticleImportDuplicate = errors.New("同样的内容已经导入过了")
+
+// ArticleImportDAO 记录导入过的文件的内容哈希，保证重复导入同一个文件不会创建重复的文章
+type ArticleImportDAO interface {
+	// Claim 占住作者的 hash，已经导入过了返回之前导入的文章 ID 和 ErrArticleImportDuplicate。
+	// 占住了但是 staleBefore 之前都还没有确认的，当作上一次导入到一半崩溃了，可以重新占住
+	Claim(ctx context.Context, uid int64, hash string, staleBefore int64) (int64, error)
+	// Confirm 导入成功，记录创建的文章
+	Confirm(ctx context.Context, uid int64, hash string, aid int64) error
+	// Release 导入失败，放弃占住的 hash，下一次还可以导入
+	Release(ctx context.Context, uid int64, hash string) error
+	// ReleaseArticles 文章彻底删除了，导入它们的文件可以重新导入
+	ReleaseArticles(ctx context.Context, aids []int64) error
+}
+
+type GORMArticleImportDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMArticleImportDAO(db *gorm.DB) ArticleImportDAO {
+	return &GORMArticleImportDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMArticleImportDAO) Claim(ctx context.Context, uid int64, hash string, staleBefore int64) (int64, error) {
+	now := time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Create(&ArticleImport{
+		Uid:   uid,
+		Hash:  hash,
+		Ctime: now,
+		Utime: now,
+	}).Error
+	if err == nil {
+		return 0, nil
+	}
+	const duplicateErr uint16 = 1062
+	if me, ok := err.(*mysql.MySQLError); !ok || me.Number != duplicateErr {
+		return 0, err
+	}
+	var existing ArticleImport
+	err = dao.db.WithContext(ctx).
+		Where("uid = ? AND hash = ?", uid, hash).
+		First(&existing).Error
+	if err != nil {
+		return 0, err
+	}
+	if existing.ArticleId > 0 {
+		return existing.ArticleId, ErrArticleImportDuplicate
+	}
+	// 用 utime 做乐观锁，同时重新导入的只有一个能占住
+	res := dao.db.WithContext(ctx).Model(&ArticleImport{}).
+		Where("id = ? AND article_id = 0 AND utime = ? AND utime < ?",
+			existing.Id, existing.Utime, staleBefore).
+		Update("utime", now)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// 别的任务正在导入
+		return 0, ErrArticleImportDuplicate
+	}
+	return 0, nil
+}
+
+func (dao *GORMArticleImportDAO) Confirm(ctx context.Context, uid int64, hash string, aid int64) error {
+	return dao.db.WithContext(ctx).Model(&ArticleImport{}).
+		Where("uid = ? AND hash = ?", uid, hash).
+		Updates(map[string]any{
+			"article_id": aid,
+			"utime":      time.Now().UnixMilli(),
+		}).Error
+}
+
+func (dao *GORMArticleImportDAO) Release(ctx context.Context, uid int64, hash string) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ? AND hash = ? AND article_id = 0", uid, hash).
+		Delete(&ArticleImport{}).Error
+}
+
+func (dao *GORMArticleImportDAO) ReleaseArticles(ctx context.Context, aids []int64) error {
+	return dao.db.WithContext(ctx).
+		Where("article_id IN ?", aids).
+		Delete(&ArticleImport{}).Error
+}
+
+// ArticleImport 导入过的文件
+type ArticleImport struct {
+	Id   int64  `gorm:"primaryKey,autoIncrement"`
+	Uid  int64  `gorm:"uniqueIndex:uid_hash"`
+	Hash string `gorm:"type:varchar(64);uniqueIndex:uid_hash"`
+	// ArticleId 0 表示正在导入
+	ArticleId int64 `gorm:"index"`
+	Ctime     int64
+	Utime     int64
+}
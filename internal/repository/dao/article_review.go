@@ -0,0 +1,130 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 和 domain.ArticleReviewStatus 保持一致
+const (
+	articleReviewStatusPending  uint8 = 1
+	articleReviewStatusCanceled uint8 = 4
+)
+
+type ArticleReviewDAO interface {
+	// Insert 同一篇文章之前还没有审核的任务会被取消，只审核最新的版本
+	Insert(ctx context.Context, r ArticleReview) (int64, error)
+	FindById(ctx context.Context, id int64) (ArticleReview, error)
+	// FindPending 待审核的任务，先提交的排在前面
+	FindPending(ctx context.Context, offset, limit int) ([]ArticleReview, error)
+	// Decide 审核一个任务，任务已经不是待审核状态的时候返回 ErrRecordNotFound
+	Decide(ctx context.Context, id int64, status uint8, reviewer int64, comment string) error
+	// Reopen 把 status 状态的任务放回待审核，Decide 之后后续步骤失败的时候用
+	Reopen(ctx context.Context, id int64, status uint8) error
+	// CancelByArticle 取消文章所有待审核的任务
+	CancelByArticle(ctx context.Context, aid int64) error
+}
+
+type GORMArticleReviewDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMArticleReviewDAO(db *gorm.DB) ArticleReviewDAO {
+	return &GORMArticleReviewDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMArticleReviewDAO) Insert(ctx context.Context, r ArticleReview) (int64, error) {
+	now := time.Now().UnixMilli()
+	r.Status = articleReviewStatusPending
+	r.Ctime = now
+	r.Utime = now
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := dao.cancel(tx, r.Aid, now)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&r).Error
+	})
+	return r.Id, err
+}
+
+func (dao *GORMArticleReviewDAO) FindById(ctx context.Context, id int64) (ArticleReview, error) {
+	var res ArticleReview
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleReviewDAO) FindPending(ctx context.Context, offset, limit int) ([]ArticleReview, error) {
+	var res []ArticleReview
+	err := dao.db.WithContext(ctx).
+		Where("status = ?", articleReviewStatusPending).
+		Order("id ASC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleReviewDAO) Decide(ctx context.Context, id int64, status uint8,
+	reviewer int64, comment string) error {
+	// 带上状态，两个审核员同时处理同一个任务的时候只有一个能成功
+	res := dao.db.WithContext(ctx).Model(&ArticleReview{}).
+		Where("id = ? AND status = ?", id, articleReviewStatusPending).
+		Updates(map[string]any{
+			"status":   status,
+			"reviewer": reviewer,
+			"comment":  comment,
+			"utime":    time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GORMArticleReviewDAO) Reopen(ctx context.Context, id int64, status uint8) error {
+	return dao.db.WithContext(ctx).Model(&ArticleReview{}).
+		Where("id = ? AND status = ?", id, status).
+		Updates(map[string]any{
+			"status":   articleReviewStatusPending,
+			"reviewer": 0,
+			"comment":  "",
+			"utime":    time.Now().UnixMilli(),
+		}).Error
+}
+
+func (dao *GORMArticleReviewDAO) CancelByArticle(ctx context.Context, aid int64) error {
+	return dao.cancel(dao.db.WithContext(ctx), aid, time.Now().UnixMilli())
+}
+
+func (dao *GORMArticleReviewDAO) cancel(tx *gorm.DB, aid int64, now int64) error {
+	return tx.Model(&ArticleReview{}).
+		Where("aid = ? AND status = ?", aid, articleReviewStatusPending).
+		Updates(map[string]any{
+			"status": articleReviewStatusCanceled,
+			"utime":  now,
+		}).Error
+}
+
+// ArticleReview 文章的人工审核任务
+type ArticleReview struct {
+	Id       int64 `gorm:"primaryKey,autoIncrement"`
+	Aid      int64 `gorm:"index"`
+	AuthorId int64
+	Title    string `gorm:"type:varchar(1024)"`
+	// 提交审核的内容，审核通过之后发表的就是这个版本
+	Content string `gorm:"type=BLOB"`
+	Format  uint8
+	// 机审转人工的原因，JSON 数组
+	Reasons  string `gorm:"type:varchar(1024)"`
+	Status   uint8  `gorm:"index"`
+	Reviewer int64
+	Comment  string `gorm:"type:varchar(1024)"`
+	Ctime    int64
+	Utime    int64
+}
@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestArticleGORMDAO_SyncStatus(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(t *testing.T) *sql.DB
+		uid  int64
+		id   int64
+
+		wantErr bool
+	}{
+		{
+			name: "同步成功",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				mock.ExpectBegin()
+				// 第三个参数是文章 ID，第四个是作者
+				mock.ExpectExec("UPDATE `articles` SET .*").
+					WithArgs(uint8(2), sqlmock.AnyArg(), int64(1), int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE `published_articles` SET .*").
+					WithArgs(uint8(2), sqlmock.AnyArg(), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				return db
+			},
+			uid: 123,
+			id:  1,
+		},
+		{
+			name: "不是作者",
+			mock: func(t *testing.T) *sql.DB {
+				db, mock, err := sqlmock.New()
+				assert.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `articles` SET .*").
+					WithArgs(uint8(2), sqlmock.AnyArg(), int64(1), int64(456)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				return db
+			},
+			uid:     456,
+			id:      1,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sqlDB := tc.mock(t)
+			db, err := gorm.Open(mysql.New(mysql.Config{
+				Conn:                      sqlDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:   true,
+				SkipDefaultTransaction: true,
+			})
+			assert.NoError(t, err)
+			dao := NewArticleGORMDAO(db)
+			err = dao.SyncStatus(context.Background(), tc.uid, tc.id, 2)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -10,7 +10,14 @@ import (
 )
 
 func InitTables(db *gorm.DB) error {
-	return db.AutoMigrate(&User{}, &Article{}, &PublishedArticle{}, &Interactive{}, &UserLikeBiz{}, &UserCollectionBiz{})
+	err := db.AutoMigrate(&User{}, &Article{}, &PublishedArticle{}, &Interactive{}, &UserLikeBiz{}, &UserCollectionBiz{},
+		&UserRecoveryCode{}, &UserOAuth2{}, &LoginLog{}, &PrivacySettings{}, &UserBlock{}, &ArticleReview{},
+		&Series{}, &SeriesArticle{}, &ArticleImport{}, &ReadingHistory{},
+		&LedgerAccount{}, &LedgerTxn{}, &LedgerEntry{})
+	if err != nil {
+		return err
+	}
+	return BackfillNicknameKey(db)
 }
 
 func InitCollection(mdb *mongo.Database) error {
@@ -19,11 +26,19 @@ func InitCollection(mdb *mongo.Database) error {
 	col := mdb.Collection("articles")
 	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
-			Keys:    bson.D{bson.E{"id", 1}},
+			Keys:    bson.D{bson.E{Key: "id", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
 		{
-			Keys: bson.D{bson.E{"author_id", 1}},
+			Keys: bson.D{bson.E{Key: "author_id", Value: 1}},
+		},
+		{
+			// 定时清理回收站用
+			Keys: bson.D{bson.E{Key: "dtime", Value: 1}},
+		},
+		{
+			// 作者的列表按照 (utime, id) 倒序翻页
+			Keys: bson.D{bson.E{Key: "author_id", Value: 1}, bson.E{Key: "utime", Value: -1}, bson.E{Key: "id", Value: -1}},
 		},
 	})
 	if err != nil {
@@ -32,11 +47,38 @@ func InitCollection(mdb *mongo.Database) error {
 	liveCol := mdb.Collection("published_articles")
 	_, err = liveCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
-			Keys:    bson.D{bson.E{"id", 1}},
+			Keys:    bson.D{bson.E{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "author_id", Value: 1}},
+		},
+		{
+			// 订阅源按照 utime 倒序取最新的，sitemap 按照 id 翻页
+			Keys: bson.D{bson.E{Key: "status", Value: 1}, bson.E{Key: "utime", Value: -1}, bson.E{Key: "id", Value: -1}},
+		},
+		{
+			Keys: bson.D{bson.E{Key: "status", Value: 1}, bson.E{Key: "id", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	seriesCol := mdb.Collection("series")
+	_, err = seriesCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{bson.E{Key: "id", Value: 1}},
 			Options: options.Index().SetUnique(true),
 		},
 		{
-			Keys: bson.D{bson.E{"author_id", 1}},
+			Keys: bson.D{bson.E{Key: "author_id", Value: 1}},
+		},
+		{
+			// 一篇文章只能属于一个系列。
+			// 空数组在索引里面是同一个值，所以只索引有文章的系列
+			Keys: bson.D{bson.E{Key: "article_ids", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"article_ids.0": bson.M{"$exists": true}}),
 		},
 	})
 	return err
@@ -2,14 +2,20 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"time"
 )
 
+// ErrLikeUnchanged 已经是点赞或者取消点赞的状态了，计数没有变
+var ErrLikeUnchanged = errors.New("点赞状态没有变化")
+
 type InteractiveDAO interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
+	// InsertLikeInfo 已经点赞过的返回 ErrLikeUnchanged，计数不变
 	InsertLikeInfo(ctx context.Context, biz string, id int64, uid int64) error
+	// DeleteLikeInfo 没有点赞过的返回 ErrLikeUnchanged，计数不变
 	DeleteLikeInfo(ctx context.Context, biz string, id int64, uid int64) error
 	InsertCollectionBiz(ctx context.Context, cb UserCollectionBiz) error
 	GetLikeInfo(ctx context.Context,
@@ -17,7 +23,24 @@ type InteractiveDAO interface {
 	GetCollectInfo(ctx context.Context,
 		biz string, id int64, uid int64) (UserCollectionBiz, error)
 	Get(ctx context.Context, biz string, id int64) (Interactive, error)
+	// GetByIds 一次查多个资源的计数，没有计数的资源不在结果里面
+	GetByIds(ctx context.Context, biz string, ids []int64) ([]Interactive, error)
+	// GetLikeInfos uid 点赞了的那些资源
+	GetLikeInfos(ctx context.Context, biz string, ids []int64, uid int64) ([]UserLikeBiz, error)
+	// GetCollectInfos uid 收藏了的那些资源
+	GetCollectInfos(ctx context.Context, biz string, ids []int64, uid int64) ([]UserCollectionBiz, error)
 	BatchIncrReadCnt(ctx context.Context, biz []string, id []int64) error
+	// TransferUser 把 from 的点赞和收藏转移给 to，返回计数有变化的资源
+	TransferUser(ctx context.Context, from, to int64) ([]Interactive, error)
+	// DeleteByUser 删除用户所有的点赞和收藏，同时修正计数，返回计数有变化的资源
+	DeleteByUser(ctx context.Context, uid int64) ([]Interactive, error)
+	// DeleteByBiz 资源被彻底删除之后，删除对应的计数、点赞和收藏
+	DeleteByBiz(ctx context.Context, biz string, ids []int64) error
+	GetCollectionsByUser(ctx context.Context, uid int64) ([]UserCollectionBiz, error)
+	// ListLikesByUser 分页查用户点赞过的资源，最近点赞的在前面
+	ListLikesByUser(ctx context.Context, uid int64, offset, limit int) ([]UserLikeBiz, error)
+	// ListCollectionsByUser 分页查用户的收藏，最近收藏的在前面
+	ListCollectionsByUser(ctx context.Context, uid int64, offset, limit int) ([]UserCollectionBiz, error)
 }
 
 type GORMInteractiveDAO struct {
@@ -40,12 +63,230 @@ func (dao *GORMInteractiveDAO) BatchIncrReadCnt(ctx context.Context, biz []strin
 
 }
 
+func (dao *GORMInteractiveDAO) TransferUser(ctx context.Context, from, to int64) ([]Interactive, error) {
+	var changed []Interactive
+	now := time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var likes []UserLikeBiz
+		err := tx.Where("uid = ?", from).Find(&likes).Error
+		if err != nil {
+			return err
+		}
+		for _, l := range likes {
+			var tl UserLikeBiz
+			err = tx.Where("uid = ? AND biz = ? AND biz_id = ?", to, l.Biz, l.BizId).
+				First(&tl).Error
+			switch {
+			case errors.Is(err, ErrRecordNotFound):
+				// to 没有点赞过，直接转过去，计数不变
+				err = tx.Model(&UserLikeBiz{}).Where("id = ?", l.Id).
+					Updates(map[string]any{
+						"uid":   to,
+						"utime": now,
+					}).Error
+				if err != nil {
+					return err
+				}
+				continue
+			case err != nil:
+				return err
+			}
+			// 唯一索引冲突，只能保留 to 的那一条
+			err = tx.Where("id = ?", l.Id).Delete(&UserLikeBiz{}).Error
+			if err != nil {
+				return err
+			}
+			if l.Status != 1 {
+				continue
+			}
+			if tl.Status == 1 {
+				// 两个账号都点赞了，合并之后只算一个
+				err = tx.Model(&Interactive{}).
+					Where("biz = ? AND biz_id = ?", l.Biz, l.BizId).
+					Updates(map[string]any{
+						"like_cnt": gorm.Expr("`like_cnt` - 1"),
+						"utime":    now,
+					}).Error
+				changed = append(changed, Interactive{Biz: l.Biz, BizId: l.BizId})
+			} else {
+				err = tx.Model(&UserLikeBiz{}).Where("id = ?", tl.Id).
+					Updates(map[string]any{
+						"status": 1,
+						"utime":  now,
+					}).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var cbs []UserCollectionBiz
+		err = tx.Where("uid = ?", from).Find(&cbs).Error
+		if err != nil {
+			return err
+		}
+		for _, cb := range cbs {
+			var tcb UserCollectionBiz
+			err = tx.Where("uid = ? AND biz = ? AND biz_id = ?", to, cb.Biz, cb.BizId).
+				First(&tcb).Error
+			switch {
+			case errors.Is(err, ErrRecordNotFound):
+				// 收藏夹也是用户的，收藏夹 ID 保持不变
+				err = tx.Model(&UserCollectionBiz{}).Where("id = ?", cb.Id).
+					Updates(map[string]any{
+						"uid":   to,
+						"utime": now,
+					}).Error
+				if err != nil {
+					return err
+				}
+				continue
+			case err != nil:
+				return err
+			}
+			err = tx.Where("id = ?", cb.Id).Delete(&UserCollectionBiz{}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&Interactive{}).
+				Where("biz = ? AND biz_id = ?", cb.Biz, cb.BizId).
+				Updates(map[string]any{
+					"collect_cnt": gorm.Expr("`collect_cnt` - 1"),
+					"utime":       now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			changed = append(changed, Interactive{Biz: cb.Biz, BizId: cb.BizId})
+		}
+		return nil
+	})
+	return changed, err
+}
+
+func (dao *GORMInteractiveDAO) DeleteByUser(ctx context.Context, uid int64) ([]Interactive, error) {
+	var changed []Interactive
+	now := time.Now().UnixMilli()
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var likes []UserLikeBiz
+		// status = 0 的是已经取消的点赞，不影响计数
+		err := tx.Where("uid = ? AND status = ?", uid, 1).Find(&likes).Error
+		if err != nil {
+			return err
+		}
+		for _, l := range likes {
+			err = tx.Model(&Interactive{}).
+				Where("biz = ? AND biz_id = ?", l.Biz, l.BizId).
+				Updates(map[string]any{
+					"like_cnt": gorm.Expr("`like_cnt` - 1"),
+					"utime":    now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			changed = append(changed, Interactive{Biz: l.Biz, BizId: l.BizId})
+		}
+		err = tx.Where("uid = ?", uid).Delete(&UserLikeBiz{}).Error
+		if err != nil {
+			return err
+		}
+
+		var cbs []UserCollectionBiz
+		err = tx.Where("uid = ?", uid).Find(&cbs).Error
+		if err != nil {
+			return err
+		}
+		for _, cb := range cbs {
+			err = tx.Model(&Interactive{}).
+				Where("biz = ? AND biz_id = ?", cb.Biz, cb.BizId).
+				Updates(map[string]any{
+					"collect_cnt": gorm.Expr("`collect_cnt` - 1"),
+					"utime":       now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			changed = append(changed, Interactive{Biz: cb.Biz, BizId: cb.BizId})
+		}
+		return tx.Where("uid = ?", uid).Delete(&UserCollectionBiz{}).Error
+	})
+	return changed, err
+}
+
+func (dao *GORMInteractiveDAO) DeleteByBiz(ctx context.Context, biz string, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("biz = ? AND biz_id IN ?", biz, ids).Delete(&Interactive{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("biz = ? AND biz_id IN ?", biz, ids).Delete(&UserLikeBiz{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("biz = ? AND biz_id IN ?", biz, ids).Delete(&UserCollectionBiz{}).Error
+	})
+}
+
+func (dao *GORMInteractiveDAO) GetCollectionsByUser(ctx context.Context, uid int64) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("ctime DESC").Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMInteractiveDAO) ListLikesByUser(ctx context.Context, uid int64, offset, limit int) ([]UserLikeBiz, error) {
+	var res []UserLikeBiz
+	// 取消了又点赞的会更新 utime
+	err := dao.db.WithContext(ctx).Where("uid = ? AND status = ?", uid, 1).
+		Order("utime DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMInteractiveDAO) ListCollectionsByUser(ctx context.Context, uid int64, offset, limit int) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("ctime DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
 func (dao *GORMInteractiveDAO) Get(ctx context.Context, biz string, id int64) (Interactive, error) {
 	var res Interactive
 	err := dao.db.WithContext(ctx).Where("biz = ? AND biz_id = ?", biz, id).First(&res).Error
 	return res, err
 }
 
+func (dao *GORMInteractiveDAO) GetByIds(ctx context.Context, biz string, ids []int64) ([]Interactive, error) {
+	var res []Interactive
+	err := dao.db.WithContext(ctx).
+		Where("biz = ? AND biz_id IN ?", biz, ids).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMInteractiveDAO) GetLikeInfos(ctx context.Context,
+	biz string, ids []int64, uid int64) ([]UserLikeBiz, error) {
+	var res []UserLikeBiz
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND biz = ? AND biz_id IN ? AND status = ?",
+			uid, biz, ids, 1).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMInteractiveDAO) GetCollectInfos(ctx context.Context,
+	biz string, ids []int64, uid int64) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND biz = ? AND biz_id IN ?", uid, biz, ids).
+		Find(&res).Error
+	return res, err
+}
+
 func (dao *GORMInteractiveDAO) GetLikeInfo(ctx context.Context,
 	biz string, id int64, uid int64) (UserLikeBiz, error) {
 	var res UserLikeBiz
@@ -94,21 +335,31 @@ func (dao *GORMInteractiveDAO) InsertLikeInfo(ctx context.Context,
 	biz string, id int64, uid int64) error {
 	now := time.Now().UnixMilli()
 	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		err := tx.Clauses(clause.OnConflict{
-			DoUpdates: clause.Assignments(map[string]interface{}{
+		// 只有状态真的变了才加一，消息重复消费的时候计数不会乱
+		res := tx.Model(&UserLikeBiz{}).
+			Where("uid = ? AND biz_id = ? AND biz = ? AND status = ?", uid, id, biz, 0).
+			Updates(map[string]interface{}{
 				"utime":  now,
 				"status": 1,
-			}),
-		}).Create(&UserLikeBiz{
-			Uid:    uid,
-			Biz:    biz,
-			BizId:  id,
-			Status: 1,
-			Utime:  now,
-			Ctime:  now,
-		}).Error
-		if err != nil {
-			return err
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserLikeBiz{
+				Uid:    uid,
+				Biz:    biz,
+				BizId:  id,
+				Status: 1,
+				Utime:  now,
+				Ctime:  now,
+			})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return ErrLikeUnchanged
+			}
 		}
 		return tx.WithContext(ctx).Clauses(clause.OnConflict{
 			DoUpdates: clause.Assignments(map[string]interface{}{
@@ -129,14 +380,17 @@ func (dao *GORMInteractiveDAO) DeleteLikeInfo(ctx context.Context,
 	biz string, id int64, uid int64) error {
 	now := time.Now().UnixMilli()
 	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		err := tx.Model(&UserLikeBiz{}).
-			Where("uid=? AND biz_id = ? AND biz=?", uid, id, biz).
+		res := tx.Model(&UserLikeBiz{}).
+			Where("uid=? AND biz_id = ? AND biz=? AND status = ?", uid, id, biz, 1).
 			Updates(map[string]interface{}{
 				"utime":  now,
 				"status": 0,
-			}).Error
-		if err != nil {
-			return err
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrLikeUnchanged
 		}
 		return tx.Model(&Interactive{}).
 			Where("biz =? AND biz_id=?", biz, id).
@@ -181,6 +435,7 @@ type Interactive struct {
 	ReadCnt    int64
 	LikeCnt    int64
 	CollectCnt int64
+	RewardCnt  int64
 	Utime      int64
 	Ctime      int64
 }
@@ -0,0 +1,43 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestGORMInteractiveDAO_LikeUnchanged(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	// 已经点赞过了，不会加一
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `user_like_bizs` SET .* AND status = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `user_like_bizs`").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	// 没有点赞过，不会减一
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `user_like_bizs` SET .* AND status = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	assert.NoError(t, err)
+	dao := NewGORMInteractiveDAO(db)
+	err = dao.InsertLikeInfo(context.Background(), "article", 1, 123)
+	assert.ErrorIs(t, err, ErrLikeUnchanged)
+	err = dao.DeleteLikeInfo(context.Background(), "article", 1, 123)
+	assert.ErrorIs(t, err, ErrLikeUnchanged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
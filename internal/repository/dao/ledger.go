@@ -0,0 +1,216 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrLedgerTxnDuplicate 同一个幂等键的交易已经记过了
+	ErrLedgerTxnDuplicate = errors.New("交易已经存在")
+	// ErrInsufficientBalance 用户账户的余额不够
+	ErrInsufficientBalance = errors.New("余额不足")
+)
+
+// 和 domain.LedgerAccountTypeUser 保持一致，只有用户账户要检查余额
+const ledgerAccountTypeUser uint8 = 1
+
+type LedgerDAO interface {
+	// Transfer 在一个事务里面记交易、记两条流水、更新两个账户的余额，
+	// 带着 Biz 的交易同时更新资源的打赏数。
+	// 幂等键已经存在的时候什么都不改，返回已有的交易和 ErrLedgerTxnDuplicate
+	Transfer(ctx context.Context, txn LedgerTxn, from, to LedgerAccount) (LedgerTxn, error)
+	// FindAccount 直接读主库，余额要强一致
+	FindAccount(ctx context.Context, typ uint8, uid int64) (LedgerAccount, error)
+	FindEntries(ctx context.Context, accountId int64, offset, limit int) ([]LedgerEntry, error)
+}
+
+type GORMLedgerDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMLedgerDAO(db *gorm.DB) LedgerDAO {
+	return &GORMLedgerDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMLedgerDAO) Transfer(ctx context.Context, txn LedgerTxn, from, to LedgerAccount) (LedgerTxn, error) {
+	now := time.Now().UnixMilli()
+	txn.Ctime = now
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 账户不存在就先建出来，余额是 0
+		from.Ctime, from.Utime = now, now
+		to.Ctime, to.Utime = now, now
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&[]LedgerAccount{from, to}).Error
+		if err != nil {
+			return err
+		}
+		// 按照 id 的顺序加锁，两个人互相打赏的时候不会死锁
+		var accs []LedgerAccount
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("(type = ? AND uid = ?) OR (type = ? AND uid = ?)",
+				from.Type, from.Uid, to.Type, to.Uid).
+			Order("id").
+			Find(&accs).Error
+		if err != nil {
+			return err
+		}
+		for _, acc := range accs {
+			if acc.Type == from.Type && acc.Uid == from.Uid {
+				from = acc
+			}
+			if acc.Type == to.Type && acc.Uid == to.Uid {
+				to = acc
+			}
+		}
+		if from.Id == 0 || to.Id == 0 || from.Id == to.Id {
+			return errors.New("积分账户不对")
+		}
+		// 先查幂等键再检查余额，重试的时候余额可能已经花掉了，还是要返回第一次的交易
+		var existing LedgerTxn
+		err = tx.Where("idempotency_key = ?", txn.IdempotencyKey).
+			Limit(1).Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if existing.Id > 0 {
+			txn = existing
+			return ErrLedgerTxnDuplicate
+		}
+		if from.Type == ledgerAccountTypeUser && from.Balance < txn.Amount {
+			return ErrInsufficientBalance
+		}
+
+		txn.FromAccount = from.Id
+		txn.ToAccount = to.Id
+		err = tx.Create(&txn).Error
+		if err != nil {
+			return dao.duplicateErr(err)
+		}
+
+		from.Balance -= txn.Amount
+		to.Balance += txn.Amount
+		for _, acc := range []LedgerAccount{from, to} {
+			err = tx.Model(&LedgerAccount{}).Where("id = ?", acc.Id).
+				Updates(map[string]any{
+					"balance": acc.Balance,
+					"utime":   now,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		err = tx.Create(&[]LedgerEntry{
+			{AccountId: from.Id, TxnId: txn.Id, Type: txn.Type,
+				Amount: -txn.Amount, Balance: from.Balance, Ctime: now},
+			{AccountId: to.Id, TxnId: txn.Id, Type: txn.Type,
+				Amount: txn.Amount, Balance: to.Balance, Ctime: now},
+		}).Error
+		if err != nil {
+			return err
+		}
+		if txn.Biz == "" {
+			return nil
+		}
+		// 打赏数和交易在一个事务里面，重试的时候不会多算
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"reward_cnt": gorm.Expr("`reward_cnt` + 1"),
+				"utime":      now,
+			}),
+		}).Create(&Interactive{
+			Biz:       txn.Biz,
+			BizId:     txn.BizId,
+			RewardCnt: 1,
+			Ctime:     now,
+			Utime:     now,
+		}).Error
+	})
+	if errors.Is(err, ErrLedgerTxnDuplicate) && txn.Id > 0 {
+		return txn, err
+	}
+	if errors.Is(err, ErrLedgerTxnDuplicate) {
+		// 并发的请求同时插入，唯一索引冲突
+		var existing LedgerTxn
+		err = dao.db.WithContext(ctx).
+			Where("idempotency_key = ?", txn.IdempotencyKey).
+			First(&existing).Error
+		if err != nil {
+			return LedgerTxn{}, err
+		}
+		return existing, ErrLedgerTxnDuplicate
+	}
+	if err != nil {
+		return LedgerTxn{}, err
+	}
+	return txn, nil
+}
+
+// duplicateErr 交易表上只有幂等键一个唯一索引
+func (dao *GORMLedgerDAO) duplicateErr(err error) error {
+	const duplicateErr uint16 = 1062
+	if me, ok := err.(*mysql.MySQLError); ok && me.Number == duplicateErr {
+		return ErrLedgerTxnDuplicate
+	}
+	return err
+}
+
+func (dao *GORMLedgerDAO) FindAccount(ctx context.Context, typ uint8, uid int64) (LedgerAccount, error) {
+	var res LedgerAccount
+	err := dao.db.WithContext(ctx).
+		Where("type = ? AND uid = ?", typ, uid).
+		First(&res).Error
+	return res, err
+}
+
+func (dao *GORMLedgerDAO) FindEntries(ctx context.Context, accountId int64, offset, limit int) ([]LedgerEntry, error) {
+	var res []LedgerEntry
+	err := dao.db.WithContext(ctx).
+		Where("account_id = ?", accountId).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+// LedgerAccount 积分账户，余额只能在 Transfer 里面改
+type LedgerAccount struct {
+	Id      int64 `gorm:"primaryKey,autoIncrement"`
+	Type    uint8 `gorm:"uniqueIndex:type_uid"`
+	Uid     int64 `gorm:"uniqueIndex:type_uid"`
+	Balance int64
+	Ctime   int64
+	Utime   int64
+}
+
+// LedgerTxn 交易，每一笔对应两条 LedgerEntry
+type LedgerTxn struct {
+	Id             int64  `gorm:"primaryKey,autoIncrement"`
+	IdempotencyKey string `gorm:"type:varchar(128);uniqueIndex"`
+	Type           uint8
+	FromAccount    int64
+	ToAccount      int64
+	Amount         int64
+	Biz            string `gorm:"type:varchar(128)"`
+	BizId          int64
+	Ref            string `gorm:"type:varchar(128)"`
+	Ctime          int64
+}
+
+// LedgerEntry 流水，同一笔交易的两条加起来是 0
+type LedgerEntry struct {
+	Id        int64 `gorm:"primaryKey,autoIncrement"`
+	AccountId int64 `gorm:"index"`
+	TxnId     int64 `gorm:"index"`
+	Type      uint8
+	Amount    int64
+	Balance   int64
+	Ctime     int64
+}
@@ -0,0 +1,140 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestGORMLedgerDAO_Transfer(t *testing.T) {
+	accountCols := []string{"id", "type", "uid", "balance"}
+	testCases := []struct {
+		name string
+		mock func(mock sqlmock.Sqlmock)
+
+		wantTxn LedgerTxn
+		wantErr error
+	}{
+		{
+			name: "打赏成功",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `ledger_accounts`.*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows(accountCols).
+						AddRow(1, 1, 123, 100).
+						AddRow(2, 1, 456, 0))
+				mock.ExpectQuery("SELECT .* FROM `ledger_txns`.*").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO `ledger_txns`.*").
+					WillReturnResult(sqlmock.NewResult(10, 1))
+				mock.ExpectExec("UPDATE `ledger_accounts`.*").
+					WithArgs(int64(70), sqlmock.AnyArg(), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE `ledger_accounts`.*").
+					WithArgs(int64(30), sqlmock.AnyArg(), int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `ledger_entries`.*").
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectExec("INSERT INTO `interactives`.*").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantTxn: LedgerTxn{Id: 10, IdempotencyKey: "reward:123:abc", Type: 2,
+				FromAccount: 1, ToAccount: 2, Amount: 30, Biz: "article", BizId: 11},
+		},
+		{
+			name: "余额不足",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `ledger_accounts`.*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows(accountCols).
+						AddRow(1, 1, 123, 10).
+						AddRow(2, 1, 456, 0))
+				mock.ExpectQuery("SELECT .* FROM `ledger_txns`.*").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrInsufficientBalance,
+		},
+		{
+			name: "余额花掉之后重试",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `ledger_accounts`.*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows(accountCols).
+						AddRow(1, 1, 123, 0).
+						AddRow(2, 1, 456, 30))
+				// 第一次的交易已经成功了，不检查余额，直接返回
+				mock.ExpectQuery("SELECT .* FROM `ledger_txns`.*").
+					WithArgs("reward:123:abc", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "idempotency_key", "amount"}).
+						AddRow(9, "reward:123:abc", 30))
+				mock.ExpectRollback()
+			},
+			wantTxn: LedgerTxn{Id: 9, IdempotencyKey: "reward:123:abc", Amount: 30},
+			wantErr: ErrLedgerTxnDuplicate,
+		},
+		{
+			name: "并发的重复请求",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `ledger_accounts`.*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows(accountCols).
+						AddRow(1, 1, 123, 100).
+						AddRow(2, 1, 456, 30))
+				mock.ExpectQuery("SELECT .* FROM `ledger_txns`.*").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO `ledger_txns`.*").
+					WillReturnError(&mysqlDriver.MySQLError{Number: 1062})
+				mock.ExpectRollback()
+				// 余额没有动，返回第一次的交易
+				mock.ExpectQuery("SELECT .* FROM `ledger_txns`.*").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "idempotency_key", "amount"}).
+						AddRow(9, "reward:123:abc", 30))
+			},
+			wantTxn: LedgerTxn{Id: 9, IdempotencyKey: "reward:123:abc", Amount: 30},
+			wantErr: ErrLedgerTxnDuplicate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			tc.mock(mock)
+			db, err := gorm.Open(mysql.New(mysql.Config{
+				Conn:                      sqlDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:   true,
+				SkipDefaultTransaction: true,
+			})
+			assert.NoError(t, err)
+			dao := NewGORMLedgerDAO(db)
+			txn, err := dao.Transfer(context.Background(), LedgerTxn{
+				IdempotencyKey: "reward:123:abc",
+				Type:           2,
+				Amount:         30,
+				Biz:            "article",
+				BizId:          11,
+			}, LedgerAccount{Type: 1, Uid: 123}, LedgerAccount{Type: 1, Uid: 456})
+			assert.ErrorIs(t, err, tc.wantErr)
+			txn.Ctime = 0
+			assert.Equal(t, tc.wantTxn, txn)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
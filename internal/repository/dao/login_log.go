@@ -0,0 +1,69 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type LoginLogDAO interface {
+	Insert(ctx context.Context, l LoginLog) error
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]LoginLog, error)
+	// FindRecent 最近 limit 次某种结果的登录
+	FindRecent(ctx context.Context, uid int64, result uint8, limit int) ([]LoginLog, error)
+	// CountByAccount 从 since 开始，某个账号某种结果的登录次数
+	CountByAccount(ctx context.Context, account string, result uint8, since int64) (int64, error)
+}
+
+type GORMLoginLogDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMLoginLogDAO(db *gorm.DB) LoginLogDAO {
+	return &GORMLoginLogDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMLoginLogDAO) Insert(ctx context.Context, l LoginLog) error {
+	l.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Create(&l).Error
+}
+
+func (dao *GORMLoginLogDAO) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]LoginLog, error) {
+	var res []LoginLog
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMLoginLogDAO) FindRecent(ctx context.Context, uid int64, result uint8, limit int) ([]LoginLog, error) {
+	var res []LoginLog
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND result = ?", uid, result).
+		Order("id DESC").Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMLoginLogDAO) CountByAccount(ctx context.Context, account string, result uint8, since int64) (int64, error) {
+	var cnt int64
+	err := dao.db.WithContext(ctx).Model(&LoginLog{}).
+		Where("account = ? AND result = ? AND ctime >= ?", account, result, since).
+		Count(&cnt).Error
+	return cnt, err
+}
+
+// LoginLog 登录记录，只增不改
+type LoginLog struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	Uid int64 `gorm:"index"`
+	// 统计失败次数按照账号来查
+	Account   string `gorm:"type:varchar(256);index:account_ctime"`
+	Method    string `gorm:"type:varchar(64)"`
+	IP        string `gorm:"type:varchar(64)"`
+	UserAgent string `gorm:"type:varchar(512)"`
+	Result    uint8
+	Ctime     int64 `gorm:"index:account_ctime"`
+}
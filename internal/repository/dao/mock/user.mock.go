@@ -11,7 +11,9 @@ package daomocks
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 	dao "webook/internal/repository/dao"
 
 	gomock "go.uber.org/mock/gomock"
@@ -23,11 +25,6 @@ type MockUserDAO struct {
 	recorder *MockUserDAOMockRecorder
 }
 
-func (m *MockUserDAO) FindByWechat(ctx context.Context, openId string) (dao.User, error) {
-	//TODO implement me
-	panic("implement me")
-}
-
 // MockUserDAOMockRecorder is the mock recorder for MockUserDAO.
 type MockUserDAOMockRecorder struct {
 	mock *MockUserDAO
@@ -45,7 +42,49 @@ func (m *MockUserDAO) EXPECT() *MockUserDAOMockRecorder {
 	return m.recorder
 }
 
-// FindByEmail mock base method.
+// DeleteOAuth2 mocks base method.
+func (m *MockUserDAO) DeleteOAuth2(ctx context.Context, uid int64, provider string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOAuth2", ctx, uid, provider)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOAuth2 indicates an expected call of DeleteOAuth2.
+func (mr *MockUserDAOMockRecorder) DeleteOAuth2(ctx, uid, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOAuth2", reflect.TypeOf((*MockUserDAO)(nil).DeleteOAuth2), ctx, uid, provider)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockUserDAO) DisableTOTP(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockUserDAOMockRecorder) DisableTOTP(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockUserDAO)(nil).DisableTOTP), ctx, uid)
+}
+
+// EnableTOTP mocks base method.
+func (m *MockUserDAO) EnableTOTP(ctx context.Context, uid int64, codes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTOTP", ctx, uid, codes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTOTP indicates an expected call of EnableTOTP.
+func (mr *MockUserDAOMockRecorder) EnableTOTP(ctx, uid, codes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTP", reflect.TypeOf((*MockUserDAO)(nil).EnableTOTP), ctx, uid, codes)
+}
+
+// FindByEmail mocks base method.
 func (m *MockUserDAO) FindByEmail(ctx context.Context, email string) (dao.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByEmail", ctx, email)
@@ -60,7 +99,7 @@ func (mr *MockUserDAOMockRecorder) FindByEmail(ctx, email any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserDAO)(nil).FindByEmail), ctx, email)
 }
 
-// FindById mock base method.
+// FindById mocks base method.
 func (m *MockUserDAO) FindById(ctx context.Context, uid int64) (dao.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindById", ctx, uid)
@@ -75,7 +114,37 @@ func (mr *MockUserDAOMockRecorder) FindById(ctx, uid any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindById", reflect.TypeOf((*MockUserDAO)(nil).FindById), ctx, uid)
 }
 
-// FindByPhone mock base method.
+// FindByNicknameKey mocks base method.
+func (m *MockUserDAO) FindByNicknameKey(ctx context.Context, key string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByNicknameKey", ctx, key)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByNicknameKey indicates an expected call of FindByNicknameKey.
+func (mr *MockUserDAOMockRecorder) FindByNicknameKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByNicknameKey", reflect.TypeOf((*MockUserDAO)(nil).FindByNicknameKey), ctx, key)
+}
+
+// FindByOAuth2 mocks base method.
+func (m *MockUserDAO) FindByOAuth2(ctx context.Context, provider, externalId string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByOAuth2", ctx, provider, externalId)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByOAuth2 indicates an expected call of FindByOAuth2.
+func (mr *MockUserDAOMockRecorder) FindByOAuth2(ctx, provider, externalId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByOAuth2", reflect.TypeOf((*MockUserDAO)(nil).FindByOAuth2), ctx, provider, externalId)
+}
+
+// FindByPhone mocks base method.
 func (m *MockUserDAO) FindByPhone(ctx context.Context, phone string) (dao.User, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindByPhone", ctx, phone)
@@ -90,7 +159,52 @@ func (mr *MockUserDAOMockRecorder) FindByPhone(ctx, phone any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserDAO)(nil).FindByPhone), ctx, phone)
 }
 
-// Insert mock base method.
+// FindByWechat mocks base method.
+func (m *MockUserDAO) FindByWechat(ctx context.Context, openId string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechat", ctx, openId)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechat indicates an expected call of FindByWechat.
+func (mr *MockUserDAOMockRecorder) FindByWechat(ctx, openId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechat", reflect.TypeOf((*MockUserDAO)(nil).FindByWechat), ctx, openId)
+}
+
+// FindDeletedBefore mocks base method.
+func (m *MockUserDAO) FindDeletedBefore(ctx context.Context, t time.Time, limit int) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDeletedBefore", ctx, t, limit)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDeletedBefore indicates an expected call of FindDeletedBefore.
+func (mr *MockUserDAOMockRecorder) FindDeletedBefore(ctx, t, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDeletedBefore", reflect.TypeOf((*MockUserDAO)(nil).FindDeletedBefore), ctx, t, limit)
+}
+
+// FindOAuth2Providers mocks base method.
+func (m *MockUserDAO) FindOAuth2Providers(ctx context.Context, uid int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOAuth2Providers", ctx, uid)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOAuth2Providers indicates an expected call of FindOAuth2Providers.
+func (mr *MockUserDAOMockRecorder) FindOAuth2Providers(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOAuth2Providers", reflect.TypeOf((*MockUserDAO)(nil).FindOAuth2Providers), ctx, uid)
+}
+
+// Insert mocks base method.
 func (m *MockUserDAO) Insert(ctx context.Context, u dao.User) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Insert", ctx, u)
@@ -104,7 +218,105 @@ func (mr *MockUserDAOMockRecorder) Insert(ctx, u any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUserDAO)(nil).Insert), ctx, u)
 }
 
-// UpdateById mock base method.
+// InsertWithOAuth2 mocks base method.
+func (m *MockUserDAO) InsertWithOAuth2(ctx context.Context, u dao.User, provider, externalId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertWithOAuth2", ctx, u, provider, externalId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertWithOAuth2 indicates an expected call of InsertWithOAuth2.
+func (mr *MockUserDAOMockRecorder) InsertWithOAuth2(ctx, u, provider, externalId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertWithOAuth2", reflect.TypeOf((*MockUserDAO)(nil).InsertWithOAuth2), ctx, u, provider, externalId)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserDAO) MarkEmailVerified(ctx context.Context, uid int64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, uid, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserDAOMockRecorder) MarkEmailVerified(ctx, uid, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserDAO)(nil).MarkEmailVerified), ctx, uid, email)
+}
+
+// MergeIdentities mocks base method.
+func (m *MockUserDAO) MergeIdentities(ctx context.Context, from, to int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeIdentities", ctx, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeIdentities indicates an expected call of MergeIdentities.
+func (mr *MockUserDAOMockRecorder) MergeIdentities(ctx, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeIdentities", reflect.TypeOf((*MockUserDAO)(nil).MergeIdentities), ctx, from, to)
+}
+
+// Purge mocks base method.
+func (m *MockUserDAO) Purge(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockUserDAOMockRecorder) Purge(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockUserDAO)(nil).Purge), ctx, uid)
+}
+
+// SetTOTPSecret mocks base method.
+func (m *MockUserDAO) SetTOTPSecret(ctx context.Context, uid int64, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTOTPSecret", ctx, uid, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTOTPSecret indicates an expected call of SetTOTPSecret.
+func (mr *MockUserDAOMockRecorder) SetTOTPSecret(ctx, uid, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTOTPSecret", reflect.TypeOf((*MockUserDAO)(nil).SetTOTPSecret), ctx, uid, secret)
+}
+
+// SoftDelete mocks base method.
+func (m *MockUserDAO) SoftDelete(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDelete", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SoftDelete indicates an expected call of SoftDelete.
+func (mr *MockUserDAOMockRecorder) SoftDelete(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDelete", reflect.TypeOf((*MockUserDAO)(nil).SoftDelete), ctx, uid)
+}
+
+// UpdateAvatar mocks base method.
+func (m *MockUserDAO) UpdateAvatar(ctx context.Context, uid int64, avatar string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAvatar", ctx, uid, avatar)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAvatar indicates an expected call of UpdateAvatar.
+func (mr *MockUserDAOMockRecorder) UpdateAvatar(ctx, uid, avatar any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAvatar", reflect.TypeOf((*MockUserDAO)(nil).UpdateAvatar), ctx, uid, avatar)
+}
+
+// UpdateById mocks base method.
 func (m *MockUserDAO) UpdateById(ctx context.Context, entity dao.User) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateById", ctx, entity)
@@ -117,3 +329,87 @@ func (mr *MockUserDAOMockRecorder) UpdateById(ctx, entity any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateById", reflect.TypeOf((*MockUserDAO)(nil).UpdateById), ctx, entity)
 }
+
+// UpdateEmail mocks base method.
+func (m *MockUserDAO) UpdateEmail(ctx context.Context, uid int64, email sql.NullString, verified bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmail", ctx, uid, email, verified)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmail indicates an expected call of UpdateEmail.
+func (mr *MockUserDAOMockRecorder) UpdateEmail(ctx, uid, email, verified any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmail", reflect.TypeOf((*MockUserDAO)(nil).UpdateEmail), ctx, uid, email, verified)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserDAO) UpdatePassword(ctx context.Context, uid int64, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, uid, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserDAOMockRecorder) UpdatePassword(ctx, uid, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserDAO)(nil).UpdatePassword), ctx, uid, password)
+}
+
+// UpdatePhone mocks base method.
+func (m *MockUserDAO) UpdatePhone(ctx context.Context, uid int64, phone sql.NullString) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePhone", ctx, uid, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePhone indicates an expected call of UpdatePhone.
+func (mr *MockUserDAOMockRecorder) UpdatePhone(ctx, uid, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePhone", reflect.TypeOf((*MockUserDAO)(nil).UpdatePhone), ctx, uid, phone)
+}
+
+// UpdateWechat mocks base method.
+func (m *MockUserDAO) UpdateWechat(ctx context.Context, uid int64, openId, unionId sql.NullString) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWechat", ctx, uid, openId, unionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWechat indicates an expected call of UpdateWechat.
+func (mr *MockUserDAOMockRecorder) UpdateWechat(ctx, uid, openId, unionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWechat", reflect.TypeOf((*MockUserDAO)(nil).UpdateWechat), ctx, uid, openId, unionId)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserDAO) UseRecoveryCode(ctx context.Context, uid int64, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, uid, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserDAOMockRecorder) UseRecoveryCode(ctx, uid, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserDAO)(nil).UseRecoveryCode), ctx, uid, code)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockUserDAO) UseTOTPStep(ctx context.Context, uid, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, uid, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockUserDAOMockRecorder) UseTOTPStep(ctx, uid, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockUserDAO)(nil).UseTOTPStep), ctx, uid, step)
+}
@@ -2,7 +2,6 @@ package dao
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/bwmarrin/snowflake"
@@ -11,6 +10,12 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// notTrashed 没有 dtime 字段或者是 0 的都不在回收站里面
+	notTrashed = bson.E{Key: "dtime", Value: bson.M{"$not": bson.M{"$gt": 0}}}
+	trashed    = bson.E{Key: "dtime", Value: bson.M{"$gt": 0}}
+)
+
 type MongoDBArticleDAO struct {
 	node    *snowflake.Node
 	col     *mongo.Collection
@@ -18,7 +23,7 @@ type MongoDBArticleDAO struct {
 }
 
 func (m *MongoDBArticleDAO) GetPubById(ctx context.Context, id int64) (PublishedArticle, error) {
-	filter := bson.D{bson.E{"id", id}}
+	filter := bson.D{bson.E{Key: "id", Value: id}}
 
 	var art PublishedArticle
 
@@ -38,7 +43,7 @@ func (m *MongoDBArticleDAO) GetPubById(ctx context.Context, id int64) (Published
 }
 
 func (m *MongoDBArticleDAO) GetById(ctx context.Context, id int64) (Article, error) {
-	filter := bson.D{bson.E{"id", id}}
+	filter := bson.D{bson.E{Key: "id", Value: id}}
 	var art Article
 	find, err := m.col.Find(ctx, filter)
 
@@ -56,16 +61,23 @@ func (m *MongoDBArticleDAO) GetById(ctx context.Context, id int64) (Article, err
 
 }
 
-func (m *MongoDBArticleDAO) GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error) {
+func (m *MongoDBArticleDAO) GetByAuthor(ctx context.Context, uid int64, utime int64, id int64, limit int) ([]Article, error) {
 	// 根据 author_id 查询出当前用户的文章数据 分页返回
 	var res []Article
-	var findOptions options.FindOptions
-	findOptions.SetLimit(int64(limit))
-	findOptions.SetSkip(int64(offset))
+	findOptions := options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}, bson.E{Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
 
-	filter := bson.D{bson.E{"author_id", uid}}
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}, notTrashed}
+	if utime > 0 || id > 0 {
+		// 同一毫秒更新的文章用 id 区分，不会漏也不会重复
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.M{"utime": bson.M{"$lt": utime}},
+			bson.M{"utime": utime, "id": bson.M{"$lt": id}},
+		}})
+	}
 
-	find, err := m.col.Find(ctx, filter, &findOptions)
+	find, err := m.col.Find(ctx, filter, findOptions)
 
 	if err != nil {
 		return []Article{}, err
@@ -80,32 +92,116 @@ func (m *MongoDBArticleDAO) GetByAuthor(ctx context.Context, uid int64, offset i
 	return res, nil
 }
 
+func (m *MongoDBArticleDAO) GetPubByAuthor(ctx context.Context, uid int64, status uint8, offset int, limit int) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	findOptions := options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}, bson.E{Key: "status", Value: status}}
+	find, err := m.liveCol.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	err = find.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBArticleDAO) CountPubByAuthor(ctx context.Context, uid int64, status uint8) (int64, error) {
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}, bson.E{Key: "status", Value: status}}
+	return m.liveCol.CountDocuments(ctx, filter)
+}
+
+func (m *MongoDBArticleDAO) GetPubLatest(ctx context.Context, status uint8, limit int) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	findOptions := options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}, bson.E{Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+	find, err := m.liveCol.Find(ctx, bson.D{bson.E{Key: "status", Value: status}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	err = find.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBArticleDAO) ScanPub(ctx context.Context, status uint8, offset int, limit int) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	findOptions := options.Find().
+		SetProjection(bson.D{bson.E{Key: "id", Value: 1}, bson.E{Key: "author_id", Value: 1}, bson.E{Key: "utime", Value: 1}}).
+		SetSort(bson.D{bson.E{Key: "id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	find, err := m.liveCol.Find(ctx, bson.D{bson.E{Key: "status", Value: status}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	err = find.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBArticleDAO) CountPub(ctx context.Context, status uint8) (int64, error) {
+	return m.liveCol.CountDocuments(ctx, bson.D{bson.E{Key: "status", Value: status}})
+}
+
 func (m *MongoDBArticleDAO) Insert(ctx context.Context, art Article) (int64, error) {
 	now := time.Now().UnixMilli()
-	art.Ctime = now
+	if art.Ctime == 0 {
+		art.Ctime = now
+	}
 	art.Utime = now
+	art.Version = 1
 	art.Id = m.node.Generate().Int64()
 	_, err := m.col.InsertOne(ctx, &art)
 	return art.Id, err
 }
 
 func (m *MongoDBArticleDAO) UpdateById(ctx context.Context, art Article) error {
-	now := time.Now().UnixMilli()
-	filter := bson.D{bson.E{"id", art.Id},
-		bson.E{"author_id", art.AuthorId}}
-	set := bson.D{bson.E{"$set", bson.M{
-		"title":   art.Title,
-		"content": art.Content,
-		"status":  art.Status,
-		"utime":   now,
-	}}}
-	res, err := m.col.UpdateOne(ctx, filter, set)
+	return m.updateWithVersion(ctx, art, bson.D{
+		bson.E{Key: "$set", Value: bson.M{
+			"title":   art.Title,
+			"content": art.Content,
+			"format":  art.Format,
+			"status":  art.Status,
+			"utime":   time.Now().UnixMilli(),
+		}},
+		bson.E{Key: "$inc", Value: bson.M{"version": 1}},
+	})
+}
+
+func (m *MongoDBArticleDAO) SaveDraft(ctx context.Context, art Article) error {
+	return m.updateWithVersion(ctx, art, bson.D{
+		bson.E{Key: "$set", Value: bson.M{
+			"title":   art.Title,
+			"content": art.Content,
+			"format":  art.Format,
+			"utime":   time.Now().UnixMilli(),
+		}},
+	})
+}
+
+func (m *MongoDBArticleDAO) updateWithVersion(ctx context.Context, art Article, update bson.D) error {
+	filter := bson.D{bson.E{Key: "id", Value: art.Id},
+		bson.E{Key: "author_id", Value: art.AuthorId}, notTrashed}
+	version := bson.E{Key: "version", Value: art.Version}
+	if art.Version == 0 {
+		// 加版本号之前的老数据没有这个字段
+		version.Value = bson.M{"$in": bson.A{0, nil}}
+	}
+	res, err := m.col.UpdateOne(ctx, append(filter, version), update)
 	if err != nil {
 		return err
 	}
-	if res.ModifiedCount == 0 {
+	if res.MatchedCount == 0 {
+		cnt, err := m.col.CountDocuments(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrArticleVersionConflict
+		}
 		// 创作者不对，说明有人在瞎搞
-		return errors.New("ID 不对或者创作者不对")
+		return ErrArticleNotFound
 	}
 	return nil
 }
@@ -118,7 +214,7 @@ func (m *MongoDBArticleDAO) Sync(ctx context.Context, art Article) (int64, error
 	if id > 0 {
 		err = m.UpdateById(ctx, art)
 	} else {
-		id, err = m.Insert(ctx, art)
+		id, err = m.Insert(ctx, art.draft())
 	}
 	if err != nil {
 		return 0, err
@@ -126,34 +222,236 @@ func (m *MongoDBArticleDAO) Sync(ctx context.Context, art Article) (int64, error
 	art.Id = id
 	now := time.Now().UnixMilli()
 	art.Utime = now
+	// 线上库不需要版本号和标签，创建时间只在第一次发表的时候设置
+	art.Version = 0
+	art.Tags = ""
+	art.Ctime = 0
 	//liveCol 是 INSERT or Update 语义
-	filter := bson.D{bson.E{"id", art.Id},
-		bson.E{"author_id", art.AuthorId}}
-	set := bson.D{bson.E{"$set", art},
-		bson.E{"$setOnInsert",
-			bson.D{bson.E{"ctime", now}}}}
+	filter := bson.D{bson.E{Key: "id", Value: art.Id},
+		bson.E{Key: "author_id", Value: art.AuthorId}}
+	set := bson.D{bson.E{Key: "$set", Value: art},
+		bson.E{Key: "$setOnInsert",
+			Value: bson.D{bson.E{Key: "ctime", Value: now}}}}
 	_, err = m.liveCol.UpdateOne(ctx,
 		filter, set,
 		options.Update().SetUpsert(true))
 	return id, err
 }
 
+func (m *MongoDBArticleDAO) SyncPub(ctx context.Context, art Article) error {
+	now := time.Now().UnixMilli()
+	filter := bson.D{bson.E{Key: "id", Value: art.Id},
+		bson.E{Key: "author_id", Value: art.AuthorId}}
+	res, err := m.col.UpdateOne(ctx, append(filter, notTrashed),
+		bson.D{bson.E{Key: "$set", Value: bson.D{
+			bson.E{Key: "status", Value: art.Status},
+			bson.E{Key: "utime", Value: now},
+		}}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount != 1 {
+		return ErrArticleNotFound
+	}
+	art.Utime = now
+	art.Version = 0
+	art.Tags = ""
+	art.Ctime = 0
+	_, err = m.liveCol.UpdateOne(ctx, filter,
+		bson.D{bson.E{Key: "$set", Value: art},
+			bson.E{Key: "$setOnInsert",
+				Value: bson.D{bson.E{Key: "ctime", Value: now}}}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
 func (m *MongoDBArticleDAO) SyncStatus(ctx context.Context, uid int64, id int64, status uint8) error {
 	filter := bson.D{bson.E{Key: "id", Value: id},
 		bson.E{Key: "author_id", Value: uid}}
 	sets := bson.D{bson.E{Key: "$set",
 		Value: bson.D{bson.E{Key: "status", Value: status}}}}
-	res, err := m.col.UpdateOne(ctx, filter, sets)
+	res, err := m.col.UpdateOne(ctx, append(filter, notTrashed), sets)
 	if err != nil {
 		return err
 	}
 	if res.ModifiedCount != 1 {
-		return errors.New("ID 不对或者创作者不对")
+		return ErrArticleNotFound
 	}
 	_, err = m.liveCol.UpdateOne(ctx, filter, sets)
 	return err
 }
 
+func (m *MongoDBArticleDAO) UpdateStatus(ctx context.Context, uid int64, id int64, status uint8) error {
+	filter := bson.D{bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid}, notTrashed}
+	res, err := m.col.UpdateOne(ctx, filter, bson.D{bson.E{Key: "$set", Value: bson.M{
+		"status": status,
+		"utime":  time.Now().UnixMilli(),
+	}}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+func (m *MongoDBArticleDAO) Trash(ctx context.Context, uid int64, id int64) error {
+	now := time.Now().UnixMilli()
+	filter := bson.D{bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid}}
+	res, err := m.col.UpdateOne(ctx, append(filter, notTrashed), bson.D{
+		bson.E{Key: "$set", Value: bson.M{
+			"dtime": now,
+			"utime": now,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrArticleNotFound
+	}
+	_, err = m.liveCol.DeleteOne(ctx, filter)
+	return err
+}
+
+func (m *MongoDBArticleDAO) Restore(ctx context.Context, uid int64, id int64, since int64, status uint8) error {
+	filter := bson.D{bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid},
+		bson.E{Key: "dtime", Value: bson.M{"$gt": 0, "$gte": since}}}
+	res, err := m.col.UpdateOne(ctx, filter, bson.D{
+		bson.E{Key: "$set", Value: bson.M{
+			"status": status,
+			"utime":  time.Now().UnixMilli(),
+		}},
+		bson.E{Key: "$unset", Value: bson.M{"dtime": ""}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+func (m *MongoDBArticleDAO) GetTrashByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]Article, error) {
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}, trashed}
+	find, err := m.col.Find(ctx, filter, options.Find().
+		SetSort(bson.D{bson.E{Key: "dtime", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = find.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBArticleDAO) FindTrashedBefore(ctx context.Context, before int64, limit int) ([]int64, error) {
+	filter := bson.D{bson.E{Key: "dtime", Value: bson.M{"$gt": 0, "$lt": before}}}
+	find, err := m.col.Find(ctx, filter, options.Find().
+		SetSort(bson.D{bson.E{Key: "dtime", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.D{bson.E{Key: "id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var arts []Article
+	err = find.All(ctx, &arts)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(arts))
+	for _, art := range arts {
+		ids = append(ids, art.Id)
+	}
+	return ids, nil
+}
+
+func (m *MongoDBArticleDAO) DeleteTrashed(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	in := bson.E{Key: "id", Value: bson.M{"$in": ids}}
+	_, err := m.col.DeleteMany(ctx, bson.D{in, trashed})
+	if err != nil {
+		return err
+	}
+	// 移到回收站的时候已经删过了，这里兜底
+	_, err = m.liveCol.DeleteMany(ctx, bson.D{in})
+	return err
+}
+
+func (m *MongoDBArticleDAO) TransferAuthor(ctx context.Context, from, to int64) error {
+	filter := bson.D{bson.E{Key: "author_id", Value: from}}
+	sets := bson.D{bson.E{Key: "$set", Value: bson.D{
+		bson.E{Key: "author_id", Value: to},
+		bson.E{Key: "utime", Value: time.Now().UnixMilli()},
+	}}}
+	_, err := m.col.UpdateMany(ctx, filter, sets)
+	if err != nil {
+		return err
+	}
+	_, err = m.liveCol.UpdateMany(ctx, filter, sets)
+	return err
+}
+
+func (m *MongoDBArticleDAO) SyncStatusByAuthor(ctx context.Context, uid int64, status uint8) ([]int64, error) {
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}}
+	ids, err := m.idsByAuthor(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	sets := bson.D{bson.E{Key: "$set", Value: bson.D{
+		bson.E{Key: "status", Value: status},
+		bson.E{Key: "utime", Value: time.Now().UnixMilli()},
+	}}}
+	_, err = m.col.UpdateMany(ctx, filter, sets)
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.liveCol.UpdateMany(ctx, filter, sets)
+	return ids, err
+}
+
+func (m *MongoDBArticleDAO) DeleteByAuthor(ctx context.Context, uid int64) ([]int64, error) {
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}}
+	ids, err := m.idsByAuthor(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.col.DeleteMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.liveCol.DeleteMany(ctx, filter)
+	return ids, err
+}
+
+// idsByAuthor 制作库里面包含了所有的文章，所以只查制作库
+func (m *MongoDBArticleDAO) idsByAuthor(ctx context.Context, uid int64) ([]int64, error) {
+	filter := bson.D{bson.E{Key: "author_id", Value: uid}}
+	find, err := m.col.Find(ctx, filter,
+		options.Find().SetProjection(bson.D{bson.E{Key: "id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var arts []Article
+	err = find.All(ctx, &arts)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(arts))
+	for _, art := range arts {
+		ids = append(ids, art.Id)
+	}
+	return ids, nil
+}
+
 func NewMongoDBArticleDAO(mdb *mongo.Database, node *snowflake.Node) ArticleDAO {
 	return &MongoDBArticleDAO{
 		node:    node,
@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PrivacyDAO interface {
+	// FindSettings 没有设置过返回 ErrRecordNotFound
+	FindSettings(ctx context.Context, uid int64) (PrivacySettings, error)
+	UpsertSettings(ctx context.Context, s PrivacySettings) error
+	// InsertBlock 重复拉黑不会报错
+	InsertBlock(ctx context.Context, b UserBlock) error
+	DeleteBlock(ctx context.Context, uid, blockedUid int64) error
+	FindBlocks(ctx context.Context, uid int64, offset, limit int) ([]UserBlock, error)
+	// IsBlocked a 拉黑了 b 或者 b 拉黑了 a
+	IsBlocked(ctx context.Context, a, b int64) (bool, error)
+}
+
+type GORMPrivacyDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMPrivacyDAO(db *gorm.DB) PrivacyDAO {
+	return &GORMPrivacyDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMPrivacyDAO) FindSettings(ctx context.Context, uid int64) (PrivacySettings, error) {
+	var res PrivacySettings
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMPrivacyDAO) UpsertSettings(ctx context.Context, s PrivacySettings) error {
+	now := time.Now().UnixMilli()
+	s.Ctime = now
+	s.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"likes":       s.Likes,
+			"collections": s.Collections,
+			"utime":       now,
+		}),
+	}).Create(&s).Error
+}
+
+func (dao *GORMPrivacyDAO) InsertBlock(ctx context.Context, b UserBlock) error {
+	b.Ctime = time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoNothing: true,
+	}).Create(&b).Error
+}
+
+func (dao *GORMPrivacyDAO) DeleteBlock(ctx context.Context, uid, blockedUid int64) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ? AND blocked_uid = ?", uid, blockedUid).
+		Delete(&UserBlock{}).Error
+}
+
+func (dao *GORMPrivacyDAO) FindBlocks(ctx context.Context, uid int64, offset, limit int) ([]UserBlock, error) {
+	var res []UserBlock
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMPrivacyDAO) IsBlocked(ctx context.Context, a, b int64) (bool, error) {
+	var cnt int64
+	err := dao.db.WithContext(ctx).Model(&UserBlock{}).
+		Where("(uid = ? AND blocked_uid = ?) OR (uid = ? AND blocked_uid = ?)", a, b, b, a).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
+
+// PrivacySettings 用户的隐私设置，没有记录就是全部公开
+type PrivacySettings struct {
+	Id          int64 `gorm:"primaryKey,autoIncrement"`
+	Uid         int64 `gorm:"uniqueIndex"`
+	Likes       uint8
+	Collections uint8
+	Ctime       int64
+	Utime       int64
+}
+
+// UserBlock 拉黑关系，Uid 拉黑了 BlockedUid
+type UserBlock struct {
+	Id         int64 `gorm:"primaryKey,autoIncrement"`
+	Uid        int64 `gorm:"uniqueIndex:uid_blocked_uid"`
+	BlockedUid int64 `gorm:"uniqueIndex:uid_blocked_uid;index"`
+	Ctime      int64
+}
@@ -0,0 +1,117 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReadingHistoryDAO interface {
+	// Upsert 批量记录阅读历史。阅读时间只会往后更新，
+	// 进度只有 progress_utime 更晚的才会覆盖，
+	// progress 小于 0 的只更新阅读时间，不改阅读进度
+	Upsert(ctx context.Context, hs []ReadingHistory) error
+	Find(ctx context.Context, uid int64, aid int64) (ReadingHistory, error)
+	// FindByUid 按照 (utime, aid) 倒序分页，utime 和 aid 是上一页最后一条，都是 0 的时候查第一页
+	FindByUid(ctx context.Context, uid int64, utime int64, aid int64, limit int) ([]ReadingHistory, error)
+	DeleteByUid(ctx context.Context, uid int64) error
+}
+
+type GORMReadingHistoryDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMReadingHistoryDAO(db *gorm.DB) ReadingHistoryDAO {
+	return &GORMReadingHistoryDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMReadingHistoryDAO) Upsert(ctx context.Context, hs []ReadingHistory) error {
+	now := time.Now().UnixMilli()
+	var withProgress, withoutProgress []ReadingHistory
+	for _, h := range hs {
+		h.Ctime = now
+		if h.Progress < 0 {
+			h.Progress = 0
+			withoutProgress = append(withoutProgress, h)
+		} else {
+			withProgress = append(withProgress, h)
+		}
+	}
+	// 同一个批次里面的消息可能是乱序的，旧的不能覆盖新的
+	utime := clause.Assignment{
+		Column: clause.Column{Name: "utime"},
+		Value:  gorm.Expr("GREATEST(utime, VALUES(utime))"),
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(withProgress) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				// MySQL 按照顺序执行赋值，progress 要在 progress_utime 前面，比较的是旧的 progress_utime。
+				// 不能用 utime 比较，合并之后的 utime 可能比进度新
+				DoUpdates: clause.Set{
+					{
+						Column: clause.Column{Name: "progress"},
+						Value:  gorm.Expr("IF(progress_utime < VALUES(progress_utime), VALUES(progress), progress)"),
+					},
+					{
+						Column: clause.Column{Name: "progress_utime"},
+						Value:  gorm.Expr("GREATEST(progress_utime, VALUES(progress_utime))"),
+					},
+					utime,
+				},
+			}).Create(&withProgress).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(withoutProgress) > 0 {
+			return tx.Clauses(clause.OnConflict{
+				DoUpdates: clause.Set{utime},
+			}).Create(&withoutProgress).Error
+		}
+		return nil
+	})
+}
+
+func (dao *GORMReadingHistoryDAO) Find(ctx context.Context, uid int64, aid int64) (ReadingHistory, error) {
+	var res ReadingHistory
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND aid = ?", uid, aid).
+		First(&res).Error
+	return res, err
+}
+
+func (dao *GORMReadingHistoryDAO) FindByUid(ctx context.Context, uid int64, utime int64, aid int64, limit int) ([]ReadingHistory, error) {
+	var res []ReadingHistory
+	db := dao.db.WithContext(ctx).Where("uid = ?", uid)
+	if utime > 0 || aid > 0 {
+		db = db.Where("utime < ? OR (utime = ? AND aid < ?)", utime, utime, aid)
+	}
+	err := db.Order("utime DESC, aid DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMReadingHistoryDAO) DeleteByUid(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ?", uid).
+		Delete(&ReadingHistory{}).Error
+}
+
+// ReadingHistory 每个用户每篇文章一行
+type ReadingHistory struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 列表按照 utime 倒序翻页
+	Uid      int64 `gorm:"uniqueIndex:uid_aid;index:uid_utime,priority:1"`
+	Aid      int64 `gorm:"uniqueIndex:uid_aid"`
+	Progress float64
+	// ProgressUtime 上报进度的时间，乱序的时候旧的进度不能覆盖新的
+	ProgressUtime int64
+	// Utime 最后一次阅读的时间
+	Utime int64 `gorm:"index:uid_utime,priority:2"`
+	Ctime int64
+}
@@ -0,0 +1,366 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/go-sql-driver/mysql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrSeriesArticleDuplicate 文章已经在某个系列里面了
+	ErrSeriesArticleDuplicate = errors.New("文章已经属于别的系列")
+	// ErrSeriesArticlesMismatch 调整顺序的时候传入的文章和系列里面的不一样
+	ErrSeriesArticlesMismatch = errors.New("系列里面的文章不一致")
+)
+
+type SeriesDAO interface {
+	Insert(ctx context.Context, s Series) (int64, error)
+	// FindById 找不到返回 ErrRecordNotFound
+	FindById(ctx context.Context, id int64) (Series, error)
+	// FindByAuthor 最新创建的在前面
+	FindByAuthor(ctx context.Context, uid int64, offset, limit int) ([]Series, error)
+	// FindByArticle 文章所在的系列，不在任何系列里面返回 ErrRecordNotFound
+	FindByArticle(ctx context.Context, aid int64) (Series, error)
+	// AddArticle 加到最后面，系列不存在或者不是 uid 的返回 ErrRecordNotFound
+	AddArticle(ctx context.Context, uid, id, aid int64) error
+	// RemoveArticle 文章不在系列里面也不会报错
+	RemoveArticle(ctx context.Context, uid, id, aid int64) error
+	// Reorder aids 必须刚好是系列里面所有的文章，不然返回 ErrSeriesArticlesMismatch
+	Reorder(ctx context.Context, uid, id int64, aids []int64) error
+}
+
+// Series 两种实现共用。
+// MongoDB 直接把文章 ID 按顺序存在文档里面，GORM 存在 SeriesArticle 表里面
+type Series struct {
+	Id          int64   `gorm:"primaryKey,autoIncrement" bson:"id,omitempty"`
+	AuthorId    int64   `gorm:"index" bson:"author_id,omitempty"`
+	Title       string  `gorm:"type:varchar(1024)" bson:"title,omitempty"`
+	Description string  `gorm:"type:varchar(4096)" bson:"description,omitempty"`
+	ArticleIds  []int64 `gorm:"-" bson:"article_ids"`
+	Ctime       int64   `bson:"ctime,omitempty"`
+	Utime       int64   `bson:"utime,omitempty"`
+}
+
+// SeriesArticle 系列里面的文章，一篇文章只能属于一个系列
+type SeriesArticle struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	Sid int64 `gorm:"index:sid_position"`
+	Aid int64 `gorm:"uniqueIndex"`
+	// Position 越小越靠前
+	Position int `gorm:"index:sid_position"`
+	Ctime    int64
+}
+
+type GORMSeriesDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMSeriesDAO(db *gorm.DB) SeriesDAO {
+	return &GORMSeriesDAO{
+		db: db,
+	}
+}
+
+func (dao *GORMSeriesDAO) Insert(ctx context.Context, s Series) (int64, error) {
+	now := time.Now().UnixMilli()
+	s.Ctime = now
+	s.Utime = now
+	err := dao.db.WithContext(ctx).Create(&s).Error
+	return s.Id, err
+}
+
+func (dao *GORMSeriesDAO) FindById(ctx context.Context, id int64) (Series, error) {
+	var s Series
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&s).Error
+	if err != nil {
+		return Series{}, err
+	}
+	s.ArticleIds, err = dao.articleIds(dao.db.WithContext(ctx), id)
+	return s, err
+}
+
+func (dao *GORMSeriesDAO) FindByAuthor(ctx context.Context, uid int64, offset, limit int) ([]Series, error) {
+	var res []Series
+	err := dao.db.WithContext(ctx).Where("author_id = ?", uid).
+		Order("id DESC").Offset(offset).Limit(limit).
+		Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].ArticleIds, err = dao.articleIds(dao.db.WithContext(ctx), res[i].Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (dao *GORMSeriesDAO) FindByArticle(ctx context.Context, aid int64) (Series, error) {
+	var sa SeriesArticle
+	err := dao.db.WithContext(ctx).Where("aid = ?", aid).First(&sa).Error
+	if err != nil {
+		return Series{}, err
+	}
+	return dao.FindById(ctx, sa.Sid)
+}
+
+func (dao *GORMSeriesDAO) AddArticle(ctx context.Context, uid, id, aid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		err := dao.touch(tx, uid, id, now)
+		if err != nil {
+			return err
+		}
+		var pos struct {
+			Max *int
+		}
+		err = tx.Model(&SeriesArticle{}).Select("MAX(position) AS max").
+			Where("sid = ?", id).Scan(&pos).Error
+		if err != nil {
+			return err
+		}
+		next := 0
+		if pos.Max != nil {
+			next = *pos.Max + 1
+		}
+		err = tx.Create(&SeriesArticle{
+			Sid:      id,
+			Aid:      aid,
+			Position: next,
+			Ctime:    now,
+		}).Error
+		if me, ok := err.(*mysql.MySQLError); ok {
+			const duplicateErr uint16 = 1062
+			if me.Number == duplicateErr {
+				return ErrSeriesArticleDuplicate
+			}
+		}
+		return err
+	})
+}
+
+func (dao *GORMSeriesDAO) RemoveArticle(ctx context.Context, uid, id, aid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := dao.touch(tx, uid, id, time.Now().UnixMilli())
+		if err != nil {
+			return err
+		}
+		return tx.Where("sid = ? AND aid = ?", id, aid).Delete(&SeriesArticle{}).Error
+	})
+}
+
+func (dao *GORMSeriesDAO) Reorder(ctx context.Context, uid, id int64, aids []int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := dao.touch(tx, uid, id, time.Now().UnixMilli())
+		if err != nil {
+			return err
+		}
+		old, err := dao.articleIds(tx, id)
+		if err != nil {
+			return err
+		}
+		if !sameIds(old, aids) {
+			return ErrSeriesArticlesMismatch
+		}
+		for i, aid := range aids {
+			err = tx.Model(&SeriesArticle{}).
+				Where("sid = ? AND aid = ?", id, aid).
+				Update("position", i).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// touch 锁住系列这一行并且更新修改时间，顺便检查系列是不是 uid 的。
+// 同一个系列的修改是串行的
+func (dao *GORMSeriesDAO) touch(tx *gorm.DB, uid, id int64, now int64) error {
+	var s Series
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND author_id = ?", id, uid).First(&s).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&s).Update("utime", now).Error
+}
+
+func (dao *GORMSeriesDAO) articleIds(db *gorm.DB, id int64) ([]int64, error) {
+	var aids []int64
+	err := db.Model(&SeriesArticle{}).Where("sid = ?", id).
+		Order("position ASC").Pluck("aid", &aids).Error
+	return aids, err
+}
+
+// sameIds 两边是不是同一批 ID，不管顺序，a 里面没有重复的
+func sameIds(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[int64]struct{}, len(a))
+	for _, id := range a {
+		set[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := set[id]; !ok {
+			return false
+		}
+		delete(set, id)
+	}
+	return true
+}
+
+type MongoDBSeriesDAO struct {
+	node *snowflake.Node
+	col  *mongo.Collection
+}
+
+func NewMongoDBSeriesDAO(mdb *mongo.Database, node *snowflake.Node) SeriesDAO {
+	return &MongoDBSeriesDAO{
+		node: node,
+		col:  mdb.Collection("series"),
+	}
+}
+
+func (m *MongoDBSeriesDAO) Insert(ctx context.Context, s Series) (int64, error) {
+	now := time.Now().UnixMilli()
+	s.Id = m.node.Generate().Int64()
+	s.Ctime = now
+	s.Utime = now
+	if s.ArticleIds == nil {
+		// 存成空数组，不然 $push 会失败
+		s.ArticleIds = []int64{}
+	}
+	_, err := m.col.InsertOne(ctx, &s)
+	return s.Id, err
+}
+
+func (m *MongoDBSeriesDAO) FindById(ctx context.Context, id int64) (Series, error) {
+	return m.findOne(ctx, bson.D{bson.E{Key: "id", Value: id}})
+}
+
+func (m *MongoDBSeriesDAO) FindByAuthor(ctx context.Context, uid int64, offset, limit int) ([]Series, error) {
+	cursor, err := m.col.Find(ctx, bson.D{bson.E{Key: "author_id", Value: uid}},
+		options.Find().SetSort(bson.D{bson.E{Key: "id", Value: -1}}).
+			SetSkip(int64(offset)).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var res []Series
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBSeriesDAO) FindByArticle(ctx context.Context, aid int64) (Series, error) {
+	return m.findOne(ctx, bson.D{bson.E{Key: "article_ids", Value: aid}})
+}
+
+func (m *MongoDBSeriesDAO) findOne(ctx context.Context, filter bson.D) (Series, error) {
+	var s Series
+	err := m.col.FindOne(ctx, filter).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Series{}, ErrRecordNotFound
+	}
+	return s, err
+}
+
+func (m *MongoDBSeriesDAO) AddArticle(ctx context.Context, uid, id, aid int64) error {
+	// article_ids 上面有唯一索引，别的系列里面有这篇文章的时候会冲突
+	res, err := m.col.UpdateOne(ctx, bson.D{
+		bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid},
+		// 唯一索引管不了同一个文档里面的重复
+		bson.E{Key: "article_ids", Value: bson.M{"$ne": aid}},
+	}, bson.D{
+		bson.E{Key: "$push", Value: bson.M{"article_ids": aid}},
+		bson.E{Key: "$set", Value: bson.M{"utime": time.Now().UnixMilli()}},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrSeriesArticleDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return m.notMatched(ctx, uid, id)
+	}
+	return nil
+}
+
+// notMatched 区分是系列不对，还是文章已经在这个系列里面了
+func (m *MongoDBSeriesDAO) notMatched(ctx context.Context, uid, id int64) error {
+	cnt, err := m.col.CountDocuments(ctx, bson.D{
+		bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid},
+	})
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrRecordNotFound
+	}
+	return ErrSeriesArticleDuplicate
+}
+
+func (m *MongoDBSeriesDAO) RemoveArticle(ctx context.Context, uid, id, aid int64) error {
+	res, err := m.col.UpdateOne(ctx, bson.D{
+		bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid},
+	}, bson.D{
+		bson.E{Key: "$pull", Value: bson.M{"article_ids": aid}},
+		bson.E{Key: "$set", Value: bson.M{"utime": time.Now().UnixMilli()}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (m *MongoDBSeriesDAO) Reorder(ctx context.Context, uid, id int64, aids []int64) error {
+	filter := bson.D{
+		bson.E{Key: "id", Value: id},
+		bson.E{Key: "author_id", Value: uid},
+	}
+	// 系列里面的文章没有重复，包含所有 aids 并且数量一样就说明是同一批文章
+	// 比较和更新在一个操作里面，并发修改的时候不会丢数据
+	cond := append(filter, bson.E{Key: "article_ids", Value: bson.M{
+		"$all":  aids,
+		"$size": len(aids),
+	}})
+	if len(aids) == 0 {
+		cond = append(filter, bson.E{Key: "article_ids", Value: bson.M{"$size": 0}})
+	}
+	res, err := m.col.UpdateOne(ctx, cond, bson.D{
+		bson.E{Key: "$set", Value: bson.M{
+			"article_ids": aids,
+			"utime":       time.Now().UnixMilli(),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+	cnt, err := m.col.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrRecordNotFound
+	}
+	return ErrSeriesArticlesMismatch
+}
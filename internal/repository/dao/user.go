@@ -4,15 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrDuplicateEmail = errors.New("邮箱冲突")
-	ErrRecordNotFound = gorm.ErrRecordNotFound
+	// ErrDuplicateNickname 修改资料的时候只有昵称是唯一的，所以冲突就是昵称冲突
+	ErrDuplicateNickname = errors.New("昵称冲突")
+	ErrRecordNotFound    = gorm.ErrRecordNotFound
 )
 
 type UserDAO interface {
@@ -20,8 +25,42 @@ type UserDAO interface {
 	FindByEmail(ctx context.Context, email string) (User, error)
 	UpdateById(ctx context.Context, entity User) error
 	FindById(ctx context.Context, uid int64) (User, error)
+	// FindByNicknameKey key 是转成小写之后的昵称
+	FindByNicknameKey(ctx context.Context, key string) (User, error)
 	FindByPhone(ctx context.Context, phone string) (User, error)
 	FindByWechat(ctx context.Context, openId string) (User, error)
+	UpdatePassword(ctx context.Context, uid int64, password string) error
+	UpdateAvatar(ctx context.Context, uid int64, avatar string) error
+	MarkEmailVerified(ctx context.Context, uid int64, email string) error
+	// SetTOTPSecret 保存还没有确认的 TOTP 密钥
+	SetTOTPSecret(ctx context.Context, uid int64, secret string) error
+	// EnableTOTP 开启两步验证，同时替换掉所有的恢复码
+	EnableTOTP(ctx context.Context, uid int64, codes []string) error
+	DisableTOTP(ctx context.Context, uid int64) error
+	// UseRecoveryCode 使用一个恢复码，恢复码不存在或者已经用过返回 ErrRecordNotFound
+	UseRecoveryCode(ctx context.Context, uid int64, code string) error
+	// UseTOTPStep 记录用过的 TOTP 时间窗口，step 不大于已经用过的返回 ErrRecordNotFound
+	UseTOTPStep(ctx context.Context, uid int64, step int64) error
+	// UpdatePhone 绑定或者解绑手机号，phone.Valid 为 false 的时候就是解绑
+	UpdatePhone(ctx context.Context, uid int64, phone sql.NullString) error
+	UpdateEmail(ctx context.Context, uid int64, email sql.NullString, verified bool) error
+	UpdateWechat(ctx context.Context, uid int64, openId, unionId sql.NullString) error
+	// MergeIdentities 把 from 上面的登录方式转移到 to 上面，包括第三方账号，to 已经有的不会覆盖
+	MergeIdentities(ctx context.Context, from, to int64) error
+	// FindByOAuth2 通过第三方登录的账号找到用户
+	FindByOAuth2(ctx context.Context, provider, externalId string) (User, error)
+	// InsertWithOAuth2 创建用户，同时绑定第三方账号
+	InsertWithOAuth2(ctx context.Context, u User, provider, externalId string) error
+	// FindOAuth2Providers 用户绑定了哪些第三方账号
+	FindOAuth2Providers(ctx context.Context, uid int64) ([]string, error)
+	// DeleteOAuth2 解绑第三方账号
+	DeleteOAuth2(ctx context.Context, uid int64, provider string) error
+	// SoftDelete 注销账号，清空个人信息和登录方式，只保留一条软删除的记录
+	SoftDelete(ctx context.Context, uid int64) error
+	// FindDeletedBefore 找出在 t 之前注销的账号
+	FindDeletedBefore(ctx context.Context, t time.Time, limit int) ([]int64, error)
+	// Purge 彻底删除已经注销的账号
+	Purge(ctx context.Context, uid int64) error
 }
 
 type GORMUserDAO struct {
@@ -45,7 +84,11 @@ func (dao *GORMUserDAO) Insert(ctx context.Context, u User) error {
 	u.CTime = now
 	u.UTime = now
 	err := dao.db.WithContext(ctx).Create(&u).Error
+	return dao.duplicateErr(err)
+}
 
+// duplicateErr 把唯一索引冲突转换成 ErrDuplicateEmail
+func (dao *GORMUserDAO) duplicateErr(err error) error {
 	if me, ok := err.(*mysql.MySQLError); ok {
 		const duplicateErr uint16 = 1062
 		if me.Number == duplicateErr {
@@ -53,7 +96,6 @@ func (dao *GORMUserDAO) Insert(ctx context.Context, u User) error {
 			return ErrDuplicateEmail
 		}
 	}
-
 	return err
 }
 
@@ -63,17 +105,406 @@ func (dao *GORMUserDAO) FindByEmail(ctx context.Context, email string) (User, er
 	return u, err
 }
 
+// BackfillNicknameKey 给加 nickname_key 之前就设置了昵称的用户补上 key，
+// 不补的话唯一索引管不到这些昵称，按照昵称也查不到他们。
+// 转小写之后冲突的，已经有 key 的或者 id 小的保留原来的昵称，
+// 其余的在昵称后面加上 _id。可以重复执行
+func BackfillNicknameKey(db *gorm.DB) error {
+	const batchSize = 100
+	var lastId int64
+	for {
+		var users []User
+		err := db.Select("id", "nickname").
+			Where("id > ? AND nickname <> '' AND nickname_key IS NULL", lastId).
+			Order("id").Limit(batchSize).
+			Find(&users).Error
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			err = backfillNicknameKey(db, u)
+			if err != nil {
+				return err
+			}
+		}
+		if len(users) < batchSize {
+			return nil
+		}
+		lastId = users[len(users)-1].Id
+	}
+}
+
+func backfillNicknameKey(db *gorm.DB, u User) error {
+	// 加上 _id 之后还冲突，说明有人恰好用了这个昵称，再加序号
+	const (
+		maxRetry            = 10
+		duplicateErr uint16 = 1062
+	)
+	nickname := u.Nickname
+	for i := 0; i < maxRetry; i++ {
+		updates := map[string]any{
+			"nickname_key": strings.ToLower(nickname),
+		}
+		if i > 0 {
+			updates["nickname"] = nickname
+			updates["u_time"] = time.Now().UnixMilli()
+		}
+		err := db.Model(&User{}).
+			Where("id = ? AND nickname_key IS NULL", u.Id).
+			Updates(updates).Error
+		if me, ok := err.(*mysql.MySQLError); !ok || me.Number != duplicateErr {
+			return err
+		}
+		nickname = fmt.Sprintf("%s_%d", u.Nickname, u.Id)
+		if i > 0 {
+			nickname = fmt.Sprintf("%s_%d", nickname, i)
+		}
+	}
+	return fmt.Errorf("用户 %d 的昵称 %s 冲突太多次", u.Id, u.Nickname)
+}
+
+func (dao *GORMUserDAO) FindByNicknameKey(ctx context.Context, key string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).Where("nickname_key = ?", key).First(&u).Error
+	return u, err
+}
+
 func (dao *GORMUserDAO) UpdateById(ctx context.Context, entity User) error {
 	// 这种写法依赖于 GORM 的零值和主键更新特性
 	// Update 非零值 WHERE id = ?
 	//return dao.db.WithContext(ctx).Updates(&entity).Error
-	return dao.db.WithContext(ctx).Model(&entity).Where("id = ?", entity.Id).
+	err := dao.db.WithContext(ctx).Model(&entity).Where("id = ?", entity.Id).
+		Updates(map[string]any{
+			"u_time":       time.Now().UnixMilli(),
+			"nickname":     entity.Nickname,
+			"nickname_key": entity.NicknameKey,
+			"birthday":     entity.Birthday,
+			"about_me":     entity.AboutMe,
+		}).Error
+	if dao.duplicateErr(err) == ErrDuplicateEmail {
+		return ErrDuplicateNickname
+	}
+	return err
+}
+
+func (dao *GORMUserDAO) UpdateAvatar(ctx context.Context, uid int64, avatar string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"u_time": time.Now().UnixMilli(),
+			"avatar": avatar,
+		}).Error
+}
+
+func (dao *GORMUserDAO) UpdatePassword(ctx context.Context, uid int64, password string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
 		Updates(map[string]any{
 			"u_time":   time.Now().UnixMilli(),
-			"nickname": entity.Nickname,
-			"birthday": entity.Birthday,
-			"about_me": entity.AboutMe,
+			"password": password,
+		}).Error
+}
+
+func (dao *GORMUserDAO) MarkEmailVerified(ctx context.Context, uid int64, email string) error {
+	// 带上 email，防止验证链接发出去之后用户又换了邮箱
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND email = ?", uid, email).
+		Updates(map[string]any{
+			"u_time":         time.Now().UnixMilli(),
+			"email_verified": true,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GORMUserDAO) SetTOTPSecret(ctx context.Context, uid int64, secret string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"u_time":       time.Now().UnixMilli(),
+			"totp_secret":  secret,
+			"totp_enabled": false,
+		}).Error
+}
+
+func (dao *GORMUserDAO) EnableTOTP(ctx context.Context, uid int64, codes []string) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("uid = ?", uid).Delete(&UserRecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		rcs := make([]UserRecoveryCode, 0, len(codes))
+		for _, c := range codes {
+			rcs = append(rcs, UserRecoveryCode{
+				Uid:   uid,
+				Code:  c,
+				Ctime: now,
+				Utime: now,
+			})
+		}
+		err = tx.Create(&rcs).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&User{}).Where("id = ?", uid).
+			Updates(map[string]any{
+				"u_time":       now,
+				"totp_enabled": true,
+			}).Error
+	})
+}
+
+func (dao *GORMUserDAO) DisableTOTP(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("uid = ?", uid).Delete(&UserRecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&User{}).Where("id = ?", uid).
+			Updates(map[string]any{
+				"u_time":       time.Now().UnixMilli(),
+				"totp_secret":  "",
+				"totp_enabled": false,
+			}).Error
+	})
+}
+
+func (dao *GORMUserDAO) UseTOTPStep(ctx context.Context, uid int64, step int64) error {
+	// 和恢复码一样靠条件更新，并发提交同一个验证码只有一个能成功
+	res := dao.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND totp_last_step < ?", uid, step).
+		Updates(map[string]any{
+			"u_time":         time.Now().UnixMilli(),
+			"totp_last_step": step,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GORMUserDAO) UseRecoveryCode(ctx context.Context, uid int64, code string) error {
+	// 利用 used = false 的条件保证一个恢复码只能用一次
+	res := dao.db.WithContext(ctx).Model(&UserRecoveryCode{}).
+		Where("uid = ? AND code = ? AND used = ?", uid, code, false).
+		Updates(map[string]any{
+			"used":  true,
+			"utime": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GORMUserDAO) UpdatePhone(ctx context.Context, uid int64, phone sql.NullString) error {
+	err := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"u_time": time.Now().UnixMilli(),
+			"phone":  phone,
+		}).Error
+	// 手机号已经被别的账号用了
+	return dao.duplicateErr(err)
+}
+
+func (dao *GORMUserDAO) UpdateEmail(ctx context.Context, uid int64, email sql.NullString, verified bool) error {
+	err := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"u_time":         time.Now().UnixMilli(),
+			"email":          email,
+			"email_verified": verified,
+		}).Error
+	return dao.duplicateErr(err)
+}
+
+func (dao *GORMUserDAO) UpdateWechat(ctx context.Context, uid int64, openId, unionId sql.NullString) error {
+	err := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).
+		Updates(map[string]any{
+			"u_time":          time.Now().UnixMilli(),
+			"wechat_open_id":  openId,
+			"wechat_union_id": unionId,
+		}).Error
+	return dao.duplicateErr(err)
+}
+
+func (dao *GORMUserDAO) MergeIdentities(ctx context.Context, from, to int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var fu, tu User
+		// 锁住两个账号，防止合并的时候用户又在绑定
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", from).First(&fu).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", to).First(&tu).Error
+		if err != nil {
+			return err
+		}
+		moved := map[string]any{}
+		if !tu.Phone.Valid && fu.Phone.Valid {
+			moved["phone"] = fu.Phone
+		}
+		if !tu.Email.Valid && fu.Email.Valid {
+			moved["email"] = fu.Email
+			moved["email_verified"] = fu.EmailVerified
+			// 邮箱登录要用到密码，一起带过去
+			if tu.Password == "" {
+				moved["password"] = fu.Password
+			}
+		}
+		if !tu.WechatOpenId.Valid && fu.WechatOpenId.Valid {
+			moved["wechat_open_id"] = fu.WechatOpenId
+			moved["wechat_union_id"] = fu.WechatUnionId
+		}
+		now := time.Now().UnixMilli()
+		// 第三方账号也一样，to 已经绑定了同一个提供商的就留在 from 上
+		var providers []string
+		err = tx.Model(&UserOAuth2{}).Where("uid = ?", to).
+			Pluck("provider", &providers).Error
+		if err != nil {
+			return err
+		}
+		query := tx.Model(&UserOAuth2{}).Where("uid = ?", from)
+		if len(providers) > 0 {
+			query = query.Where("provider NOT IN ?", providers)
+		}
+		err = query.Updates(map[string]any{
+			"uid":   to,
+			"utime": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if len(moved) == 0 {
+			return nil
+		}
+		// 先清空 from，不然会违反唯一索引
+		cleared := map[string]any{"u_time": now}
+		for k := range moved {
+			switch k {
+			case "email_verified":
+				cleared[k] = false
+			case "password":
+				cleared[k] = ""
+			default:
+				cleared[k] = sql.NullString{}
+			}
+		}
+		err = tx.Model(&User{}).Where("id = ?", from).Updates(cleared).Error
+		if err != nil {
+			return err
+		}
+		moved["u_time"] = now
+		return tx.Model(&User{}).Where("id = ?", to).Updates(moved).Error
+	})
+}
+
+func (dao *GORMUserDAO) FindByOAuth2(ctx context.Context, provider, externalId string) (User, error) {
+	var b UserOAuth2
+	err := dao.db.WithContext(ctx).
+		Where("provider = ? AND external_id = ?", provider, externalId).
+		First(&b).Error
+	if err != nil {
+		return User{}, err
+	}
+	return dao.FindById(ctx, b.Uid)
+}
+
+func (dao *GORMUserDAO) InsertWithOAuth2(ctx context.Context, u User, provider, externalId string) error {
+	now := time.Now().UnixMilli()
+	u.CTime = now
+	u.UTime = now
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&u).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&UserOAuth2{
+			Uid:        u.Id,
+			Provider:   provider,
+			ExternalId: externalId,
+			Ctime:      now,
+			Utime:      now,
 		}).Error
+	})
+	// 并发的时候第三方账号可能已经绑定了
+	return dao.duplicateErr(err)
+}
+
+func (dao *GORMUserDAO) FindOAuth2Providers(ctx context.Context, uid int64) ([]string, error) {
+	var providers []string
+	err := dao.db.WithContext(ctx).Model(&UserOAuth2{}).
+		Where("uid = ?", uid).
+		Order("id").
+		Pluck("provider", &providers).Error
+	return providers, err
+}
+
+func (dao *GORMUserDAO) DeleteOAuth2(ctx context.Context, uid int64, provider string) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ? AND provider = ?", uid, provider).
+		Delete(&UserOAuth2{}).Error
+}
+
+func (dao *GORMUserDAO) SoftDelete(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 登录方式都清空，这样手机号、邮箱可以重新注册
+		err := tx.Model(&User{}).Where("id = ?", uid).
+			Updates(map[string]any{
+				"u_time":          time.Now().UnixMilli(),
+				"email":           sql.NullString{},
+				"email_verified":  false,
+				"phone":           sql.NullString{},
+				"wechat_open_id":  sql.NullString{},
+				"wechat_union_id": sql.NullString{},
+				"password":        "",
+				"nickname":        "",
+				"nickname_key":    sql.NullString{},
+				"birthday":        0,
+				"about_me":        "",
+				"avatar":          "",
+				"totp_secret":     "",
+				"totp_enabled":    false,
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", uid).Delete(&UserRecoveryCode{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("uid = ?", uid).Delete(&UserOAuth2{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", uid).Delete(&User{}).Error
+	})
+}
+
+func (dao *GORMUserDAO) FindDeletedBefore(ctx context.Context, t time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := dao.db.WithContext(ctx).Unscoped().Model(&User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", t).
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (dao *GORMUserDAO) Purge(ctx context.Context, uid int64) error {
+	// 只能删除已经注销的账号
+	return dao.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", uid).
+		Delete(&User{}).Error
 }
 
 func (dao *GORMUserDAO) FindById(ctx context.Context, uid int64) (User, error) {
@@ -91,11 +522,15 @@ func (dao *GORMUserDAO) FindByPhone(ctx context.Context, phone string) (User, er
 type User struct {
 	Id int64 `gorm:"primaryKey,autoIncrement"`
 	// 代表这是一个可以为 NULL 的列
-	Email    sql.NullString `gorm:"unique"`
-	Nickname string         `gorm:"type=varchar(128)"`
-	Birthday int64
-	AboutMe  string `gorm:"type=varchar(4096)"`
-	Password string
+	Email         sql.NullString `gorm:"unique"`
+	EmailVerified bool
+	Nickname      string `gorm:"type=varchar(128)"`
+	// 转成小写的昵称，用来保证昵称不区分大小写唯一，没有昵称的时候是 NULL
+	NicknameKey sql.NullString `gorm:"type:varchar(128);unique"`
+	Birthday    int64
+	AboutMe     string `gorm:"type=varchar(4096)"`
+	Avatar      string `gorm:"type=varchar(256)"`
+	Password    string
 
 	// 1 如果查询要求同时使用 openid 和 unionid，就要创建联合唯一索引
 	// 2 如果查询只用 openid，那么就在 openid 上创建唯一索引，或者 <openid, unionId> 联合索引
@@ -104,6 +539,37 @@ type User struct {
 	WechatUnionId sql.NullString
 
 	Phone sql.NullString `gorm:"unique"`
+
+	// 两步验证
+	TotpSecret  string `gorm:"type=varchar(64)"`
+	TotpEnabled bool
+	// 最后一次用过的验证码所在的时间窗口，不大于它的验证码都不能再用
+	TotpLastStep int64
+
 	CTime int64
 	UTime int64
+	// 注销的时间，GORM 的查询会自动过滤掉已经注销的账号
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// UserRecoveryCode 两步验证的恢复码，手机丢了的时候用
+type UserRecoveryCode struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	Uid int64 `gorm:"index"`
+	// 只保存 hash 之后的值
+	Code  string `gorm:"type:varchar(128)"`
+	Used  bool
+	Ctime int64
+	Utime int64
+}
+
+// UserOAuth2 用户绑定的第三方账号，微信因为历史原因直接放在 User 上
+type UserOAuth2 struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	Uid int64 `gorm:"index"`
+	// <provider, external_id> 唯一确定一个第三方账号
+	Provider   string `gorm:"type:varchar(64);uniqueIndex:provider_external_id"`
+	ExternalId string `gorm:"type:varchar(255);uniqueIndex:provider_external_id"`
+	Ctime      int64
+	Utime      int64
 }
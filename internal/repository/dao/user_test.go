@@ -88,3 +88,63 @@ func TestGORMUserDAO_Insert(t *testing.T) {
 		})
 	}
 }
+
+func TestGORMUserDAO_MergeIdentities(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .* FOR UPDATE").WithArgs(int64(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone"}).AddRow(1, "15212345678"))
+	mock.ExpectQuery("SELECT .* FOR UPDATE").WithArgs(int64(2), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "phone"}).AddRow(2, "15212345679"))
+	mock.ExpectQuery("SELECT `provider` FROM `user_o_auth2`").WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"provider"}).AddRow("oidc"))
+	// to 没有绑定的第三方账号转过去，手机号 to 已经有了不动
+	mock.ExpectExec("UPDATE `user_o_auth2` SET .* WHERE uid = \\? AND provider NOT IN \\(\\?\\)").
+		WithArgs(int64(2), sqlmock.AnyArg(), int64(1), "oidc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	assert.NoError(t, err)
+	err = NewUserDAO(db).MergeIdentities(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillNicknameKey(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	mock.ExpectQuery("SELECT `id`,`nickname` FROM `users` WHERE .*nickname_key IS NULL").
+		WithArgs(int64(0), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nickname"}).
+			AddRow(1, "Tom").AddRow(2, "TOM"))
+	mock.ExpectExec("UPDATE `users` SET `nickname_key`=\\? WHERE \\(id = \\? AND nickname_key IS NULL\\)").
+		WithArgs("tom", int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 转小写之后和 1 冲突，改成 TOM_2
+	mock.ExpectExec("UPDATE `users` SET `nickname_key`=\\? WHERE").
+		WithArgs("tom", int64(2)).
+		WillReturnError(&mysqlDriver.MySQLError{Number: 1062})
+	mock.ExpectExec("UPDATE `users` SET `nickname`=\\?,`nickname_key`=\\?,`u_time`=\\? WHERE").
+		WithArgs("TOM_2", "tom_2", sqlmock.AnyArg(), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	assert.NoError(t, err)
+	err = BackfillNicknameKey(db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
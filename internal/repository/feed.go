@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"webook/internal/domain"
+	"webook/internal/repository/cache"
+)
+
+// ErrFeedNotCached 还没有生成过，或者已经失效了
+var ErrFeedNotCached = cache.ErrKeyNotExist
+
+// FeedRepository 生成好的订阅源和 sitemap。
+// 文章在线上库有变化的时候由 ArticleRepository 负责让它们失效
+type FeedRepository interface {
+	// GetFeed uid 是 0 的时候是全站的订阅源
+	GetFeed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error)
+	SetFeed(ctx context.Context, uid int64, format domain.FeedFormat, data []byte) error
+	// GetSitemap page 是 0 的时候是 sitemap 索引
+	GetSitemap(ctx context.Context, page int) ([]byte, error)
+	SetSitemap(ctx context.Context, page int, data []byte) error
+}
+
+type CachedFeedRepository struct {
+	cache cache.FeedCache
+}
+
+func NewCachedFeedRepository(cache cache.FeedCache) FeedRepository {
+	return &CachedFeedRepository{
+		cache: cache,
+	}
+}
+
+func (repo *CachedFeedRepository) GetFeed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error) {
+	return repo.cache.GetFeed(ctx, uid, format)
+}
+
+func (repo *CachedFeedRepository) SetFeed(ctx context.Context, uid int64, format domain.FeedFormat, data []byte) error {
+	return repo.cache.SetFeed(ctx, uid, format, data)
+}
+
+func (repo *CachedFeedRepository) GetSitemap(ctx context.Context, page int) ([]byte, error) {
+	return repo.cache.GetSitemap(ctx, page)
+}
+
+func (repo *CachedFeedRepository) SetSitemap(ctx context.Context, page int, data []byte) error {
+	return repo.cache.SetSitemap(ctx, page, data)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
 	"time"
 	"webook/internal/domain"
 	"webook/internal/repository/cache"
@@ -11,19 +13,44 @@ import (
 
 type InteractiveRepository interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
+	// MarkRead 同一个用户一段时间之内重复阅读只返回一次 true，只有 true 的才要记阅读数
+	MarkRead(ctx context.Context, biz string, id int64, uid int64) (bool, error)
 	IncrLike(ctx context.Context, biz string, id int64, uid int64) error
 	DecrLike(ctx context.Context, biz string, id int64, uid int64) error
 	AddCollectionItem(ctx context.Context, biz string, id int64, cid int64, uid int64) error
 	Get(ctx context.Context, biz string, id int64) (domain.Interactive, error)
+	// GetByIds 列表页用，没有互动数据的资源计数都是 0
+	GetByIds(ctx context.Context, biz string, ids []int64) (map[int64]domain.Interactive, error)
 	Liked(ctx context.Context, biz string, id int64, uid int64) (bool, error)
 	Collected(ctx context.Context, biz string, id int64, uid int64) (bool, error)
+	// BatchLiked 返回 uid 点赞了的那些资源
+	BatchLiked(ctx context.Context, biz string, ids []int64, uid int64) (map[int64]bool, error)
+	// BatchCollected 返回 uid 收藏了的那些资源
+	BatchCollected(ctx context.Context, biz string, ids []int64, uid int64) (map[int64]bool, error)
 	BatchIncrReadCnt(ctx context.Context, biz []string, bizId []int64) error
+	// TransferUser 合并账号的时候，把 from 的点赞和收藏转给 to
+	TransferUser(ctx context.Context, from, to int64) error
+	// DeleteByUser 删除用户所有的点赞和收藏，注销账号用
+	DeleteByUser(ctx context.Context, uid int64) error
+	// DeleteByBiz 资源被彻底删除之后，清理对应的互动数据
+	DeleteByBiz(ctx context.Context, biz string, ids []int64) error
+	GetCollections(ctx context.Context, uid int64) ([]domain.CollectionItem, error)
+	// ListLikes 分页查用户点赞过的资源
+	ListLikes(ctx context.Context, uid int64, offset, limit int) ([]domain.LikeItem, error)
+	// ListCollections 分页查用户的收藏
+	ListCollections(ctx context.Context, uid int64, offset, limit int) ([]domain.CollectionItem, error)
 }
 
 type CachedInteractiveRepository struct {
 	dao   dao.InteractiveDAO
 	cache cache.InteractiveCache
-	l     logger.Logger
+	// stateCache 用户的点赞、收藏状态
+	stateCache cache.InteractiveStateCache
+	l          logger.Logger
+}
+
+func (c *CachedInteractiveRepository) MarkRead(ctx context.Context, biz string, id int64, uid int64) (bool, error) {
+	return c.cache.MarkRead(ctx, biz, id, uid)
 }
 
 func (c *CachedInteractiveRepository) BatchIncrReadCnt(ctx context.Context, biz []string, bizId []int64) error {
@@ -74,53 +101,246 @@ func (c *CachedInteractiveRepository) Get(ctx context.Context, biz string, id in
 
 }
 
+func (c *CachedInteractiveRepository) GetByIds(ctx context.Context, biz string, ids []int64) (map[int64]domain.Interactive, error) {
+	if len(ids) == 0 {
+		return map[int64]domain.Interactive{}, nil
+	}
+	res, err := c.cache.GetByIds(ctx, biz, ids)
+	if err != nil {
+		// 缓存出问题了就全部查数据库
+		c.l.Error("批量查询互动缓存失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+		res = make(map[int64]domain.Interactive, len(ids))
+	}
+	missed := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := res[id]; !ok {
+			missed = append(missed, id)
+		}
+	}
+	if len(missed) == 0 {
+		return res, nil
+	}
+	ies, err := c.dao.GetByIds(ctx, biz, missed)
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[int64]domain.Interactive, len(missed))
+	// 没有记录的也缓存起来，免得每次都打到数据库
+	for _, id := range missed {
+		loaded[id] = domain.Interactive{}
+	}
+	for _, ie := range ies {
+		loaded[ie.BizId] = c.toDomain(ie)
+	}
+	for id, intr := range loaded {
+		res[id] = intr
+	}
+	err = c.cache.SetByIds(ctx, biz, loaded)
+	if err != nil {
+		c.l.Error("批量回写互动缓存失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+	}
+	return res, nil
+}
+
+func (c *CachedInteractiveRepository) BatchLiked(ctx context.Context, biz string, ids []int64, uid int64) (map[int64]bool, error) {
+	return c.batchState(ctx, cache.InteractiveStateLiked, biz, ids, uid,
+		func(ctx context.Context, ids []int64) (map[int64]bool, error) {
+			likes, err := c.dao.GetLikeInfos(ctx, biz, ids, uid)
+			if err != nil {
+				return nil, err
+			}
+			res := make(map[int64]bool, len(likes))
+			for _, l := range likes {
+				res[l.BizId] = true
+			}
+			return res, nil
+		})
+}
+
+func (c *CachedInteractiveRepository) BatchCollected(ctx context.Context, biz string, ids []int64, uid int64) (map[int64]bool, error) {
+	return c.batchState(ctx, cache.InteractiveStateCollected, biz, ids, uid,
+		func(ctx context.Context, ids []int64) (map[int64]bool, error) {
+			cbs, err := c.dao.GetCollectInfos(ctx, biz, ids, uid)
+			if err != nil {
+				return nil, err
+			}
+			res := make(map[int64]bool, len(cbs))
+			for _, cb := range cbs {
+				res[cb.BizId] = true
+			}
+			return res, nil
+		})
+}
+
+// batchState 先查状态缓存，没有的用 load 从数据库加载再回写，只返回为 true 的
+func (c *CachedInteractiveRepository) batchState(ctx context.Context,
+	kind cache.InteractiveStateKind, biz string, ids []int64, uid int64,
+	load func(ctx context.Context, ids []int64) (map[int64]bool, error)) (map[int64]bool, error) {
+	res := make(map[int64]bool, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	cached, err := c.stateCache.Get(ctx, kind, biz, ids, uid)
+	if err != nil {
+		c.l.Error("查询点赞收藏状态缓存失败",
+			logger.String("kind", string(kind)),
+			logger.String("biz", biz),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+		cached = map[int64]bool{}
+	}
+	missed := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		val, ok := cached[id]
+		if !ok {
+			missed = append(missed, id)
+			continue
+		}
+		if val {
+			res[id] = true
+		}
+	}
+	if len(missed) == 0 {
+		return res, nil
+	}
+	loaded, err := load(ctx, missed)
+	if err != nil {
+		return nil, err
+	}
+	// 没有点赞、收藏的也要缓存
+	vals := make(map[int64]bool, len(missed))
+	for _, id := range missed {
+		vals[id] = loaded[id]
+		if loaded[id] {
+			res[id] = true
+		}
+	}
+	c.fillState(ctx, kind, biz, vals, uid)
+	return res, nil
+}
+
+func (c *CachedInteractiveRepository) fillState(ctx context.Context,
+	kind cache.InteractiveStateKind, biz string, vals map[int64]bool, uid int64) {
+	err := c.stateCache.Fill(ctx, kind, biz, vals, uid)
+	if err != nil {
+		c.l.Error("回写点赞收藏状态缓存失败",
+			logger.String("kind", string(kind)),
+			logger.String("biz", biz),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+}
+
+// setState 用户操作之后更新状态缓存，失败了就删掉整个用户的状态，免得读到旧的
+func (c *CachedInteractiveRepository) setState(ctx context.Context,
+	kind cache.InteractiveStateKind, biz string, id int64, uid int64, val bool) {
+	err := c.stateCache.Set(ctx, kind, biz, id, uid, val)
+	if err == nil {
+		return
+	}
+	c.l.Error("更新点赞收藏状态缓存失败",
+		logger.String("kind", string(kind)),
+		logger.String("biz", biz),
+		logger.Int64("bizId", id),
+		logger.Int64("uid", uid),
+		logger.Error(err))
+	c.delState(ctx, uid)
+}
+
+func (c *CachedInteractiveRepository) delState(ctx context.Context, uid int64) {
+	err := c.stateCache.Del(ctx, uid)
+	if err != nil {
+		c.l.Error("删除点赞收藏状态缓存失败",
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+}
+
 func (c *CachedInteractiveRepository) toDomain(ie dao.Interactive) domain.Interactive {
 	return domain.Interactive{
 		ReadCnt:    ie.ReadCnt,
 		LikeCnt:    ie.LikeCnt,
 		CollectCnt: ie.CollectCnt,
+		RewardCnt:  ie.RewardCnt,
 	}
 }
 
 func (c *CachedInteractiveRepository) Liked(ctx context.Context,
 	biz string, id int64, uid int64) (bool, error) {
-	_, err := c.dao.GetLikeInfo(ctx, biz, id, uid)
-	switch err {
-	case nil:
-		return true, nil
-	case dao.ErrRecordNotFound:
-		return false, nil
-	default:
-		return false, err
-	}
+	return c.state(ctx, cache.InteractiveStateLiked, biz, id, uid,
+		func(ctx context.Context) error {
+			_, err := c.dao.GetLikeInfo(ctx, biz, id, uid)
+			return err
+		})
 }
 
 func (c *CachedInteractiveRepository) Collected(ctx context.Context,
 	biz string, id int64, uid int64) (bool, error) {
-	_, err := c.dao.GetCollectInfo(ctx, biz, id, uid)
+	return c.state(ctx, cache.InteractiveStateCollected, biz, id, uid,
+		func(ctx context.Context) error {
+			_, err := c.dao.GetCollectInfo(ctx, biz, id, uid)
+			return err
+		})
+}
+
+// state 查单个资源的状态，find 查不到记录的时候返回 dao.ErrRecordNotFound
+func (c *CachedInteractiveRepository) state(ctx context.Context,
+	kind cache.InteractiveStateKind, biz string, id int64, uid int64,
+	find func(ctx context.Context) error) (bool, error) {
+	cached, err := c.stateCache.Get(ctx, kind, biz, []int64{id}, uid)
+	if err == nil {
+		if val, ok := cached[id]; ok {
+			return val, nil
+		}
+	} else {
+		c.l.Error("查询点赞收藏状态缓存失败",
+			logger.String("kind", string(kind)),
+			logger.String("biz", biz),
+			logger.Int64("bizId", id),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+	var val bool
+	err = find(ctx)
 	switch err {
 	case nil:
-		return true, nil
+		val = true
 	case dao.ErrRecordNotFound:
-		return false, nil
+		val = false
 	default:
 		return false, err
 	}
+	c.fillState(ctx, kind, biz, map[int64]bool{id: val}, uid)
+	return val, nil
 }
 
 func (c *CachedInteractiveRepository) IncrLike(ctx context.Context, biz string, id int64, uid int64) error {
 	err := c.dao.InsertLikeInfo(ctx, biz, id, uid)
+	if errors.Is(err, dao.ErrLikeUnchanged) {
+		c.setState(ctx, cache.InteractiveStateLiked, biz, id, uid, true)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	c.setState(ctx, cache.InteractiveStateLiked, biz, id, uid, true)
 	return c.cache.IncrLikeCntIfPresent(ctx, biz, id)
 }
 
 func (c *CachedInteractiveRepository) DecrLike(ctx context.Context, biz string, id int64, uid int64) error {
 	err := c.dao.DeleteLikeInfo(ctx, biz, id, uid)
+	if errors.Is(err, dao.ErrLikeUnchanged) {
+		c.setState(ctx, cache.InteractiveStateLiked, biz, id, uid, false)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	c.setState(ctx, cache.InteractiveStateLiked, biz, id, uid, false)
 	return c.cache.DecrLikeCntIfPresent(ctx, biz, id)
 }
 
@@ -146,13 +366,105 @@ func (c *CachedInteractiveRepository) AddCollectionItem(ctx context.Context,
 	if err != nil {
 		return err
 	}
+	c.setState(ctx, cache.InteractiveStateCollected, biz, id, uid, true)
 	return c.cache.IncrCollectCntIfPresent(ctx, biz, id)
 }
 
-func NewCachedInteractiveRepository(dao dao.InteractiveDAO, l logger.Logger, cache cache.InteractiveCache) InteractiveRepository {
+func (c *CachedInteractiveRepository) TransferUser(ctx context.Context, from, to int64) error {
+	changed, err := c.dao.TransferUser(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	c.delCache(ctx, changed)
+	c.delState(ctx, from)
+	c.delState(ctx, to)
+	return nil
+}
+
+func (c *CachedInteractiveRepository) DeleteByUser(ctx context.Context, uid int64) error {
+	changed, err := c.dao.DeleteByUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	c.delCache(ctx, changed)
+	c.delState(ctx, uid)
+	return nil
+}
+
+func (c *CachedInteractiveRepository) DeleteByBiz(ctx context.Context, biz string, ids []int64) error {
+	err := c.dao.DeleteByBiz(ctx, biz, ids)
+	if err != nil {
+		return err
+	}
+	changed := make([]dao.Interactive, 0, len(ids))
+	for _, id := range ids {
+		changed = append(changed, dao.Interactive{Biz: biz, BizId: id})
+	}
+	c.delCache(ctx, changed)
+	// 用户的点赞收藏状态找不全是哪些用户的，等它自己过期
+	// 资源都没了，旧状态也不会再被查到
+	return nil
+}
+
+func (c *CachedInteractiveRepository) GetCollections(ctx context.Context, uid int64) ([]domain.CollectionItem, error) {
+	cbs, err := c.dao.GetCollectionsByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.UserCollectionBiz, domain.CollectionItem](cbs, c.toCollectionItem), nil
+}
+
+func (c *CachedInteractiveRepository) ListLikes(ctx context.Context, uid int64, offset, limit int) ([]domain.LikeItem, error) {
+	likes, err := c.dao.ListLikesByUser(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.UserLikeBiz, domain.LikeItem](likes,
+		func(idx int, src dao.UserLikeBiz) domain.LikeItem {
+			return domain.LikeItem{
+				Biz:   src.Biz,
+				BizId: src.BizId,
+				Ctime: time.UnixMilli(src.Utime),
+			}
+		}), nil
+}
+
+func (c *CachedInteractiveRepository) ListCollections(ctx context.Context, uid int64, offset, limit int) ([]domain.CollectionItem, error) {
+	cbs, err := c.dao.ListCollectionsByUser(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map[dao.UserCollectionBiz, domain.CollectionItem](cbs, c.toCollectionItem), nil
+}
+
+func (c *CachedInteractiveRepository) toCollectionItem(idx int, src dao.UserCollectionBiz) domain.CollectionItem {
+	return domain.CollectionItem{
+		Biz:   src.Biz,
+		BizId: src.BizId,
+		Cid:   src.Cid,
+		Ctime: time.UnixMilli(src.Ctime),
+	}
+}
+
+// delCache 计数变了的直接删掉缓存，下次查询的时候重新加载
+func (c *CachedInteractiveRepository) delCache(ctx context.Context, changed []dao.Interactive) {
+	for _, ie := range changed {
+		er := c.cache.Del(ctx, ie.Biz, ie.BizId)
+		if er != nil {
+			c.l.Error("删除缓存失败",
+				logger.String("biz", ie.Biz),
+				logger.Int64("bizId", ie.BizId),
+				logger.Error(er))
+		}
+	}
+}
+
+func NewCachedInteractiveRepository(dao dao.InteractiveDAO, l logger.Logger,
+	cache cache.InteractiveCache, stateCache cache.InteractiveStateCache) InteractiveRepository {
 	return &CachedInteractiveRepository{
-		dao:   dao,
-		cache: cache,
-		l:     l,
+		dao:        dao,
+		cache:      cache,
+		stateCache: stateCache,
+		l:          l,
 	}
 }
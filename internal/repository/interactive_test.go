@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository/cache"
+	cachemocks "webook/internal/repository/cache/mocks"
+	"webook/internal/repository/dao"
+	daomocks "webook/internal/repository/dao/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCachedInteractiveRepository_GetByIds(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache)
+
+		wantRes map[int64]domain.Interactive
+		wantErr error
+	}{
+		{
+			name: "全部命中缓存",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1, 2, 3}).
+					Return(map[int64]domain.Interactive{
+						1: {ReadCnt: 1}, 2: {ReadCnt: 2}, 3: {ReadCnt: 3},
+					}, nil)
+				return daomocks.NewMockInteractiveDAO(ctrl), c
+			},
+			wantRes: map[int64]domain.Interactive{
+				1: {ReadCnt: 1}, 2: {ReadCnt: 2}, 3: {ReadCnt: 3},
+			},
+		},
+		{
+			name: "部分命中，没有记录的是 0",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1, 2, 3}).
+					Return(map[int64]domain.Interactive{1: {ReadCnt: 1}}, nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetByIds(gomock.Any(), "article", []int64{2, 3}).
+					Return([]dao.Interactive{{Biz: "article", BizId: 2, LikeCnt: 5, RewardCnt: 1}}, nil)
+				// 只回写缓存里面没有的
+				c.EXPECT().SetByIds(gomock.Any(), "article", map[int64]domain.Interactive{
+					2: {LikeCnt: 5, RewardCnt: 1}, 3: {},
+				}).Return(nil)
+				return d, c
+			},
+			wantRes: map[int64]domain.Interactive{
+				1: {ReadCnt: 1}, 2: {LikeCnt: 5, RewardCnt: 1}, 3: {},
+			},
+		},
+		{
+			name: "缓存出错查数据库",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1, 2, 3}).
+					Return(nil, errors.New("redis 错误"))
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetByIds(gomock.Any(), "article", []int64{1, 2, 3}).
+					Return([]dao.Interactive{{Biz: "article", BizId: 1, ReadCnt: 7}}, nil)
+				c.EXPECT().SetByIds(gomock.Any(), "article", gomock.Any()).Return(nil)
+				return d, c
+			},
+			wantRes: map[int64]domain.Interactive{
+				1: {ReadCnt: 7}, 2: {}, 3: {},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, c := tc.mock(ctrl)
+			repo := NewCachedInteractiveRepository(d, logger.NewNopLogger(), c, nil)
+			res, err := repo.GetByIds(context.Background(), "article", []int64{1, 2, 3})
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestCachedInteractiveRepository_Liked(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveStateCache)
+
+		wantRes bool
+		wantErr error
+	}{
+		{
+			name: "命中缓存",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveStateCache) {
+				sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+				sc.EXPECT().Get(gomock.Any(), cache.InteractiveStateLiked, "article", []int64{1}, int64(123)).
+					Return(map[int64]bool{1: true}, nil)
+				return daomocks.NewMockInteractiveDAO(ctrl), sc
+			},
+			wantRes: true,
+		},
+		{
+			name: "缓存里面是没点赞",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveStateCache) {
+				sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+				sc.EXPECT().Get(gomock.Any(), cache.InteractiveStateLiked, "article", []int64{1}, int64(123)).
+					Return(map[int64]bool{1: false}, nil)
+				return daomocks.NewMockInteractiveDAO(ctrl), sc
+			},
+			wantRes: false,
+		},
+		{
+			name: "未命中，没点赞也回写",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveStateCache) {
+				sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+				sc.EXPECT().Get(gomock.Any(), cache.InteractiveStateLiked, "article", []int64{1}, int64(123)).
+					Return(map[int64]bool{}, nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetLikeInfo(gomock.Any(), "article", int64(1), int64(123)).
+					Return(dao.UserLikeBiz{}, dao.ErrRecordNotFound)
+				sc.EXPECT().Fill(gomock.Any(), cache.InteractiveStateLiked, "article",
+					map[int64]bool{1: false}, int64(123)).Return(nil)
+				return d, sc
+			},
+			wantRes: false,
+		},
+		{
+			name: "缓存出错查数据库",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveStateCache) {
+				sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+				sc.EXPECT().Get(gomock.Any(), cache.InteractiveStateLiked, "article", []int64{1}, int64(123)).
+					Return(nil, errors.New("redis 错误"))
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetLikeInfo(gomock.Any(), "article", int64(1), int64(123)).
+					Return(dao.UserLikeBiz{Id: 1}, nil)
+				sc.EXPECT().Fill(gomock.Any(), cache.InteractiveStateLiked, "article",
+					map[int64]bool{1: true}, int64(123)).Return(nil)
+				return d, sc
+			},
+			wantRes: true,
+		},
+		{
+			name: "数据库出错",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveStateCache) {
+				sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+				sc.EXPECT().Get(gomock.Any(), cache.InteractiveStateLiked, "article", []int64{1}, int64(123)).
+					Return(map[int64]bool{}, nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetLikeInfo(gomock.Any(), "article", int64(1), int64(123)).
+					Return(dao.UserLikeBiz{}, errors.New("db 错误"))
+				return d, sc
+			},
+			wantErr: errors.New("db 错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			d, sc := tc.mock(ctrl)
+			repo := NewCachedInteractiveRepository(d, logger.NewNopLogger(), nil, sc)
+			res, err := repo.Liked(context.Background(), "article", 1, 123)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestCachedInteractiveRepository_BatchCollected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+	sc.EXPECT().Get(gomock.Any(), cache.InteractiveStateCollected, "article", []int64{1, 2, 3, 4}, int64(123)).
+		Return(map[int64]bool{1: true, 2: false}, nil)
+	d := daomocks.NewMockInteractiveDAO(ctrl)
+	// 只查缓存里面没有的
+	d.EXPECT().GetCollectInfos(gomock.Any(), "article", []int64{3, 4}, int64(123)).
+		Return([]dao.UserCollectionBiz{{BizId: 4}}, nil)
+	sc.EXPECT().Fill(gomock.Any(), cache.InteractiveStateCollected, "article",
+		map[int64]bool{3: false, 4: true}, int64(123)).Return(nil)
+
+	repo := NewCachedInteractiveRepository(d, logger.NewNopLogger(), nil, sc)
+	res, err := repo.BatchCollected(context.Background(), "article", []int64{1, 2, 3, 4}, 123)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true, 4: true}, res)
+}
+
+func TestCachedInteractiveRepository_DecrLike(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := daomocks.NewMockInteractiveDAO(ctrl)
+	d.EXPECT().DeleteLikeInfo(gomock.Any(), "article", int64(1), int64(123)).Return(nil)
+	sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+	// 更新状态失败，整个用户的状态都删掉
+	sc.EXPECT().Set(gomock.Any(), cache.InteractiveStateLiked, "article", int64(1), int64(123), false).
+		Return(errors.New("redis 错误"))
+	sc.EXPECT().Del(gomock.Any(), int64(123)).Return(nil)
+	c := cachemocks.NewMockInteractiveCache(ctrl)
+	c.EXPECT().DecrLikeCntIfPresent(gomock.Any(), "article", int64(1)).Return(nil)
+
+	repo := NewCachedInteractiveRepository(d, logger.NewNopLogger(), c, sc)
+	err := repo.DecrLike(context.Background(), "article", 1, 123)
+	assert.NoError(t, err)
+}
+
+func TestCachedInteractiveRepository_IncrLikeUnchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := daomocks.NewMockInteractiveDAO(ctrl)
+	d.EXPECT().InsertLikeInfo(gomock.Any(), "article", int64(1), int64(123)).
+		Return(dao.ErrLikeUnchanged)
+	sc := cachemocks.NewMockInteractiveStateCache(ctrl)
+	sc.EXPECT().Set(gomock.Any(), cache.InteractiveStateLiked, "article", int64(1), int64(123), true).
+		Return(nil)
+	// 已经点赞过了，缓存里面的计数不能再加
+	c := cachemocks.NewMockInteractiveCache(ctrl)
+
+	repo := NewCachedInteractiveRepository(d, logger.NewNopLogger(), c, sc)
+	err := repo.IncrLike(context.Background(), "article", 1, 123)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/cache"
+	"webook/internal/repository/dao"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+var (
+	ErrLedgerTxnDuplicate  = dao.ErrLedgerTxnDuplicate
+	ErrInsufficientBalance = dao.ErrInsufficientBalance
+)
+
+// LedgerRepository 积分账本。余额不走缓存，每次都读数据库
+type LedgerRepository interface {
+	// Transfer 记一笔交易。幂等键已经存在的时候返回已有的交易和 ErrLedgerTxnDuplicate
+	Transfer(ctx context.Context, txn domain.LedgerTxn) (domain.LedgerTxn, error)
+	// Balance 没有账户的时候余额是 0
+	Balance(ctx context.Context, acc domain.LedgerAccount) (int64, error)
+	// Entries 流水，最新的在前面
+	Entries(ctx context.Context, acc domain.LedgerAccount, offset, limit int) ([]domain.LedgerEntry, error)
+}
+
+type ledgerRepository struct {
+	dao dao.LedgerDAO
+	// intrCache 打赏之后更新缓存里面的打赏数
+	intrCache cache.InteractiveCache
+	l         logger.Logger
+}
+
+func NewLedgerRepository(dao dao.LedgerDAO, intrCache cache.InteractiveCache, l logger.Logger) LedgerRepository {
+	return &ledgerRepository{
+		dao:       dao,
+		intrCache: intrCache,
+		l:         l,
+	}
+}
+
+func (repo *ledgerRepository) Transfer(ctx context.Context, txn domain.LedgerTxn) (domain.LedgerTxn, error) {
+	res, err := repo.dao.Transfer(ctx, repo.toEntity(txn),
+		repo.accountEntity(txn.From), repo.accountEntity(txn.To))
+	if err != nil && !errors.Is(err, dao.ErrLedgerTxnDuplicate) {
+		return domain.LedgerTxn{}, err
+	}
+	// 重复的交易打赏数已经加过了
+	if err == nil && txn.Biz != "" {
+		er := repo.intrCache.IncrRewardCntIfPresent(ctx, txn.Biz, txn.BizId)
+		if er != nil {
+			repo.l.Error("更新缓存里面的打赏数失败",
+				logger.String("biz", txn.Biz),
+				logger.Int64("bizId", txn.BizId),
+				logger.Error(er))
+		}
+	}
+	// 账户 id 换回类型和用户
+	out := repo.toDomain(res)
+	out.From, out.To = txn.From, txn.To
+	return out, err
+}
+
+func (repo *ledgerRepository) Balance(ctx context.Context, acc domain.LedgerAccount) (int64, error) {
+	res, err := repo.dao.FindAccount(ctx, acc.Type.ToUint8(), acc.Uid)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return res.Balance, err
+}
+
+func (repo *ledgerRepository) Entries(ctx context.Context, acc domain.LedgerAccount, offset, limit int) ([]domain.LedgerEntry, error) {
+	a, err := repo.dao.FindAccount(ctx, acc.Type.ToUint8(), acc.Uid)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return []domain.LedgerEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	es, err := repo.dao.FindEntries(ctx, a.Id, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(es, func(idx int, src dao.LedgerEntry) domain.LedgerEntry {
+		return domain.LedgerEntry{
+			TxnId:   src.TxnId,
+			Type:    domain.LedgerTxnType(src.Type),
+			Amount:  src.Amount,
+			Balance: src.Balance,
+			Ctime:   time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
+
+func (repo *ledgerRepository) accountEntity(acc domain.LedgerAccount) dao.LedgerAccount {
+	return dao.LedgerAccount{
+		Type: acc.Type.ToUint8(),
+		Uid:  acc.Uid,
+	}
+}
+
+func (repo *ledgerRepository) toEntity(txn domain.LedgerTxn) dao.LedgerTxn {
+	return dao.LedgerTxn{
+		IdempotencyKey: txn.Key,
+		Type:           txn.Type.ToUint8(),
+		Amount:         txn.Amount,
+		Biz:            txn.Biz,
+		BizId:          txn.BizId,
+		Ref:            txn.Ref,
+	}
+}
+
+func (repo *ledgerRepository) toDomain(txn dao.LedgerTxn) domain.LedgerTxn {
+	return domain.LedgerTxn{
+		Id:     txn.Id,
+		Key:    txn.IdempotencyKey,
+		Type:   domain.LedgerTxnType(txn.Type),
+		Amount: txn.Amount,
+		Biz:    txn.Biz,
+		BizId:  txn.BizId,
+		Ref:    txn.Ref,
+		Ctime:  time.UnixMilli(txn.Ctime),
+	}
+}
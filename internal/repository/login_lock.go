@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"webook/internal/repository/cache"
+)
+
+type LoginLockRepository interface {
+	Lock(ctx context.Context, key string, base, max time.Duration) (time.Duration, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Unlock(ctx context.Context, key string) error
+}
+
+type CachedLoginLockRepository struct {
+	cache cache.LoginLockCache
+}
+
+func NewLoginLockRepository(c cache.LoginLockCache) LoginLockRepository {
+	return &CachedLoginLockRepository{
+		cache: c,
+	}
+}
+
+func (repo *CachedLoginLockRepository) Lock(ctx context.Context, key string, base, max time.Duration) (time.Duration, error) {
+	return repo.cache.Lock(ctx, key, base, max)
+}
+
+func (repo *CachedLoginLockRepository) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return repo.cache.TTL(ctx, key)
+}
+
+func (repo *CachedLoginLockRepository) Unlock(ctx context.Context, key string) error {
+	return repo.cache.Unlock(ctx, key)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/dao"
+)
+
+type LoginLogRepository interface {
+	Create(ctx context.Context, l domain.LoginLog) error
+	FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error)
+	FindRecent(ctx context.Context, uid int64, result domain.LoginResult, limit int) ([]domain.LoginLog, error)
+	CountByAccount(ctx context.Context, account string, result domain.LoginResult, since time.Time) (int64, error)
+}
+
+type loginLogRepository struct {
+	dao dao.LoginLogDAO
+}
+
+func NewLoginLogRepository(dao dao.LoginLogDAO) LoginLogRepository {
+	return &loginLogRepository{
+		dao: dao,
+	}
+}
+
+func (repo *loginLogRepository) Create(ctx context.Context, l domain.LoginLog) error {
+	return repo.dao.Insert(ctx, repo.toEntity(l))
+}
+
+func (repo *loginLogRepository) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error) {
+	logs, err := repo.dao.FindByUid(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(logs, func(idx int, src dao.LoginLog) domain.LoginLog {
+		return repo.toDomain(src)
+	}), nil
+}
+
+func (repo *loginLogRepository) FindRecent(ctx context.Context, uid int64,
+	result domain.LoginResult, limit int) ([]domain.LoginLog, error) {
+	logs, err := repo.dao.FindRecent(ctx, uid, result.ToUint8(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(logs, func(idx int, src dao.LoginLog) domain.LoginLog {
+		return repo.toDomain(src)
+	}), nil
+}
+
+func (repo *loginLogRepository) CountByAccount(ctx context.Context, account string,
+	result domain.LoginResult, since time.Time) (int64, error) {
+	return repo.dao.CountByAccount(ctx, account, result.ToUint8(), since.UnixMilli())
+}
+
+func (repo *loginLogRepository) toEntity(l domain.LoginLog) dao.LoginLog {
+	return dao.LoginLog{
+		Id:        l.Id,
+		Uid:       l.Uid,
+		Account:   l.Account,
+		Method:    string(l.Method),
+		IP:        l.IP,
+		UserAgent: l.UserAgent,
+		Result:    l.Result.ToUint8(),
+	}
+}
+
+func (repo *loginLogRepository) toDomain(l dao.LoginLog) domain.LoginLog {
+	return domain.LoginLog{
+		Id:        l.Id,
+		Uid:       l.Uid,
+		Account:   l.Account,
+		Method:    domain.LoginMethod(l.Method),
+		IP:        l.IP,
+		UserAgent: l.UserAgent,
+		Result:    domain.LoginResult(l.Result),
+		Ctime:     time.UnixMilli(l.Ctime),
+	}
+}
@@ -0,0 +1,157 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./article_import.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./article_import.go -destination=./mock/article_import.mock.go -package=repomocks
+//
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockArticleImportRepository is a mock of ArticleImportRepository interface.
+type MockArticleImportRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockArticleImportRepositoryMockRecorder
+}
+
+// MockArticleImportRepositoryMockRecorder is the mock recorder for MockArticleImportRepository.
+type MockArticleImportRepositoryMockRecorder struct {
+	mock *MockArticleImportRepository
+}
+
+// NewMockArticleImportRepository creates a new mock instance.
+func NewMockArticleImportRepository(ctrl *gomock.Controller) *MockArticleImportRepository {
+	mock := &MockArticleImportRepository{ctrl: ctrl}
+	mock.recorder = &MockArticleImportRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArticleImportRepository) EXPECT() *MockArticleImportRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddFile mocks base method.
+func (m *MockArticleImportRepository) AddFile(ctx context.Context, id int64, file domain.ArticleImportFile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFile", ctx, id, file)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddFile indicates an expected call of AddFile.
+func (mr *MockArticleImportRepositoryMockRecorder) AddFile(ctx, id, file any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFile", reflect.TypeOf((*MockArticleImportRepository)(nil).AddFile), ctx, id, file)
+}
+
+// ClaimHash mocks base method.
+func (m *MockArticleImportRepository) ClaimHash(ctx context.Context, uid int64, hash string, staleBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimHash", ctx, uid, hash, staleBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimHash indicates an expected call of ClaimHash.
+func (mr *MockArticleImportRepositoryMockRecorder) ClaimHash(ctx, uid, hash, staleBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimHash", reflect.TypeOf((*MockArticleImportRepository)(nil).ClaimHash), ctx, uid, hash, staleBefore)
+}
+
+// ConfirmHash mocks base method.
+func (m *MockArticleImportRepository) ConfirmHash(ctx context.Context, uid int64, hash string, aid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmHash", ctx, uid, hash, aid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmHash indicates an expected call of ConfirmHash.
+func (mr *MockArticleImportRepositoryMockRecorder) ConfirmHash(ctx, uid, hash, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmHash", reflect.TypeOf((*MockArticleImportRepository)(nil).ConfirmHash), ctx, uid, hash, aid)
+}
+
+// CreateJob mocks base method.
+func (m *MockArticleImportRepository) CreateJob(ctx context.Context, job domain.ArticleImportJob) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", ctx, job)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockArticleImportRepositoryMockRecorder) CreateJob(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockArticleImportRepository)(nil).CreateJob), ctx, job)
+}
+
+// GetJob mocks base method.
+func (m *MockArticleImportRepository) GetJob(ctx context.Context, id int64) (domain.ArticleImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(domain.ArticleImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockArticleImportRepositoryMockRecorder) GetJob(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockArticleImportRepository)(nil).GetJob), ctx, id)
+}
+
+// ReleaseArticles mocks base method.
+func (m *MockArticleImportRepository) ReleaseArticles(ctx context.Context, aids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseArticles", ctx, aids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseArticles indicates an expected call of ReleaseArticles.
+func (mr *MockArticleImportRepositoryMockRecorder) ReleaseArticles(ctx, aids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseArticles", reflect.TypeOf((*MockArticleImportRepository)(nil).ReleaseArticles), ctx, aids)
+}
+
+// ReleaseHash mocks base method.
+func (m *MockArticleImportRepository) ReleaseHash(ctx context.Context, uid int64, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHash", ctx, uid, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseHash indicates an expected call of ReleaseHash.
+func (mr *MockArticleImportRepositoryMockRecorder) ReleaseHash(ctx, uid, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHash", reflect.TypeOf((*MockArticleImportRepository)(nil).ReleaseHash), ctx, uid, hash)
+}
+
+// SetJobStatus mocks base method.
+func (m *MockArticleImportRepository) SetJobStatus(ctx context.Context, id int64, status domain.ArticleImportJobStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetJobStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetJobStatus indicates an expected call of SetJobStatus.
+func (mr *MockArticleImportRepositoryMockRecorder) SetJobStatus(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetJobStatus", reflect.TypeOf((*MockArticleImportRepository)(nil).SetJobStatus), ctx, id, status)
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./feed.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./feed.go -destination=./mock/feed.mock.go -package=repomocks
+//
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFeedRepository is a mock of FeedRepository interface.
+type MockFeedRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedRepositoryMockRecorder
+}
+
+// MockFeedRepositoryMockRecorder is the mock recorder for MockFeedRepository.
+type MockFeedRepositoryMockRecorder struct {
+	mock *MockFeedRepository
+}
+
+// NewMockFeedRepository creates a new mock instance.
+func NewMockFeedRepository(ctrl *gomock.Controller) *MockFeedRepository {
+	mock := &MockFeedRepository{ctrl: ctrl}
+	mock.recorder = &MockFeedRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedRepository) EXPECT() *MockFeedRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetFeed mocks base method.
+func (m *MockFeedRepository) GetFeed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeed", ctx, uid, format)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeed indicates an expected call of GetFeed.
+func (mr *MockFeedRepositoryMockRecorder) GetFeed(ctx, uid, format any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeed", reflect.TypeOf((*MockFeedRepository)(nil).GetFeed), ctx, uid, format)
+}
+
+// GetSitemap mocks base method.
+func (m *MockFeedRepository) GetSitemap(ctx context.Context, page int) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSitemap", ctx, page)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSitemap indicates an expected call of GetSitemap.
+func (mr *MockFeedRepositoryMockRecorder) GetSitemap(ctx, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSitemap", reflect.TypeOf((*MockFeedRepository)(nil).GetSitemap), ctx, page)
+}
+
+// SetFeed mocks base method.
+func (m *MockFeedRepository) SetFeed(ctx context.Context, uid int64, format domain.FeedFormat, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFeed", ctx, uid, format, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFeed indicates an expected call of SetFeed.
+func (mr *MockFeedRepositoryMockRecorder) SetFeed(ctx, uid, format, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFeed", reflect.TypeOf((*MockFeedRepository)(nil).SetFeed), ctx, uid, format, data)
+}
+
+// SetSitemap mocks base method.
+func (m *MockFeedRepository) SetSitemap(ctx context.Context, page int, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSitemap", ctx, page, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSitemap indicates an expected call of SetSitemap.
+func (mr *MockFeedRepositoryMockRecorder) SetSitemap(ctx, page, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSitemap", reflect.TypeOf((*MockFeedRepository)(nil).SetSitemap), ctx, page, data)
+}
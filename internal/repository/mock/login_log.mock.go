@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./login_log.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./login_log.go -destination=./mock/login_log.mock.go -package=repomocks
+//
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginLogRepository is a mock of LoginLogRepository interface.
+type MockLoginLogRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginLogRepositoryMockRecorder
+}
+
+// MockLoginLogRepositoryMockRecorder is the mock recorder for MockLoginLogRepository.
+type MockLoginLogRepositoryMockRecorder struct {
+	mock *MockLoginLogRepository
+}
+
+// NewMockLoginLogRepository creates a new mock instance.
+func NewMockLoginLogRepository(ctrl *gomock.Controller) *MockLoginLogRepository {
+	mock := &MockLoginLogRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginLogRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginLogRepository) EXPECT() *MockLoginLogRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountByAccount mocks base method.
+func (m *MockLoginLogRepository) CountByAccount(ctx context.Context, account string, result domain.LoginResult, since time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByAccount", ctx, account, result, since)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByAccount indicates an expected call of CountByAccount.
+func (mr *MockLoginLogRepositoryMockRecorder) CountByAccount(ctx, account, result, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByAccount", reflect.TypeOf((*MockLoginLogRepository)(nil).CountByAccount), ctx, account, result, since)
+}
+
+// Create mocks base method.
+func (m *MockLoginLogRepository) Create(ctx context.Context, l domain.LoginLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, l)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockLoginLogRepositoryMockRecorder) Create(ctx, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockLoginLogRepository)(nil).Create), ctx, l)
+}
+
+// FindByUid mocks base method.
+func (m *MockLoginLogRepository) FindByUid(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUid", ctx, uid, offset, limit)
+	ret0, _ := ret[0].([]domain.LoginLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUid indicates an expected call of FindByUid.
+func (mr *MockLoginLogRepositoryMockRecorder) FindByUid(ctx, uid, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUid", reflect.TypeOf((*MockLoginLogRepository)(nil).FindByUid), ctx, uid, offset, limit)
+}
+
+// FindRecent mocks base method.
+func (m *MockLoginLogRepository) FindRecent(ctx context.Context, uid int64, result domain.LoginResult, limit int) ([]domain.LoginLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRecent", ctx, uid, result, limit)
+	ret0, _ := ret[0].([]domain.LoginLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRecent indicates an expected call of FindRecent.
+func (mr *MockLoginLogRepositoryMockRecorder) FindRecent(ctx, uid, result, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRecent", reflect.TypeOf((*MockLoginLogRepository)(nil).FindRecent), ctx, uid, result, limit)
+}
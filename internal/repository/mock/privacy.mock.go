@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./privacy.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./privacy.go -destination=./mock/privacy.mock.go -package=repomocks
+//
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPrivacyRepository is a mock of PrivacyRepository interface.
+type MockPrivacyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyRepositoryMockRecorder
+}
+
+// MockPrivacyRepositoryMockRecorder is the mock recorder for MockPrivacyRepository.
+type MockPrivacyRepositoryMockRecorder struct {
+	mock *MockPrivacyRepository
+}
+
+// NewMockPrivacyRepository creates a new mock instance.
+func NewMockPrivacyRepository(ctrl *gomock.Controller) *MockPrivacyRepository {
+	mock := &MockPrivacyRepository{ctrl: ctrl}
+	mock.recorder = &MockPrivacyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyRepository) EXPECT() *MockPrivacyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Block mocks base method.
+func (m *MockPrivacyRepository) Block(ctx context.Context, uid, blockedUid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Block", ctx, uid, blockedUid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Block indicates an expected call of Block.
+func (mr *MockPrivacyRepositoryMockRecorder) Block(ctx, uid, blockedUid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Block", reflect.TypeOf((*MockPrivacyRepository)(nil).Block), ctx, uid, blockedUid)
+}
+
+// FindBlocks mocks base method.
+func (m *MockPrivacyRepository) FindBlocks(ctx context.Context, uid int64, offset, limit int) ([]domain.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindBlocks", ctx, uid, offset, limit)
+	ret0, _ := ret[0].([]domain.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindBlocks indicates an expected call of FindBlocks.
+func (mr *MockPrivacyRepositoryMockRecorder) FindBlocks(ctx, uid, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBlocks", reflect.TypeOf((*MockPrivacyRepository)(nil).FindBlocks), ctx, uid, offset, limit)
+}
+
+// GetSettings mocks base method.
+func (m *MockPrivacyRepository) GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", ctx, uid)
+	ret0, _ := ret[0].(domain.PrivacySettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockPrivacyRepositoryMockRecorder) GetSettings(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockPrivacyRepository)(nil).GetSettings), ctx, uid)
+}
+
+// IsBlocked mocks base method.
+func (m *MockPrivacyRepository) IsBlocked(ctx context.Context, a, b int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlocked", ctx, a, b)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlocked indicates an expected call of IsBlocked.
+func (mr *MockPrivacyRepositoryMockRecorder) IsBlocked(ctx, a, b any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlocked", reflect.TypeOf((*MockPrivacyRepository)(nil).IsBlocked), ctx, a, b)
+}
+
+// SaveSettings mocks base method.
+func (m *MockPrivacyRepository) SaveSettings(ctx context.Context, s domain.PrivacySettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSettings", ctx, s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSettings indicates an expected call of SaveSettings.
+func (mr *MockPrivacyRepositoryMockRecorder) SaveSettings(ctx, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSettings", reflect.TypeOf((*MockPrivacyRepository)(nil).SaveSettings), ctx, s)
+}
+
+// Unblock mocks base method.
+func (m *MockPrivacyRepository) Unblock(ctx context.Context, uid, blockedUid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unblock", ctx, uid, blockedUid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unblock indicates an expected call of Unblock.
+func (mr *MockPrivacyRepositoryMockRecorder) Unblock(ctx, uid, blockedUid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unblock", reflect.TypeOf((*MockPrivacyRepository)(nil).Unblock), ctx, uid, blockedUid)
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./reading_history.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./reading_history.go -destination=./mock/reading_history.mock.go -package=repomocks
+//
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReadingHistoryRepository is a mock of ReadingHistoryRepository interface.
+type MockReadingHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReadingHistoryRepositoryMockRecorder
+}
+
+// MockReadingHistoryRepositoryMockRecorder is the mock recorder for MockReadingHistoryRepository.
+type MockReadingHistoryRepositoryMockRecorder struct {
+	mock *MockReadingHistoryRepository
+}
+
+// NewMockReadingHistoryRepository creates a new mock instance.
+func NewMockReadingHistoryRepository(ctrl *gomock.Controller) *MockReadingHistoryRepository {
+	mock := &MockReadingHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockReadingHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReadingHistoryRepository) EXPECT() *MockReadingHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockReadingHistoryRepository) Clear(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockReadingHistoryRepositoryMockRecorder) Clear(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockReadingHistoryRepository)(nil).Clear), ctx, uid)
+}
+
+// Get mocks base method.
+func (m *MockReadingHistoryRepository) Get(ctx context.Context, uid, aid int64) (domain.ReadingHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, uid, aid)
+	ret0, _ := ret[0].(domain.ReadingHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockReadingHistoryRepositoryMockRecorder) Get(ctx, uid, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockReadingHistoryRepository)(nil).Get), ctx, uid, aid)
+}
+
+// List mocks base method.
+func (m *MockReadingHistoryRepository) List(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.ReadingHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.ReadingHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockReadingHistoryRepositoryMockRecorder) List(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockReadingHistoryRepository)(nil).List), ctx, uid, cursor, limit)
+}
+
+// Upsert mocks base method.
+func (m *MockReadingHistoryRepository) Upsert(ctx context.Context, hs []domain.ReadingHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, hs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockReadingHistoryRepositoryMockRecorder) Upsert(ctx, hs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockReadingHistoryRepository)(nil).Upsert), ctx, hs)
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/cache"
+	"webook/internal/repository/dao"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+type PrivacyRepository interface {
+	// GetSettings 没有设置过的返回默认设置
+	GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error)
+	SaveSettings(ctx context.Context, s domain.PrivacySettings) error
+	Block(ctx context.Context, uid, blockedUid int64) error
+	Unblock(ctx context.Context, uid, blockedUid int64) error
+	FindBlocks(ctx context.Context, uid int64, offset, limit int) ([]domain.Block, error)
+	// IsBlocked 不分方向，任何一方拉黑了对方都是 true
+	IsBlocked(ctx context.Context, a, b int64) (bool, error)
+}
+
+type CachedPrivacyRepository struct {
+	dao   dao.PrivacyDAO
+	cache cache.PrivacyCache
+}
+
+func NewCachedPrivacyRepository(dao dao.PrivacyDAO, c cache.PrivacyCache) PrivacyRepository {
+	return &CachedPrivacyRepository{
+		dao:   dao,
+		cache: c,
+	}
+}
+
+func (repo *CachedPrivacyRepository) GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error) {
+	res, err := repo.cache.GetSettings(ctx, uid)
+	if err == nil {
+		return res, nil
+	}
+	s, err := repo.dao.FindSettings(ctx, uid)
+	switch {
+	case err == nil:
+		res = repo.toDomain(s)
+	case errors.Is(err, dao.ErrRecordNotFound):
+		// 没有设置过，默认设置也缓存起来，免得每次都打到数据库
+		res = domain.PrivacySettings{Uid: uid}
+	default:
+		return domain.PrivacySettings{}, err
+	}
+	err = repo.cache.SetSettings(ctx, res)
+	if err != nil {
+		// 记录日志
+	}
+	return res, nil
+}
+
+func (repo *CachedPrivacyRepository) SaveSettings(ctx context.Context, s domain.PrivacySettings) error {
+	err := repo.dao.UpsertSettings(ctx, repo.toEntity(s))
+	if err != nil {
+		return err
+	}
+	return repo.cache.DelSettings(ctx, s.Uid)
+}
+
+func (repo *CachedPrivacyRepository) Block(ctx context.Context, uid, blockedUid int64) error {
+	err := repo.dao.InsertBlock(ctx, dao.UserBlock{
+		Uid:        uid,
+		BlockedUid: blockedUid,
+	})
+	if err != nil {
+		return err
+	}
+	return repo.cache.DelBlocked(ctx, uid, blockedUid)
+}
+
+func (repo *CachedPrivacyRepository) Unblock(ctx context.Context, uid, blockedUid int64) error {
+	err := repo.dao.DeleteBlock(ctx, uid, blockedUid)
+	if err != nil {
+		return err
+	}
+	return repo.cache.DelBlocked(ctx, uid, blockedUid)
+}
+
+func (repo *CachedPrivacyRepository) FindBlocks(ctx context.Context, uid int64, offset, limit int) ([]domain.Block, error) {
+	blocks, err := repo.dao.FindBlocks(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(blocks, func(idx int, src dao.UserBlock) domain.Block {
+		return domain.Block{
+			Uid:        src.Uid,
+			BlockedUid: src.BlockedUid,
+			Ctime:      time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
+
+func (repo *CachedPrivacyRepository) IsBlocked(ctx context.Context, a, b int64) (bool, error) {
+	res, err := repo.cache.GetBlocked(ctx, a, b)
+	if err == nil {
+		return res, nil
+	}
+	res, err = repo.dao.IsBlocked(ctx, a, b)
+	if err != nil {
+		return false, err
+	}
+	err = repo.cache.SetBlocked(ctx, a, b, res)
+	if err != nil {
+		// 记录日志
+	}
+	return res, nil
+}
+
+func (repo *CachedPrivacyRepository) toEntity(s domain.PrivacySettings) dao.PrivacySettings {
+	return dao.PrivacySettings{
+		Uid:         s.Uid,
+		Likes:       s.Likes.ToUint8(),
+		Collections: s.Collections.ToUint8(),
+	}
+}
+
+func (repo *CachedPrivacyRepository) toDomain(s dao.PrivacySettings) domain.PrivacySettings {
+	return domain.PrivacySettings{
+		Uid:         s.Uid,
+		Likes:       domain.PrivacyScope(s.Likes),
+		Collections: domain.PrivacyScope(s.Collections),
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/dao"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+var ErrReadingHistoryNotFound = errors.New("没有阅读记录")
+
+type ReadingHistoryRepository interface {
+	// Upsert 批量记录阅读历史，旧的记录不会覆盖新的
+	Upsert(ctx context.Context, hs []domain.ReadingHistory) error
+	Get(ctx context.Context, uid int64, aid int64) (domain.ReadingHistory, error)
+	// List 按照最后阅读时间倒序，零值的 cursor 是第一页
+	List(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.ReadingHistory, error)
+	Clear(ctx context.Context, uid int64) error
+}
+
+type CachedReadingHistoryRepository struct {
+	dao dao.ReadingHistoryDAO
+}
+
+func NewCachedReadingHistoryRepository(dao dao.ReadingHistoryDAO) ReadingHistoryRepository {
+	return &CachedReadingHistoryRepository{
+		dao: dao,
+	}
+}
+
+func (repo *CachedReadingHistoryRepository) Upsert(ctx context.Context, hs []domain.ReadingHistory) error {
+	if len(hs) == 0 {
+		return nil
+	}
+	return repo.dao.Upsert(ctx, slice.Map(hs, func(idx int, src domain.ReadingHistory) dao.ReadingHistory {
+		return repo.toEntity(src)
+	}))
+}
+
+func (repo *CachedReadingHistoryRepository) Get(ctx context.Context, uid int64, aid int64) (domain.ReadingHistory, error) {
+	h, err := repo.dao.Find(ctx, uid, aid)
+	if errors.Is(err, dao.ErrRecordNotFound) {
+		return domain.ReadingHistory{}, ErrReadingHistoryNotFound
+	}
+	if err != nil {
+		return domain.ReadingHistory{}, err
+	}
+	return repo.toDomain(h), nil
+}
+
+func (repo *CachedReadingHistoryRepository) List(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.ReadingHistory, error) {
+	var utime int64
+	if !cursor.IsZero() {
+		utime = cursor.Utime.UnixMilli()
+	}
+	hs, err := repo.dao.FindByUid(ctx, uid, utime, cursor.Id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(hs, func(idx int, src dao.ReadingHistory) domain.ReadingHistory {
+		return repo.toDomain(src)
+	}), nil
+}
+
+func (repo *CachedReadingHistoryRepository) Clear(ctx context.Context, uid int64) error {
+	return repo.dao.DeleteByUid(ctx, uid)
+}
+
+func (repo *CachedReadingHistoryRepository) toEntity(h domain.ReadingHistory) dao.ReadingHistory {
+	var progressUtime int64
+	if !h.ProgressTime.IsZero() {
+		progressUtime = h.ProgressTime.UnixMilli()
+	}
+	return dao.ReadingHistory{
+		Uid:           h.Uid,
+		Aid:           h.Aid,
+		Progress:      h.Progress,
+		ProgressUtime: progressUtime,
+		Utime:         h.Utime.UnixMilli(),
+	}
+}
+
+func (repo *CachedReadingHistoryRepository) toDomain(h dao.ReadingHistory) domain.ReadingHistory {
+	res := domain.ReadingHistory{
+		Uid:      h.Uid,
+		Aid:      h.Aid,
+		Progress: h.Progress,
+		Utime:    time.UnixMilli(h.Utime),
+		Article:  domain.Article{Id: h.Aid},
+	}
+	if h.ProgressUtime > 0 {
+		res.ProgressTime = time.UnixMilli(h.ProgressUtime)
+	}
+	return res
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository/dao"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+var (
+	// ErrSeriesNotFound 系列不存在，或者不是这个作者的
+	ErrSeriesNotFound         = dao.ErrRecordNotFound
+	ErrSeriesArticleDuplicate = dao.ErrSeriesArticleDuplicate
+	ErrSeriesArticlesMismatch = dao.ErrSeriesArticlesMismatch
+)
+
+type SeriesRepository interface {
+	Create(ctx context.Context, s domain.Series) (int64, error)
+	FindById(ctx context.Context, id int64) (domain.Series, error)
+	FindByAuthor(ctx context.Context, uid int64, offset, limit int) ([]domain.Series, error)
+	// FindByArticle 文章不在任何系列里面返回 ErrSeriesNotFound
+	FindByArticle(ctx context.Context, aid int64) (domain.Series, error)
+	AddArticle(ctx context.Context, uid, id, aid int64) error
+	RemoveArticle(ctx context.Context, uid, id, aid int64) error
+	Reorder(ctx context.Context, uid, id int64, aids []int64) error
+}
+
+type seriesRepository struct {
+	dao dao.SeriesDAO
+}
+
+func NewSeriesRepository(dao dao.SeriesDAO) SeriesRepository {
+	return &seriesRepository{
+		dao: dao,
+	}
+}
+
+func (repo *seriesRepository) Create(ctx context.Context, s domain.Series) (int64, error) {
+	return repo.dao.Insert(ctx, dao.Series{
+		AuthorId:    s.AuthorId,
+		Title:       s.Title,
+		Description: s.Description,
+	})
+}
+
+func (repo *seriesRepository) FindById(ctx context.Context, id int64) (domain.Series, error) {
+	s, err := repo.dao.FindById(ctx, id)
+	if err != nil {
+		return domain.Series{}, err
+	}
+	return repo.toDomain(s), nil
+}
+
+func (repo *seriesRepository) FindByAuthor(ctx context.Context, uid int64, offset, limit int) ([]domain.Series, error) {
+	ss, err := repo.dao.FindByAuthor(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(ss, func(idx int, src dao.Series) domain.Series {
+		return repo.toDomain(src)
+	}), nil
+}
+
+func (repo *seriesRepository) FindByArticle(ctx context.Context, aid int64) (domain.Series, error) {
+	s, err := repo.dao.FindByArticle(ctx, aid)
+	if err != nil {
+		return domain.Series{}, err
+	}
+	return repo.toDomain(s), nil
+}
+
+func (repo *seriesRepository) AddArticle(ctx context.Context, uid, id, aid int64) error {
+	return repo.dao.AddArticle(ctx, uid, id, aid)
+}
+
+func (repo *seriesRepository) RemoveArticle(ctx context.Context, uid, id, aid int64) error {
+	return repo.dao.RemoveArticle(ctx, uid, id, aid)
+}
+
+func (repo *seriesRepository) Reorder(ctx context.Context, uid, id int64, aids []int64) error {
+	return repo.dao.Reorder(ctx, uid, id, aids)
+}
+
+func (repo *seriesRepository) toDomain(s dao.Series) domain.Series {
+	return domain.Series{
+		Id:          s.Id,
+		AuthorId:    s.AuthorId,
+		Title:       s.Title,
+		Description: s.Description,
+		ArticleIds:  s.ArticleIds,
+		Ctime:       time.UnixMilli(s.Ctime),
+		Utime:       time.UnixMilli(s.Utime),
+	}
+}
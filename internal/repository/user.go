@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
 	"webook/internal/domain"
 	"webook/internal/repository/cache"
@@ -10,8 +11,11 @@ import (
 )
 
 var (
-	ErrDuplicateUser = dao.ErrDuplicateEmail
-	ErrUserNotFound  = dao.ErrRecordNotFound
+	ErrDuplicateUser     = dao.ErrDuplicateEmail
+	ErrDuplicateNickname = dao.ErrDuplicateNickname
+	ErrUserNotFound      = dao.ErrRecordNotFound
+	// ErrVerifyTokenNotFound 邮箱验证的 token 不存在或者已经过期
+	ErrVerifyTokenNotFound = cache.ErrKeyNotExist
 )
 
 type UserRepository interface {
@@ -20,7 +24,37 @@ type UserRepository interface {
 	UpdateNonZeroFields(ctx context.Context, user domain.User) error
 	FindByPhone(ctx context.Context, phone string) (domain.User, error)
 	FindById(ctx context.Context, uid int64) (domain.User, error)
+	// FindByNickname 昵称不区分大小写
+	FindByNickname(ctx context.Context, nickname string) (domain.User, error)
 	FindByWechat(ctx context.Context, openId string) (domain.User, error)
+	UpdatePassword(ctx context.Context, uid int64, password string) error
+	UpdateAvatar(ctx context.Context, uid int64, avatar string) error
+	// SetEmailVerifyToken 保存邮箱验证链接里面的 token
+	SetEmailVerifyToken(ctx context.Context, token string, uid int64, email string) error
+	// ConsumeEmailVerifyToken 取出 token 对应的用户和邮箱，token 只能用一次
+	ConsumeEmailVerifyToken(ctx context.Context, token string) (int64, string, error)
+	MarkEmailVerified(ctx context.Context, uid int64, email string) error
+	SetTOTPSecret(ctx context.Context, uid int64, secret string) error
+	EnableTOTP(ctx context.Context, uid int64, recoveryCodes []string) error
+	DisableTOTP(ctx context.Context, uid int64) error
+	UseRecoveryCode(ctx context.Context, uid int64, code string) error
+	// UseTOTPStep 验证码用过了或者比用过的还旧，返回 ErrUserNotFound
+	UseTOTPStep(ctx context.Context, uid int64, step int64) error
+	// UpdatePhone 绑定手机号，phone 为空就是解绑
+	UpdatePhone(ctx context.Context, uid int64, phone string) error
+	// UpdateEmail 绑定邮箱，email 为空就是解绑
+	UpdateEmail(ctx context.Context, uid int64, email string, verified bool) error
+	// UpdateWechat 绑定微信，OpenId 为空就是解绑
+	UpdateWechat(ctx context.Context, uid int64, info domain.WechatInfo) error
+	MergeIdentities(ctx context.Context, from, to int64) error
+	FindByOAuth2(ctx context.Context, provider, externalId string) (domain.User, error)
+	// CreateWithOAuth2 创建用户，同时绑定第三方账号
+	CreateWithOAuth2(ctx context.Context, u domain.User, provider, externalId string) error
+	// DeleteOAuth2 解绑第三方账号
+	DeleteOAuth2(ctx context.Context, uid int64, provider string) error
+	SoftDelete(ctx context.Context, uid int64) error
+	FindDeletedBefore(ctx context.Context, t time.Time, limit int) ([]int64, error)
+	Purge(ctx context.Context, uid int64) error
 }
 
 type CachedUserRepository struct {
@@ -57,18 +91,22 @@ func (repo *CachedUserRepository) FindByEmail(ctx context.Context, email string)
 
 func (repo *CachedUserRepository) toDomain(u dao.User) domain.User {
 	return domain.User{
-		Id:       u.Id,
-		Email:    u.Email.String,
-		Password: u.Password,
-		AboutMe:  u.AboutMe,
-		Nickname: u.Nickname,
-		Phone:    u.Phone.String,
-		Birthday: time.UnixMilli(u.Birthday),
-		Ctime:    time.UnixMilli(u.CTime),
+		Id:            u.Id,
+		Email:         u.Email.String,
+		EmailVerified: u.EmailVerified,
+		Password:      u.Password,
+		AboutMe:       u.AboutMe,
+		Avatar:        u.Avatar,
+		Nickname:      u.Nickname,
+		Phone:         u.Phone.String,
+		Birthday:      time.UnixMilli(u.Birthday),
+		Ctime:         time.UnixMilli(u.CTime),
 		WechatInfo: domain.WechatInfo{
 			OpenId:  u.WechatOpenId.String,
 			UnionId: u.WechatUnionId.String,
 		},
+		TOTPSecret:  u.TotpSecret,
+		TOTPEnabled: u.TotpEnabled,
 	}
 }
 
@@ -77,7 +115,177 @@ func (repo *CachedUserRepository) UpdateNonZeroFields(ctx context.Context, user
 	if err != nil {
 		return err
 	}
-	return nil
+	return repo.cache.Del(ctx, user.Id)
+}
+
+func (repo *CachedUserRepository) FindByNickname(ctx context.Context, nickname string) (domain.User, error) {
+	u, err := repo.dao.FindByNicknameKey(ctx, nicknameKey(nickname))
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repo.toDomain(u), nil
+}
+
+// nicknameKey 昵称唯一索引用的 key
+func nicknameKey(nickname string) string {
+	return strings.ToLower(nickname)
+}
+
+func (repo *CachedUserRepository) UpdatePassword(ctx context.Context, uid int64, password string) error {
+	err := repo.dao.UpdatePassword(ctx, uid, password)
+	if err != nil {
+		return err
+	}
+	// 缓存里面也有密码，直接删掉
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) UpdateAvatar(ctx context.Context, uid int64, avatar string) error {
+	err := repo.dao.UpdateAvatar(ctx, uid, avatar)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) SetEmailVerifyToken(ctx context.Context, token string, uid int64, email string) error {
+	return repo.cache.SetEmailVerifyToken(ctx, token, cache.EmailVerifyToken{
+		Uid:   uid,
+		Email: email,
+	})
+}
+
+func (repo *CachedUserRepository) ConsumeEmailVerifyToken(ctx context.Context, token string) (int64, string, error) {
+	t, err := repo.cache.GetDelEmailVerifyToken(ctx, token)
+	if err != nil {
+		return 0, "", err
+	}
+	return t.Uid, t.Email, nil
+}
+
+func (repo *CachedUserRepository) MarkEmailVerified(ctx context.Context, uid int64, email string) error {
+	err := repo.dao.MarkEmailVerified(ctx, uid, email)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) SetTOTPSecret(ctx context.Context, uid int64, secret string) error {
+	err := repo.dao.SetTOTPSecret(ctx, uid, secret)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) EnableTOTP(ctx context.Context, uid int64, recoveryCodes []string) error {
+	err := repo.dao.EnableTOTP(ctx, uid, recoveryCodes)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) DisableTOTP(ctx context.Context, uid int64) error {
+	err := repo.dao.DisableTOTP(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) UseTOTPStep(ctx context.Context, uid int64, step int64) error {
+	// 缓存里面没有这个字段，不用删缓存
+	return repo.dao.UseTOTPStep(ctx, uid, step)
+}
+
+func (repo *CachedUserRepository) UseRecoveryCode(ctx context.Context, uid int64, code string) error {
+	return repo.dao.UseRecoveryCode(ctx, uid, code)
+}
+
+func (repo *CachedUserRepository) UpdatePhone(ctx context.Context, uid int64, phone string) error {
+	err := repo.dao.UpdatePhone(ctx, uid, sql.NullString{
+		String: phone,
+		Valid:  phone != "",
+	})
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) UpdateEmail(ctx context.Context, uid int64, email string, verified bool) error {
+	err := repo.dao.UpdateEmail(ctx, uid, sql.NullString{
+		String: email,
+		Valid:  email != "",
+	}, verified)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) UpdateWechat(ctx context.Context, uid int64, info domain.WechatInfo) error {
+	err := repo.dao.UpdateWechat(ctx, uid, sql.NullString{
+		String: info.OpenId,
+		Valid:  info.OpenId != "",
+	}, sql.NullString{
+		String: info.UnionId,
+		Valid:  info.UnionId != "",
+	})
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) MergeIdentities(ctx context.Context, from, to int64) error {
+	err := repo.dao.MergeIdentities(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	err = repo.cache.Del(ctx, from)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, to)
+}
+
+func (repo *CachedUserRepository) FindByOAuth2(ctx context.Context, provider, externalId string) (domain.User, error) {
+	u, err := repo.dao.FindByOAuth2(ctx, provider, externalId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return repo.toDomain(u), nil
+}
+
+func (repo *CachedUserRepository) CreateWithOAuth2(ctx context.Context, u domain.User, provider, externalId string) error {
+	return repo.dao.InsertWithOAuth2(ctx, repo.toEntity(u), provider, externalId)
+}
+
+func (repo *CachedUserRepository) DeleteOAuth2(ctx context.Context, uid int64, provider string) error {
+	err := repo.dao.DeleteOAuth2(ctx, uid, provider)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) SoftDelete(ctx context.Context, uid int64) error {
+	err := repo.dao.SoftDelete(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return repo.cache.Del(ctx, uid)
+}
+
+func (repo *CachedUserRepository) FindDeletedBefore(ctx context.Context, t time.Time, limit int) ([]int64, error) {
+	return repo.dao.FindDeletedBefore(ctx, t, limit)
+}
+
+func (repo *CachedUserRepository) Purge(ctx context.Context, uid int64) error {
+	return repo.dao.Purge(ctx, uid)
 }
 
 func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
@@ -87,6 +295,7 @@ func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
 			String: u.Email,
 			Valid:  u.Email != "",
 		},
+		EmailVerified: u.EmailVerified,
 		Phone: sql.NullString{
 			String: u.Phone,
 			Valid:  u.Phone != "",
@@ -94,7 +303,12 @@ func (repo *CachedUserRepository) toEntity(u domain.User) dao.User {
 		Password: u.Password,
 		Birthday: u.Birthday.UnixMilli(),
 		AboutMe:  u.AboutMe,
+		Avatar:   u.Avatar,
 		Nickname: u.Nickname,
+		NicknameKey: sql.NullString{
+			String: nicknameKey(u.Nickname),
+			Valid:  u.Nickname != "",
+		},
 		WechatUnionId: sql.NullString{
 			String: u.WechatInfo.UnionId,
 			Valid:  u.WechatInfo.UnionId != "",
@@ -120,6 +334,11 @@ func (repo *CachedUserRepository) FindById(ctx context.Context, uid int64) (doma
 	}
 
 	du := repo.toDomain(ue)
+	// 只有按照 ID 查的时候才带上第三方账号，解绑、合并都是用 ID 查的
+	du.OAuth2Providers, err = repo.dao.FindOAuth2Providers(ctx, uid)
+	if err != nil {
+		return domain.User{}, err
+	}
 
 	// 忽略掉redis插入数据的错误，回写缓存
 	_ = repo.cache.Set(ctx, du)
@@ -47,15 +47,17 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 						CTime: 101,
 						UTime: 102,
 					}, nil)
+				ud.EXPECT().FindOAuth2Providers(gomock.Any(), int64(111)).Return([]string{"github"}, nil)
 				uc.EXPECT().Get(gomock.Any(), int64(111)).Return(domain.User{}, cache.ErrKeyNotExist)
 				uc.EXPECT().Set(gomock.Any(), domain.User{
-					Id:       111,
-					Email:    "123@qq.com",
-					Password: "123456",
-					Birthday: time.UnixMilli(100),
-					AboutMe:  "自我介绍",
-					Phone:    "15212345678",
-					Ctime:    time.UnixMilli(101),
+					Id:              111,
+					Email:           "123@qq.com",
+					Password:        "123456",
+					Birthday:        time.UnixMilli(100),
+					AboutMe:         "自我介绍",
+					Phone:           "15212345678",
+					Ctime:           time.UnixMilli(101),
+					OAuth2Providers: []string{"github"},
 				}).Return(nil)
 
 				return ud, uc
@@ -63,13 +65,14 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 			uid: 111,
 			ctx: context.Background(),
 			wantUser: domain.User{
-				Id:       111,
-				Email:    "123@qq.com",
-				Password: "123456",
-				Birthday: time.UnixMilli(100),
-				AboutMe:  "自我介绍",
-				Phone:    "15212345678",
-				Ctime:    time.UnixMilli(101),
+				Id:              111,
+				Email:           "123@qq.com",
+				Password:        "123456",
+				Birthday:        time.UnixMilli(100),
+				AboutMe:         "自我介绍",
+				Phone:           "15212345678",
+				Ctime:           time.UnixMilli(101),
+				OAuth2Providers: []string{"github"},
 			},
 			wantErr: nil,
 		},
@@ -146,6 +149,7 @@ func TestCachedUserRepository_FindById(t *testing.T) {
 						CTime: 101,
 						UTime: 102,
 					}, nil)
+				d.EXPECT().FindOAuth2Providers(gomock.Any(), uid).Return(nil, nil)
 				c.EXPECT().Set(gomock.Any(), domain.User{
 					Id:       123,
 					Email:    "123@qq.com",
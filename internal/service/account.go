@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+)
+
+// 导出文章的时候每一批的数量。
+// 不能是 100，offset = 0 limit = 100 会命中第一页的缓存，缓存里面只有摘要
+const exportBatchSize = 50
+
+var ErrMergeSameUser = errors.New("不能合并同一个账号")
+
+// AccountService 跨越用户、文章、互动几个模块的账号操作
+type AccountService interface {
+	// Merge 合并账号，from 的文章、点赞和收藏都转移给 to，
+	// to 没有的登录方式也会从 from 转过去
+	Merge(ctx context.Context, from, to int64) error
+	// Delete 注销账号。文章设置为仅自己可见，点赞、收藏和阅读历史直接删除，
+	// 过了冷静期之后由 PurgeDeleted 彻底删除
+	Delete(ctx context.Context, uid int64) error
+	// PurgeDeleted 彻底删除在 before 之前注销的账号，返回删除的数量
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
+	// Export 导出个人数据
+	Export(ctx context.Context, uid int64) (domain.AccountExport, error)
+}
+
+type accountService struct {
+	userRepo repository.UserRepository
+	artRepo  repository.ArticleRepository
+	intrRepo repository.InteractiveRepository
+	// historyRepo 注销的时候阅读历史也一起清空
+	historyRepo repository.ReadingHistoryRepository
+}
+
+func NewAccountService(userRepo repository.UserRepository,
+	artRepo repository.ArticleRepository,
+	intrRepo repository.InteractiveRepository,
+	historyRepo repository.ReadingHistoryRepository) AccountService {
+	return &accountService{
+		userRepo:    userRepo,
+		artRepo:     artRepo,
+		intrRepo:    intrRepo,
+		historyRepo: historyRepo,
+	}
+}
+
+func (svc *accountService) Merge(ctx context.Context, from, to int64) error {
+	if from == to {
+		return ErrMergeSameUser
+	}
+	// 确认两个账号都存在
+	_, err := svc.userRepo.FindById(ctx, from)
+	if err != nil {
+		return err
+	}
+	_, err = svc.userRepo.FindById(ctx, to)
+	if err != nil {
+		return err
+	}
+	// 文章在 MongoDB，没办法和 MySQL 放在一个事务里面。
+	// 每一步都是幂等的，中间失败了管理员重试就可以
+	err = svc.userRepo.MergeIdentities(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	err = svc.artRepo.TransferAuthor(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	return svc.intrRepo.TransferUser(ctx, from, to)
+}
+
+func (svc *accountService) Delete(ctx context.Context, uid int64) error {
+	// 先注销账号，保证不能再登录，后面的步骤失败了可以重试
+	err := svc.userRepo.SoftDelete(ctx, uid)
+	if err != nil {
+		return err
+	}
+	err = svc.artRepo.SyncStatusByAuthor(ctx, uid, domain.ArticleStatusPrivate)
+	if err != nil {
+		return err
+	}
+	err = svc.intrRepo.DeleteByUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	return svc.historyRepo.Clear(ctx, uid)
+}
+
+func (svc *accountService) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	uids, err := svc.userRepo.FindDeletedBefore(ctx, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, uid := range uids {
+		ids, err := svc.artRepo.DeleteByAuthor(ctx, uid)
+		if err != nil {
+			return i, err
+		}
+		err = svc.intrRepo.DeleteByBiz(ctx, domain.BizArticle, ids)
+		if err != nil {
+			return i, err
+		}
+		// 最后才删除用户，中间失败了下一次还能找到
+		err = svc.userRepo.Purge(ctx, uid)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(uids), nil
+}
+
+func (svc *accountService) Export(ctx context.Context, uid int64) (domain.AccountExport, error) {
+	u, err := svc.userRepo.FindById(ctx, uid)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+	res := domain.AccountExport{User: u}
+	// 列表缓存里面只有摘要，导出要完整的内容
+	var cursor domain.ArticleCursor
+	for {
+		arts, err := svc.artRepo.ScanByAuthor(ctx, uid, cursor, exportBatchSize)
+		if err != nil {
+			return domain.AccountExport{}, err
+		}
+		res.Articles = append(res.Articles, arts...)
+		if len(arts) < exportBatchSize {
+			break
+		}
+		cursor = arts[len(arts)-1].Cursor()
+	}
+	res.Collections, err = svc.intrRepo.GetCollections(ctx, uid)
+	if err != nil {
+		return domain.AccountExport{}, err
+	}
+	return res, nil
+}
@@ -2,25 +2,72 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 	"webook/internal/domain"
 	"webook/internal/events/article"
 	"webook/internal/repository"
+	"webook/internal/service/moderation"
 	"webook/pkg/logger"
+	"webook/pkg/markdown"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+var (
+	// ErrArticleRejected 发表的时候没有通过内容审核，错误信息里面带着原因
+	ErrArticleRejected = errors.New("文章没有通过审核")
+	// ErrArticleNotInTrash 文章不在回收站里面，或者已经过了恢复期限
+	ErrArticleNotInTrash = errors.New("文章不在回收站里面")
+	// ErrArticleConflict 文章在别的地方修改过了，要刷新之后再改
+	ErrArticleConflict = repository.ErrArticleConflict
+	ErrArticleNotFound = repository.ErrArticleNotFound
 )
 
+// ArticleTrashRetention 回收站里面的文章保留这么久，过期之后彻底删除
+const ArticleTrashRetention = time.Hour * 24 * 30
+
 type ArticleService interface {
+	// Save 更新的时候 art.Version 要和数据库里面的一样，不然返回 ErrArticleConflict。
+	// 保存成功之后版本号加一
 	Save(ctx context.Context, art domain.Article) (int64, error)
-	Publish(ctx context.Context, art domain.Article) (int64, error)
+	// Autosave 自动保存，只写到缓冲区，定时刷到数据库，版本号不变
+	Autosave(ctx context.Context, art domain.Article) error
+	// FlushAutosave 把 before 之前自动保存的内容刷到数据库，返回处理的数量
+	FlushAutosave(ctx context.Context, before time.Time, limit int) (int, error)
+	// Publish 发表之前先经过内容审核，返回文章 ID 和发表之后的状态。
+	// 审核拒绝的时候内容保存成草稿，返回 ErrArticleRejected
+	Publish(ctx context.Context, art domain.Article) (int64, domain.ArticleStatus, error)
 	Withdraw(ctx context.Context, uid int64, id int64) error
-	GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	// Delete 移到回收站，已经发表的同时下线
+	Delete(ctx context.Context, uid int64, id int64) error
+	// Restore 从回收站恢复，之前是发表状态的会重新发表，和 Publish 一样要审核。
+	// 审核不通过的时候返回 ErrArticleRejected，文章恢复成草稿
+	Restore(ctx context.Context, uid int64, id int64) error
+	ListTrash(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	// PurgeTrash 彻底删除 before 之前移到回收站的文章和它们的点赞收藏，返回删除的数量
+	PurgeTrash(ctx context.Context, before time.Time, limit int) (int, error)
+	// GetByAuthor 作者自己的文章列表，cursor 是上一页最后一篇，零值表示第一页
+	GetByAuthor(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
+	// GetById 有还没有刷到数据库的自动保存内容的话，用自动保存的内容
 	GetById(ctx context.Context, id int64) (domain.Article, error)
 	GetPubById(ctx context.Context, id, uid int64) (domain.Article, error)
+	// ListPub 作者已经发表的文章，给别人看的，不包括仅自己可见的
+	ListPub(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	CountPub(ctx context.Context, uid int64) (int64, error)
 }
 
 type articleService struct {
-	repo     repository.ArticleRepository
-	producer article.Producer
-	l        logger.Logger
+	repo       repository.ArticleRepository
+	userRepo   repository.UserRepository
+	reviewRepo repository.ArticleReviewRepository
+	intrRepo   repository.InteractiveRepository
+	importRepo repository.ArticleImportRepository
+	checker    moderation.Checker
+	producer   article.Producer
+	l          logger.Logger
 }
 
 func (a *articleService) GetPubById(ctx context.Context, id, uid int64) (domain.Article, error) {
@@ -29,8 +76,11 @@ func (a *articleService) GetPubById(ctx context.Context, id, uid int64) (domain.
 		// 发送消息
 		if err == nil {
 			evt := article.ReadEvent{
-				Aid: id,
-				Uid: uid,
+				Aid:   id,
+				Biz:   domain.BizArticle,
+				BizId: id,
+				Uid:   uid,
+				Time:  time.Now().UnixMilli(),
 			}
 
 			err := a.producer.ProducerReadEvent(evt)
@@ -47,35 +97,259 @@ func (a *articleService) GetPubById(ctx context.Context, id, uid int64) (domain.
 	return res, err
 }
 
+func (a *articleService) ListPub(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error) {
+	return a.repo.GetPubByAuthor(ctx, uid, offset, limit)
+}
+
+func (a *articleService) CountPub(ctx context.Context, uid int64) (int64, error) {
+	return a.repo.CountPubByAuthor(ctx, uid)
+}
+
 func (a *articleService) GetById(ctx context.Context, id int64) (domain.Article, error) {
-	return a.repo.GetById(ctx, id)
+	art, err := a.repo.GetById(ctx, id)
+	if err != nil {
+		return domain.Article{}, err
+	}
+	draft, err := a.repo.GetAutosave(ctx, id)
+	// 基于旧版本的自动保存已经没用了，等着刷新的时候丢掉
+	if err == nil && draft.Version == art.Version {
+		art.Title = draft.Title
+		art.Content = draft.Content
+		art.Format = draft.Format
+	}
+	return art, nil
+}
+
+func (a *articleService) Autosave(ctx context.Context, art domain.Article) error {
+	draft, err := a.repo.GetAutosave(ctx, art.Id)
+	if err == nil {
+		if draft.Author.Id != art.Author.Id {
+			return ErrArticleNotFound
+		}
+		// 另外一个设备基于别的版本在自动保存
+		if draft.Version != art.Version {
+			return ErrArticleConflict
+		}
+		return a.repo.Autosave(ctx, art)
+	}
+	// 第一次自动保存，确认一下是自己的文章，不然会污染别人的缓冲区
+	// 版本号等刷新的时候再检查
+	old, err := a.repo.GetById(ctx, art.Id)
+	if err != nil {
+		return err
+	}
+	if old.Author.Id != art.Author.Id || old.Trashed() {
+		return ErrArticleNotFound
+	}
+	return a.repo.Autosave(ctx, art)
 }
 
-func (a *articleService) GetByAuthor(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error) {
-	return a.repo.GetByAuthor(ctx, uid, offset, limit)
+func (a *articleService) FlushAutosave(ctx context.Context, before time.Time, limit int) (int, error) {
+	ids, err := a.repo.AutosaveIds(ctx, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		err = a.repo.FlushAutosave(ctx, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrArticleConflict), errors.Is(err, ErrArticleNotFound):
+			// 作者在别的地方手动保存过了，前端下次保存的时候会收到冲突
+			a.l.Warn("丢弃过时的自动保存内容",
+				logger.Int64("aid", id), logger.Error(err))
+		default:
+			return 0, err
+		}
+	}
+	return len(ids), nil
 }
 
-func (a *articleService) Publish(ctx context.Context, art domain.Article) (int64, error) {
-	art.Status = domain.ArticleStatusPublished
-	return a.repo.Sync(ctx, art)
+func (a *articleService) GetByAuthor(ctx context.Context, uid int64,
+	cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
+	return a.repo.GetByAuthor(ctx, uid, cursor, limit)
 }
 
-func NewArticleService(repo repository.ArticleRepository, producer article.Producer) ArticleService {
+func (a *articleService) Publish(ctx context.Context, art domain.Article) (int64, domain.ArticleStatus, error) {
+	author, err := a.userRepo.FindById(ctx, art.Author.Id)
+	if err != nil {
+		return 0, domain.ArticleStatusUnknown, err
+	}
+	// 用邮箱注册的用户，要先验证邮箱才能发表文章
+	if author.Email != "" && !author.EmailVerified {
+		return 0, domain.ArticleStatusUnknown, ErrEmailNotVerified
+	}
+	res, err := a.checker.Check(ctx, art)
+	if err != nil {
+		return 0, domain.ArticleStatusUnknown, err
+	}
+	switch res.Decision {
+	case domain.ModerationReject:
+		// 内容先保存成草稿，作者改了之后可以重新发表
+		id, err := a.Save(ctx, art)
+		if err != nil {
+			return 0, domain.ArticleStatusUnknown, err
+		}
+		return id, domain.ArticleStatusUnpublished,
+			fmt.Errorf("%w：%s", ErrArticleRejected, strings.Join(res.Reasons, "；"))
+	case domain.ModerationReview:
+		// 只写制作库，已经发表的版本继续在线，审核通过之后才替换
+		art.Status = domain.ArticleStatusPendingReview
+		id := art.Id
+		if id > 0 {
+			err = a.repo.Update(ctx, art)
+		} else {
+			id, err = a.repo.Create(ctx, art)
+		}
+		if err != nil {
+			return 0, domain.ArticleStatusUnknown, err
+		}
+		// 之前提交的版本还在排队的话，已经被这次覆盖了
+		err = a.reviewRepo.CancelByArticle(ctx, id)
+		if err != nil {
+			return 0, domain.ArticleStatusUnknown, err
+		}
+		_, err = a.reviewRepo.Create(ctx, domain.ArticleReview{
+			Aid:      id,
+			AuthorId: art.Author.Id,
+			Title:    art.Title,
+			Content:  art.Content,
+			Format:   art.Format,
+			Reasons:  res.Reasons,
+		})
+		return id, domain.ArticleStatusPendingReview, err
+	default:
+		art.Status = domain.ArticleStatusPublished
+		id, err := a.repo.Sync(ctx, renderArticle(art))
+		if err != nil {
+			return 0, domain.ArticleStatusUnknown, err
+		}
+		// 之前提交的版本还在排队的话，已经被这次覆盖了，不用再审
+		err = a.reviewRepo.CancelByArticle(ctx, id)
+		return id, domain.ArticleStatusPublished, err
+	}
+}
+
+// renderArticle Markdown 在发表的时候渲染成 HTML，读的时候直接用
+func renderArticle(art domain.Article) domain.Article {
+	if art.Format != domain.ArticleFormatMarkdown {
+		art.HTML = ""
+		art.TOC = nil
+		return art
+	}
+	doc := markdown.Render(art.Content)
+	art.HTML = doc.HTML
+	art.TOC = slice.Map[markdown.Heading, domain.TOCItem](doc.Headings,
+		func(idx int, src markdown.Heading) domain.TOCItem {
+			return domain.TOCItem{
+				Level:  src.Level,
+				Title:  src.Text,
+				Anchor: src.Anchor,
+			}
+		})
+	return art
+}
+
+func NewArticleService(repo repository.ArticleRepository, userRepo repository.UserRepository,
+	reviewRepo repository.ArticleReviewRepository, intrRepo repository.InteractiveRepository,
+	importRepo repository.ArticleImportRepository,
+	checker moderation.Checker, producer article.Producer, l logger.Logger) ArticleService {
 	return &articleService{
-		repo:     repo,
-		producer: producer,
+		repo:       repo,
+		userRepo:   userRepo,
+		reviewRepo: reviewRepo,
+		intrRepo:   intrRepo,
+		importRepo: importRepo,
+		checker:    checker,
+		producer:   producer,
+		l:          l,
 	}
 }
 
 func (a *articleService) Withdraw(ctx context.Context, uid int64, id int64) error {
-	return a.repo.SyncStatus(ctx, uid, id, domain.ArticleStatusPrivate)
+	err := a.repo.SyncStatus(ctx, uid, id, domain.ArticleStatusPrivate)
+	if err != nil {
+		return err
+	}
+	// 撤回了就不用审核了，免得审核通过之后又公开
+	return a.reviewRepo.CancelByArticle(ctx, id)
+}
+
+func (a *articleService) Delete(ctx context.Context, uid int64, id int64) error {
+	err := a.repo.Trash(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	// 删除了就不用审核了
+	return a.reviewRepo.CancelByArticle(ctx, id)
+}
+
+func (a *articleService) Restore(ctx context.Context, uid int64, id int64) error {
+	art, err := a.repo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if art.Author.Id != uid || !art.Trashed() {
+		return ErrArticleNotInTrash
+	}
+	// 先恢复成草稿，重新发表失败了也是一个正常的状态
+	err = a.repo.Restore(ctx, uid, id, time.Now().Add(-ArticleTrashRetention),
+		domain.ArticleStatusUnpublished)
+	if errors.Is(err, ErrArticleNotFound) {
+		return ErrArticleNotInTrash
+	}
+	if err != nil {
+		return err
+	}
+	switch art.Status {
+	case domain.ArticleStatusPublished:
+		// 线上库在移到回收站的时候已经删了，和正常发表一样走一遍审核
+		_, _, err = a.Publish(ctx, art)
+		return err
+	case domain.ArticleStatusPrivate:
+		// 只有自己能看到，不用审核
+		_, err = a.repo.Sync(ctx, renderArticle(art))
+		return err
+	default:
+		// 审核中和被驳回的恢复成草稿，作者重新发表的时候再审
+		return nil
+	}
+}
+
+func (a *articleService) ListTrash(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error) {
+	return a.repo.GetTrashByAuthor(ctx, uid, offset, limit)
+}
+
+func (a *articleService) PurgeTrash(ctx context.Context, before time.Time, limit int) (int, error) {
+	ids, err := a.repo.FindTrashedBefore(ctx, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	// 先删点赞收藏，最后才删文章，中间失败了下一次还能找到
+	err = a.intrRepo.DeleteByBiz(ctx, domain.BizArticle, ids)
+	if err != nil {
+		return 0, err
+	}
+	// 导入的文章删掉之后，同一个文件要能重新导入
+	err = a.importRepo.ReleaseArticles(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	err = a.repo.DeleteTrashed(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
 }
 
 func (a *articleService) Save(ctx context.Context, art domain.Article) (int64, error) {
 	art.Status = domain.ArticleStatusUnpublished
 	if art.Id > 0 {
 		err := a.repo.Update(ctx, art)
-		return art.Id, err
+		if err != nil {
+			return 0, err
+		}
+		// 草稿改了，之前提交审核的版本作者已经不要了，要重新发表
+		return art.Id, a.reviewRepo.CancelByArticle(ctx, art.Id)
 	}
 	return a.repo.Create(ctx, art)
 }
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/pkg/logger"
+	"webook/pkg/markdown"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxImportFiles 一次最多导入这么多篇
+	maxImportFiles = 500
+	// maxImportFileSize 单个文件解压之后的大小上限，超过的这个文件导入失败
+	maxImportFileSize = 1 << 20
+	// maxImportTotalSize 解压之后的总大小上限，防止 ZIP 炸弹
+	maxImportTotalSize = 50 << 20
+	maxArticleTags     = 10
+	// maxArticleTagLen 单个标签最多这么多个字
+	maxArticleTagLen = 32
+	// maxArticleTagsSize 标签 JSON 编码之后的字节数上限，对应制作库 tags 列的 varchar(1024)
+	maxArticleTagsSize = 1024
+	// importClaimTimeout 占住内容哈希超过这么久还没有导入成功的，当作上一次导入到一半崩溃了
+	importClaimTimeout = time.Minute * 10
+	importTimeout      = time.Minute * 30
+)
+
+var (
+	ErrArticleImportInvalid = errors.New("不是合法的 ZIP 文件，或者里面没有 Markdown 文件")
+	// ErrArticleImportTooLarge 文件数量或者解压之后的总大小超过了上限
+	ErrArticleImportTooLarge    = errors.New("导入的文件太多或者太大了")
+	ErrArticleImportJobNotFound = repository.ErrArticleImportJobNotFound
+)
+
+// ArticleArchiveService 用带 YAML front-matter 的 Markdown 文件批量导入导出文章，
+// 方便作者从别的平台迁移过来或者迁移出去
+type ArticleArchiveService interface {
+	// Import 校验 ZIP 之后创建后台任务，把里面的 Markdown 文件导入成草稿，返回任务 ID。
+	// 内容完全一样的文件只会导入一次，重复导入会跳过
+	Import(ctx context.Context, uid int64, data []byte) (int64, error)
+	// ImportJob 导入的进度和每个文件的结果，不是自己的任务返回 ErrArticleImportJobNotFound
+	ImportJob(ctx context.Context, uid int64, id int64) (domain.ArticleImportJob, error)
+	// Export 把作者所有的文章导出成带 front-matter 的 Markdown 文件，打包成 ZIP 写到 w
+	Export(ctx context.Context, uid int64, w io.Writer) error
+}
+
+type articleArchiveService struct {
+	repo    repository.ArticleImportRepository
+	artRepo repository.ArticleRepository
+	artSvc  ArticleService
+	l       logger.Logger
+}
+
+func NewArticleArchiveService(repo repository.ArticleImportRepository, artRepo repository.ArticleRepository,
+	artSvc ArticleService, l logger.Logger) ArticleArchiveService {
+	return &articleArchiveService{
+		repo:    repo,
+		artRepo: artRepo,
+		artSvc:  artSvc,
+		l:       l,
+	}
+}
+
+// importFile ZIP 里面的一个 Markdown 文件，err 是解压的时候就发现的问题
+type importFile struct {
+	name string
+	data []byte
+	err  string
+}
+
+func (svc *articleArchiveService) Import(ctx context.Context, uid int64, data []byte) (int64, error) {
+	files, err := svc.unzip(data)
+	if err != nil {
+		return 0, err
+	}
+	id, err := svc.repo.CreateJob(ctx, domain.ArticleImportJob{
+		Uid:    uid,
+		Status: domain.ArticleImportJobStatusRunning,
+		Total:  len(files),
+	})
+	if err != nil {
+		return 0, err
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+		defer cancel()
+		svc.runImport(ctx, id, uid, files)
+	}()
+	return id, nil
+}
+
+func (svc *articleArchiveService) unzip(data []byte) ([]importFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrArticleImportInvalid
+	}
+	var (
+		files []importFile
+		total int
+	)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isImportable(f.Name) {
+			continue
+		}
+		if len(files) >= maxImportFiles {
+			return nil, ErrArticleImportTooLarge
+		}
+		file := importFile{name: f.Name}
+		if f.UncompressedSize64 > maxImportFileSize {
+			file.err = "文件太大"
+			files = append(files, file)
+			continue
+		}
+		file.data, err = readZipFile(f)
+		switch {
+		case err != nil:
+			file.err = "文件损坏"
+		case len(file.data) > maxImportFileSize:
+			// 头里面的大小是可以伪造的
+			file.data = nil
+			file.err = "文件太大"
+		}
+		total += len(file.data)
+		if total > maxImportTotalSize {
+			return nil, ErrArticleImportTooLarge
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, ErrArticleImportInvalid
+	}
+	return files, nil
+}
+
+func (svc *articleArchiveService) runImport(ctx context.Context, id int64, uid int64, files []importFile) {
+	for _, f := range files {
+		res := svc.importOne(ctx, uid, f)
+		err := svc.repo.AddFile(ctx, id, res)
+		if err != nil {
+			svc.l.Error("记录导入进度失败",
+				logger.Int64("job", id),
+				logger.String("file", f.name),
+				logger.Error(err))
+		}
+	}
+	err := svc.repo.SetJobStatus(ctx, id, domain.ArticleImportJobStatusDone)
+	if err != nil {
+		svc.l.Error("结束导入任务失败",
+			logger.Int64("job", id),
+			logger.Error(err))
+	}
+}
+
+func (svc *articleArchiveService) importOne(ctx context.Context, uid int64, f importFile) domain.ArticleImportFile {
+	res := domain.ArticleImportFile{Name: f.name}
+	if f.err != "" {
+		res.Status = domain.ArticleImportFileStatusFailed
+		res.Error = f.err
+		return res
+	}
+	art, err := parseImportedArticle(f.name, f.data)
+	if err != nil {
+		res.Status = domain.ArticleImportFileStatusFailed
+		res.Error = err.Error()
+		return res
+	}
+	art.Author = domain.Author{Id: uid}
+
+	sum := sha256.Sum256(f.data)
+	hash := hex.EncodeToString(sum[:])
+	aid, err := svc.repo.ClaimHash(ctx, uid, hash, time.Now().Add(-importClaimTimeout))
+	switch {
+	case errors.Is(err, repository.ErrArticleImportDuplicate):
+		// 正在被别的任务导入的时候 aid 是 0
+		res.Status = domain.ArticleImportFileStatusSkipped
+		res.ArticleId = aid
+		return res
+	case err != nil:
+		svc.l.Error("导入文章的时候占住内容哈希失败",
+			logger.Int64("uid", uid),
+			logger.String("file", f.name),
+			logger.Error(err))
+		res.Status = domain.ArticleImportFileStatusFailed
+		res.Error = "系统错误"
+		return res
+	}
+
+	aid, err = svc.artSvc.Save(ctx, art)
+	if err != nil {
+		svc.l.Error("导入文章失败",
+			logger.Int64("uid", uid),
+			logger.String("file", f.name),
+			logger.Error(err))
+		er := svc.repo.ReleaseHash(ctx, uid, hash)
+		if er != nil {
+			svc.l.Error("释放内容哈希失败",
+				logger.Int64("uid", uid),
+				logger.String("hash", hash),
+				logger.Error(er))
+		}
+		res.Status = domain.ArticleImportFileStatusFailed
+		res.Error = "系统错误"
+		return res
+	}
+	err = svc.repo.ConfirmHash(ctx, uid, hash, aid)
+	if err != nil {
+		// 文章已经创建了，只是超时之后可能会被重复导入
+		svc.l.Error("确认内容哈希失败",
+			logger.Int64("uid", uid),
+			logger.Int64("aid", aid),
+			logger.Error(err))
+	}
+	res.Status = domain.ArticleImportFileStatusCreated
+	res.ArticleId = aid
+	return res
+}
+
+func (svc *articleArchiveService) ImportJob(ctx context.Context, uid int64, id int64) (domain.ArticleImportJob, error) {
+	job, err := svc.repo.GetJob(ctx, id)
+	if err != nil {
+		return domain.ArticleImportJob{}, err
+	}
+	if job.Uid != uid {
+		return domain.ArticleImportJob{}, ErrArticleImportJobNotFound
+	}
+	return job, nil
+}
+
+func (svc *articleArchiveService) Export(ctx context.Context, uid int64, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	// 列表缓存里面只有摘要，要完整的内容
+	var cursor domain.ArticleCursor
+	for {
+		arts, err := svc.artRepo.ScanByAuthor(ctx, uid, cursor, exportBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, art := range arts {
+			data, err := formatExportedArticle(art)
+			if err != nil {
+				return err
+			}
+			fw, err := zw.Create(exportFileName(art))
+			if err != nil {
+				return err
+			}
+			_, err = fw.Write(data)
+			if err != nil {
+				return err
+			}
+		}
+		if len(arts) < exportBatchSize {
+			break
+		}
+		cursor = arts[len(arts)-1].Cursor()
+	}
+	return zw.Close()
+}
+
+// articleFrontMatter 导入导出的 front-matter。
+// 导入的时候 status 会被忽略，要发表的话作者自己发表，经过审核
+type articleFrontMatter struct {
+	Title  string    `yaml:"title"`
+	Tags   []string  `yaml:"tags,omitempty"`
+	Date   time.Time `yaml:"date,omitempty"`
+	Status string    `yaml:"status,omitempty"`
+	// Format 不是 Markdown 的文章导出的时候是 plain
+	Format string `yaml:"format,omitempty"`
+}
+
+const articleFormatPlainName = "plain"
+
+// parseImportedArticle 返回的错误是给作者看的
+func parseImportedArticle(name string, data []byte) (domain.Article, error) {
+	if !utf8.Valid(data) {
+		return domain.Article{}, errors.New("不是 UTF-8 编码")
+	}
+	meta, body := markdown.SplitFrontMatter(string(data))
+	var fm articleFrontMatter
+	err := yaml.Unmarshal([]byte(meta), &fm)
+	if err != nil {
+		return domain.Article{}, fmt.Errorf("front-matter 格式错误：%w", err)
+	}
+	art := domain.Article{
+		Title:   strings.TrimSpace(fm.Title),
+		Content: body,
+		Format:  domain.ArticleFormatMarkdown,
+		Ctime:   fm.Date,
+	}
+	if art.Title == "" {
+		base := path.Base(name)
+		art.Title = strings.TrimSuffix(base, path.Ext(base))
+	}
+	switch fm.Format {
+	case "", "markdown":
+	case articleFormatPlainName:
+		art.Format = domain.ArticleFormatPlain
+	default:
+		return domain.Article{}, fmt.Errorf("不支持的格式 %s", fm.Format)
+	}
+	for _, tag := range fm.Tags {
+		tag = strings.TrimSpace(tag)
+		if utf8.RuneCountInString(tag) > maxArticleTagLen {
+			return domain.Article{}, fmt.Errorf("标签 %.32s... 太长了，不能超过 %d 个字", tag, maxArticleTagLen)
+		}
+		if tag != "" && !slices.Contains(art.Tags, tag) {
+			art.Tags = append(art.Tags, tag)
+		}
+	}
+	if len(art.Tags) > maxArticleTags {
+		return domain.Article{}, fmt.Errorf("标签不能超过 %d 个", maxArticleTags)
+	}
+	// 每个标签都不长，但是有特殊字符的时候转义之后还是可能超过
+	tags, err := json.Marshal(art.Tags)
+	if err != nil {
+		return domain.Article{}, err
+	}
+	if len(tags) > maxArticleTagsSize {
+		return domain.Article{}, errors.New("标签加起来太长了，请减少标签的数量或者长度")
+	}
+	return art, nil
+}
+
+func formatExportedArticle(art domain.Article) ([]byte, error) {
+	fm := articleFrontMatter{
+		Title:  art.Title,
+		Tags:   art.Tags,
+		Date:   art.Ctime,
+		Status: articleStatusName(art.Status),
+	}
+	if art.Format == domain.ArticleFormatPlain {
+		fm.Format = articleFormatPlainName
+	}
+	meta, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(markdown.JoinFrontMatter(string(meta), art.Content)), nil
+}
+
+func articleStatusName(status domain.ArticleStatus) string {
+	switch status {
+	case domain.ArticleStatusPublished:
+		return "published"
+	case domain.ArticleStatusPrivate:
+		return "private"
+	case domain.ArticleStatusPendingReview:
+		return "pending_review"
+	case domain.ArticleStatusRejected:
+		return "rejected"
+	default:
+		return "draft"
+	}
+}
+
+// exportFileName ID 加上标题，标题里面文件名不能用的字符换成下划线
+func exportFileName(art domain.Article) string {
+	title := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(art.Title))
+	if runes := []rune(title); len(runes) > 50 {
+		title = string(runes[:50])
+	}
+	name := strconv.FormatInt(art.Id, 10)
+	if title != "" {
+		name += "-" + title
+	}
+	return name + ".md"
+}
+
+// isImportable 只导入 Markdown 文件，跳过 macOS 打包带上的元数据
+func isImportable(name string) bool {
+	if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxImportFileSize+1))
+}
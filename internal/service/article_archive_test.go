@@ -0,0 +1,292 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseImportedArticle(t *testing.T) {
+	testCases := []struct {
+		name string
+		file string
+		data string
+
+		want    domain.Article
+		wantErr bool
+	}{
+		{
+			name: "完整的 front-matter",
+			file: "posts/a.md",
+			data: "---\ntitle: 标题\ntags: [go, \" go \", web]\ndate: 2023-04-05T06:07:08Z\nstatus: published\n---\n\n# 正文\n",
+			want: domain.Article{
+				Title:   "标题",
+				Content: "# 正文\n",
+				Format:  domain.ArticleFormatMarkdown,
+				Tags:    []string{"go", "web"},
+				Ctime:   time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC),
+			},
+		},
+		{
+			name: "没有 front-matter 用文件名当标题",
+			file: "posts/我的文章.markdown",
+			data: "正文",
+			want: domain.Article{
+				Title:   "我的文章",
+				Content: "正文",
+				Format:  domain.ArticleFormatMarkdown,
+			},
+		},
+		{
+			name: "纯文本",
+			file: "a.md",
+			data: "---\ntitle: 标题\nformat: plain\n---\n正文",
+			want: domain.Article{
+				Title:   "标题",
+				Content: "正文",
+				Format:  domain.ArticleFormatPlain,
+			},
+		},
+		{
+			name:    "front-matter 格式错误",
+			file:    "a.md",
+			data:    "---\ntitle: [\n---\n正文",
+			wantErr: true,
+		},
+		{
+			name:    "标签太多",
+			file:    "a.md",
+			data:    "---\ntags: [a, b, c, d, e, f, g, h, i, j, k]\n---\n正文",
+			wantErr: true,
+		},
+		{
+			name:    "标签太长",
+			file:    "a.md",
+			data:    "---\ntags: [" + strings.Repeat("长", 33) + "]\n---\n正文",
+			wantErr: true,
+		},
+		{
+			name: "标签加起来太长",
+			file: "a.md",
+			// 每个标签 32 个字，转义之后一百多个字节
+			data: "---\ntags: [" + func() string {
+				var sb strings.Builder
+				for i := 0; i < 6; i++ {
+					sb.WriteString(strings.Repeat("<", 31) + strconv.Itoa(i) + ", ")
+				}
+				return sb.String()
+			}() + "]\n---\n正文",
+			wantErr: true,
+		},
+		{
+			name: "标签刚好不超过",
+			file: "a.md",
+			data: "---\ntitle: 标题\ntags: [" + strings.Repeat("长", 32) + "]\n---\n正文",
+			want: domain.Article{
+				Title:   "标题",
+				Content: "正文",
+				Format:  domain.ArticleFormatMarkdown,
+				Tags:    []string{strings.Repeat("长", 32)},
+			},
+		},
+		{
+			name:    "不是 UTF-8",
+			file:    "a.md",
+			data:    "\xff\xfe正文",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			art, err := parseImportedArticle(tc.file, []byte(tc.data))
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tc.want.Ctime.Equal(art.Ctime))
+			art.Ctime = tc.want.Ctime
+			assert.Equal(t, tc.want, art)
+		})
+	}
+}
+
+func TestArticleArchiveService_importOne(t *testing.T) {
+	file := importFile{name: "a.md", data: []byte("---\ntitle: 标题\n---\n正文")}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.ArticleImportRepository, repository.ArticleRepository)
+		file importFile
+
+		want domain.ArticleImportFile
+	}{
+		{
+			name: "创建草稿",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleImportRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockArticleImportRepository(ctrl)
+				repo.EXPECT().ClaimHash(gomock.Any(), int64(123), gomock.Any(), gomock.Any()).Return(int64(0), nil)
+				repo.EXPECT().ConfirmHash(gomock.Any(), int64(123), gomock.Any(), int64(1)).Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().Create(gomock.Any(), domain.Article{
+					Title:   "标题",
+					Content: "正文",
+					Format:  domain.ArticleFormatMarkdown,
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatusUnpublished,
+				}).Return(int64(1), nil)
+				return repo, artRepo
+			},
+			file: file,
+			want: domain.ArticleImportFile{
+				Name:      "a.md",
+				Status:    domain.ArticleImportFileStatusCreated,
+				ArticleId: 1,
+			},
+		},
+		{
+			name: "已经导入过",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleImportRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockArticleImportRepository(ctrl)
+				repo.EXPECT().ClaimHash(gomock.Any(), int64(123), gomock.Any(), gomock.Any()).
+					Return(int64(1), repository.ErrArticleImportDuplicate)
+				return repo, repomocks.NewMockArticleRepository(ctrl)
+			},
+			file: file,
+			want: domain.ArticleImportFile{
+				Name:      "a.md",
+				Status:    domain.ArticleImportFileStatusSkipped,
+				ArticleId: 1,
+			},
+		},
+		{
+			name: "保存失败放弃哈希",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleImportRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockArticleImportRepository(ctrl)
+				repo.EXPECT().ClaimHash(gomock.Any(), int64(123), gomock.Any(), gomock.Any()).Return(int64(0), nil)
+				repo.EXPECT().ReleaseHash(gomock.Any(), int64(123), gomock.Any()).Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("mock db error"))
+				return repo, artRepo
+			},
+			file: file,
+			want: domain.ArticleImportFile{
+				Name:   "a.md",
+				Status: domain.ArticleImportFileStatusFailed,
+				Error:  "系统错误",
+			},
+		},
+		{
+			name: "解压的时候就失败了",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleImportRepository, repository.ArticleRepository) {
+				return repomocks.NewMockArticleImportRepository(ctrl), repomocks.NewMockArticleRepository(ctrl)
+			},
+			file: importFile{name: "b.md", err: "文件太大"},
+			want: domain.ArticleImportFile{
+				Name:   "b.md",
+				Status: domain.ArticleImportFileStatusFailed,
+				Error:  "文件太大",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, artRepo := tc.mock(ctrl)
+			artSvc := NewArticleService(artRepo, nil, nil, nil, nil, nil, nil, logger.NewNopLogger())
+			svc := NewArticleArchiveService(repo, artRepo, artSvc, logger.NewNopLogger()).(*articleArchiveService)
+			res := svc.importOne(context.Background(), 123, tc.file)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestArticleArchiveService_unzip(t *testing.T) {
+	zipOf := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range files {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		return buf.Bytes()
+	}
+	svc := &articleArchiveService{}
+
+	files, err := svc.unzip(zipOf(map[string]string{
+		"posts/a.md":          "a",
+		"posts/b.txt":         "b",
+		"__MACOSX/posts/a.md": "a",
+		"posts/.hidden.md":    "c",
+	}))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "posts/a.md", files[0].name)
+	assert.Equal(t, []byte("a"), files[0].data)
+
+	_, err = svc.unzip(zipOf(map[string]string{"a.txt": "a"}))
+	assert.ErrorIs(t, err, ErrArticleImportInvalid)
+	_, err = svc.unzip([]byte("不是 ZIP"))
+	assert.ErrorIs(t, err, ErrArticleImportInvalid)
+}
+
+func TestArticleArchiveService_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctime := time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)
+	arts := []domain.Article{
+		{Id: 2, Title: "a/b: c", Content: "# 正文\n", Format: domain.ArticleFormatMarkdown,
+			Tags: []string{"go"}, Status: domain.ArticleStatusPublished, Ctime: ctime},
+		{Id: 1, Title: "纯文本", Content: "正文", Format: domain.ArticleFormatPlain,
+			Status: domain.ArticleStatusUnpublished, Ctime: ctime},
+	}
+	artRepo := repomocks.NewMockArticleRepository(ctrl)
+	artRepo.EXPECT().ScanByAuthor(gomock.Any(), int64(123), domain.ArticleCursor{}, exportBatchSize).
+		Return(arts, nil)
+	svc := NewArticleArchiveService(nil, artRepo, nil, logger.NewNopLogger())
+
+	var buf bytes.Buffer
+	err := svc.Export(context.Background(), 123, &buf)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+	assert.Equal(t, "2-a_b_ c.md", zr.File[0].Name)
+	assert.Equal(t, "1-纯文本.md", zr.File[1].Name)
+	for i, f := range zr.File {
+		data, err := readZipFile(f)
+		require.NoError(t, err)
+		if i == 0 {
+			assert.Contains(t, string(data), "status: published\n")
+		}
+		// 导出的文件可以原样导回来
+		art, err := parseImportedArticle(f.Name, data)
+		require.NoError(t, err)
+		assert.Equal(t, arts[i].Title, art.Title)
+		assert.Equal(t, arts[i].Content, art.Content)
+		assert.Equal(t, arts[i].Format, art.Format)
+		assert.Equal(t, arts[i].Tags, art.Tags)
+		assert.True(t, arts[i].Ctime.Equal(art.Ctime))
+	}
+}
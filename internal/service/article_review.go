@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/internal/service/email"
+	"webook/pkg/logger"
+)
+
+// ErrArticleReviewNotPending 审核任务不存在，或者已经被别的审核员处理了
+var ErrArticleReviewNotPending = repository.ErrArticleReviewNotPending
+
+// ArticleReviewService 人工审核，机审拿不准的文章进入审核队列，由审核员在管理后台处理
+type ArticleReviewService interface {
+	// Pending 待审核的任务，先提交的排在前面
+	Pending(ctx context.Context, offset, limit int) ([]domain.ArticleReview, error)
+	Approve(ctx context.Context, id int64, reviewer int64) error
+	// Reject comment 会发给作者
+	Reject(ctx context.Context, id int64, reviewer int64, comment string) error
+}
+
+type articleReviewService struct {
+	repo     repository.ArticleReviewRepository
+	artRepo  repository.ArticleRepository
+	userRepo repository.UserRepository
+	emailSvc email.Service
+	l        logger.Logger
+}
+
+func NewArticleReviewService(repo repository.ArticleReviewRepository,
+	artRepo repository.ArticleRepository, userRepo repository.UserRepository,
+	emailSvc email.Service, l logger.Logger) ArticleReviewService {
+	return &articleReviewService{
+		repo:     repo,
+		artRepo:  artRepo,
+		userRepo: userRepo,
+		emailSvc: emailSvc,
+		l:        l,
+	}
+}
+
+func (svc *articleReviewService) Pending(ctx context.Context, offset, limit int) ([]domain.ArticleReview, error) {
+	return svc.repo.FindPending(ctx, offset, limit)
+}
+
+func (svc *articleReviewService) Approve(ctx context.Context, id int64, reviewer int64) error {
+	return svc.decide(ctx, id, reviewer, "",
+		domain.ArticleReviewStatusApproved, domain.ArticleStatusPublished)
+}
+
+func (svc *articleReviewService) Reject(ctx context.Context, id int64, reviewer int64, comment string) error {
+	return svc.decide(ctx, id, reviewer, comment,
+		domain.ArticleReviewStatusRejected, domain.ArticleStatusRejected)
+}
+
+func (svc *articleReviewService) decide(ctx context.Context, id int64, reviewer int64, comment string,
+	status domain.ArticleReviewStatus, artStatus domain.ArticleStatus) error {
+	r, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r.Status != domain.ArticleReviewStatusPending {
+		return ErrArticleReviewNotPending
+	}
+	// 先把任务占下来，两个审核员同时处理的时候只有一个能成功
+	err = svc.repo.Decide(ctx, id, status, reviewer, comment)
+	if err != nil {
+		return err
+	}
+	if status == domain.ArticleReviewStatusApproved {
+		err = svc.publish(ctx, r)
+	} else {
+		// 没通过的只改草稿的状态，之前发表的版本继续在线
+		err = svc.artRepo.UpdateStatus(ctx, r.AuthorId, r.Aid, artStatus)
+	}
+	if err != nil {
+		// 文章没有处理成功，把任务放回去让审核员重试
+		if er := svc.repo.Reopen(ctx, id, status); er != nil {
+			svc.l.Error("审核任务放回待审核失败",
+				logger.Int64("id", id), logger.Error(er))
+		}
+		return err
+	}
+	r.Status = status
+	r.Comment = comment
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		svc.notify(ctx, r)
+	}()
+	return nil
+}
+
+// publish 发表提交审核时候的内容，草稿现在的内容和版本号都不动
+func (svc *articleReviewService) publish(ctx context.Context, r domain.ArticleReview) error {
+	return svc.artRepo.SyncPub(ctx, renderArticle(domain.Article{
+		Id:      r.Aid,
+		Title:   r.Title,
+		Content: r.Content,
+		Format:  r.Format,
+		Author:  domain.Author{Id: r.AuthorId},
+		Status:  domain.ArticleStatusPublished,
+	}))
+}
+
+// notify 把审核结果发邮件告诉作者，没有邮箱的作者只能自己在文章列表里面看状态
+func (svc *articleReviewService) notify(ctx context.Context, r domain.ArticleReview) {
+	author, err := svc.userRepo.FindById(ctx, r.AuthorId)
+	if err != nil {
+		svc.l.Error("查询作者失败，无法通知审核结果",
+			logger.Int64("uid", r.AuthorId), logger.Error(err))
+		return
+	}
+	if author.Email == "" {
+		return
+	}
+	var subject, content string
+	if r.Status == domain.ArticleReviewStatusApproved {
+		subject = "你的文章已经通过审核"
+		content = fmt.Sprintf("你的文章《%s》已经通过审核，现在所有人都可以看到了。", html.EscapeString(r.Title))
+	} else {
+		subject = "你的文章没有通过审核"
+		content = fmt.Sprintf("你的文章《%s》没有通过审核，修改之后可以重新发表。", html.EscapeString(r.Title))
+		if r.Comment != "" {
+			content += "\n审核意见：" + html.EscapeString(r.Comment)
+		}
+	}
+	err = svc.emailSvc.Send(ctx, subject, content, author.Email)
+	if err != nil {
+		svc.l.Error("发送审核结果失败",
+			logger.Int64("uid", r.AuthorId),
+			logger.Int64("aid", r.Aid),
+			logger.Error(err))
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/internal/service/moderation"
+	modmocks "webook/internal/service/moderation/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestArticleService_Publish(t *testing.T) {
+	art := domain.Article{
+		Title:   "我的标题",
+		Content: "我的内容",
+		Author:  domain.Author{Id: 123},
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.ArticleRepository,
+			repository.ArticleReviewRepository, moderation.Checker)
+		// 不传就是 art
+		art domain.Article
+
+		wantId     int64
+		wantStatus domain.ArticleStatus
+		wantErr    error
+	}{
+		{
+			name: "机审通过",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository,
+				repository.ArticleReviewRepository, moderation.Checker) {
+				checker := modmocks.NewMockChecker(ctrl)
+				checker.EXPECT().Check(gomock.Any(), art).
+					Return(domain.ModerationResult{Decision: domain.ModerationApprove}, nil)
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				pub := art
+				pub.Status = domain.ArticleStatusPublished
+				repo.EXPECT().Sync(gomock.Any(), pub).Return(int64(1), nil)
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().CancelByArticle(gomock.Any(), int64(1)).Return(nil)
+				return repo, reviewRepo, checker
+			},
+			wantId:     1,
+			wantStatus: domain.ArticleStatusPublished,
+		},
+		{
+			name: "Markdown 发表的时候渲染",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository,
+				repository.ArticleReviewRepository, moderation.Checker) {
+				checker := modmocks.NewMockChecker(ctrl)
+				checker.EXPECT().Check(gomock.Any(), gomock.Any()).
+					Return(domain.ModerationResult{Decision: domain.ModerationApprove}, nil)
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().Sync(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, art domain.Article) (int64, error) {
+						assert.Equal(t, "<h1 id=\"标题\">标题</h1>\n<p><strong>内容</strong></p>\n", art.HTML)
+						assert.Equal(t, []domain.TOCItem{{Level: 1, Title: "标题", Anchor: "标题"}}, art.TOC)
+						return 1, nil
+					})
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().CancelByArticle(gomock.Any(), int64(1)).Return(nil)
+				return repo, reviewRepo, checker
+			},
+			art: domain.Article{
+				Title:   "我的标题",
+				Content: "# 标题\n\n**内容**",
+				Format:  domain.ArticleFormatMarkdown,
+				Author:  domain.Author{Id: 123},
+			},
+			wantId:     1,
+			wantStatus: domain.ArticleStatusPublished,
+		},
+		{
+			name: "转人工审核",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository,
+				repository.ArticleReviewRepository, moderation.Checker) {
+				checker := modmocks.NewMockChecker(ctrl)
+				checker.EXPECT().Check(gomock.Any(), art).Return(domain.ModerationResult{
+					Decision: domain.ModerationReview,
+					Reasons:  []string{"外链太多"},
+				}, nil)
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				pending := art
+				pending.Status = domain.ArticleStatusPendingReview
+				repo.EXPECT().Create(gomock.Any(), pending).Return(int64(1), nil)
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().CancelByArticle(gomock.Any(), int64(1)).Return(nil)
+				reviewRepo.EXPECT().Create(gomock.Any(), domain.ArticleReview{
+					Aid:      1,
+					AuthorId: 123,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Reasons:  []string{"外链太多"},
+				}).Return(int64(10), nil)
+				return repo, reviewRepo, checker
+			},
+			wantId:     1,
+			wantStatus: domain.ArticleStatusPendingReview,
+		},
+		{
+			name: "已经发表的文章转人工审核，线上版本不动",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository,
+				repository.ArticleReviewRepository, moderation.Checker) {
+				checker := modmocks.NewMockChecker(ctrl)
+				checker.EXPECT().Check(gomock.Any(), gomock.Any()).Return(domain.ModerationResult{
+					Decision: domain.ModerationReview,
+					Reasons:  []string{"外链太多"},
+				}, nil)
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				// 只更新制作库，不会调用 Sync
+				repo.EXPECT().Update(gomock.Any(), domain.Article{
+					Id:      1,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatusPendingReview,
+				}).Return(nil)
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().CancelByArticle(gomock.Any(), int64(1)).Return(nil)
+				reviewRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(int64(10), nil)
+				return repo, reviewRepo, checker
+			},
+			art: domain.Article{
+				Id:      1,
+				Title:   "我的标题",
+				Content: "我的内容",
+				Author:  domain.Author{Id: 123},
+			},
+			wantId:     1,
+			wantStatus: domain.ArticleStatusPendingReview,
+		},
+		{
+			name: "机审拒绝，保存成草稿",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleRepository,
+				repository.ArticleReviewRepository, moderation.Checker) {
+				checker := modmocks.NewMockChecker(ctrl)
+				checker.EXPECT().Check(gomock.Any(), art).Return(domain.ModerationResult{
+					Decision: domain.ModerationReject,
+					Reasons:  []string{"包含敏感词：赌博"},
+				}, nil)
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				draft := art
+				draft.Status = domain.ArticleStatusUnpublished
+				repo.EXPECT().Create(gomock.Any(), draft).Return(int64(1), nil)
+				return repo, repomocks.NewMockArticleReviewRepository(ctrl), checker
+			},
+			wantId:     1,
+			wantStatus: domain.ArticleStatusUnpublished,
+			wantErr:    ErrArticleRejected,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, reviewRepo, checker := tc.mock(ctrl)
+			userRepo := repomocks.NewMockUserRepository(ctrl)
+			userRepo.EXPECT().FindById(gomock.Any(), int64(123)).
+				Return(domain.User{Id: 123, Email: "123@qq.com", EmailVerified: true}, nil)
+			svc := NewArticleService(repo, userRepo, reviewRepo, nil, nil, checker, nil, logger.NewNopLogger())
+			if tc.art.Title == "" {
+				tc.art = art
+			}
+			id, status, err := svc.Publish(context.Background(), tc.art)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantId, id)
+			assert.Equal(t, tc.wantStatus, status)
+		})
+	}
+}
+
+func TestArticleReviewService_Reject(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.ArticleReviewRepository, repository.ArticleRepository)
+
+		wantErr error
+	}{
+		{
+			name: "驳回",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleReviewRepository, repository.ArticleRepository) {
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().FindById(gomock.Any(), int64(10)).Return(domain.ArticleReview{
+					Id:       10,
+					Aid:      1,
+					AuthorId: 123,
+					Status:   domain.ArticleReviewStatusPending,
+				}, nil)
+				reviewRepo.EXPECT().Decide(gomock.Any(), int64(10),
+					domain.ArticleReviewStatusRejected, int64(1), "广告").Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				// 之前发表的版本继续在线
+				artRepo.EXPECT().UpdateStatus(gomock.Any(), int64(123), int64(1),
+					domain.ArticleStatus(domain.ArticleStatusRejected)).Return(nil)
+				return reviewRepo, artRepo
+			},
+		},
+		{
+			name: "已经处理过了",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleReviewRepository, repository.ArticleRepository) {
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().FindById(gomock.Any(), int64(10)).Return(domain.ArticleReview{
+					Id:     10,
+					Status: domain.ArticleReviewStatusApproved,
+				}, nil)
+				return reviewRepo, repomocks.NewMockArticleRepository(ctrl)
+			},
+			wantErr: ErrArticleReviewNotPending,
+		},
+		{
+			name: "被别的审核员抢先了",
+			mock: func(ctrl *gomock.Controller) (repository.ArticleReviewRepository, repository.ArticleRepository) {
+				reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+				reviewRepo.EXPECT().FindById(gomock.Any(), int64(10)).Return(domain.ArticleReview{
+					Id:     10,
+					Status: domain.ArticleReviewStatusPending,
+				}, nil)
+				reviewRepo.EXPECT().Decide(gomock.Any(), int64(10),
+					domain.ArticleReviewStatusRejected, int64(1), "广告").
+					Return(repository.ErrArticleReviewNotPending)
+				return reviewRepo, repomocks.NewMockArticleRepository(ctrl)
+			},
+			wantErr: ErrArticleReviewNotPending,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			reviewRepo, artRepo := tc.mock(ctrl)
+			// 通知是异步的，作者没有邮箱就不发
+			userRepo := repomocks.NewMockUserRepository(ctrl)
+			userRepo.EXPECT().FindById(gomock.Any(), gomock.Any()).AnyTimes().
+				Return(domain.User{}, errors.New("mock error"))
+			svc := NewArticleReviewService(reviewRepo, artRepo, userRepo, nil, logger.NewNopLogger())
+			err := svc.Reject(context.Background(), 10, 1, "广告")
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestArticleReviewService_Approve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+	reviewRepo.EXPECT().FindById(gomock.Any(), int64(10)).Return(domain.ArticleReview{
+		Id:       10,
+		Aid:      1,
+		AuthorId: 123,
+		Title:    "提交的标题",
+		Content:  "# 提交的内容",
+		Format:   domain.ArticleFormatMarkdown,
+		Status:   domain.ArticleReviewStatusPending,
+	}, nil)
+	reviewRepo.EXPECT().Decide(gomock.Any(), int64(10),
+		domain.ArticleReviewStatusApproved, int64(1), "").Return(nil)
+	artRepo := repomocks.NewMockArticleRepository(ctrl)
+	// 作者提交之后可能又改过草稿，发表的是审核过的内容，草稿不动
+	artRepo.EXPECT().SyncPub(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, art domain.Article) error {
+			assert.Equal(t, int64(1), art.Id)
+			assert.Equal(t, int64(123), art.Author.Id)
+			assert.Equal(t, "提交的标题", art.Title)
+			assert.Equal(t, "# 提交的内容", art.Content)
+			assert.Equal(t, "<h1 id=\"提交的内容\">提交的内容</h1>\n", art.HTML)
+			assert.Equal(t, domain.ArticleStatus(domain.ArticleStatusPublished), art.Status)
+			return nil
+		})
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindById(gomock.Any(), gomock.Any()).AnyTimes().
+		Return(domain.User{}, errors.New("mock error"))
+	svc := NewArticleReviewService(reviewRepo, artRepo, userRepo, nil, logger.NewNopLogger())
+	err := svc.Approve(context.Background(), 10, 1)
+	assert.NoError(t, err)
+}
+
+func TestArticleReviewService_ApproveFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+	reviewRepo.EXPECT().FindById(gomock.Any(), int64(10)).Return(domain.ArticleReview{
+		Id:       10,
+		Aid:      1,
+		AuthorId: 123,
+		Status:   domain.ArticleReviewStatusPending,
+	}, nil)
+	reviewRepo.EXPECT().Decide(gomock.Any(), int64(10),
+		domain.ArticleReviewStatusApproved, int64(1), "").Return(nil)
+	// 没有发表成功，任务要放回去，不然谁都没法重试
+	reviewRepo.EXPECT().Reopen(gomock.Any(), int64(10),
+		domain.ArticleReviewStatusApproved).Return(nil)
+	artRepo := repomocks.NewMockArticleRepository(ctrl)
+	artRepo.EXPECT().SyncPub(gomock.Any(), gomock.Any()).Return(errors.New("mock db error"))
+	svc := NewArticleReviewService(reviewRepo, artRepo, nil, nil, logger.NewNopLogger())
+	err := svc.Approve(context.Background(), 10, 1)
+	assert.Equal(t, errors.New("mock db error"), err)
+}
+
+// recordEmailService 记下最后一封邮件的内容
+type recordEmailService struct {
+	content string
+}
+
+func (s *recordEmailService) Send(ctx context.Context, subject, content string, to ...string) error {
+	s.content = content
+	return nil
+}
+
+func TestArticleReviewService_notify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindById(gomock.Any(), int64(123)).
+		Return(domain.User{Id: 123, Email: "123@qq.com"}, nil)
+	emailSvc := &recordEmailService{}
+	svc := &articleReviewService{userRepo: userRepo, emailSvc: emailSvc, l: logger.NewNopLogger()}
+	// 标题和审核意见都是用户输入，不能当成 HTML
+	svc.notify(context.Background(), domain.ArticleReview{
+		AuthorId: 123,
+		Title:    "<script>alert(1)</script>",
+		Status:   domain.ArticleReviewStatusRejected,
+		Comment:  "<a href=\"https://evil.com\">点这里</a>",
+	})
+	assert.NotContains(t, emailSvc.content, "<script>")
+	assert.NotContains(t, emailSvc.content, "<a ")
+	assert.Contains(t, emailSvc.content, "&lt;script&gt;")
+}
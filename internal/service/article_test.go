@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/internal/repository/cache"
+	repomocks "webook/internal/repository/mock"
+	modmocks "webook/internal/service/moderation/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestArticleService_Autosave(t *testing.T) {
+	art := domain.Article{
+		Id:      1,
+		Title:   "标题",
+		Content: "内容",
+		Version: 3,
+		Author:  domain.Author{Id: 123},
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.ArticleRepository
+
+		wantErr error
+	}{
+		{
+			name: "第一次自动保存",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetAutosave(gomock.Any(), int64(1)).
+					Return(domain.Article{}, cache.ErrKeyNotExist)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).
+					Return(domain.Article{Id: 1, Version: 3, Author: domain.Author{Id: 123}}, nil)
+				repo.EXPECT().Autosave(gomock.Any(), art).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "第一次自动保存，别人的文章",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetAutosave(gomock.Any(), int64(1)).
+					Return(domain.Article{}, cache.ErrKeyNotExist)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).
+					Return(domain.Article{Id: 1, Version: 3, Author: domain.Author{Id: 456}}, nil)
+				return repo
+			},
+			wantErr: ErrArticleNotFound,
+		},
+		{
+			name: "继续自动保存",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetAutosave(gomock.Any(), int64(1)).
+					Return(domain.Article{Id: 1, Version: 3, Author: domain.Author{Id: 123}}, nil)
+				repo.EXPECT().Autosave(gomock.Any(), art).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "另外一个设备基于旧版本自动保存",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetAutosave(gomock.Any(), int64(1)).
+					Return(domain.Article{Id: 1, Version: 4, Author: domain.Author{Id: 123}}, nil)
+				return repo
+			},
+			wantErr: ErrArticleConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewArticleService(tc.mock(ctrl), nil, nil, nil, nil, nil, nil, logger.NewNopLogger())
+			err := svc.Autosave(context.Background(), art)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestArticleService_FlushAutosave(t *testing.T) {
+	before := time.UnixMilli(1700000000000)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.ArticleRepository
+
+		wantCnt int
+		wantErr error
+	}{
+		{
+			name: "冲突的跳过",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().AutosaveIds(gomock.Any(), before, 10).Return([]int64{1, 2, 3}, nil)
+				repo.EXPECT().FlushAutosave(gomock.Any(), int64(1)).Return(nil)
+				repo.EXPECT().FlushAutosave(gomock.Any(), int64(2)).Return(ErrArticleConflict)
+				repo.EXPECT().FlushAutosave(gomock.Any(), int64(3)).Return(nil)
+				return repo
+			},
+			wantCnt: 3,
+		},
+		{
+			name: "系统错误",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().AutosaveIds(gomock.Any(), before, 10).Return([]int64{1, 2}, nil)
+				repo.EXPECT().FlushAutosave(gomock.Any(), int64(1)).Return(errors.New("db 错误"))
+				return repo
+			},
+			wantErr: errors.New("db 错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewArticleService(tc.mock(ctrl), nil, nil, nil, nil, nil, nil, logger.NewNopLogger())
+			cnt, err := svc.FlushAutosave(context.Background(), before, 10)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCnt, cnt)
+		})
+	}
+}
+
+func TestArticleService_Restore(t *testing.T) {
+	dtime := time.Now().Add(-time.Hour)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.ArticleRepository
+		// 重新发表的时候机审的结果
+		decision domain.ModerationDecision
+
+		wantErr error
+	}{
+		{
+			name: "恢复草稿",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).Return(domain.Article{
+					Id:     1,
+					Author: domain.Author{Id: 123},
+					Status: domain.ArticleStatus(domain.ArticleStatusPendingReview),
+					Dtime:  dtime,
+				}, nil)
+				repo.EXPECT().Restore(gomock.Any(), int64(123), int64(1), gomock.Any(),
+					domain.ArticleStatus(domain.ArticleStatusUnpublished)).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "恢复之后重新发表",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				art := domain.Article{
+					Id:      1,
+					Content: "# 标题",
+					Format:  domain.ArticleFormatMarkdown,
+					Version: 2,
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatus(domain.ArticleStatusPublished),
+					Dtime:   dtime,
+				}
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).Return(art, nil)
+				repo.EXPECT().Restore(gomock.Any(), int64(123), int64(1), gomock.Any(),
+					domain.ArticleStatus(domain.ArticleStatusUnpublished)).Return(nil)
+				repo.EXPECT().Sync(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, a domain.Article) (int64, error) {
+						assert.Equal(t, domain.ArticleStatus(domain.ArticleStatusPublished), a.Status)
+						assert.Equal(t, int64(2), a.Version)
+						assert.NotEmpty(t, a.HTML)
+						return 1, nil
+					})
+				return repo
+			},
+		},
+		{
+			name: "恢复的时候转人工审核，不会直接上线",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				art := domain.Article{
+					Id:      1,
+					Content: "内容",
+					Version: 2,
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatus(domain.ArticleStatusPublished),
+					Dtime:   dtime,
+				}
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).Return(art, nil)
+				repo.EXPECT().Restore(gomock.Any(), int64(123), int64(1), gomock.Any(),
+					domain.ArticleStatus(domain.ArticleStatusUnpublished)).Return(nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, a domain.Article) error {
+						assert.Equal(t, domain.ArticleStatus(domain.ArticleStatusPendingReview), a.Status)
+						return nil
+					})
+				return repo
+			},
+			decision: domain.ModerationReview,
+		},
+		{
+			name: "恢复的时候机审拒绝",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				art := domain.Article{
+					Id:      1,
+					Content: "内容",
+					Version: 2,
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatus(domain.ArticleStatusPublished),
+					Dtime:   dtime,
+				}
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).Return(art, nil)
+				repo.EXPECT().Restore(gomock.Any(), int64(123), int64(1), gomock.Any(),
+					domain.ArticleStatus(domain.ArticleStatusUnpublished)).Return(nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				return repo
+			},
+			decision: domain.ModerationReject,
+			wantErr:  ErrArticleRejected,
+		},
+		{
+			name: "不在回收站里面",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).
+					Return(domain.Article{Id: 1, Author: domain.Author{Id: 123}}, nil)
+				return repo
+			},
+			wantErr: ErrArticleNotInTrash,
+		},
+		{
+			name: "过了恢复期限",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				repo := repomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(1)).Return(domain.Article{
+					Id:     1,
+					Author: domain.Author{Id: 123},
+					Dtime:  dtime.Add(-ArticleTrashRetention),
+				}, nil)
+				repo.EXPECT().Restore(gomock.Any(), int64(123), int64(1), gomock.Any(), gomock.Any()).
+					Return(ErrArticleNotFound)
+				return repo
+			},
+			wantErr: ErrArticleNotInTrash,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := repomocks.NewMockUserRepository(ctrl)
+			userRepo.EXPECT().FindById(gomock.Any(), int64(123)).AnyTimes().
+				Return(domain.User{Id: 123}, nil)
+			reviewRepo := repomocks.NewMockArticleReviewRepository(ctrl)
+			reviewRepo.EXPECT().CancelByArticle(gomock.Any(), int64(1)).AnyTimes().Return(nil)
+			reviewRepo.EXPECT().Create(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(10), nil)
+			checker := modmocks.NewMockChecker(ctrl)
+			checker.EXPECT().Check(gomock.Any(), gomock.Any()).AnyTimes().
+				Return(domain.ModerationResult{Decision: tc.decision}, nil)
+			svc := NewArticleService(tc.mock(ctrl), userRepo, reviewRepo, nil, nil, checker, nil, logger.NewNopLogger())
+			err := svc.Restore(context.Background(), 123, 1)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestArticleService_PurgeTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	before := time.UnixMilli(1700000000000)
+	repo := repomocks.NewMockArticleRepository(ctrl)
+	intrRepo := repomocks.NewMockInteractiveRepository(ctrl)
+	importRepo := repomocks.NewMockArticleImportRepository(ctrl)
+	// 点赞收藏和导入记录先删，文章最后删
+	gomock.InOrder(
+		repo.EXPECT().FindTrashedBefore(gomock.Any(), before, 10).Return([]int64{1, 2}, nil),
+		intrRepo.EXPECT().DeleteByBiz(gomock.Any(), "article", []int64{1, 2}).Return(nil),
+		importRepo.EXPECT().ReleaseArticles(gomock.Any(), []int64{1, 2}).Return(nil),
+		repo.EXPECT().DeleteTrashed(gomock.Any(), []int64{1, 2}).Return(nil),
+	)
+	svc := NewArticleService(repo, nil, nil, intrRepo, importRepo, nil, nil, logger.NewNopLogger())
+	cnt, err := svc.PurgeTrash(context.Background(), before, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cnt)
+}
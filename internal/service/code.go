@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/rand"
 	"webook/internal/repository"
+	"webook/internal/service/email"
 	"webook/internal/service/sms"
 )
 
@@ -15,16 +16,22 @@ type CodeService interface {
 	Send(ctx context.Context, biz, phone string) error
 	Verify(ctx context.Context,
 		biz, phone, inputCode string) (bool, error)
+	// SendByEmail 通过邮件发送验证码
+	SendByEmail(ctx context.Context, biz, email string) error
+	VerifyByEmail(ctx context.Context,
+		biz, email, inputCode string) (bool, error)
 }
 type codeService struct {
-	repo repository.CodeRepository
-	sms  sms.Service
+	repo  repository.CodeRepository
+	sms   sms.Service
+	email email.Service
 }
 
-func NewCodeService(repo repository.CodeRepository, smsSvc sms.Service) CodeService {
+func NewCodeService(repo repository.CodeRepository, smsSvc sms.Service, emailSvc email.Service) CodeService {
 	return &codeService{
-		repo: repo,
-		sms:  smsSvc,
+		repo:  repo,
+		sms:   smsSvc,
+		email: emailSvc,
 	}
 }
 
@@ -51,6 +58,21 @@ func (c *codeService) Verify(ctx context.Context, biz, phone, inputCode string)
 	return ok, nil
 }
 
+func (c *codeService) SendByEmail(ctx context.Context, biz, email string) error {
+	code := c.generate()
+	// 邮箱里面一定有 @，所以和手机号共用一套存储也不会冲突
+	err := c.repo.Set(ctx, biz, email, code)
+	if err != nil {
+		return err
+	}
+	content := fmt.Sprintf("你的验证码是 %s，10 分钟内有效，请勿泄露给他人。", code)
+	return c.email.Send(ctx, "webook 验证码", content, email)
+}
+
+func (c *codeService) VerifyByEmail(ctx context.Context, biz, email, inputCode string) (bool, error) {
+	return c.Verify(ctx, biz, email, inputCode)
+}
+
 func (c *codeService) generate() string {
 	// 0-999999
 	code := rand.Intn(1000000)
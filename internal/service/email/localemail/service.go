@@ -0,0 +1,18 @@
+package localemail
+
+import (
+	"context"
+	"log"
+)
+
+type Service struct {
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+func (s *Service) Send(ctx context.Context, subject, content string, to ...string) error {
+	log.Println("发送邮件", to, subject, content)
+	return nil
+}
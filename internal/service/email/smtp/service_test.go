@@ -0,0 +1,112 @@
+package smtp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer 一个极简的 SMTP 服务器，只实现发送一封邮件需要的命令
+type fakeServer struct {
+	ln   net.Listener
+	from string
+	to   []string
+	data string
+	auth string
+	done chan struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{ln: ln, done: make(chan struct{})}
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tc := textproto.NewConn(conn)
+	_ = tc.PrintfLine("220 localhost ESMTP fake")
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO":
+			_ = tc.PrintfLine("250-localhost")
+			_ = tc.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			_ = tc.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			s.from = line
+			_ = tc.PrintfLine("250 OK")
+		case "RCPT":
+			s.to = append(s.to, line)
+			_ = tc.PrintfLine("250 OK")
+		case "DATA":
+			_ = tc.PrintfLine("354 Go ahead")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			_ = tc.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tc.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tc.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func TestService_Send(t *testing.T) {
+	srv := newFakeServer(t)
+	defer srv.ln.Close()
+
+	host, port, err := net.SplitHostPort(srv.ln.Addr().String())
+	require.NoError(t, err)
+	p, err := net.LookupPort("tcp", port)
+	require.NoError(t, err)
+
+	svc := NewService(host, p, "webook", "123456", "noreply@webook.com")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	err = svc.Send(ctx, "验证你的邮箱", "<p>点击链接完成验证</p>", "123@qq.com", "456@qq.com")
+	require.NoError(t, err)
+	<-srv.done
+
+	assert.Equal(t, "MAIL FROM:<noreply@webook.com>", srv.from)
+	assert.Equal(t, []string{"RCPT TO:<123@qq.com>", "RCPT TO:<456@qq.com>"}, srv.to)
+	assert.True(t, strings.HasPrefix(srv.auth, "AUTH PLAIN"))
+
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(srv.data))).ReadMIMEHeader()
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(headers.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "验证你的邮箱", subject)
+	assert.Equal(t, "123@qq.com, 456@qq.com", headers.Get("To"))
+
+	body := srv.data[strings.Index(srv.data, "\n\n")+2:]
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "<p>点击链接完成验证</p>", string(content))
+}
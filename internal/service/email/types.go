@@ -0,0 +1,9 @@
+package email
+
+import "context"
+
+// Service 发送邮件的抽象
+// 和 sms.Service 一样，屏蔽不同发送渠道之间的区别
+type Service interface {
+	Send(ctx context.Context, subject, content string, to ...string) error
+}
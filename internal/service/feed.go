@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/pkg/logger"
+)
+
+const (
+	// feedSize 订阅源里面最新的这么多篇
+	feedSize = 20
+	// sitemapPageSize 一页 sitemap 的文章数量，协议规定最多 50000 个
+	sitemapPageSize = 1000
+)
+
+var ErrSitemapPageNotFound = errors.New("sitemap 页码超出范围")
+
+// FeedConfig 订阅源和 sitemap 里面的链接都是站点的页面，不是 API
+type FeedConfig struct {
+	// SiteURL 例如 https://meoying.com，文章是 SiteURL/articles/:id，
+	// 作者主页是 SiteURL/users/:id
+	SiteURL     string
+	Title       string
+	Description string
+}
+
+// FeedService 已经发表的文章的 RSS、Atom 订阅源和 sitemap，生成之后缓存起来
+type FeedService interface {
+	// Feed uid 是 0 的时候是全站的订阅源，作者不存在返回 ErrUserNotFound
+	Feed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error)
+	// Sitemap page 从 1 开始，0 是 sitemap 索引，超出范围返回 ErrSitemapPageNotFound
+	Sitemap(ctx context.Context, page int) ([]byte, error)
+}
+
+type feedService struct {
+	repo     repository.FeedRepository
+	artRepo  repository.ArticleRepository
+	userRepo repository.UserRepository
+	cfg      FeedConfig
+	l        logger.Logger
+}
+
+func NewFeedService(repo repository.FeedRepository, artRepo repository.ArticleRepository,
+	userRepo repository.UserRepository, cfg FeedConfig, l logger.Logger) FeedService {
+	return &feedService{
+		repo:     repo,
+		artRepo:  artRepo,
+		userRepo: userRepo,
+		cfg:      cfg,
+		l:        l,
+	}
+}
+
+func (svc *feedService) Feed(ctx context.Context, uid int64, format domain.FeedFormat) ([]byte, error) {
+	data, err := svc.repo.GetFeed(ctx, uid, format)
+	if err == nil {
+		return data, nil
+	}
+	var (
+		arts []domain.Article
+		ch   feedChannel
+	)
+	if uid == 0 {
+		arts, err = svc.artRepo.GetPubLatest(ctx, feedSize)
+		if err != nil {
+			return nil, err
+		}
+		ch = feedChannel{
+			Title:       svc.cfg.Title,
+			Link:        svc.cfg.SiteURL,
+			Description: svc.cfg.Description,
+			Author:      svc.cfg.Title,
+		}
+		svc.fillAuthors(ctx, arts)
+	} else {
+		u, er := svc.userRepo.FindById(ctx, uid)
+		if er != nil {
+			return nil, er
+		}
+		arts, err = svc.artRepo.GetPubByAuthor(ctx, uid, 0, feedSize)
+		if err != nil {
+			return nil, err
+		}
+		ch = feedChannel{
+			Title:       fmt.Sprintf("%s - %s", u.Nickname, svc.cfg.Title),
+			Link:        svc.authorURL(uid),
+			Description: u.AboutMe,
+			Author:      u.Nickname,
+		}
+		for i := range arts {
+			arts[i].Author.Name = u.Nickname
+		}
+	}
+
+	switch format {
+	case domain.FeedFormatRSS:
+		data, err = svc.rss(ch, arts)
+	case domain.FeedFormatAtom:
+		data, err = svc.atom(ch, svc.feedURL(uid, format), arts)
+	default:
+		return nil, fmt.Errorf("未知的订阅源格式 %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	er := svc.repo.SetFeed(ctx, uid, format, data)
+	if er != nil {
+		svc.l.Warn("缓存订阅源失败",
+			logger.Int64("uid", uid),
+			logger.String("format", string(format)),
+			logger.Error(er))
+	}
+	return data, nil
+}
+
+func (svc *feedService) Sitemap(ctx context.Context, page int) ([]byte, error) {
+	if page < 0 {
+		return nil, ErrSitemapPageNotFound
+	}
+	data, err := svc.repo.GetSitemap(ctx, page)
+	if err == nil {
+		return data, nil
+	}
+	cnt, err := svc.artRepo.CountPub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 没有文章的时候也有一页空的
+	pages := max(int((cnt+sitemapPageSize-1)/sitemapPageSize), 1)
+	if page > pages {
+		return nil, ErrSitemapPageNotFound
+	}
+	if page == 0 {
+		data, err = svc.sitemapIndex(pages)
+	} else {
+		data, err = svc.sitemapPage(ctx, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+	er := svc.repo.SetSitemap(ctx, page, data)
+	if er != nil {
+		svc.l.Warn("缓存 sitemap 失败",
+			logger.Int("page", page),
+			logger.Error(er))
+	}
+	return data, nil
+}
+
+func (svc *feedService) sitemapIndex(pages int) ([]byte, error) {
+	idx := sitemapIndex{Sitemaps: make([]sitemapURL, 0, pages)}
+	for i := 1; i <= pages; i++ {
+		idx.Sitemaps = append(idx.Sitemaps, sitemapURL{
+			Loc: svc.cfg.SiteURL + "/sitemap.xml?page=" + strconv.Itoa(i),
+		})
+	}
+	return marshalXML(idx)
+}
+
+func (svc *feedService) sitemapPage(ctx context.Context, page int) ([]byte, error) {
+	arts, err := svc.artRepo.ScanPub(ctx, (page-1)*sitemapPageSize, sitemapPageSize)
+	if err != nil {
+		return nil, err
+	}
+	set := sitemapURLSet{URLs: make([]sitemapURL, 0, len(arts))}
+	for _, art := range arts {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     svc.articleURL(art.Id),
+			Lastmod: art.Utime.Format(time.RFC3339),
+		})
+	}
+	return marshalXML(set)
+}
+
+// fillAuthors 全站的订阅源里面有不同作者的文章，查不到作者的时候不填
+func (svc *feedService) fillAuthors(ctx context.Context, arts []domain.Article) {
+	names := make(map[int64]string, len(arts))
+	for i := range arts {
+		uid := arts[i].Author.Id
+		name, ok := names[uid]
+		if !ok {
+			u, err := svc.userRepo.FindById(ctx, uid)
+			if err != nil {
+				svc.l.Warn("订阅源查询作者失败",
+					logger.Int64("uid", uid),
+					logger.Error(err))
+			}
+			name = u.Nickname
+			names[uid] = name
+		}
+		arts[i].Author.Name = name
+	}
+}
+
+func (svc *feedService) rss(ch feedChannel, arts []domain.Article) ([]byte, error) {
+	res := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       ch.Title,
+			Link:        ch.Link,
+			Description: ch.Description,
+			Items:       make([]rssItem, 0, len(arts)),
+		},
+	}
+	if len(arts) > 0 {
+		res.Channel.LastBuildDate = latestUtime(arts).Format(time.RFC1123Z)
+	}
+	for _, art := range arts {
+		link := svc.articleURL(art.Id)
+		res.Channel.Items = append(res.Channel.Items, rssItem{
+			Title:       art.Title,
+			Link:        link,
+			Description: art.Abstract(),
+			GUID:        rssGUID{IsPermaLink: "true", Value: link},
+			PubDate:     art.Utime.Format(time.RFC1123Z),
+		})
+	}
+	return marshalXML(res)
+}
+
+func (svc *feedService) atom(ch feedChannel, self string, arts []domain.Article) ([]byte, error) {
+	updated := time.Now()
+	if len(arts) > 0 {
+		updated = latestUtime(arts)
+	}
+	res := atomFeed{
+		Title: ch.Title,
+		Id:    self,
+		Links: []atomLink{
+			{Href: ch.Link},
+			{Href: self, Rel: "self"},
+		},
+		Updated:  updated.Format(time.RFC3339),
+		Subtitle: ch.Description,
+		// 每一篇都有作者的话会覆盖这里的
+		Author:  &atomPerson{Name: ch.Author},
+		Entries: make([]atomEntry, 0, len(arts)),
+	}
+	for _, art := range arts {
+		link := svc.articleURL(art.Id)
+		entry := atomEntry{
+			Title:   art.Title,
+			Id:      link,
+			Link:    atomLink{Href: link},
+			Updated: art.Utime.Format(time.RFC3339),
+			Summary: atomText{Body: art.Abstract()},
+		}
+		if art.Author.Name != "" {
+			entry.Author = &atomPerson{Name: art.Author.Name}
+		}
+		if art.HTML != "" {
+			entry.Content = &atomText{Type: "html", Body: art.HTML}
+		}
+		res.Entries = append(res.Entries, entry)
+	}
+	return marshalXML(res)
+}
+
+func (svc *feedService) articleURL(id int64) string {
+	return fmt.Sprintf("%s/articles/%d", svc.cfg.SiteURL, id)
+}
+
+func (svc *feedService) authorURL(uid int64) string {
+	return fmt.Sprintf("%s/users/%d", svc.cfg.SiteURL, uid)
+}
+
+func (svc *feedService) feedURL(uid int64, format domain.FeedFormat) string {
+	if uid == 0 {
+		return fmt.Sprintf("%s/%s.xml", svc.cfg.SiteURL, format)
+	}
+	return fmt.Sprintf("%s/%s.xml", svc.authorURL(uid), format)
+}
+
+func latestUtime(arts []domain.Article) time.Time {
+	var res time.Time
+	for _, art := range arts {
+		if art.Utime.After(res) {
+			res = art.Utime
+		}
+	}
+	return res
+}
+
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// feedChannel RSS 和 Atom 共用的订阅源信息
+type feedChannel struct {
+	Title       string
+	Link        string
+	Description string
+	Author      string
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Id       string      `xml:"id"`
+	Links    []atomLink  `xml:"link"`
+	Updated  string      `xml:"updated"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  *atomPerson `xml:"author,omitempty"`
+	Summary atomText    `xml:"summary"`
+	Content *atomText   `xml:"content,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod,omitempty"`
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testFeedConfig = FeedConfig{
+	SiteURL: "https://meoying.com",
+	Title:   "webook",
+}
+
+func TestFeedService_Feed(t *testing.T) {
+	utime := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository, repository.UserRepository)
+		uid    int64
+		format domain.FeedFormat
+
+		wantContains []string
+		wantErr      error
+	}{
+		{
+			name: "命中缓存",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository, repository.UserRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetFeed(gomock.Any(), int64(0), domain.FeedFormat(domain.FeedFormatRSS)).
+					Return([]byte("缓存的订阅源"), nil)
+				return repo, repomocks.NewMockArticleRepository(ctrl), repomocks.NewMockUserRepository(ctrl)
+			},
+			format:       domain.FeedFormatRSS,
+			wantContains: []string{"缓存的订阅源"},
+		},
+		{
+			name: "全站的 RSS",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository, repository.UserRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetFeed(gomock.Any(), int64(0), domain.FeedFormat(domain.FeedFormatRSS)).
+					Return(nil, repository.ErrFeedNotCached)
+				repo.EXPECT().SetFeed(gomock.Any(), int64(0), domain.FeedFormat(domain.FeedFormatRSS), gomock.Any()).
+					Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubLatest(gomock.Any(), feedSize).Return([]domain.Article{
+					{Id: 2, Title: "第二篇", Content: "内容 <b>2</b>", Author: domain.Author{Id: 123}, Utime: utime},
+					{Id: 1, Title: "第一篇", Content: "内容 1", Author: domain.Author{Id: 123}, Utime: utime.Add(-time.Hour)},
+				}, nil)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				// 同一个作者只查一次
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(domain.User{Id: 123, Nickname: "大明"}, nil)
+				return repo, artRepo, userRepo
+			},
+			format: domain.FeedFormatRSS,
+			wantContains: []string{
+				`<rss version="2.0">`,
+				"<title>第二篇</title>",
+				"<link>https://meoying.com/articles/2</link>",
+				"<description>内容 &lt;b&gt;2&lt;/b&gt;</description>",
+				"<lastBuildDate>Wed, 01 May 2024 08:00:00 +0000</lastBuildDate>",
+				"<pubDate>Wed, 01 May 2024 07:00:00 +0000</pubDate>",
+			},
+		},
+		{
+			name: "作者的 Atom",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository, repository.UserRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetFeed(gomock.Any(), int64(123), domain.FeedFormat(domain.FeedFormatAtom)).
+					Return(nil, repository.ErrFeedNotCached)
+				repo.EXPECT().SetFeed(gomock.Any(), int64(123), domain.FeedFormat(domain.FeedFormatAtom), gomock.Any()).
+					Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubByAuthor(gomock.Any(), int64(123), 0, feedSize).Return([]domain.Article{
+					{Id: 2, Title: "第二篇", Content: "内容", HTML: "<p>内容</p>", Author: domain.Author{Id: 123}, Utime: utime},
+				}, nil)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(domain.User{Id: 123, Nickname: "大明"}, nil)
+				return repo, artRepo, userRepo
+			},
+			uid:    123,
+			format: domain.FeedFormatAtom,
+			wantContains: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom">`,
+				"<title>大明 - webook</title>",
+				`<link href="https://meoying.com/users/123/atom.xml" rel="self"></link>`,
+				"<updated>2024-05-01T08:00:00Z</updated>",
+				"<author><name>大明</name></author>",
+				`<content type="html">&lt;p&gt;内容&lt;/p&gt;</content>`,
+			},
+		},
+		{
+			name: "作者不存在",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository, repository.UserRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetFeed(gomock.Any(), int64(123), domain.FeedFormat(domain.FeedFormatRSS)).
+					Return(nil, repository.ErrFeedNotCached)
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(domain.User{}, repository.ErrUserNotFound)
+				return repo, repomocks.NewMockArticleRepository(ctrl), userRepo
+			},
+			uid:     123,
+			format:  domain.FeedFormatRSS,
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, artRepo, userRepo := tc.mock(ctrl)
+			svc := NewFeedService(repo, artRepo, userRepo, testFeedConfig, logger.NewNopLogger())
+			data, err := svc.Feed(context.Background(), tc.uid, tc.format)
+			assert.ErrorIs(t, err, tc.wantErr)
+			for _, s := range tc.wantContains {
+				assert.Contains(t, string(data), s)
+			}
+		})
+	}
+}
+
+func TestFeedService_Sitemap(t *testing.T) {
+	utime := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository)
+		page int
+
+		wantContains []string
+		wantErr      error
+	}{
+		{
+			name: "索引",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetSitemap(gomock.Any(), 0).Return(nil, repository.ErrFeedNotCached)
+				repo.EXPECT().SetSitemap(gomock.Any(), 0, gomock.Any()).Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().CountPub(gomock.Any()).Return(int64(sitemapPageSize+1), nil)
+				return repo, artRepo
+			},
+			wantContains: []string{
+				"<loc>https://meoying.com/sitemap.xml?page=1</loc>",
+				"<loc>https://meoying.com/sitemap.xml?page=2</loc>",
+			},
+		},
+		{
+			name: "第二页",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetSitemap(gomock.Any(), 2).Return(nil, repository.ErrFeedNotCached)
+				repo.EXPECT().SetSitemap(gomock.Any(), 2, gomock.Any()).Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().CountPub(gomock.Any()).Return(int64(sitemapPageSize+1), nil)
+				artRepo.EXPECT().ScanPub(gomock.Any(), sitemapPageSize, sitemapPageSize).
+					Return([]domain.Article{{Id: 9, Utime: utime}}, nil)
+				return repo, artRepo
+			},
+			page: 2,
+			wantContains: []string{
+				`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+				"<url><loc>https://meoying.com/articles/9</loc><lastmod>2024-05-01T08:00:00Z</lastmod></url>",
+			},
+		},
+		{
+			name: "超出范围",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				repo.EXPECT().GetSitemap(gomock.Any(), 3).Return(nil, repository.ErrFeedNotCached)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().CountPub(gomock.Any()).Return(int64(sitemapPageSize+1), nil)
+				return repo, artRepo
+			},
+			page:    3,
+			wantErr: ErrSitemapPageNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, artRepo := tc.mock(ctrl)
+			svc := NewFeedService(repo, artRepo, nil, testFeedConfig, logger.NewNopLogger())
+			data, err := svc.Sitemap(context.Background(), tc.page)
+			assert.ErrorIs(t, err, tc.wantErr)
+			for _, s := range tc.wantContains {
+				assert.Contains(t, string(data), s)
+			}
+		})
+	}
+}
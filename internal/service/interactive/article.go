@@ -0,0 +1,38 @@
+package interactive
+
+import (
+	"context"
+	"errors"
+	"webook/internal/domain"
+	"webook/internal/repository"
+)
+
+// ArticleBiz 已经发表的文章，仅自己可见的只有作者能互动
+type ArticleBiz struct {
+	repo repository.ArticleRepository
+}
+
+func NewArticleBiz(repo repository.ArticleRepository) *ArticleBiz {
+	return &ArticleBiz{
+		repo: repo,
+	}
+}
+
+func (b *ArticleBiz) Name() string {
+	return domain.BizArticle
+}
+
+func (b *ArticleBiz) Owner(ctx context.Context, uid, id int64) (int64, error) {
+	art, err := b.repo.GetPubById(ctx, id)
+	if errors.Is(err, repository.ErrArticleNotFound) {
+		return 0, ErrTargetNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if art.Id == 0 ||
+		art.Status != domain.ArticleStatusPublished && art.Author.Id != uid {
+		return 0, ErrTargetNotFound
+	}
+	return art.Author.Id, nil
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./types.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./types.go -destination=./mock/types.mock.go -package=interactivemocks
+//
+
+// Package interactivemocks is a generated GoMock package.
+package interactivemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBiz is a mock of Biz interface.
+type MockBiz struct {
+	ctrl     *gomock.Controller
+	recorder *MockBizMockRecorder
+}
+
+// MockBizMockRecorder is the mock recorder for MockBiz.
+type MockBizMockRecorder struct {
+	mock *MockBiz
+}
+
+// NewMockBiz creates a new mock instance.
+func NewMockBiz(ctrl *gomock.Controller) *MockBiz {
+	mock := &MockBiz{ctrl: ctrl}
+	mock.recorder = &MockBizMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBiz) EXPECT() *MockBizMockRecorder {
+	return m.recorder
+}
+
+// Name mocks base method.
+func (m *MockBiz) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockBizMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockBiz)(nil).Name))
+}
+
+// Owner mocks base method.
+func (m *MockBiz) Owner(ctx context.Context, uid, id int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Owner", ctx, uid, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Owner indicates an expected call of Owner.
+func (mr *MockBizMockRecorder) Owner(ctx, uid, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Owner", reflect.TypeOf((*MockBiz)(nil).Owner), ctx, uid, id)
+}
@@ -0,0 +1,34 @@
+package interactive
+
+import (
+	"context"
+	"errors"
+	"webook/internal/domain"
+	"webook/internal/repository"
+)
+
+// SeriesBiz 系列，所有人都能看到
+type SeriesBiz struct {
+	repo repository.SeriesRepository
+}
+
+func NewSeriesBiz(repo repository.SeriesRepository) *SeriesBiz {
+	return &SeriesBiz{
+		repo: repo,
+	}
+}
+
+func (b *SeriesBiz) Name() string {
+	return domain.BizSeries
+}
+
+func (b *SeriesBiz) Owner(ctx context.Context, uid, id int64) (int64, error) {
+	s, err := b.repo.FindById(ctx, id)
+	if errors.Is(err, repository.ErrSeriesNotFound) {
+		return 0, ErrTargetNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return s.AuthorId, nil
+}
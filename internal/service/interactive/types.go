@@ -0,0 +1,43 @@
+package interactive
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTargetNotFound 资源不存在，或者当前用户看不到
+var ErrTargetNotFound = errors.New("资源不存在")
+
+// Biz 一种可以点赞、收藏、阅读的资源，每种资源一个实现
+type Biz interface {
+	// Name 资源类型，对应互动数据里面的 biz 和 /interactive/:biz 里面的 biz
+	Name() string
+	// Owner 资源存在而且 uid 能看到的时候返回资源的所有者，
+	// 否则返回 ErrTargetNotFound
+	Owner(ctx context.Context, uid, id int64) (int64, error)
+}
+
+// Registry 按照名字找到对应的资源类型，没有注册的资源不能互动
+type Registry struct {
+	bizs map[string]Biz
+}
+
+func NewRegistry(bizs ...Biz) *Registry {
+	r := &Registry{
+		bizs: make(map[string]Biz, len(bizs)),
+	}
+	for _, biz := range bizs {
+		r.Register(biz)
+	}
+	return r
+}
+
+// Register 同名的资源会被覆盖，只在启动的时候调用，所以没有加锁
+func (r *Registry) Register(biz Biz) {
+	r.bizs[biz.Name()] = biz
+}
+
+func (r *Registry) Get(name string) (Biz, bool) {
+	biz, ok := r.bizs[name]
+	return biz, ok
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/internal/service/interactive"
+	interactivemocks "webook/internal/service/interactive/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInteractiveService_Collect(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+			interactive.Biz, repository.PrivacyRepository)
+		biz string
+
+		wantErr error
+	}{
+		{
+			name: "收藏成功",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).Return(int64(456), nil)
+				privacyRepo := repomocks.NewMockPrivacyRepository(ctrl)
+				privacyRepo.EXPECT().IsBlocked(gomock.Any(), int64(123), int64(456)).Return(false, nil)
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().AddCollectionItem(gomock.Any(), "series", int64(1), int64(2), int64(123)).
+					Return(nil)
+				return repo, biz, privacyRepo
+			},
+			biz: "series",
+		},
+		{
+			name: "没有注册的资源",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				return repomocks.NewMockInteractiveRepository(ctrl), biz,
+					repomocks.NewMockPrivacyRepository(ctrl)
+			},
+			biz:     "video",
+			wantErr: ErrUnknownBiz,
+		},
+		{
+			name: "资源不存在",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).
+					Return(int64(0), interactive.ErrTargetNotFound)
+				return repomocks.NewMockInteractiveRepository(ctrl), biz,
+					repomocks.NewMockPrivacyRepository(ctrl)
+			},
+			biz:     "series",
+			wantErr: ErrInteractiveTargetNotFound,
+		},
+		{
+			name: "被拉黑了",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).Return(int64(456), nil)
+				privacyRepo := repomocks.NewMockPrivacyRepository(ctrl)
+				privacyRepo.EXPECT().IsBlocked(gomock.Any(), int64(123), int64(456)).Return(true, nil)
+				return repomocks.NewMockInteractiveRepository(ctrl), biz, privacyRepo
+			},
+			biz:     "series",
+			wantErr: ErrInteractiveTargetNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, biz, privacyRepo := tc.mock(ctrl)
+			svc := NewInteractiveService(repo, nil, interactive.NewRegistry(biz),
+				NewPrivacyService(privacyRepo, repomocks.NewMockUserRepository(ctrl)),
+				logger.NewNopLogger())
+			err := svc.Collect(context.Background(), tc.biz, 1, 2, 123)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestInteractiveService_Get(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+			interactive.Biz, repository.PrivacyRepository)
+
+		wantIntr domain.Interactive
+		wantErr  error
+	}{
+		{
+			name: "查询成功",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).Return(int64(456), nil)
+				privacyRepo := repomocks.NewMockPrivacyRepository(ctrl)
+				privacyRepo.EXPECT().IsBlocked(gomock.Any(), int64(123), int64(456)).Return(false, nil)
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().Get(gomock.Any(), "series", int64(1)).
+					Return(domain.Interactive{ReadCnt: 3, LikeCnt: 2}, nil)
+				repo.EXPECT().Liked(gomock.Any(), "series", int64(1), int64(123)).Return(true, nil)
+				repo.EXPECT().Collected(gomock.Any(), "series", int64(1), int64(123)).Return(false, nil)
+				return repo, biz, privacyRepo
+			},
+			wantIntr: domain.Interactive{ReadCnt: 3, LikeCnt: 2, Liked: true},
+		},
+		{
+			name: "看不到的资源不返回计数",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).
+					Return(int64(0), interactive.ErrTargetNotFound)
+				return repomocks.NewMockInteractiveRepository(ctrl), biz,
+					repomocks.NewMockPrivacyRepository(ctrl)
+			},
+			wantErr: ErrInteractiveTargetNotFound,
+		},
+		{
+			name: "被拉黑了",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				interactive.Biz, repository.PrivacyRepository) {
+				biz := interactivemocks.NewMockBiz(ctrl)
+				biz.EXPECT().Name().Return("series").AnyTimes()
+				biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).Return(int64(456), nil)
+				privacyRepo := repomocks.NewMockPrivacyRepository(ctrl)
+				privacyRepo.EXPECT().IsBlocked(gomock.Any(), int64(123), int64(456)).Return(true, nil)
+				return repomocks.NewMockInteractiveRepository(ctrl), biz, privacyRepo
+			},
+			wantErr: ErrInteractiveTargetNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, biz, privacyRepo := tc.mock(ctrl)
+			svc := NewInteractiveService(repo, nil, interactive.NewRegistry(biz),
+				NewPrivacyService(privacyRepo, repomocks.NewMockUserRepository(ctrl)),
+				logger.NewNopLogger())
+			intr, err := svc.Get(context.Background(), "series", 1, 123)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantIntr, intr)
+		})
+	}
+}
+
+func TestInteractiveService_Read(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	biz := interactivemocks.NewMockBiz(ctrl)
+	biz.EXPECT().Name().Return("series").AnyTimes()
+	biz.EXPECT().Owner(gomock.Any(), int64(123), int64(1)).Return(int64(456), nil)
+	privacyRepo := repomocks.NewMockPrivacyRepository(ctrl)
+	privacyRepo.EXPECT().IsBlocked(gomock.Any(), int64(123), int64(456)).Return(false, nil)
+	repo := repomocks.NewMockInteractiveRepository(ctrl)
+	// 刚刚读过，不发消息，producer 是 nil 调用了就会 panic
+	repo.EXPECT().MarkRead(gomock.Any(), "series", int64(1), int64(123)).Return(false, nil)
+	svc := NewInteractiveService(repo, nil, interactive.NewRegistry(biz),
+		NewPrivacyService(privacyRepo, repomocks.NewMockUserRepository(ctrl)),
+		logger.NewNopLogger())
+	err := svc.Read(context.Background(), "series", 1, 123)
+	assert.NoError(t, err)
+}
@@ -2,28 +2,56 @@ package service
 
 import (
 	"context"
+	"errors"
 	"golang.org/x/sync/errgroup"
+	"time"
 	"webook/internal/domain"
 	"webook/internal/events/article"
 	"webook/internal/repository"
+	"webook/internal/service/interactive"
 	"webook/pkg/logger"
 )
 
+var (
+	// ErrUnknownBiz 没有注册的资源类型不能互动
+	ErrUnknownBiz = errors.New("不支持的资源类型")
+	// ErrInteractiveTargetNotFound 资源不存在、看不到，或者和所有者之间有拉黑关系
+	ErrInteractiveTargetNotFound = interactive.ErrTargetNotFound
+)
+
+// InteractiveService 点赞、收藏、阅读数。biz 要先在 interactive.Registry 里面注册，
+// 点赞、收藏和阅读之前会检查资源是不是存在
 type InteractiveService interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
+	// Read 记一次阅读，异步更新阅读数。同一个用户一段时间之内重复阅读只算一次
+	Read(ctx context.Context, biz string, id int64, uid int64) error
 	Like(c context.Context, biz string, id int64, uid int64) error
+	// CancelLike 资源已经删除了也可以取消
 	CancelLike(c context.Context, biz string, id int64, uid int64) error
 	Collect(ctx context.Context, biz string, bizId, cid, uid int64) error
+	// Get 资源不存在或者 uid 看不到的时候返回 ErrInteractiveTargetNotFound
 	Get(ctx context.Context, biz string, id int64, uid int64) (domain.Interactive, error)
+	// GetByIds 列表页一次查多个资源，uid 是 0 的时候 Liked 和 Collected 都是 false
+	GetByIds(ctx context.Context, biz string, ids []int64, uid int64) (map[int64]domain.Interactive, error)
+	// ListLikes uid 点赞过的资源，调用方要先用 PrivacyService 检查能不能看
+	ListLikes(ctx context.Context, uid int64, offset, limit int) ([]domain.LikeItem, error)
+	// ListCollections uid 的收藏，调用方要先用 PrivacyService 检查能不能看
+	ListCollections(ctx context.Context, uid int64, offset, limit int) ([]domain.CollectionItem, error)
 }
 
 type interactiveService struct {
-	repo     repository.InteractiveRepository
-	producer article.Producer
-	l        logger.Logger
+	repo       repository.InteractiveRepository
+	producer   article.Producer
+	registry   *interactive.Registry
+	privacySvc PrivacyService
+	l          logger.Logger
 }
 
 func (i *interactiveService) Get(ctx context.Context, biz string, id int64, uid int64) (domain.Interactive, error) {
+	err := i.checkTarget(ctx, biz, id, uid, domain.PrivacyActionView)
+	if err != nil {
+		return domain.Interactive{}, err
+	}
 	intr, err := i.repo.Get(ctx, biz, id)
 	if err != nil {
 		return domain.Interactive{}, err
@@ -48,21 +76,109 @@ func (i *interactiveService) Get(ctx context.Context, biz string, id int64, uid
 	return intr, eg.Wait()
 }
 
+func (i *interactiveService) GetByIds(ctx context.Context, biz string, ids []int64, uid int64) (map[int64]domain.Interactive, error) {
+	if _, ok := i.registry.Get(biz); !ok {
+		return nil, ErrUnknownBiz
+	}
+	var (
+		eg        errgroup.Group
+		intrs     map[int64]domain.Interactive
+		liked     map[int64]bool
+		collected map[int64]bool
+	)
+	eg.Go(func() error {
+		var er error
+		intrs, er = i.repo.GetByIds(ctx, biz, ids)
+		return er
+	})
+	// 没登录的用户不用查
+	if uid > 0 {
+		eg.Go(func() error {
+			var er error
+			liked, er = i.repo.BatchLiked(ctx, biz, ids, uid)
+			return er
+		})
+		eg.Go(func() error {
+			var er error
+			collected, er = i.repo.BatchCollected(ctx, biz, ids, uid)
+			return er
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	for id, intr := range intrs {
+		intr.Liked = liked[id]
+		intr.Collected = collected[id]
+		intrs[id] = intr
+	}
+	return intrs, nil
+}
+
+func (i *interactiveService) ListLikes(ctx context.Context, uid int64, offset, limit int) ([]domain.LikeItem, error) {
+	return i.repo.ListLikes(ctx, uid, offset, limit)
+}
+
+func (i *interactiveService) ListCollections(ctx context.Context, uid int64, offset, limit int) ([]domain.CollectionItem, error) {
+	return i.repo.ListCollections(ctx, uid, offset, limit)
+}
+
+func (i *interactiveService) Read(ctx context.Context, biz string, id int64, uid int64) error {
+	err := i.checkTarget(ctx, biz, id, uid, domain.PrivacyActionView)
+	if err != nil {
+		return err
+	}
+	ok, err := i.repo.MarkRead(ctx, biz, id, uid)
+	if err != nil {
+		// Redis 出问题的时候照样计数，不影响阅读
+		i.l.Error("记录阅读去重失败",
+			logger.String("biz", biz),
+			logger.Int64("bizId", id),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+		ok = true
+	}
+	if !ok {
+		// 刚刚读过，不重复计数
+		return nil
+	}
+	evt := article.ReadEvent{
+		Biz:   biz,
+		BizId: id,
+		Uid:   uid,
+		Time:  time.Now().UnixMilli(),
+	}
+	if biz == domain.BizArticle {
+		// 还没升级的消费者只认识 Aid
+		evt.Aid = id
+	}
+	return i.producer.ProducerReadEvent(evt)
+}
+
 func (i *interactiveService) Like(c context.Context, biz string, id int64, uid int64) error {
+	err := i.checkTarget(c, biz, id, uid, domain.PrivacyActionInteract)
+	if err != nil {
+		return err
+	}
 	//err := i.repo.IncrLike(c, biz, id, uid)
 	go func() {
 		// 发送消息
 
 		evt := article.LikeEvent{
-			Aid: id,
-			Uid: uid,
+			Biz:   biz,
+			BizId: id,
+			Uid:   uid,
+		}
+		if biz == domain.BizArticle {
+			evt.Aid = id
 		}
 
 		err := i.producer.ProducerLikeEvent(evt)
 
 		if err != nil {
 			i.l.Error("发送 LikeEvent 失败",
-				logger.Int64("aid", id),
+				logger.String("biz", biz),
+				logger.Int64("bizId", id),
 				logger.Int64("uid", uid),
 				logger.Error(err))
 		}
@@ -73,6 +189,9 @@ func (i *interactiveService) Like(c context.Context, biz string, id int64, uid i
 }
 
 func (i *interactiveService) CancelLike(c context.Context, biz string, id int64, uid int64) error {
+	if _, ok := i.registry.Get(biz); !ok {
+		return ErrUnknownBiz
+	}
 	// 同步发送
 	//err := i.repo.DecrLike(c, biz, id, uid)
 	// 异步消息队列发送
@@ -80,15 +199,20 @@ func (i *interactiveService) CancelLike(c context.Context, biz string, id int64,
 		// 发送消息
 
 		evt := article.UnLikeEvent{
-			Aid: id,
-			Uid: uid,
+			Biz:   biz,
+			BizId: id,
+			Uid:   uid,
+		}
+		if biz == domain.BizArticle {
+			evt.Aid = id
 		}
 
 		err := i.producer.ProducerUnLikeEvent(evt)
 
 		if err != nil {
 			i.l.Error("发送 UnLikeEvent 失败",
-				logger.Int64("aid", id),
+				logger.String("biz", biz),
+				logger.Int64("bizId", id),
 				logger.Int64("uid", uid),
 				logger.Error(err))
 		}
@@ -103,9 +227,40 @@ func (i *interactiveService) IncrReadCnt(ctx context.Context, biz string, bizId
 }
 
 func (i *interactiveService) Collect(ctx context.Context, biz string, bizId, cid, uid int64) error {
+	err := i.checkTarget(ctx, biz, bizId, uid, domain.PrivacyActionInteract)
+	if err != nil {
+		return err
+	}
 	return i.repo.AddCollectionItem(ctx, biz, bizId, cid, uid)
 }
 
-func NewInteractiveService(repo repository.InteractiveRepository, producer article.Producer, l logger.Logger) InteractiveService {
-	return &interactiveService{repo: repo, producer: producer, l: l}
+// checkTarget 资源要存在而且 uid 能看到，和所有者之间有拉黑关系的也当做不存在
+func (i *interactiveService) checkTarget(ctx context.Context, biz string, id, uid int64, action domain.PrivacyAction) error {
+	b, ok := i.registry.Get(biz)
+	if !ok {
+		return ErrUnknownBiz
+	}
+	owner, err := b.Owner(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	allowed, err := i.privacySvc.Allow(ctx, uid, owner, action)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrInteractiveTargetNotFound
+	}
+	return nil
+}
+
+func NewInteractiveService(repo repository.InteractiveRepository, producer article.Producer,
+	registry *interactive.Registry, privacySvc PrivacyService, l logger.Logger) InteractiveService {
+	return &interactiveService{
+		repo:       repo,
+		producer:   producer,
+		registry:   registry,
+		privacySvc: privacySvc,
+		l:          l,
+	}
 }
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/pkg/logger"
+)
+
+// 异常登录二次验证用的验证码
+const bizLoginStepUp = "login_step_up"
+
+var ErrInvalidStepUpCode = errors.New("二次验证的验证码不对")
+
+// 用户既没有手机号也没有验证过的邮箱，没办法发验证码
+var errNoStepUpChannel = errors.New("没有可以接收验证码的手机或者邮箱")
+
+// LoginAuditService 登录记录和风险控制
+type LoginAuditService interface {
+	// RecordFailure 记录一次凭证不对的登录
+	RecordFailure(ctx context.Context, l domain.LoginLog)
+	// Assess 凭证校验通过之后评估风险，同时记录这一次登录。
+	// 需要二次验证的时候，验证码会发送到用户绑定的手机或者邮箱
+	Assess(ctx context.Context, u domain.User, l domain.LoginLog) (domain.LoginRisk, error)
+	// VerifyStepUp 校验二次验证的验证码，通过之后记录一次成功的登录
+	VerifyStepUp(ctx context.Context, l domain.LoginLog, code string) (domain.User, error)
+	History(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error)
+}
+
+type loginAuditService struct {
+	repo     repository.LoginLogRepository
+	userRepo repository.UserRepository
+	codeSvc  CodeService
+	l        logger.Logger
+
+	// 统计失败次数的时间窗口，锁定也是在这个窗口之后自动解除
+	failureWindow time.Duration
+	// 窗口内失败这么多次之后要二次验证
+	stepUpFailures int64
+	// 窗口内失败这么多次之后暂时锁定
+	lockFailures int64
+	// 和最近多少次成功的登录比较设备和网段
+	recentLogins int
+}
+
+func NewLoginAuditService(repo repository.LoginLogRepository,
+	userRepo repository.UserRepository,
+	codeSvc CodeService, l logger.Logger) LoginAuditService {
+	return &loginAuditService{
+		repo:           repo,
+		userRepo:       userRepo,
+		codeSvc:        codeSvc,
+		l:              l,
+		failureWindow:  time.Minute * 15,
+		stepUpFailures: 3,
+		lockFailures:   10,
+		recentLogins:   20,
+	}
+}
+
+func (svc *loginAuditService) RecordFailure(ctx context.Context, l domain.LoginLog) {
+	l.Result = domain.LoginResultFailed
+	svc.record(ctx, l)
+}
+
+func (svc *loginAuditService) Assess(ctx context.Context, u domain.User, l domain.LoginLog) (domain.LoginRisk, error) {
+	l.Uid = u.Id
+	risk, err := svc.assess(ctx, u, l)
+	if err != nil {
+		return domain.LoginRiskNone, err
+	}
+	switch risk {
+	case domain.LoginRiskLocked:
+		l.Result = domain.LoginResultLocked
+	case domain.LoginRiskStepUp:
+		err = svc.sendStepUpCode(ctx, u)
+		switch {
+		case err == nil:
+			l.Result = domain.LoginResultStepUp
+		case errors.Is(err, errNoStepUpChannel):
+			// 只有第三方登录的用户，第三方那边已经验证过了，只能放行
+			risk = domain.LoginRiskNone
+			l.Result = domain.LoginResultSuccess
+		default:
+			return domain.LoginRiskNone, err
+		}
+	default:
+		l.Result = domain.LoginResultSuccess
+	}
+	svc.record(ctx, l)
+	return risk, nil
+}
+
+func (svc *loginAuditService) assess(ctx context.Context, u domain.User, l domain.LoginLog) (domain.LoginRisk, error) {
+	failures, err := svc.repo.CountByAccount(ctx, l.Account,
+		domain.LoginResultFailed, time.Now().Add(-svc.failureWindow))
+	if err != nil {
+		return domain.LoginRiskNone, err
+	}
+	if failures >= svc.lockFailures {
+		return domain.LoginRiskLocked, nil
+	}
+	// 开启了两步验证的用户后面还要输入 TOTP 验证码，不需要再验证一次
+	if u.TOTPEnabled {
+		return domain.LoginRiskNone, nil
+	}
+	if failures >= svc.stepUpFailures {
+		return domain.LoginRiskStepUp, nil
+	}
+	recent, err := svc.repo.FindRecent(ctx, u.Id, domain.LoginResultSuccess, svc.recentLogins)
+	if err != nil {
+		return domain.LoginRiskNone, err
+	}
+	// 第一次登录，没有可以比较的
+	if len(recent) == 0 {
+		return domain.LoginRiskNone, nil
+	}
+	newDevice, newRange := true, true
+	ipRange := l.IPRange()
+	for _, r := range recent {
+		if r.UserAgent == l.UserAgent {
+			newDevice = false
+		}
+		if r.IPRange() == ipRange {
+			newRange = false
+		}
+	}
+	if newDevice || newRange {
+		return domain.LoginRiskStepUp, nil
+	}
+	return domain.LoginRiskNone, nil
+}
+
+func (svc *loginAuditService) VerifyStepUp(ctx context.Context, l domain.LoginLog, code string) (domain.User, error) {
+	u, err := svc.userRepo.FindById(ctx, l.Uid)
+	if err != nil {
+		return domain.User{}, err
+	}
+	var ok bool
+	switch {
+	case u.Phone != "":
+		ok, err = svc.codeSvc.Verify(ctx, bizLoginStepUp, u.Phone, code)
+	case u.Email != "" && u.EmailVerified:
+		ok, err = svc.codeSvc.VerifyByEmail(ctx, bizLoginStepUp, u.Email, code)
+	default:
+		return domain.User{}, errNoStepUpChannel
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	if !ok {
+		return domain.User{}, ErrInvalidStepUpCode
+	}
+	l.Result = domain.LoginResultSuccess
+	svc.record(ctx, l)
+	return u, nil
+}
+
+func (svc *loginAuditService) History(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error) {
+	return svc.repo.FindByUid(ctx, uid, offset, limit)
+}
+
+func (svc *loginAuditService) sendStepUpCode(ctx context.Context, u domain.User) error {
+	switch {
+	case u.Phone != "":
+		return svc.codeSvc.Send(ctx, bizLoginStepUp, u.Phone)
+	case u.Email != "" && u.EmailVerified:
+		return svc.codeSvc.SendByEmail(ctx, bizLoginStepUp, u.Email)
+	default:
+		return errNoStepUpChannel
+	}
+}
+
+// record 登录记录写失败了不影响登录
+func (svc *loginAuditService) record(ctx context.Context, l domain.LoginLog) {
+	err := svc.repo.Create(ctx, l)
+	if err != nil {
+		svc.l.Error("记录登录日志失败",
+			logger.Int64("uid", l.Uid),
+			logger.String("method", string(l.Method)),
+			logger.Error(err))
+	}
+}
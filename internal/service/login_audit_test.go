@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	svcmocks "webook/internal/service/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoginAuditService_Assess(t *testing.T) {
+	const ua = "Mozilla/5.0 Chrome/120"
+	known := []domain.LoginLog{
+		{Uid: 123, IP: "10.0.1.2", UserAgent: ua, Result: domain.LoginResultSuccess},
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService)
+		user domain.User
+		log  domain.LoginLog
+
+		wantRisk   domain.LoginRisk
+		wantResult domain.LoginResult
+	}{
+		{
+			name: "第一次登录",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), "123@qq.com", domain.LoginResultFailed, gomock.Any()).
+					Return(int64(0), nil)
+				repo.EXPECT().FindRecent(gomock.Any(), int64(123), domain.LoginResultSuccess, gomock.Any()).
+					Return(nil, nil)
+				return repo, nil
+			},
+			user:       domain.User{Id: 123, Phone: "15212345678"},
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "1.2.3.4", UserAgent: ua},
+			wantRisk:   domain.LoginRiskNone,
+			wantResult: domain.LoginResultSuccess,
+		},
+		{
+			name: "常用设备和网段",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(1), nil)
+				repo.EXPECT().FindRecent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(known, nil)
+				return repo, nil
+			},
+			user: domain.User{Id: 123, Phone: "15212345678"},
+			// 同一个 /24 网段
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "10.0.1.200", UserAgent: ua},
+			wantRisk:   domain.LoginRiskNone,
+			wantResult: domain.LoginResultSuccess,
+		},
+		{
+			name: "新设备，验证码发到手机",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(0), nil)
+				repo.EXPECT().FindRecent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(known, nil)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), bizLoginStepUp, "15212345678").Return(nil)
+				return repo, codeSvc
+			},
+			user:       domain.User{Id: 123, Phone: "15212345678"},
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "10.0.1.2", UserAgent: "curl/8.0"},
+			wantRisk:   domain.LoginRiskStepUp,
+			wantResult: domain.LoginResultStepUp,
+		},
+		{
+			name: "新网段，验证码发到邮箱",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(0), nil)
+				repo.EXPECT().FindRecent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(known, nil)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().SendByEmail(gomock.Any(), bizLoginStepUp, "123@qq.com").Return(nil)
+				return repo, codeSvc
+			},
+			user:       domain.User{Id: 123, Email: "123@qq.com", EmailVerified: true},
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "10.0.2.2", UserAgent: ua},
+			wantRisk:   domain.LoginRiskStepUp,
+			wantResult: domain.LoginResultStepUp,
+		},
+		{
+			name: "失败次数比较多",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(3), nil)
+				codeSvc := svcmocks.NewMockCodeService(ctrl)
+				codeSvc.EXPECT().Send(gomock.Any(), bizLoginStepUp, "15212345678").Return(nil)
+				return repo, codeSvc
+			},
+			user:       domain.User{Id: 123, Phone: "15212345678"},
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "10.0.1.2", UserAgent: ua},
+			wantRisk:   domain.LoginRiskStepUp,
+			wantResult: domain.LoginResultStepUp,
+		},
+		{
+			name: "失败次数太多，锁定",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(10), nil)
+				return repo, nil
+			},
+			user:       domain.User{Id: 123, Phone: "15212345678", TOTPEnabled: true},
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "10.0.1.2", UserAgent: ua},
+			wantRisk:   domain.LoginRiskLocked,
+			wantResult: domain.LoginResultLocked,
+		},
+		{
+			name: "开启了两步验证，不需要二次验证",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(5), nil)
+				return repo, nil
+			},
+			user:       domain.User{Id: 123, Phone: "15212345678", TOTPEnabled: true},
+			log:        domain.LoginLog{Account: "123@qq.com", IP: "10.0.1.2", UserAgent: ua},
+			wantRisk:   domain.LoginRiskNone,
+			wantResult: domain.LoginResultSuccess,
+		},
+		{
+			name: "没有手机和邮箱，只能放行",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLogRepository, CodeService) {
+				repo := repomocks.NewMockLoginLogRepository(ctrl)
+				repo.EXPECT().CountByAccount(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(int64(0), nil)
+				repo.EXPECT().FindRecent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(known, nil)
+				return repo, nil
+			},
+			user:       domain.User{Id: 123},
+			log:        domain.LoginLog{Account: "openid", IP: "8.8.8.8", UserAgent: ua},
+			wantRisk:   domain.LoginRiskNone,
+			wantResult: domain.LoginResultSuccess,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, codeSvc := tc.mock(ctrl)
+			// 不管什么结果都要记录下来
+			repo.(*repomocks.MockLoginLogRepository).EXPECT().
+				Create(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, l domain.LoginLog) error {
+					assert.Equal(t, tc.user.Id, l.Uid)
+					assert.Equal(t, tc.wantResult, l.Result)
+					return nil
+				})
+			svc := NewLoginAuditService(repo, nil, codeSvc, logger.NewNopLogger())
+			risk, err := svc.Assess(context.Background(), tc.user, tc.log)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantRisk, risk)
+		})
+	}
+}
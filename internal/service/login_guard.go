@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"webook/internal/repository"
+	"webook/pkg/limiter"
+)
+
+var ErrLoginLocked = errors.New("登录失败次数太多，暂时锁定")
+
+// MFAGuardAccount 两步验证按照用户计数，验证码输错和密码输错一样会锁定
+func MFAGuardAccount(uid int64) string {
+	return fmt.Sprintf("mfa:%d", uid)
+}
+
+// LoginGuardService 防止暴力破解密码、短信验证码和两步验证码
+type LoginGuardService interface {
+	// Check 校验凭证之前调用，账号或者 IP 被锁定的时候返回 ErrLoginLocked 和剩余的锁定时间
+	Check(ctx context.Context, account, ip string) (time.Duration, error)
+	// Fail 记录一次失败，失败太多次就锁定，返回 ErrLoginLocked 和锁定的时长
+	Fail(ctx context.Context, account, ip string) (time.Duration, error)
+	// Unlock 管理员解锁账号，邮箱、手机号和两步验证都会解锁
+	Unlock(ctx context.Context, uid int64) error
+}
+
+type loginGuardService struct {
+	repo     repository.LoginLockRepository
+	userRepo repository.UserRepository
+	// 账号和 IP 两个维度的失败计数。
+	// 一个 IP 后面可能有很多用户，所以 IP 的阈值要比账号的高很多
+	accountLimiter limiter.Limiter
+	ipLimiter      limiter.Limiter
+	// 第一次锁定的时长，之后每次翻倍
+	lockBase time.Duration
+	lockMax  time.Duration
+}
+
+func NewLoginGuardService(repo repository.LoginLockRepository,
+	userRepo repository.UserRepository,
+	accountLimiter limiter.Limiter, ipLimiter limiter.Limiter) LoginGuardService {
+	return &loginGuardService{
+		repo:           repo,
+		userRepo:       userRepo,
+		accountLimiter: accountLimiter,
+		ipLimiter:      ipLimiter,
+		lockBase:       time.Minute * 5,
+		lockMax:        time.Hour * 24,
+	}
+}
+
+func (svc *loginGuardService) Check(ctx context.Context, account, ip string) (time.Duration, error) {
+	var res time.Duration
+	for _, t := range svc.targets(account, ip) {
+		ttl, err := svc.repo.TTL(ctx, t.key)
+		if err != nil {
+			return 0, err
+		}
+		res = max(res, ttl)
+	}
+	if res > 0 {
+		return res, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+func (svc *loginGuardService) Fail(ctx context.Context, account, ip string) (time.Duration, error) {
+	var res time.Duration
+	for _, t := range svc.targets(account, ip) {
+		limited, err := t.limiter.Limit(ctx, svc.failKey(t.key))
+		if err != nil {
+			return 0, err
+		}
+		if !limited {
+			continue
+		}
+		ttl, err := svc.repo.Lock(ctx, t.key, svc.lockBase, svc.lockMax)
+		if err != nil {
+			return 0, err
+		}
+		res = max(res, ttl)
+	}
+	if res > 0 {
+		return res, ErrLoginLocked
+	}
+	return 0, nil
+}
+
+func (svc *loginGuardService) Unlock(ctx context.Context, uid int64) error {
+	u, err := svc.userRepo.FindById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	for _, account := range []string{u.Email, u.Phone, MFAGuardAccount(uid)} {
+		if account == "" {
+			continue
+		}
+		key := svc.accountKey(account)
+		err = svc.repo.Unlock(ctx, key)
+		if err != nil {
+			return err
+		}
+		// 失败计数也要清掉，不然解锁之后再错一次又会锁上
+		err = svc.accountLimiter.Reset(ctx, svc.failKey(key))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardTarget 一个维度的失败计数
+type guardTarget struct {
+	key     string
+	limiter limiter.Limiter
+}
+
+func (svc *loginGuardService) targets(account, ip string) []guardTarget {
+	return []guardTarget{
+		{key: svc.accountKey(account), limiter: svc.accountLimiter},
+		{key: "ip:" + ip, limiter: svc.ipLimiter},
+	}
+}
+
+// failKey 滑动窗口里面失败计数的 key
+func (svc *loginGuardService) failKey(key string) string {
+	return "login_fail:" + key
+}
+
+func (svc *loginGuardService) accountKey(account string) string {
+	return "account:" + account
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/pkg/limiter"
+	limitermocks "webook/pkg/limiter/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoginGuardService_Fail(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.LoginLockRepository, limiter.Limiter, limiter.Limiter)
+
+		wantTTL time.Duration
+		wantErr error
+	}{
+		{
+			name: "没有超过阈值",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLockRepository, limiter.Limiter, limiter.Limiter) {
+				accountLimiter := limitermocks.NewMockLimiter(ctrl)
+				accountLimiter.EXPECT().Limit(gomock.Any(), "login_fail:account:123@qq.com").Return(false, nil)
+				ipLimiter := limitermocks.NewMockLimiter(ctrl)
+				ipLimiter.EXPECT().Limit(gomock.Any(), "login_fail:ip:1.2.3.4").Return(false, nil)
+				return repomocks.NewMockLoginLockRepository(ctrl), accountLimiter, ipLimiter
+			},
+		},
+		{
+			name: "账号失败太多次，锁定账号",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLockRepository, limiter.Limiter, limiter.Limiter) {
+				accountLimiter := limitermocks.NewMockLimiter(ctrl)
+				accountLimiter.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(true, nil)
+				ipLimiter := limitermocks.NewMockLimiter(ctrl)
+				ipLimiter.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(false, nil)
+				repo := repomocks.NewMockLoginLockRepository(ctrl)
+				repo.EXPECT().Lock(gomock.Any(), "account:123@qq.com", time.Minute*5, time.Hour*24).
+					Return(time.Minute*10, nil)
+				return repo, accountLimiter, ipLimiter
+			},
+			wantTTL: time.Minute * 10,
+			wantErr: ErrLoginLocked,
+		},
+		{
+			name: "账号和 IP 都锁定，返回比较长的",
+			mock: func(ctrl *gomock.Controller) (repository.LoginLockRepository, limiter.Limiter, limiter.Limiter) {
+				accountLimiter := limitermocks.NewMockLimiter(ctrl)
+				accountLimiter.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(true, nil)
+				ipLimiter := limitermocks.NewMockLimiter(ctrl)
+				ipLimiter.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(true, nil)
+				repo := repomocks.NewMockLoginLockRepository(ctrl)
+				repo.EXPECT().Lock(gomock.Any(), "account:123@qq.com", gomock.Any(), gomock.Any()).
+					Return(time.Minute*5, nil)
+				repo.EXPECT().Lock(gomock.Any(), "ip:1.2.3.4", gomock.Any(), gomock.Any()).
+					Return(time.Minute*20, nil)
+				return repo, accountLimiter, ipLimiter
+			},
+			wantTTL: time.Minute * 20,
+			wantErr: ErrLoginLocked,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, accountLimiter, ipLimiter := tc.mock(ctrl)
+			svc := NewLoginGuardService(repo, nil, accountLimiter, ipLimiter)
+			ttl, err := svc.Fail(context.Background(), "123@qq.com", "1.2.3.4")
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantTTL, ttl)
+		})
+	}
+}
+
+func TestLoginGuardService_Unlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindById(gomock.Any(), int64(123)).
+		Return(domain.User{Id: 123, Email: "123@qq.com", Phone: "15212345678"}, nil)
+	repo := repomocks.NewMockLoginLockRepository(ctrl)
+	repo.EXPECT().Unlock(gomock.Any(), "account:123@qq.com").Return(nil)
+	repo.EXPECT().Unlock(gomock.Any(), "account:15212345678").Return(nil)
+	repo.EXPECT().Unlock(gomock.Any(), "account:mfa:123").Return(nil)
+	accountLimiter := limitermocks.NewMockLimiter(ctrl)
+	accountLimiter.EXPECT().Reset(gomock.Any(), "login_fail:account:123@qq.com").Return(nil)
+	accountLimiter.EXPECT().Reset(gomock.Any(), "login_fail:account:15212345678").Return(nil)
+	accountLimiter.EXPECT().Reset(gomock.Any(), "login_fail:account:mfa:123").Return(nil)
+	svc := NewLoginGuardService(repo, userRepo, accountLimiter, nil)
+	assert.NoError(t, svc.Unlock(context.Background(), 123))
+}
+
+// 解锁之后再失败一次，不应该马上又被锁上
+func TestLoginGuardService_UnlockThenFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindById(gomock.Any(), int64(123)).
+		Return(domain.User{Id: 123, Email: "123@qq.com"}, nil)
+	repo := repomocks.NewMockLoginLockRepository(ctrl)
+	repo.EXPECT().Lock(gomock.Any(), "account:123@qq.com", gomock.Any(), gomock.Any()).
+		Return(time.Minute*5, nil)
+	repo.EXPECT().Unlock(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	accountLimiter := newCountLimiter(3)
+	svc := NewLoginGuardService(repo, userRepo, accountLimiter, newCountLimiter(100))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.Fail(ctx, "123@qq.com", "1.2.3.4")
+		assert.NoError(t, err)
+	}
+	_, err := svc.Fail(ctx, "123@qq.com", "1.2.3.4")
+	assert.Equal(t, ErrLoginLocked, err)
+
+	assert.NoError(t, svc.Unlock(ctx, 123))
+	_, err = svc.Fail(ctx, "123@qq.com", "1.2.3.4")
+	assert.NoError(t, err)
+}
+
+// countLimiter 不按时间过期的计数，超过 rate 就限流
+type countLimiter struct {
+	rate int
+	cnts map[string]int
+}
+
+func newCountLimiter(rate int) *countLimiter {
+	return &countLimiter{rate: rate, cnts: map[string]int{}}
+}
+
+func (l *countLimiter) Limit(ctx context.Context, key string) (bool, error) {
+	l.cnts[key]++
+	return l.cnts[key] > l.rate, nil
+}
+
+func (l *countLimiter) Reset(ctx context.Context, key string) error {
+	delete(l.cnts, key)
+	return nil
+}
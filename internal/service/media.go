@@ -0,0 +1,154 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	// 注册 GIF 的解码器
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service/storage"
+	"webook/pkg/imagex"
+)
+
+var (
+	ErrMediaTooLarge        = errors.New("文件太大")
+	ErrUnsupportedMediaType = errors.New("不支持的文件类型")
+)
+
+// 允许上传的类型，都是标准库能解码的。
+// 类型是根据内容判断的，不相信前端传过来的 Content-Type
+var mediaExts = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// MediaService 图片上传，头像和文章里面的图片都用这个
+type MediaService interface {
+	// Upload 上传图片，同时生成各个尺寸的缩略图。同样的内容只存一份
+	Upload(ctx context.Context, data []byte) (domain.Media, error)
+	// URL 带签名的访问地址。size 为 0 的时候是原图，否则是对应尺寸的缩略图
+	URL(ctx context.Context, key string, size int) (string, error)
+}
+
+type mediaService struct {
+	store   storage.Storage
+	maxSize int64
+	// 防止解压炸弹，文件很小但是解码之后非常大
+	maxPixels int
+	// 缩略图的尺寸，长边的像素
+	thumbnailSizes []int
+	// 签名 URL 的有效期
+	urlExpiration time.Duration
+}
+
+func NewMediaService(store storage.Storage) MediaService {
+	return &mediaService{
+		store:          store,
+		maxSize:        5 << 20,
+		maxPixels:      40_000_000,
+		thumbnailSizes: []int{64, 256},
+		urlExpiration:  time.Hour,
+	}
+}
+
+func (svc *mediaService) Upload(ctx context.Context, data []byte) (domain.Media, error) {
+	if int64(len(data)) > svc.maxSize {
+		return domain.Media{}, ErrMediaTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := mediaExts[contentType]
+	if !ok {
+		return domain.Media{}, ErrUnsupportedMediaType
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return domain.Media{}, ErrUnsupportedMediaType
+	}
+	if cfg.Width*cfg.Height > svc.maxPixels {
+		return domain.Media{}, ErrMediaTooLarge
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	// 按照哈希的前两位分目录，避免一个目录下面文件太多
+	key := "media/" + hash[:2] + "/" + hash + ext
+	m := domain.Media{
+		Key:         key,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}
+	err = svc.putIfAbsent(ctx, key, data, contentType)
+	if err != nil {
+		return domain.Media{}, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return domain.Media{}, ErrUnsupportedMediaType
+	}
+	for _, size := range svc.thumbnailSizes {
+		err = svc.putThumbnail(ctx, key, img, size)
+		if err != nil {
+			return domain.Media{}, err
+		}
+	}
+	return m, nil
+}
+
+func (svc *mediaService) URL(ctx context.Context, key string, size int) (string, error) {
+	if size > 0 {
+		key = thumbnailKey(key, size)
+	}
+	return svc.store.SignedURL(ctx, key, svc.urlExpiration)
+}
+
+func (svc *mediaService) putThumbnail(ctx context.Context, key string, img image.Image, size int) error {
+	tk := thumbnailKey(key, size)
+	ok, err := svc.store.Exists(ctx, tk)
+	if err != nil || ok {
+		return err
+	}
+	var buf bytes.Buffer
+	thumb := imagex.Thumbnail(img, size)
+	contentType := "image/png"
+	if strings.HasSuffix(tk, ".jpg") {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return err
+	}
+	return svc.store.Put(ctx, tk, &buf, int64(buf.Len()), contentType)
+}
+
+func (svc *mediaService) putIfAbsent(ctx context.Context, key string, data []byte, contentType string) error {
+	ok, err := svc.store.Exists(ctx, key)
+	if err != nil || ok {
+		return err
+	}
+	return svc.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// thumbnailKey 缩略图和原图放在一起，例如 media/ab/abcdef_256.png。
+// GIF 的缩略图只取第一帧，存成 PNG
+func thumbnailKey(key string, size int) string {
+	idx := strings.LastIndexByte(key, '.')
+	base, ext := key[:idx], key[idx:]
+	if ext == ".gif" {
+		ext = ".png"
+	}
+	return base + "_" + strconv.Itoa(size) + ext
+}
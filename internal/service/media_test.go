@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"webook/internal/service/storage/local"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaService_Upload(t *testing.T) {
+	store := local.NewStorage(t.TempDir(), "http://localhost/media/files", []byte("sign-key"))
+	svc := NewMediaService(store)
+	ctx := context.Background()
+
+	img := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	img.Set(10, 10, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	m, err := svc.Upload(ctx, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", m.ContentType)
+	assert.Equal(t, 600, m.Width)
+	assert.Equal(t, 300, m.Height)
+	assert.True(t, strings.HasPrefix(m.Key, "media/"))
+	assert.True(t, strings.HasSuffix(m.Key, ".png"))
+
+	// 缩略图
+	for _, key := range []string{m.Key, thumbnailKey(m.Key, 64), thumbnailKey(m.Key, 256)} {
+		ok, err := store.Exists(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, ok, key)
+	}
+	rc, err := store.Get(ctx, thumbnailKey(m.Key, 256))
+	require.NoError(t, err)
+	thumb, err := png.Decode(rc)
+	_ = rc.Close()
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), thumb.Bounds())
+
+	// 内容一样，key 也一样
+	m2, err := svc.Upload(ctx, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, m.Key, m2.Key)
+
+	// 签名的 URL 可以访问，篡改之后不行
+	signed, err := svc.URL(ctx, m.Key, 64)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	files := http.StripPrefix("/media/files", store)
+	resp := httptest.NewRecorder()
+	files.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+	resp = httptest.NewRecorder()
+	files.ServeHTTP(resp, httptest.NewRequest(http.MethodGet,
+		strings.Replace(u.RequestURI(), "_64", "_256", 1), nil))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestMediaService_UploadInvalid(t *testing.T) {
+	svc := NewMediaService(local.NewStorage(t.TempDir(), "", []byte("sign-key")))
+	_, err := svc.Upload(context.Background(), []byte("<html>不是图片</html>"))
+	assert.Equal(t, ErrUnsupportedMediaType, err)
+	_, err = svc.Upload(context.Background(), make([]byte, 6<<20))
+	assert.Equal(t, ErrMediaTooLarge, err)
+}
+
+func TestMediaService_URLExpired(t *testing.T) {
+	store := local.NewStorage(t.TempDir(), "http://localhost/media/files", []byte("sign-key"))
+	signed, err := store.SignedURL(context.Background(), "media/ab/abc.png", -time.Second)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	resp := httptest.NewRecorder()
+	http.StripPrefix("/media/files", store).
+		ServeHTTP(resp, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
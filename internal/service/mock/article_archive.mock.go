@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./article_archive.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./article_archive.go -destination=./mock/article_archive.mock.go -package=svcmocks
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockArticleArchiveService is a mock of ArticleArchiveService interface.
+type MockArticleArchiveService struct {
+	ctrl     *gomock.Controller
+	recorder *MockArticleArchiveServiceMockRecorder
+}
+
+// MockArticleArchiveServiceMockRecorder is the mock recorder for MockArticleArchiveService.
+type MockArticleArchiveServiceMockRecorder struct {
+	mock *MockArticleArchiveService
+}
+
+// NewMockArticleArchiveService creates a new mock instance.
+func NewMockArticleArchiveService(ctrl *gomock.Controller) *MockArticleArchiveService {
+	mock := &MockArticleArchiveService{ctrl: ctrl}
+	mock.recorder = &MockArticleArchiveServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArticleArchiveService) EXPECT() *MockArticleArchiveServiceMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockArticleArchiveService) Export(ctx context.Context, uid int64, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, uid, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockArticleArchiveServiceMockRecorder) Export(ctx, uid, w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockArticleArchiveService)(nil).Export), ctx, uid, w)
+}
+
+// Import mocks base method.
+func (m *MockArticleArchiveService) Import(ctx context.Context, uid int64, data []byte) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, uid, data)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockArticleArchiveServiceMockRecorder) Import(ctx, uid, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockArticleArchiveService)(nil).Import), ctx, uid, data)
+}
+
+// ImportJob mocks base method.
+func (m *MockArticleArchiveService) ImportJob(ctx context.Context, uid, id int64) (domain.ArticleImportJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportJob", ctx, uid, id)
+	ret0, _ := ret[0].(domain.ArticleImportJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportJob indicates an expected call of ImportJob.
+func (mr *MockArticleArchiveServiceMockRecorder) ImportJob(ctx, uid, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportJob", reflect.TypeOf((*MockArticleArchiveService)(nil).ImportJob), ctx, uid, id)
+}
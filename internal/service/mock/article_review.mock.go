@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./article_review.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./article_review.go -destination=./mock/article_review.mock.go -package=svcmocks
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockArticleReviewService is a mock of ArticleReviewService interface.
+type MockArticleReviewService struct {
+	ctrl     *gomock.Controller
+	recorder *MockArticleReviewServiceMockRecorder
+}
+
+// MockArticleReviewServiceMockRecorder is the mock recorder for MockArticleReviewService.
+type MockArticleReviewServiceMockRecorder struct {
+	mock *MockArticleReviewService
+}
+
+// NewMockArticleReviewService creates a new mock instance.
+func NewMockArticleReviewService(ctrl *gomock.Controller) *MockArticleReviewService {
+	mock := &MockArticleReviewService{ctrl: ctrl}
+	mock.recorder = &MockArticleReviewServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockArticleReviewService) EXPECT() *MockArticleReviewServiceMockRecorder {
+	return m.recorder
+}
+
+// Approve mocks base method.
+func (m *MockArticleReviewService) Approve(ctx context.Context, id, reviewer int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Approve", ctx, id, reviewer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Approve indicates an expected call of Approve.
+func (mr *MockArticleReviewServiceMockRecorder) Approve(ctx, id, reviewer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Approve", reflect.TypeOf((*MockArticleReviewService)(nil).Approve), ctx, id, reviewer)
+}
+
+// Pending mocks base method.
+func (m *MockArticleReviewService) Pending(ctx context.Context, offset, limit int) ([]domain.ArticleReview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pending", ctx, offset, limit)
+	ret0, _ := ret[0].([]domain.ArticleReview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pending indicates an expected call of Pending.
+func (mr *MockArticleReviewServiceMockRecorder) Pending(ctx, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pending", reflect.TypeOf((*MockArticleReviewService)(nil).Pending), ctx, offset, limit)
+}
+
+// Reject mocks base method.
+func (m *MockArticleReviewService) Reject(ctx context.Context, id, reviewer int64, comment string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reject", ctx, id, reviewer, comment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reject indicates an expected call of Reject.
+func (mr *MockArticleReviewServiceMockRecorder) Reject(ctx, id, reviewer, comment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reject", reflect.TypeOf((*MockArticleReviewService)(nil).Reject), ctx, id, reviewer, comment)
+}
@@ -39,7 +39,7 @@ func (m *MockCodeService) EXPECT() *MockCodeServiceMockRecorder {
 	return m.recorder
 }
 
-// Send mock base method.
+// Send mocks base method.
 func (m *MockCodeService) Send(ctx context.Context, biz, phone string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Send", ctx, biz, phone)
@@ -53,7 +53,21 @@ func (mr *MockCodeServiceMockRecorder) Send(ctx, biz, phone any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockCodeService)(nil).Send), ctx, biz, phone)
 }
 
-// Verify mock base method.
+// SendByEmail mocks base method.
+func (m *MockCodeService) SendByEmail(ctx context.Context, biz, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendByEmail", ctx, biz, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendByEmail indicates an expected call of SendByEmail.
+func (mr *MockCodeServiceMockRecorder) SendByEmail(ctx, biz, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendByEmail", reflect.TypeOf((*MockCodeService)(nil).SendByEmail), ctx, biz, email)
+}
+
+// Verify mocks base method.
 func (m *MockCodeService) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Verify", ctx, biz, phone, inputCode)
@@ -67,3 +81,18 @@ func (mr *MockCodeServiceMockRecorder) Verify(ctx, biz, phone, inputCode any) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockCodeService)(nil).Verify), ctx, biz, phone, inputCode)
 }
+
+// VerifyByEmail mocks base method.
+func (m *MockCodeService) VerifyByEmail(ctx context.Context, biz, email, inputCode string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyByEmail", ctx, biz, email, inputCode)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyByEmail indicates an expected call of VerifyByEmail.
+func (mr *MockCodeServiceMockRecorder) VerifyByEmail(ctx, biz, email, inputCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyByEmail", reflect.TypeOf((*MockCodeService)(nil).VerifyByEmail), ctx, biz, email, inputCode)
+}
@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./login_audit.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./login_audit.go -destination=./mock/login_audit.mock.go -package=svcmocks
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginAuditService is a mock of LoginAuditService interface.
+type MockLoginAuditService struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAuditServiceMockRecorder
+}
+
+// MockLoginAuditServiceMockRecorder is the mock recorder for MockLoginAuditService.
+type MockLoginAuditServiceMockRecorder struct {
+	mock *MockLoginAuditService
+}
+
+// NewMockLoginAuditService creates a new mock instance.
+func NewMockLoginAuditService(ctrl *gomock.Controller) *MockLoginAuditService {
+	mock := &MockLoginAuditService{ctrl: ctrl}
+	mock.recorder = &MockLoginAuditServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAuditService) EXPECT() *MockLoginAuditServiceMockRecorder {
+	return m.recorder
+}
+
+// Assess mocks base method.
+func (m *MockLoginAuditService) Assess(ctx context.Context, u domain.User, l domain.LoginLog) (domain.LoginRisk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Assess", ctx, u, l)
+	ret0, _ := ret[0].(domain.LoginRisk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Assess indicates an expected call of Assess.
+func (mr *MockLoginAuditServiceMockRecorder) Assess(ctx, u, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assess", reflect.TypeOf((*MockLoginAuditService)(nil).Assess), ctx, u, l)
+}
+
+// History mocks base method.
+func (m *MockLoginAuditService) History(ctx context.Context, uid int64, offset, limit int) ([]domain.LoginLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", ctx, uid, offset, limit)
+	ret0, _ := ret[0].([]domain.LoginLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockLoginAuditServiceMockRecorder) History(ctx, uid, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockLoginAuditService)(nil).History), ctx, uid, offset, limit)
+}
+
+// RecordFailure mocks base method.
+func (m *MockLoginAuditService) RecordFailure(ctx context.Context, l domain.LoginLog) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordFailure", ctx, l)
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockLoginAuditServiceMockRecorder) RecordFailure(ctx, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockLoginAuditService)(nil).RecordFailure), ctx, l)
+}
+
+// VerifyStepUp mocks base method.
+func (m *MockLoginAuditService) VerifyStepUp(ctx context.Context, l domain.LoginLog, code string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyStepUp", ctx, l, code)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyStepUp indicates an expected call of VerifyStepUp.
+func (mr *MockLoginAuditServiceMockRecorder) VerifyStepUp(ctx, l, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyStepUp", reflect.TypeOf((*MockLoginAuditService)(nil).VerifyStepUp), ctx, l, code)
+}
@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./privacy.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./privacy.go -destination=./mock/privacy.mock.go -package=svcmocks
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPrivacyService is a mock of PrivacyService interface.
+type MockPrivacyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPrivacyServiceMockRecorder
+}
+
+// MockPrivacyServiceMockRecorder is the mock recorder for MockPrivacyService.
+type MockPrivacyServiceMockRecorder struct {
+	mock *MockPrivacyService
+}
+
+// NewMockPrivacyService creates a new mock instance.
+func NewMockPrivacyService(ctrl *gomock.Controller) *MockPrivacyService {
+	mock := &MockPrivacyService{ctrl: ctrl}
+	mock.recorder = &MockPrivacyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPrivacyService) EXPECT() *MockPrivacyServiceMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockPrivacyService) Allow(ctx context.Context, viewer, owner int64, action domain.PrivacyAction) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", ctx, viewer, owner, action)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockPrivacyServiceMockRecorder) Allow(ctx, viewer, owner, action any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockPrivacyService)(nil).Allow), ctx, viewer, owner, action)
+}
+
+// Block mocks base method.
+func (m *MockPrivacyService) Block(ctx context.Context, uid, target int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Block", ctx, uid, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Block indicates an expected call of Block.
+func (mr *MockPrivacyServiceMockRecorder) Block(ctx, uid, target any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Block", reflect.TypeOf((*MockPrivacyService)(nil).Block), ctx, uid, target)
+}
+
+// Blocks mocks base method.
+func (m *MockPrivacyService) Blocks(ctx context.Context, uid int64, offset, limit int) ([]domain.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Blocks", ctx, uid, offset, limit)
+	ret0, _ := ret[0].([]domain.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Blocks indicates an expected call of Blocks.
+func (mr *MockPrivacyServiceMockRecorder) Blocks(ctx, uid, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Blocks", reflect.TypeOf((*MockPrivacyService)(nil).Blocks), ctx, uid, offset, limit)
+}
+
+// GetSettings mocks base method.
+func (m *MockPrivacyService) GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", ctx, uid)
+	ret0, _ := ret[0].(domain.PrivacySettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockPrivacyServiceMockRecorder) GetSettings(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockPrivacyService)(nil).GetSettings), ctx, uid)
+}
+
+// Unblock mocks base method.
+func (m *MockPrivacyService) Unblock(ctx context.Context, uid, target int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unblock", ctx, uid, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unblock indicates an expected call of Unblock.
+func (mr *MockPrivacyServiceMockRecorder) Unblock(ctx, uid, target any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unblock", reflect.TypeOf((*MockPrivacyService)(nil).Unblock), ctx, uid, target)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockPrivacyService) UpdateSettings(ctx context.Context, s domain.PrivacySettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", ctx, s)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockPrivacyServiceMockRecorder) UpdateSettings(ctx, s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockPrivacyService)(nil).UpdateSettings), ctx, s)
+}
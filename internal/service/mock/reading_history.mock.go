@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./reading_history.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./reading_history.go -destination=./mock/reading_history.mock.go -package=svcmocks
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReadingHistoryService is a mock of ReadingHistoryService interface.
+type MockReadingHistoryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockReadingHistoryServiceMockRecorder
+}
+
+// MockReadingHistoryServiceMockRecorder is the mock recorder for MockReadingHistoryService.
+type MockReadingHistoryServiceMockRecorder struct {
+	mock *MockReadingHistoryService
+}
+
+// NewMockReadingHistoryService creates a new mock instance.
+func NewMockReadingHistoryService(ctrl *gomock.Controller) *MockReadingHistoryService {
+	mock := &MockReadingHistoryService{ctrl: ctrl}
+	mock.recorder = &MockReadingHistoryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReadingHistoryService) EXPECT() *MockReadingHistoryServiceMockRecorder {
+	return m.recorder
+}
+
+// Clear mocks base method.
+func (m *MockReadingHistoryService) Clear(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clear", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Clear indicates an expected call of Clear.
+func (mr *MockReadingHistoryServiceMockRecorder) Clear(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clear", reflect.TypeOf((*MockReadingHistoryService)(nil).Clear), ctx, uid)
+}
+
+// Get mocks base method.
+func (m *MockReadingHistoryService) Get(ctx context.Context, uid, aid int64) (domain.ReadingHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, uid, aid)
+	ret0, _ := ret[0].(domain.ReadingHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockReadingHistoryServiceMockRecorder) Get(ctx, uid, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockReadingHistoryService)(nil).Get), ctx, uid, aid)
+}
+
+// List mocks base method.
+func (m *MockReadingHistoryService) List(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.ReadingHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.ReadingHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockReadingHistoryServiceMockRecorder) List(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockReadingHistoryService)(nil).List), ctx, uid, cursor, limit)
+}
+
+// ReportProgress mocks base method.
+func (m *MockReadingHistoryService) ReportProgress(ctx context.Context, uid, aid int64, progress float64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportProgress", ctx, uid, aid, progress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReportProgress indicates an expected call of ReportProgress.
+func (mr *MockReadingHistoryServiceMockRecorder) ReportProgress(ctx, uid, aid, progress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportProgress", reflect.TypeOf((*MockReadingHistoryService)(nil).ReportProgress), ctx, uid, aid, progress)
+}
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./reward.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./reward.go -destination=./mock/reward.mock.go -package=svcmocks
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "webook/internal/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRewardService is a mock of RewardService interface.
+type MockRewardService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRewardServiceMockRecorder
+}
+
+// MockRewardServiceMockRecorder is the mock recorder for MockRewardService.
+type MockRewardServiceMockRecorder struct {
+	mock *MockRewardService
+}
+
+// NewMockRewardService creates a new mock instance.
+func NewMockRewardService(ctrl *gomock.Controller) *MockRewardService {
+	mock := &MockRewardService{ctrl: ctrl}
+	mock.recorder = &MockRewardServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRewardService) EXPECT() *MockRewardServiceMockRecorder {
+	return m.recorder
+}
+
+// Balance mocks base method.
+func (m *MockRewardService) Balance(ctx context.Context, uid int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Balance", ctx, uid)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Balance indicates an expected call of Balance.
+func (mr *MockRewardServiceMockRecorder) Balance(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Balance", reflect.TypeOf((*MockRewardService)(nil).Balance), ctx, uid)
+}
+
+// Entries mocks base method.
+func (m *MockRewardService) Entries(ctx context.Context, uid int64, offset, limit int) ([]domain.LedgerEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Entries", ctx, uid, offset, limit)
+	ret0, _ := ret[0].([]domain.LedgerEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Entries indicates an expected call of Entries.
+func (mr *MockRewardServiceMockRecorder) Entries(ctx, uid, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Entries", reflect.TypeOf((*MockRewardService)(nil).Entries), ctx, uid, offset, limit)
+}
+
+// Reward mocks base method.
+func (m *MockRewardService) Reward(ctx context.Context, uid int64, biz string, bizId, amount int64, key string) (domain.LedgerTxn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reward", ctx, uid, biz, bizId, amount, key)
+	ret0, _ := ret[0].(domain.LedgerTxn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reward indicates an expected call of Reward.
+func (mr *MockRewardServiceMockRecorder) Reward(ctx, uid, biz, bizId, amount, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reward", reflect.TypeOf((*MockRewardService)(nil).Reward), ctx, uid, biz, bizId, amount, key)
+}
+
+// TopUp mocks base method.
+func (m *MockRewardService) TopUp(ctx context.Context, uid, amount int64, key string) (domain.LedgerTxn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopUp", ctx, uid, amount, key)
+	ret0, _ := ret[0].(domain.LedgerTxn)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TopUp indicates an expected call of TopUp.
+func (mr *MockRewardServiceMockRecorder) TopUp(ctx, uid, amount, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopUp", reflect.TypeOf((*MockRewardService)(nil).TopUp), ctx, uid, amount, key)
+}
@@ -0,0 +1,36 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"webook/internal/domain"
+)
+
+// LengthChecker 标题和内容不能为空，也不能太长，长度按照字符算
+type LengthChecker struct {
+	maxTitle   int
+	maxContent int
+}
+
+func NewLengthChecker(maxTitle, maxContent int) *LengthChecker {
+	return &LengthChecker{
+		maxTitle:   maxTitle,
+		maxContent: maxContent,
+	}
+}
+
+func (c *LengthChecker) Check(ctx context.Context, art domain.Article) (domain.ModerationResult, error) {
+	switch {
+	case strings.TrimSpace(art.Title) == "":
+		return reject("标题不能为空"), nil
+	case strings.TrimSpace(art.Content) == "":
+		return reject("内容不能为空"), nil
+	case utf8.RuneCountInString(art.Title) > c.maxTitle:
+		return reject(fmt.Sprintf("标题不能超过 %d 个字", c.maxTitle)), nil
+	case utf8.RuneCountInString(art.Content) > c.maxContent:
+		return reject(fmt.Sprintf("内容不能超过 %d 个字", c.maxContent)), nil
+	}
+	return approve(), nil
+}
@@ -0,0 +1,29 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"webook/internal/domain"
+)
+
+var linkRegexp = regexp.MustCompile(`(?i)https?://[^\s)\]>"']+`)
+
+// LinkChecker 链接太多的一般是广告，转人工
+type LinkChecker struct {
+	maxLinks int
+}
+
+func NewLinkChecker(maxLinks int) *LinkChecker {
+	return &LinkChecker{
+		maxLinks: maxLinks,
+	}
+}
+
+func (c *LinkChecker) Check(ctx context.Context, art domain.Article) (domain.ModerationResult, error) {
+	cnt := len(linkRegexp.FindAllStringIndex(art.Content, -1))
+	if cnt > c.maxLinks {
+		return review(fmt.Sprintf("包含 %d 个链接", cnt)), nil
+	}
+	return approve(), nil
+}
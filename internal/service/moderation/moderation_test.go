@@ -0,0 +1,90 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"webook/internal/domain"
+	"webook/pkg/sensitive"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_Check(t *testing.T) {
+	chain := NewChain(
+		NewLengthChecker(20, 200),
+		NewSensitiveChecker(sensitive.NewACFilter([]string{"赌博"})),
+		NewLinkChecker(2),
+		NewSpamChecker(3, 10),
+	)
+	testCases := []struct {
+		name string
+		art  domain.Article
+
+		want domain.ModerationResult
+	}{
+		{
+			name: "通过",
+			art:  domain.Article{Title: "标题", Content: "正常的内容 https://meoying.com"},
+			want: domain.ModerationResult{Decision: domain.ModerationApprove},
+		},
+		{
+			name: "标题为空",
+			art:  domain.Article{Title: "  ", Content: "内容"},
+			want: domain.ModerationResult{
+				Decision: domain.ModerationReject,
+				Reasons:  []string{"标题不能为空"},
+			},
+		},
+		{
+			name: "内容太长",
+			art:  domain.Article{Title: "标题", Content: strings.Repeat("长", 201)},
+			want: domain.ModerationResult{
+				Decision: domain.ModerationReject,
+				Reasons:  []string{"内容不能超过 200 个字"},
+			},
+		},
+		{
+			name: "敏感词直接拒绝，不用再看后面的",
+			art: domain.Article{Title: "标题",
+				Content: "网上赌博 http://a.com http://b.com http://c.com"},
+			want: domain.ModerationResult{
+				Decision: domain.ModerationReject,
+				Reasons:  []string{"包含敏感词：赌博"},
+			},
+		},
+		{
+			name: "链接太多并且灌水，原因合并",
+			art: domain.Article{Title: "标题",
+				Content: "http://a.com http://b.com http://c.com\n哈哈哈哈哈哈哈哈哈哈哈"},
+			want: domain.ModerationResult{
+				Decision: domain.ModerationReview,
+				Reasons:  []string{"包含 3 个链接", "有大量连续重复的字符"},
+			},
+		},
+		{
+			name: "重复的行",
+			art: domain.Article{Title: "标题",
+				Content: strings.Repeat("点击领取优惠券\n", 4)},
+			want: domain.ModerationResult{
+				Decision: domain.ModerationReview,
+				Reasons:  []string{"有大量重复的内容"},
+			},
+		},
+		{
+			name: "markdown 的分隔线和代码不算灌水",
+			art: domain.Article{Title: "标题",
+				Content: "```\n}\n}\n}\n}\n```\n--------------------\n"},
+			want: domain.ModerationResult{Decision: domain.ModerationApprove},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := chain.Check(context.Background(), tc.art)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"webook/internal/domain"
+	"webook/pkg/sensitive"
+)
+
+// SensitiveChecker 标题或者内容里面有敏感词就拒绝
+type SensitiveChecker struct {
+	filter sensitive.Filter
+}
+
+func NewSensitiveChecker(filter sensitive.Filter) *SensitiveChecker {
+	return &SensitiveChecker{
+		filter: filter,
+	}
+}
+
+func (c *SensitiveChecker) Check(ctx context.Context, art domain.Article) (domain.ModerationResult, error) {
+	words := c.filter.Find(art.Title + "\n" + art.Content)
+	if len(words) > 0 {
+		return reject("包含敏感词：" + strings.Join(words, "、")), nil
+	}
+	return approve(), nil
+}
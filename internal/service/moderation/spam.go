@@ -0,0 +1,72 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+	"webook/internal/domain"
+)
+
+// SpamChecker 灌水的启发式规则：同一行重复很多次，或者同一个字符连续出现很多次。
+// 误判的可能性比较大，所以只是转人工
+type SpamChecker struct {
+	// 同一行最多出现几次
+	maxRepeatLines int
+	// 同一个字符最多连续出现几次
+	maxRepeatRunes int
+}
+
+func NewSpamChecker(maxRepeatLines, maxRepeatRunes int) *SpamChecker {
+	return &SpamChecker{
+		maxRepeatLines: maxRepeatLines,
+		maxRepeatRunes: maxRepeatRunes,
+	}
+}
+
+func (c *SpamChecker) Check(ctx context.Context, art domain.Article) (domain.ModerationResult, error) {
+	var reasons []string
+	if c.repeatLines(art.Content) {
+		reasons = append(reasons, "有大量重复的内容")
+	}
+	if c.repeatRunes(art.Content) {
+		reasons = append(reasons, "有大量连续重复的字符")
+	}
+	if len(reasons) > 0 {
+		return review(reasons...), nil
+	}
+	return approve(), nil
+}
+
+func (c *SpamChecker) repeatLines(content string) bool {
+	cnt := make(map[string]int)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		// 太短的行，例如 markdown 的分隔线、代码块的括号，重复很正常
+		if len([]rune(line)) < 4 {
+			continue
+		}
+		cnt[line]++
+		if cnt[line] > c.maxRepeatLines {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *SpamChecker) repeatRunes(content string) bool {
+	var (
+		last rune
+		run  int
+	)
+	for _, r := range content {
+		if r == last {
+			run++
+		} else {
+			last, run = r, 1
+		}
+		// 空白和 markdown 常用的符号不算
+		if run > c.maxRepeatRunes && !strings.ContainsRune(" \t\n-=*#`~_", r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package moderation
+
+import (
+	"context"
+	"webook/internal/domain"
+)
+
+// Checker 发表文章的时候做的一项检查
+type Checker interface {
+	Check(ctx context.Context, art domain.Article) (domain.ModerationResult, error)
+}
+
+// Chain 按顺序执行所有的检查。
+// 任何一个拒绝就直接拒绝；有转人工的就转人工，原因合并在一起；都通过才算通过
+type Chain struct {
+	checkers []Checker
+}
+
+func NewChain(checkers ...Checker) *Chain {
+	return &Chain{
+		checkers: checkers,
+	}
+}
+
+func (c *Chain) Check(ctx context.Context, art domain.Article) (domain.ModerationResult, error) {
+	res := domain.ModerationResult{Decision: domain.ModerationApprove}
+	for _, checker := range c.checkers {
+		r, err := checker.Check(ctx, art)
+		if err != nil {
+			return domain.ModerationResult{}, err
+		}
+		switch r.Decision {
+		case domain.ModerationReject:
+			return r, nil
+		case domain.ModerationReview:
+			res.Decision = domain.ModerationReview
+			res.Reasons = append(res.Reasons, r.Reasons...)
+		}
+	}
+	return res, nil
+}
+
+func approve() domain.ModerationResult {
+	return domain.ModerationResult{Decision: domain.ModerationApprove}
+}
+
+func review(reasons ...string) domain.ModerationResult {
+	return domain.ModerationResult{Decision: domain.ModerationReview, Reasons: reasons}
+}
+
+func reject(reasons ...string) domain.ModerationResult {
+	return domain.ModerationResult{Decision: domain.ModerationReject, Reasons: reasons}
+}
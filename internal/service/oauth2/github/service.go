@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"webook/internal/domain"
+)
+
+// Service GitHub 不支持 OIDC，只能走普通的 OAuth2，再调用接口拿用户信息
+type Service struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	// 测试的时候替换成本地的假服务器
+	authURL  string
+	tokenURL string
+	apiURL   string
+}
+
+func NewService(clientID, clientSecret, redirectURL string) *Service {
+	return &Service{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       http.DefaultClient,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		apiURL:       "https://api.github.com",
+	}
+}
+
+func (s *Service) Name() string {
+	return "github"
+}
+
+func (s *Service) AuthURL(ctx context.Context, state string) (string, error) {
+	params := url.Values{}
+	params.Set("client_id", s.clientID)
+	params.Set("redirect_uri", s.redirectURL)
+	params.Set("scope", "read:user user:email")
+	params.Set("state", state)
+	return s.authURL + "?" + params.Encode(), nil
+}
+
+func (s *Service) VerifyCode(ctx context.Context, code string) (domain.OAuth2Info, error) {
+	form := url.Values{}
+	form.Set("client_id", s.clientID)
+	form.Set("client_secret", s.clientSecret)
+	form.Set("code", code)
+	form.Set("redirect_uri", s.redirectURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// 不设置的话 GitHub 返回的是 form 格式
+	req.Header.Set("Accept", "application/json")
+	var tr tokenResult
+	err = s.do(req, &tr)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	if tr.Error != "" {
+		return domain.OAuth2Info{}, fmt.Errorf("换取 token 失败 %s %s", tr.Error, tr.ErrorDescription)
+	}
+	if tr.AccessToken == "" {
+		return domain.OAuth2Info{}, errors.New("没有返回 access_token")
+	}
+
+	var u user
+	err = s.get(ctx, tr.AccessToken, "/user", &u)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	info := domain.OAuth2Info{
+		Provider:   s.Name(),
+		ExternalId: strconv.FormatInt(u.Id, 10),
+		Nickname:   u.Name,
+	}
+	if info.Nickname == "" {
+		info.Nickname = u.Login
+	}
+	// /user 里面的是公开邮箱，不一定验证过，要从 /user/emails 里面找主邮箱
+	var emails []email
+	err = s.get(ctx, tr.AccessToken, "/user/emails", &emails)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	for _, e := range emails {
+		if e.Primary {
+			info.Email = e.Email
+			info.EmailVerified = e.Verified
+			break
+		}
+	}
+	return info, nil
+}
+
+func (s *Service) get(ctx context.Context, token, path string, val any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	return s.do(req, val)
+}
+
+func (s *Service) do(req *http.Request, val any) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 失败，状态码 %d", req.URL, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(val)
+}
+
+type tokenResult struct {
+	AccessToken string `json:"access_token"`
+	Scope       string `json:"scope"`
+	TokenType   string `json:"token_type"`
+
+	// 错误返回
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type user struct {
+	Id    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type email struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
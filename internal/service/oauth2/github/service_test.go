@@ -0,0 +1,93 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"webook/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" {
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error":             "bad_verification_code",
+				"error_description": "The code passed is incorrect or expired.",
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "gho_token",
+			"token_type":   "bearer",
+		})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":    123,
+			"login": "daming",
+		})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "other@qq.com", "primary": false, "verified": true},
+			{"email": "123@qq.com", "primary": true, "verified": true},
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestService_VerifyCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		code string
+
+		wantInfo domain.OAuth2Info
+		wantErr  bool
+	}{
+		{
+			name: "校验成功",
+			code: "good-code",
+			wantInfo: domain.OAuth2Info{
+				Provider:      "github",
+				ExternalId:    "123",
+				Email:         "123@qq.com",
+				EmailVerified: true,
+				// 没有设置名字的时候用登录名
+				Nickname: "daming",
+			},
+		},
+		{
+			name:    "授权码不对",
+			code:    "bad-code",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeServer(t)
+			svc := NewService("client", "secret", "https://meoying.com/oauth2/github/callback")
+			svc.tokenURL = server.URL + "/login/oauth/access_token"
+			svc.apiURL = server.URL
+			info, err := svc.VerifyCode(context.Background(), tc.code)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantInfo, info)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package oauth2test 提供测试用的本地授权服务器
+package oauth2test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ClientID     = "client"
+	ClientSecret = "secret"
+	// GoodCode 只有这个授权码能换到 token
+	GoodCode = "good-code"
+)
+
+// FakeOIDCServer 一个最小的 OIDC 授权服务器，支持 discovery、JWKS 和 token 接口
+type FakeOIDCServer struct {
+	*httptest.Server
+	// Claims 用来构造 ID token，默认是一个合法的 ID token
+	Claims func(issuer string) jwt.MapClaims
+}
+
+func NewFakeOIDCServer(t *testing.T) *FakeOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	fs := &FakeOIDCServer{
+		Claims: func(issuer string) jwt.MapClaims {
+			return jwt.MapClaims{
+				"iss":            issuer,
+				"aud":            ClientID,
+				"sub":            "user-123",
+				"exp":            time.Now().Add(time.Minute).Unix(),
+				"email":          "123@qq.com",
+				"email_verified": true,
+				"name":           "大明",
+			}
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 fs.URL,
+			"authorization_endpoint": fs.URL + "/authorize",
+			"token_endpoint":         fs.URL + "/token",
+			"jwks_uri":               fs.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "test-key",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != GoodCode || r.FormValue("client_secret") != ClientSecret {
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, fs.Claims(fs.URL))
+		token.Header["kid"] = "test-key"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-token",
+			"id_token":     idToken,
+		})
+	})
+	fs.Server = httptest.NewServer(mux)
+	t.Cleanup(fs.Close)
+	return fs
+}
@@ -0,0 +1,247 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"webook/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrKeyNotFound = errors.New("找不到签名 ID token 的公钥")
+
+// Service 通用的 OIDC 实现，通过 issuer 下面的 .well-known/openid-configuration
+// 拿到各个接口的地址，然后用 jwks_uri 里面的公钥校验 ID token
+type Service struct {
+	name         string
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	client       *http.Client
+
+	mu sync.Mutex
+	// 懒加载，第一次用到的时候才去拉
+	discovery *discovery
+	// kid => 公钥
+	keys map[string]*rsa.PublicKey
+}
+
+func NewService(name, issuer, clientID, clientSecret, redirectURL string) *Service {
+	return &Service{
+		name:         name,
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		client:       http.DefaultClient,
+	}
+}
+
+func (s *Service) Name() string {
+	return s.name
+}
+
+func (s *Service) AuthURL(ctx context.Context, state string) (string, error) {
+	d, err := s.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("client_id", s.clientID)
+	params.Set("redirect_uri", s.redirectURL)
+	params.Set("response_type", "code")
+	params.Set("scope", "openid email profile")
+	params.Set("state", state)
+	return d.AuthorizationEndpoint + "?" + params.Encode(), nil
+}
+
+func (s *Service) VerifyCode(ctx context.Context, code string) (domain.OAuth2Info, error) {
+	d, err := s.getDiscovery(ctx)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.redirectURL)
+	form.Set("client_id", s.clientID)
+	form.Set("client_secret", s.clientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	var res tokenResult
+	err = s.do(req, &res)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	if res.Error != "" {
+		return domain.OAuth2Info{}, fmt.Errorf("换取 token 失败 %s %s", res.Error, res.ErrorDescription)
+	}
+	if res.IDToken == "" {
+		return domain.OAuth2Info{}, errors.New("没有返回 id_token")
+	}
+	claims, err := s.verifyIDToken(ctx, d, res.IDToken)
+	if err != nil {
+		return domain.OAuth2Info{}, err
+	}
+	return domain.OAuth2Info{
+		Provider:      s.name,
+		ExternalId:    claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Nickname:      claims.Name,
+	}, nil
+}
+
+func (s *Service) verifyIDToken(ctx context.Context, d discovery, idToken string) (IDTokenClaims, error) {
+	var claims IDTokenClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.getKey(ctx, d, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(s.clientID),
+		jwt.WithExpirationRequired())
+	if err != nil {
+		return IDTokenClaims{}, fmt.Errorf("ID token 校验失败 %w", err)
+	}
+	if claims.Subject == "" {
+		return IDTokenClaims{}, errors.New("ID token 里面没有 sub")
+	}
+	return claims, nil
+}
+
+func (s *Service) getDiscovery(ctx context.Context) (discovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discovery != nil {
+		return *s.discovery, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		s.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return discovery{}, err
+	}
+	var d discovery
+	err = s.do(req, &d)
+	if err != nil {
+		return discovery{}, err
+	}
+	// 规范要求返回的 issuer 和配置的完全一致，防止被冒充
+	if strings.TrimSuffix(d.Issuer, "/") != s.issuer {
+		return discovery{}, fmt.Errorf("issuer 不一致，期望 %s 实际 %s", s.issuer, d.Issuer)
+	}
+	s.discovery = &d
+	return d, nil
+}
+
+// getKey 找不到 kid 对应的公钥的时候，重新拉一次 JWKS，兼容对方轮换密钥
+func (s *Service) getKey(ctx context.Context, d discovery, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwks
+	err = s.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		key, er := k.rsaPublicKey()
+		if er != nil {
+			return nil, er
+		}
+		keys[k.Kid] = key
+	}
+	s.keys = keys
+	key, ok := keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *Service) do(req *http.Request, val any) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 %s 失败，状态码 %d", req.URL, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(val)
+}
+
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResult struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+
+	// 错误返回
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
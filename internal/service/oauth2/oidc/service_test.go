@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service/oauth2/oauth2test"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_AuthURL(t *testing.T) {
+	fs := oauth2test.NewFakeOIDCServer(t)
+	svc := NewService("test", fs.URL, "client", "secret", "https://meoying.com/oauth2/test/callback")
+	authURL, err := svc.AuthURL(context.Background(), "my-state")
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, fs.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "client", u.Query().Get("client_id"))
+	assert.Equal(t, "my-state", u.Query().Get("state"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+}
+
+func TestService_VerifyCode(t *testing.T) {
+	testCases := []struct {
+		name   string
+		code   string
+		claims func(issuer string) jwt.MapClaims
+
+		wantInfo domain.OAuth2Info
+		wantErr  bool
+	}{
+		{
+			name: "校验成功",
+			code: oauth2test.GoodCode,
+			// 不设置 claims 就用假服务器默认的合法 ID token
+			wantInfo: domain.OAuth2Info{
+				Provider:      "test",
+				ExternalId:    "user-123",
+				Email:         "123@qq.com",
+				EmailVerified: true,
+				Nickname:      "大明",
+			},
+		},
+		{
+			name: "授权码不对",
+			code: "bad-code",
+			claims: func(issuer string) jwt.MapClaims {
+				return jwt.MapClaims{}
+			},
+			wantErr: true,
+		},
+		{
+			name: "aud 不是自己",
+			code: oauth2test.GoodCode,
+			claims: func(issuer string) jwt.MapClaims {
+				return jwt.MapClaims{
+					"iss": issuer,
+					"aud": "other-client",
+					"sub": "user-123",
+					"exp": time.Now().Add(time.Minute).Unix(),
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "issuer 不对",
+			code: oauth2test.GoodCode,
+			claims: func(issuer string) jwt.MapClaims {
+				return jwt.MapClaims{
+					"iss": "https://evil.com",
+					"aud": "client",
+					"sub": "user-123",
+					"exp": time.Now().Add(time.Minute).Unix(),
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "ID token 过期",
+			code: oauth2test.GoodCode,
+			claims: func(issuer string) jwt.MapClaims {
+				return jwt.MapClaims{
+					"iss": issuer,
+					"aud": "client",
+					"sub": "user-123",
+					"exp": time.Now().Add(-time.Minute).Unix(),
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := oauth2test.NewFakeOIDCServer(t)
+			if tc.claims != nil {
+				fs.Claims = tc.claims
+			}
+			svc := NewService("test", fs.URL, "client", "secret", "https://meoying.com/oauth2/test/callback")
+			info, err := svc.VerifyCode(context.Background(), tc.code)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantInfo, info)
+		})
+	}
+}
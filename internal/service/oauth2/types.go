@@ -0,0 +1,40 @@
+package oauth2
+
+import (
+	"context"
+	"webook/internal/domain"
+)
+
+// Service 第三方登录，每一个提供商一个实现
+type Service interface {
+	// Name 提供商的名字，对应 /oauth2/:provider 里面的 provider
+	Name() string
+	AuthURL(ctx context.Context, state string) (string, error)
+	// VerifyCode 用回调里面的授权码换取用户信息
+	VerifyCode(ctx context.Context, code string) (domain.OAuth2Info, error)
+}
+
+// Registry 按照名字找到对应的提供商
+type Registry struct {
+	providers map[string]Service
+}
+
+func NewRegistry(svcs ...Service) *Registry {
+	r := &Registry{
+		providers: make(map[string]Service, len(svcs)),
+	}
+	for _, svc := range svcs {
+		r.Register(svc)
+	}
+	return r
+}
+
+// Register 同名的提供商会被覆盖，只在启动的时候调用，所以没有加锁
+func (r *Registry) Register(svc Service) {
+	r.providers[svc.Name()] = svc
+}
+
+func (r *Registry) Get(name string) (Service, bool) {
+	svc, ok := r.providers[name]
+	return svc, ok
+}
@@ -0,0 +1,39 @@
+package localpay
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"webook/internal/service/payment"
+)
+
+// Gateway 本地开发用的假网关，什么都不扣，直接成功。
+// 单号只记在内存里面，重启之后重复的单号会再成功一次
+type Gateway struct {
+	lock   sync.Mutex
+	trades map[string]payment.ChargeResult
+	seq    int64
+}
+
+func NewGateway() *Gateway {
+	return &Gateway{
+		trades: make(map[string]payment.ChargeResult),
+	}
+}
+
+func (g *Gateway) Charge(ctx context.Context, req payment.ChargeRequest) (payment.ChargeResult, error) {
+	if req.Amount <= 0 || req.OutTradeNo == "" {
+		return payment.ChargeResult{}, payment.ErrChargeDeclined
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if res, ok := g.trades[req.OutTradeNo]; ok {
+		return res, nil
+	}
+	g.seq++
+	res := payment.ChargeResult{TradeNo: "local-" + strconv.FormatInt(g.seq, 10)}
+	g.trades[req.OutTradeNo] = res
+	log.Println("模拟扣款", req.OutTradeNo, req.Uid, req.Amount, res.TradeNo)
+	return res, nil
+}
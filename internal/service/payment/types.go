@@ -0,0 +1,28 @@
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrChargeDeclined 支付网关拒绝了这笔扣款，比如金额不对或者余额不足
+var ErrChargeDeclined = errors.New("扣款被拒绝")
+
+// Gateway 支付网关的抽象，充值积分的时候用。
+// 同一个 OutTradeNo 重复调用只会扣一次款，返回第一次的结果，调用方可以放心重试
+type Gateway interface {
+	Charge(ctx context.Context, req ChargeRequest) (ChargeResult, error)
+}
+
+type ChargeRequest struct {
+	// OutTradeNo 我们这边的单号，网关用它去重
+	OutTradeNo string
+	Uid        int64
+	// Amount 扣款金额，单位是分
+	Amount int64
+}
+
+type ChargeResult struct {
+	// TradeNo 网关那边的交易号，对账用
+	TradeNo string
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"webook/internal/domain"
+	"webook/internal/repository"
+)
+
+var (
+	ErrBlockSelf           = errors.New("不能拉黑自己")
+	ErrInvalidPrivacyScope = errors.New("隐私设置不合法")
+)
+
+// PrivacyService 隐私设置和拉黑。
+// 拉黑是双向的：任何一方拉黑了对方，双方都看不到对方的内容，也不能互动
+type PrivacyService interface {
+	GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error)
+	UpdateSettings(ctx context.Context, s domain.PrivacySettings) error
+	Block(ctx context.Context, uid, target int64) error
+	Unblock(ctx context.Context, uid, target int64) error
+	// Blocks 我拉黑的人
+	Blocks(ctx context.Context, uid int64, offset, limit int) ([]domain.Block, error)
+	// Allow viewer 能不能对 owner 做 action，viewer 为 0 表示没有登录
+	Allow(ctx context.Context, viewer, owner int64, action domain.PrivacyAction) (bool, error)
+}
+
+type privacyService struct {
+	repo     repository.PrivacyRepository
+	userRepo repository.UserRepository
+}
+
+func NewPrivacyService(repo repository.PrivacyRepository, userRepo repository.UserRepository) PrivacyService {
+	return &privacyService{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+func (svc *privacyService) GetSettings(ctx context.Context, uid int64) (domain.PrivacySettings, error) {
+	return svc.repo.GetSettings(ctx, uid)
+}
+
+func (svc *privacyService) UpdateSettings(ctx context.Context, s domain.PrivacySettings) error {
+	if !s.Likes.Valid() || !s.Collections.Valid() {
+		return ErrInvalidPrivacyScope
+	}
+	return svc.repo.SaveSettings(ctx, s)
+}
+
+func (svc *privacyService) Block(ctx context.Context, uid, target int64) error {
+	if uid == target {
+		return ErrBlockSelf
+	}
+	// 确认用户存在，注销了的也不用拉黑了
+	_, err := svc.userRepo.FindById(ctx, target)
+	if err != nil {
+		return err
+	}
+	return svc.repo.Block(ctx, uid, target)
+}
+
+func (svc *privacyService) Unblock(ctx context.Context, uid, target int64) error {
+	return svc.repo.Unblock(ctx, uid, target)
+}
+
+func (svc *privacyService) Blocks(ctx context.Context, uid int64, offset, limit int) ([]domain.Block, error) {
+	return svc.repo.FindBlocks(ctx, uid, offset, limit)
+}
+
+func (svc *privacyService) Allow(ctx context.Context, viewer, owner int64,
+	action domain.PrivacyAction) (bool, error) {
+	if viewer == owner {
+		return true, nil
+	}
+	if viewer > 0 {
+		blocked, err := svc.repo.IsBlocked(ctx, viewer, owner)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			return false, nil
+		}
+	}
+	switch action {
+	case domain.PrivacyActionView, domain.PrivacyActionInteract:
+		// 只受拉黑影响
+		return true, nil
+	}
+	s, err := svc.repo.GetSettings(ctx, owner)
+	if err != nil {
+		return false, err
+	}
+	return s.Scope(action) == domain.PrivacyScopeEveryone, nil
+}
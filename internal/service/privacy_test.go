@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPrivacyService_Allow(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) repository.PrivacyRepository
+		viewer int64
+		action domain.PrivacyAction
+
+		wantAllow bool
+	}{
+		{
+			name: "看自己的",
+			mock: func(ctrl *gomock.Controller) repository.PrivacyRepository {
+				return repomocks.NewMockPrivacyRepository(ctrl)
+			},
+			viewer:    123,
+			action:    domain.PrivacyActionViewLikes,
+			wantAllow: true,
+		},
+		{
+			name: "拉黑了，不能看",
+			mock: func(ctrl *gomock.Controller) repository.PrivacyRepository {
+				repo := repomocks.NewMockPrivacyRepository(ctrl)
+				repo.EXPECT().IsBlocked(gomock.Any(), int64(456), int64(123)).Return(true, nil)
+				return repo
+			},
+			viewer: 456,
+			action: domain.PrivacyActionView,
+		},
+		{
+			name: "没拉黑，看文章不用查设置",
+			mock: func(ctrl *gomock.Controller) repository.PrivacyRepository {
+				repo := repomocks.NewMockPrivacyRepository(ctrl)
+				repo.EXPECT().IsBlocked(gomock.Any(), int64(456), int64(123)).Return(false, nil)
+				return repo
+			},
+			viewer:    456,
+			action:    domain.PrivacyActionView,
+			wantAllow: true,
+		},
+		{
+			name: "没登录，收藏不公开",
+			mock: func(ctrl *gomock.Controller) repository.PrivacyRepository {
+				repo := repomocks.NewMockPrivacyRepository(ctrl)
+				repo.EXPECT().GetSettings(gomock.Any(), int64(123)).Return(domain.PrivacySettings{
+					Uid:         123,
+					Collections: domain.PrivacyScopeNobody,
+				}, nil)
+				return repo
+			},
+			action: domain.PrivacyActionViewCollections,
+		},
+		{
+			name: "默认公开点赞",
+			mock: func(ctrl *gomock.Controller) repository.PrivacyRepository {
+				repo := repomocks.NewMockPrivacyRepository(ctrl)
+				repo.EXPECT().IsBlocked(gomock.Any(), int64(456), int64(123)).Return(false, nil)
+				repo.EXPECT().GetSettings(gomock.Any(), int64(123)).
+					Return(domain.PrivacySettings{Uid: 123}, nil)
+				return repo
+			},
+			viewer:    456,
+			action:    domain.PrivacyActionViewLikes,
+			wantAllow: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewPrivacyService(tc.mock(ctrl), repomocks.NewMockUserRepository(ctrl))
+			allow, err := svc.Allow(context.Background(), tc.viewer, 123, tc.action)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantAllow, allow)
+		})
+	}
+}
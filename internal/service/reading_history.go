@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/events/article"
+	"webook/internal/repository"
+	"webook/pkg/logger"
+)
+
+var (
+	ErrReadingHistoryNotFound = repository.ErrReadingHistoryNotFound
+	ErrInvalidReadingProgress = errors.New("阅读进度要在 0 到 1 之间")
+)
+
+type ReadingHistoryService interface {
+	// ReportProgress 上报阅读进度，异步写入，不算阅读数
+	ReportProgress(ctx context.Context, uid, aid int64, progress float64) error
+	// Get 某一篇文章的阅读记录，用来恢复阅读位置
+	Get(ctx context.Context, uid, aid int64) (domain.ReadingHistory, error)
+	// List 按照最后阅读时间倒序，cursor 是上一页最后一条，零值表示第一页。
+	// 已经看不到的文章 Article 里面只有 Id
+	List(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.ReadingHistory, error)
+	Clear(ctx context.Context, uid int64) error
+}
+
+type readingHistoryService struct {
+	repo     repository.ReadingHistoryRepository
+	artRepo  repository.ArticleRepository
+	producer article.Producer
+	l        logger.Logger
+}
+
+func NewReadingHistoryService(repo repository.ReadingHistoryRepository,
+	artRepo repository.ArticleRepository,
+	producer article.Producer, l logger.Logger) ReadingHistoryService {
+	return &readingHistoryService{
+		repo:     repo,
+		artRepo:  artRepo,
+		producer: producer,
+		l:        l,
+	}
+}
+
+func (svc *readingHistoryService) ReportProgress(ctx context.Context, uid, aid int64, progress float64) error {
+	// NaN 也过不去
+	if !(progress >= 0 && progress <= 1) {
+		return ErrInvalidReadingProgress
+	}
+	art, err := svc.artRepo.GetPubById(ctx, aid)
+	if err != nil {
+		return err
+	}
+	if art.Status != domain.ArticleStatusPublished && art.Author.Id != uid {
+		return ErrArticleNotFound
+	}
+	// 和阅读事件走同一个 topic，由消费者合并之后批量写入
+	return svc.producer.ProducerReadEvent(article.ReadEvent{
+		Aid:      aid,
+		Biz:      domain.BizArticle,
+		BizId:    aid,
+		Uid:      uid,
+		Progress: &progress,
+		Time:     time.Now().UnixMilli(),
+	})
+}
+
+func (svc *readingHistoryService) Get(ctx context.Context, uid, aid int64) (domain.ReadingHistory, error) {
+	return svc.repo.Get(ctx, uid, aid)
+}
+
+func (svc *readingHistoryService) List(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.ReadingHistory, error) {
+	hs, err := svc.repo.List(ctx, uid, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hs {
+		art, err := svc.artRepo.GetPubById(ctx, hs[i].Aid)
+		if err != nil {
+			// 文章可能已经被删除了，保留记录，由上层决定要不要展示
+			svc.l.Warn("查询阅读历史里面的文章失败",
+				logger.Int64("aid", hs[i].Aid),
+				logger.Error(err))
+			continue
+		}
+		hs[i].Article = art
+	}
+	return hs, nil
+}
+
+func (svc *readingHistoryService) Clear(ctx context.Context, uid int64) error {
+	return svc.repo.Clear(ctx, uid)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReadingHistoryService_ReportProgress(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.ArticleRepository
+		progress float64
+
+		wantErr error
+	}{
+		{
+			name: "进度超过 1",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				return repomocks.NewMockArticleRepository(ctrl)
+			},
+			progress: 1.5,
+			wantErr:  ErrInvalidReadingProgress,
+		},
+		{
+			name: "进度是 NaN",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				return repomocks.NewMockArticleRepository(ctrl)
+			},
+			progress: math.NaN(),
+			wantErr:  ErrInvalidReadingProgress,
+		},
+		{
+			name: "别人仅自己可见的文章",
+			mock: func(ctrl *gomock.Controller) repository.ArticleRepository {
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(10)).
+					Return(domain.Article{Id: 10, Author: domain.Author{Id: 456},
+						Status: domain.ArticleStatusPrivate}, nil)
+				return artRepo
+			},
+			progress: 0.5,
+			wantErr:  ErrArticleNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc := NewReadingHistoryService(repomocks.NewMockReadingHistoryRepository(ctrl),
+				tc.mock(ctrl), nil, logger.NewNopLogger())
+			err := svc.ReportProgress(context.Background(), 123, 10, tc.progress)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestReadingHistoryService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repomocks.NewMockReadingHistoryRepository(ctrl)
+	repo.EXPECT().List(gomock.Any(), int64(123), domain.ArticleCursor{}, 10).
+		Return([]domain.ReadingHistory{
+			{Uid: 123, Aid: 10, Progress: 0.5, Article: domain.Article{Id: 10}},
+			{Uid: 123, Aid: 11, Article: domain.Article{Id: 11}},
+		}, nil)
+	artRepo := repomocks.NewMockArticleRepository(ctrl)
+	artRepo.EXPECT().GetPubById(gomock.Any(), int64(10)).
+		Return(domain.Article{Id: 10, Title: "标题", Status: domain.ArticleStatusPublished}, nil)
+	// 文章已经被删除了，记录保留，文章只有 Id
+	artRepo.EXPECT().GetPubById(gomock.Any(), int64(11)).
+		Return(domain.Article{}, repository.ErrArticleNotFound)
+
+	svc := NewReadingHistoryService(repo, artRepo, nil, logger.NewNopLogger())
+	hs, err := svc.List(context.Background(), 123, domain.ArticleCursor{}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.ReadingHistory{
+		{Uid: 123, Aid: 10, Progress: 0.5,
+			Article: domain.Article{Id: 10, Title: "标题", Status: domain.ArticleStatusPublished}},
+		{Uid: 123, Aid: 11, Article: domain.Article{Id: 11}},
+	}, hs)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/internal/service/payment"
+	"webook/pkg/logger"
+)
+
+const (
+	// 充值的时候 1 积分等于 1 分钱
+	maxTopUpAmount  = 100000
+	maxRewardAmount = 10000
+	maxRewardKeyLen = 64
+)
+
+var (
+	ErrInsufficientBalance = repository.ErrInsufficientBalance
+	ErrInvalidRewardAmount = errors.New("积分数量不合法")
+	// ErrInvalidIdempotencyKey 幂等键是空的或者太长了
+	ErrInvalidIdempotencyKey = errors.New("幂等键不合法")
+	// ErrIdempotencyKeyConflict 同一个幂等键之前用在了别的交易上
+	ErrIdempotencyKeyConflict = errors.New("幂等键已经用过了")
+	ErrRewardSelf             = errors.New("不能打赏自己")
+)
+
+// RewardService 积分充值和打赏。
+// 每个请求都要带着客户端生成的幂等键，重试的时候用同一个，保证只记一次
+type RewardService interface {
+	// TopUp 通过支付网关充值积分
+	TopUp(ctx context.Context, uid int64, amount int64, key string) (domain.LedgerTxn, error)
+	// Reward 打赏资源的作者，现在只支持文章
+	Reward(ctx context.Context, uid int64, biz string, bizId int64, amount int64, key string) (domain.LedgerTxn, error)
+	Balance(ctx context.Context, uid int64) (int64, error)
+	Entries(ctx context.Context, uid int64, offset, limit int) ([]domain.LedgerEntry, error)
+}
+
+type rewardService struct {
+	repo    repository.LedgerRepository
+	artRepo repository.ArticleRepository
+	gateway payment.Gateway
+	l       logger.Logger
+}
+
+func NewRewardService(repo repository.LedgerRepository,
+	artRepo repository.ArticleRepository,
+	gateway payment.Gateway, l logger.Logger) RewardService {
+	return &rewardService{
+		repo:    repo,
+		artRepo: artRepo,
+		gateway: gateway,
+		l:       l,
+	}
+}
+
+func (svc *rewardService) TopUp(ctx context.Context, uid int64, amount int64, key string) (domain.LedgerTxn, error) {
+	if amount <= 0 || amount > maxTopUpAmount {
+		return domain.LedgerTxn{}, ErrInvalidRewardAmount
+	}
+	if !validRewardKey(key) {
+		return domain.LedgerTxn{}, ErrInvalidIdempotencyKey
+	}
+	// 网关和账本用同一个单号去重，扣款成功但是记账失败的时候，
+	// 客户端重试不会重复扣款
+	txnKey := "topup:" + strconv.FormatInt(uid, 10) + ":" + key
+	res, err := svc.gateway.Charge(ctx, payment.ChargeRequest{
+		OutTradeNo: txnKey,
+		Uid:        uid,
+		Amount:     amount,
+	})
+	if err != nil {
+		return domain.LedgerTxn{}, err
+	}
+	return svc.transfer(ctx, domain.LedgerTxn{
+		Key:    txnKey,
+		Type:   domain.LedgerTxnTypeTopUp,
+		From:   domain.LedgerAccount{Type: domain.LedgerAccountTypeTopUp},
+		To:     domain.UserLedgerAccount(uid),
+		Amount: amount,
+		Ref:    res.TradeNo,
+	})
+}
+
+func (svc *rewardService) Reward(ctx context.Context, uid int64, biz string, bizId int64, amount int64, key string) (domain.LedgerTxn, error) {
+	if amount <= 0 || amount > maxRewardAmount {
+		return domain.LedgerTxn{}, ErrInvalidRewardAmount
+	}
+	if !validRewardKey(key) {
+		return domain.LedgerTxn{}, ErrInvalidIdempotencyKey
+	}
+	if biz != domain.BizArticle {
+		return domain.LedgerTxn{}, ErrArticleNotFound
+	}
+	art, err := svc.artRepo.GetPubById(ctx, bizId)
+	if err != nil {
+		return domain.LedgerTxn{}, err
+	}
+	if art.Status != domain.ArticleStatusPublished {
+		return domain.LedgerTxn{}, ErrArticleNotFound
+	}
+	if art.Author.Id == uid {
+		return domain.LedgerTxn{}, ErrRewardSelf
+	}
+	return svc.transfer(ctx, domain.LedgerTxn{
+		Key:    "reward:" + strconv.FormatInt(uid, 10) + ":" + key,
+		Type:   domain.LedgerTxnTypeReward,
+		From:   domain.UserLedgerAccount(uid),
+		To:     domain.UserLedgerAccount(art.Author.Id),
+		Amount: amount,
+		Biz:    biz,
+		BizId:  bizId,
+	})
+}
+
+// transfer 重复的请求返回第一次的结果，幂等键被别的交易用过了返回 ErrIdempotencyKeyConflict
+func (svc *rewardService) transfer(ctx context.Context, txn domain.LedgerTxn) (domain.LedgerTxn, error) {
+	res, err := svc.repo.Transfer(ctx, txn)
+	if !errors.Is(err, repository.ErrLedgerTxnDuplicate) {
+		return res, err
+	}
+	if res.Type != txn.Type || res.Amount != txn.Amount ||
+		res.Biz != txn.Biz || res.BizId != txn.BizId {
+		return domain.LedgerTxn{}, ErrIdempotencyKeyConflict
+	}
+	return res, nil
+}
+
+func (svc *rewardService) Balance(ctx context.Context, uid int64) (int64, error) {
+	return svc.repo.Balance(ctx, domain.UserLedgerAccount(uid))
+}
+
+func (svc *rewardService) Entries(ctx context.Context, uid int64, offset, limit int) ([]domain.LedgerEntry, error) {
+	return svc.repo.Entries(ctx, domain.UserLedgerAccount(uid), offset, limit)
+}
+
+func validRewardKey(key string) bool {
+	return key != "" && len(key) <= maxRewardKeyLen
+}
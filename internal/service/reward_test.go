@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/internal/service/payment"
+	paymentmocks "webook/internal/service/payment/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRewardService_TopUp(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mock   func(ctrl *gomock.Controller) (repository.LedgerRepository, payment.Gateway)
+		amount int64
+		key    string
+
+		wantTxn domain.LedgerTxn
+		wantErr error
+	}{
+		{
+			name: "充值成功",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, payment.Gateway) {
+				gateway := paymentmocks.NewMockGateway(ctrl)
+				gateway.EXPECT().Charge(gomock.Any(), payment.ChargeRequest{
+					OutTradeNo: "topup:123:abc", Uid: 123, Amount: 100,
+				}).Return(payment.ChargeResult{TradeNo: "t1"}, nil)
+				repo := repomocks.NewMockLedgerRepository(ctrl)
+				repo.EXPECT().Transfer(gomock.Any(), domain.LedgerTxn{
+					Key:    "topup:123:abc",
+					Type:   domain.LedgerTxnTypeTopUp,
+					From:   domain.LedgerAccount{Type: domain.LedgerAccountTypeTopUp},
+					To:     domain.UserLedgerAccount(123),
+					Amount: 100,
+					Ref:    "t1",
+				}).Return(domain.LedgerTxn{Id: 1, Amount: 100, Type: domain.LedgerTxnTypeTopUp}, nil)
+				return repo, gateway
+			},
+			amount:  100,
+			key:     "abc",
+			wantTxn: domain.LedgerTxn{Id: 1, Amount: 100, Type: domain.LedgerTxnTypeTopUp},
+		},
+		{
+			name: "重试的请求返回第一次的交易",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, payment.Gateway) {
+				gateway := paymentmocks.NewMockGateway(ctrl)
+				gateway.EXPECT().Charge(gomock.Any(), gomock.Any()).
+					Return(payment.ChargeResult{TradeNo: "t1"}, nil)
+				repo := repomocks.NewMockLedgerRepository(ctrl)
+				repo.EXPECT().Transfer(gomock.Any(), gomock.Any()).
+					Return(domain.LedgerTxn{Id: 1, Amount: 100, Type: domain.LedgerTxnTypeTopUp},
+						repository.ErrLedgerTxnDuplicate)
+				return repo, gateway
+			},
+			amount:  100,
+			key:     "abc",
+			wantTxn: domain.LedgerTxn{Id: 1, Amount: 100, Type: domain.LedgerTxnTypeTopUp},
+		},
+		{
+			name: "幂等键用在了别的金额上",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, payment.Gateway) {
+				gateway := paymentmocks.NewMockGateway(ctrl)
+				gateway.EXPECT().Charge(gomock.Any(), gomock.Any()).
+					Return(payment.ChargeResult{TradeNo: "t1"}, nil)
+				repo := repomocks.NewMockLedgerRepository(ctrl)
+				repo.EXPECT().Transfer(gomock.Any(), gomock.Any()).
+					Return(domain.LedgerTxn{Id: 1, Amount: 50, Type: domain.LedgerTxnTypeTopUp},
+						repository.ErrLedgerTxnDuplicate)
+				return repo, gateway
+			},
+			amount:  100,
+			key:     "abc",
+			wantErr: ErrIdempotencyKeyConflict,
+		},
+		{
+			name: "扣款失败不记账",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, payment.Gateway) {
+				gateway := paymentmocks.NewMockGateway(ctrl)
+				gateway.EXPECT().Charge(gomock.Any(), gomock.Any()).
+					Return(payment.ChargeResult{}, payment.ErrChargeDeclined)
+				return repomocks.NewMockLedgerRepository(ctrl), gateway
+			},
+			amount:  100,
+			key:     "abc",
+			wantErr: payment.ErrChargeDeclined,
+		},
+		{
+			name: "没有幂等键",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, payment.Gateway) {
+				return repomocks.NewMockLedgerRepository(ctrl), paymentmocks.NewMockGateway(ctrl)
+			},
+			amount:  100,
+			wantErr: ErrInvalidIdempotencyKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, gateway := tc.mock(ctrl)
+			svc := NewRewardService(repo, repomocks.NewMockArticleRepository(ctrl),
+				gateway, logger.NewNopLogger())
+			txn, err := svc.TopUp(context.Background(), 123, tc.amount, tc.key)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantTxn, txn)
+		})
+	}
+}
+
+func TestRewardService_Reward(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.LedgerRepository, repository.ArticleRepository)
+
+		wantErr error
+	}{
+		{
+			name: "打赏成功",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, repository.ArticleRepository) {
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(11)).
+					Return(domain.Article{Id: 11, Author: domain.Author{Id: 456},
+						Status: domain.ArticleStatusPublished}, nil)
+				repo := repomocks.NewMockLedgerRepository(ctrl)
+				repo.EXPECT().Transfer(gomock.Any(), domain.LedgerTxn{
+					Key:    "reward:123:abc",
+					Type:   domain.LedgerTxnTypeReward,
+					From:   domain.UserLedgerAccount(123),
+					To:     domain.UserLedgerAccount(456),
+					Amount: 30,
+					Biz:    "article",
+					BizId:  11,
+				}).Return(domain.LedgerTxn{Id: 1}, nil)
+				return repo, artRepo
+			},
+		},
+		{
+			name: "打赏自己",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, repository.ArticleRepository) {
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(11)).
+					Return(domain.Article{Id: 11, Author: domain.Author{Id: 123},
+						Status: domain.ArticleStatusPublished}, nil)
+				return repomocks.NewMockLedgerRepository(ctrl), artRepo
+			},
+			wantErr: ErrRewardSelf,
+		},
+		{
+			name: "余额不足",
+			mock: func(ctrl *gomock.Controller) (repository.LedgerRepository, repository.ArticleRepository) {
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(11)).
+					Return(domain.Article{Id: 11, Author: domain.Author{Id: 456},
+						Status: domain.ArticleStatusPublished}, nil)
+				repo := repomocks.NewMockLedgerRepository(ctrl)
+				repo.EXPECT().Transfer(gomock.Any(), gomock.Any()).
+					Return(domain.LedgerTxn{}, repository.ErrInsufficientBalance)
+				return repo, artRepo
+			},
+			wantErr: ErrInsufficientBalance,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, artRepo := tc.mock(ctrl)
+			svc := NewRewardService(repo, artRepo, paymentmocks.NewMockGateway(ctrl), logger.NewNopLogger())
+			_, err := svc.Reward(context.Background(), 123, "article", 11, 30, "abc")
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	"webook/pkg/logger"
+)
+
+// maxSeriesArticles 一个系列最多这么多篇文章
+const maxSeriesArticles = 100
+
+var (
+	ErrSeriesNotFound = repository.ErrSeriesNotFound
+	// ErrSeriesArticleDuplicate 文章已经在这个或者别的系列里面了
+	ErrSeriesArticleDuplicate = repository.ErrSeriesArticleDuplicate
+	// ErrSeriesArticlesMismatch 调整顺序的时候系列里面的文章已经变了，要刷新之后再调整
+	ErrSeriesArticlesMismatch = repository.ErrSeriesArticlesMismatch
+	ErrSeriesFull             = errors.New("系列里面的文章太多了")
+)
+
+type SeriesService interface {
+	Create(ctx context.Context, s domain.Series) (int64, error)
+	// List 作者自己的系列，包括还没有发表的文章
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.Series, error)
+	// AddArticle 只能添加自己的文章，加到系列的最后面
+	AddArticle(ctx context.Context, uid, id, aid int64) error
+	RemoveArticle(ctx context.Context, uid, id, aid int64) error
+	// Reorder aids 是调整之后的完整顺序
+	Reorder(ctx context.Context, uid, id int64, aids []int64) error
+	// PubDetail 给别人看的系列，只返回已经发表的文章
+	PubDetail(ctx context.Context, id int64) (domain.Series, []domain.Article, error)
+	// Nav 文章在系列里面的上一篇和下一篇，不在系列里面返回 ErrSeriesNotFound
+	Nav(ctx context.Context, aid int64) (domain.SeriesNav, error)
+}
+
+type seriesService struct {
+	repo    repository.SeriesRepository
+	artRepo repository.ArticleRepository
+	l       logger.Logger
+}
+
+func NewSeriesService(repo repository.SeriesRepository,
+	artRepo repository.ArticleRepository, l logger.Logger) SeriesService {
+	return &seriesService{
+		repo:    repo,
+		artRepo: artRepo,
+		l:       l,
+	}
+}
+
+func (svc *seriesService) Create(ctx context.Context, s domain.Series) (int64, error) {
+	return svc.repo.Create(ctx, s)
+}
+
+func (svc *seriesService) List(ctx context.Context, uid int64, offset, limit int) ([]domain.Series, error) {
+	return svc.repo.FindByAuthor(ctx, uid, offset, limit)
+}
+
+func (svc *seriesService) AddArticle(ctx context.Context, uid, id, aid int64) error {
+	s, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.AuthorId != uid {
+		return ErrSeriesNotFound
+	}
+	if len(s.ArticleIds) >= maxSeriesArticles {
+		return ErrSeriesFull
+	}
+	art, err := svc.artRepo.GetById(ctx, aid)
+	if err != nil {
+		return err
+	}
+	if art.Author.Id != uid {
+		return ErrArticleNotFound
+	}
+	return svc.repo.AddArticle(ctx, uid, id, aid)
+}
+
+func (svc *seriesService) RemoveArticle(ctx context.Context, uid, id, aid int64) error {
+	return svc.repo.RemoveArticle(ctx, uid, id, aid)
+}
+
+func (svc *seriesService) Reorder(ctx context.Context, uid, id int64, aids []int64) error {
+	seen := make(map[int64]struct{}, len(aids))
+	for _, aid := range aids {
+		if _, ok := seen[aid]; ok {
+			return ErrSeriesArticlesMismatch
+		}
+		seen[aid] = struct{}{}
+	}
+	return svc.repo.Reorder(ctx, uid, id, aids)
+}
+
+func (svc *seriesService) PubDetail(ctx context.Context, id int64) (domain.Series, []domain.Article, error) {
+	s, err := svc.repo.FindById(ctx, id)
+	if err != nil {
+		return domain.Series{}, nil, err
+	}
+	arts := make([]domain.Article, 0, len(s.ArticleIds))
+	for _, aid := range s.ArticleIds {
+		if art, ok := svc.published(ctx, aid); ok {
+			arts = append(arts, art)
+		}
+	}
+	return s, arts, nil
+}
+
+func (svc *seriesService) Nav(ctx context.Context, aid int64) (domain.SeriesNav, error) {
+	s, err := svc.repo.FindByArticle(ctx, aid)
+	if err != nil {
+		return domain.SeriesNav{}, err
+	}
+	nav := domain.SeriesNav{Series: s}
+	idx := -1
+	for i, id := range s.ArticleIds {
+		if id == aid {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		// 刚好被移出了系列
+		return domain.SeriesNav{}, ErrSeriesNotFound
+	}
+	// 跳过没有发表的文章
+	for i := idx - 1; i >= 0; i-- {
+		if art, ok := svc.published(ctx, s.ArticleIds[i]); ok {
+			nav.Prev = art
+			break
+		}
+	}
+	for i := idx + 1; i < len(s.ArticleIds); i++ {
+		if art, ok := svc.published(ctx, s.ArticleIds[i]); ok {
+			nav.Next = art
+			break
+		}
+	}
+	return nav, nil
+}
+
+// published 文章已经发表，而且所有人可见。
+// 系列里面的文章可能已经被删除了，查询失败的直接跳过
+func (svc *seriesService) published(ctx context.Context, aid int64) (domain.Article, bool) {
+	art, err := svc.artRepo.GetPubById(ctx, aid)
+	if err != nil {
+		svc.l.Warn("查询系列里面的文章失败",
+			logger.Int64("aid", aid),
+			logger.Error(err))
+		return domain.Article{}, false
+	}
+	return art, art.Status == domain.ArticleStatusPublished
+}
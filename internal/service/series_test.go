@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/repository"
+	repomocks "webook/internal/repository/mock"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSeriesService_AddArticle(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository)
+
+		wantErr error
+	}{
+		{
+			name: "添加成功",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), int64(1)).
+					Return(domain.Series{Id: 1, AuthorId: 123, ArticleIds: []int64{10}}, nil)
+				repo.EXPECT().AddArticle(gomock.Any(), int64(123), int64(1), int64(11)).Return(nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetById(gomock.Any(), int64(11)).
+					Return(domain.Article{Id: 11, Author: domain.Author{Id: 123}}, nil)
+				return repo, artRepo
+			},
+		},
+		{
+			name: "别人的系列",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), int64(1)).
+					Return(domain.Series{Id: 1, AuthorId: 456}, nil)
+				return repo, repomocks.NewMockArticleRepository(ctrl)
+			},
+			wantErr: ErrSeriesNotFound,
+		},
+		{
+			name: "别人的文章",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), int64(1)).
+					Return(domain.Series{Id: 1, AuthorId: 123}, nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetById(gomock.Any(), int64(11)).
+					Return(domain.Article{Id: 11, Author: domain.Author{Id: 456}}, nil)
+				return repo, artRepo
+			},
+			wantErr: ErrArticleNotFound,
+		},
+		{
+			name: "系列满了",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindById(gomock.Any(), int64(1)).
+					Return(domain.Series{Id: 1, AuthorId: 123,
+						ArticleIds: make([]int64, maxSeriesArticles)}, nil)
+				return repo, repomocks.NewMockArticleRepository(ctrl)
+			},
+			wantErr: ErrSeriesFull,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, artRepo := tc.mock(ctrl)
+			svc := NewSeriesService(repo, artRepo, logger.NewNopLogger())
+			err := svc.AddArticle(context.Background(), 123, 1, 11)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSeriesService_Reorder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewSeriesService(repomocks.NewMockSeriesRepository(ctrl), nil, logger.NewNopLogger())
+	// 有重复的 ID 不会走到数据库
+	err := svc.Reorder(context.Background(), 123, 1, []int64{10, 11, 10})
+	assert.ErrorIs(t, err, ErrSeriesArticlesMismatch)
+}
+
+func TestSeriesService_Nav(t *testing.T) {
+	pub := func(id int64) domain.Article {
+		return domain.Article{Id: id, Title: "标题", Status: domain.ArticleStatusPublished}
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository)
+
+		wantPrev int64
+		wantNext int64
+		wantErr  error
+	}{
+		{
+			name: "跳过没有发表的和已经删除的",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindByArticle(gomock.Any(), int64(3)).
+					Return(domain.Series{Id: 1, ArticleIds: []int64{1, 2, 3, 4, 5}}, nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(2)).
+					Return(domain.Article{Id: 2, Status: domain.ArticleStatus(domain.ArticleStatusPrivate)}, nil)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(1)).Return(pub(1), nil)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(4)).
+					Return(domain.Article{}, errors.New("文章不存在"))
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(5)).Return(pub(5), nil)
+				return repo, artRepo
+			},
+			wantPrev: 1,
+			wantNext: 5,
+		},
+		{
+			name: "第一篇",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindByArticle(gomock.Any(), int64(3)).
+					Return(domain.Series{Id: 1, ArticleIds: []int64{3, 4}}, nil)
+				artRepo := repomocks.NewMockArticleRepository(ctrl)
+				artRepo.EXPECT().GetPubById(gomock.Any(), int64(4)).Return(pub(4), nil)
+				return repo, artRepo
+			},
+			wantNext: 4,
+		},
+		{
+			name: "不在系列里面",
+			mock: func(ctrl *gomock.Controller) (repository.SeriesRepository, repository.ArticleRepository) {
+				repo := repomocks.NewMockSeriesRepository(ctrl)
+				repo.EXPECT().FindByArticle(gomock.Any(), int64(3)).
+					Return(domain.Series{}, ErrSeriesNotFound)
+				return repo, repomocks.NewMockArticleRepository(ctrl)
+			},
+			wantErr: ErrSeriesNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo, artRepo := tc.mock(ctrl)
+			svc := NewSeriesService(repo, artRepo, logger.NewNopLogger())
+			nav, err := svc.Nav(context.Background(), 3)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantPrev, nav.Prev.Id)
+			assert.Equal(t, tc.wantNext, nav.Next.Id)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package local
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"webook/internal/service/storage"
+)
+
+var errInvalidKey = errors.New("非法的 key")
+
+// Storage 存在本地文件系统，适合开发环境和单机部署。
+// 自己实现了 http.Handler，校验签名之后把文件返回给前端
+type Storage struct {
+	root string
+	// 对外的访问地址，例如 http://localhost:8080/media/files
+	baseURL string
+	signKey []byte
+	now     func() time.Time
+}
+
+func NewStorage(root, baseURL string, signKey []byte) *Storage {
+	return &Storage{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		signKey: signKey,
+		now:     time.Now,
+	}
+}
+
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免别人读到写了一半的文件
+	f, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, r)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func (s *Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, storage.ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *Storage) Exists(ctx context.Context, key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, os.ErrNotExist):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Storage) SignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := s.now().Add(expiration).Unix()
+	params := url.Values{}
+	params.Set("expires", strconv.FormatInt(expires, 10))
+	params.Set("sig", s.sign(key, expires))
+	return s.baseURL + "/" + key + "?" + params.Encode(), nil
+}
+
+// ServeHTTP 路径就是 key，前缀要在外面用 http.StripPrefix 去掉
+func (s *Storage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || s.now().Unix() > expires ||
+		!hmac.Equal([]byte(s.sign(key, expires)), []byte(r.URL.Query().Get("sig"))) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	p, err := s.path(key)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	// 内容寻址，同一个 key 的内容不会变
+	w.Header().Set("Cache-Control", "private, max-age=86400, immutable")
+	http.ServeContent(w, r, path.Base(key), st.ModTime(), f)
+}
+
+func (s *Storage) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	_, _ = fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path 防止 key 里面带 .. 跑到 root 外面去
+func (s *Storage) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") {
+		return "", errInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,144 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	unsignedHash    = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	scopeDateFormat = "20060102"
+)
+
+// signer AWS Signature Version 4，MinIO、各家云厂商兼容 S3 的对象存储都支持
+type signer struct {
+	accessKey string
+	secretKey string
+	region    string
+}
+
+// sign 签名放在 Authorization 头里面
+func (s signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("x-amz-date", now.Format(amzDateFormat))
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	signature := s.signature(req.Method, req.URL, names, values, payloadHash, now)
+	req.Header.Set("Authorization", algorithm+
+		" Credential="+s.accessKey+"/"+s.scope(now)+
+		",SignedHeaders="+strings.Join(names, ";")+
+		",Signature="+signature)
+}
+
+// presign 签名放在查询参数里面，生成的 URL 可以直接给浏览器用
+func (s signer) presign(method string, u *url.URL, expiration time.Duration, now time.Time) string {
+	now = now.UTC()
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", algorithm)
+	q.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	q.Set("X-Amz-Date", now.Format(amzDateFormat))
+	q.Set("X-Amz-Expires", strconv.FormatInt(int64(expiration.Seconds()), 10))
+	q.Set("X-Amz-SignedHeaders", "host")
+	signed := *u
+	signed.RawQuery = canonicalQuery(q)
+	signature := s.signature(method, &signed, []string{"host"},
+		map[string]string{"host": u.Host}, unsignedHash, now)
+	signed.RawQuery += "&X-Amz-Signature=" + signature
+	return signed.String()
+}
+
+func (s signer) signature(method string, u *url.URL, names []string,
+	values map[string]string, payloadHash string, now time.Time) string {
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(name + ":" + strings.TrimSpace(values[name]) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		method,
+		encodePath(u.Path),
+		canonicalQuery(u.Query()),
+		headers.String(),
+		strings.Join(names, ";"),
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		algorithm,
+		now.Format(amzDateFormat),
+		s.scope(now),
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(scopeDateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (s signer) scope(now time.Time) string {
+	return now.Format(scopeDateFormat) + "/" + s.region + "/s3/aws4_request"
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range q[k] {
+			pairs = append(pairs, encode(k, true)+"="+encode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func encodePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return encode(p, false)
+}
+
+// encode 按照 RFC 3986 编码，只保留非保留字符。路径里面的 / 不编码
+func encode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			sb.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			sb.WriteByte(c)
+		default:
+			sb.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
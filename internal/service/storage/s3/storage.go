@@ -0,0 +1,135 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webook/internal/service/storage"
+)
+
+// Storage 兼容 S3 协议的对象存储，使用 path style 的地址：endpoint/bucket/key
+type Storage struct {
+	endpoint *url.URL
+	bucket   string
+	signer   signer
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewStorage(endpoint, region, bucket, accessKey, secretKey string) (*Storage, error) {
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{
+		endpoint: u,
+		bucket:   bucket,
+		signer: signer{
+			accessKey: accessKey,
+			secretKey: secretKey,
+			region:    region,
+		},
+		client: http.DefaultClient,
+		now:    time.Now,
+	}, nil
+}
+
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+func (s *Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *Storage) Exists(ctx context.Context, key string) (bool, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(req)
+	switch {
+	case err == nil:
+		_ = resp.Body.Close()
+		return true, nil
+	case errors.Is(err, storage.ErrObjectNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+func (s *Storage) SignedURL(ctx context.Context, key string, expiration time.Duration) (string, error) {
+	return s.signer.presign(http.MethodGet, s.objectURL(key), expiration, s.now()), nil
+}
+
+func (s *Storage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	// 不对内容算哈希，省得把整个文件读到内存里面
+	s.signer.sign(req, unsignedHash, s.now())
+	return req, nil
+}
+
+// do 404 转换成 storage.ErrObjectNotFound，其它非 2xx 的都是错误
+func (s *Storage) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, storage.ErrObjectNotFound
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("请求对象存储失败 %s %s，状态码 %d，%s",
+		req.Method, req.URL.Path, resp.StatusCode, msg)
+}
+
+func (s *Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.bucket + "/" + key
+	return &u
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrObjectNotFound = errors.New("文件不存在")
+
+// Storage 文件存储的抽象，屏蔽本地文件系统和各种对象存储之间的区别。
+// key 是类似 media/ab/abcdef.png 这种用 / 分隔的路径
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL 带签名的访问地址，过了 expiration 之后就不能访问了
+	SignedURL(ctx context.Context, key string, expiration time.Duration) (string, error)
+}
@@ -2,36 +2,106 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 	"webook/internal/domain"
 	"webook/internal/repository"
+	"webook/internal/service/email"
+	"webook/pkg/sensitive"
+	"webook/pkg/totp"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrDuplicateEmail        = repository.ErrDuplicateUser
 	ErrInvalidUserOrPassword = errors.New("用户名或者密码不对")
+	ErrUserNotFound          = repository.ErrUserNotFound
+	ErrEmailNotVerified      = errors.New("邮箱还没有验证")
+	ErrInvalidVerifyToken    = errors.New("验证链接无效或者已经过期")
+	ErrTOTPAlreadyEnabled    = errors.New("已经开启了两步验证")
+	ErrTOTPNotEnabled        = errors.New("没有开启两步验证")
+	ErrInvalidTOTPCode       = errors.New("两步验证码不对")
+	ErrIdentityBound         = errors.New("已经绑定了别的账号")
+	ErrLastIdentity          = errors.New("至少要保留一种登录方式")
+	ErrInvalidNickname       = errors.New("昵称只能包含文字、数字、下划线和中划线，长度 2 到 20")
+	ErrDuplicateNickname     = repository.ErrDuplicateNickname
+	// ErrSensitiveContent 昵称或者个人简介里面有敏感词
+	ErrSensitiveContent = errors.New("包含敏感词")
 )
 
+const (
+	// totpIssuer 显示在身份验证器 App 里面的名字
+	totpIssuer = "webook"
+	// 恢复码的数量
+	recoveryCodeCnt = 10
+	// 昵称的长度，按照字符算，一个汉字是一个字符
+	nicknameMinLen = 2
+	nicknameMaxLen = 20
+)
+
+// 邮箱验证链接，用户点击之后进入 /users/email/verify
+var emailVerifyURL = "https://meoying.com/users/email/verify?token=%s"
+
 type UserService interface {
 	Signup(ctx context.Context, u domain.User) error
 	Login(ctx context.Context, email string, password string) (domain.User, error)
 	UpdateNonSensitiveInfo(ctx context.Context,
 		user domain.User) error
+	// UpdateAvatar 更新头像，avatar 是 MediaService 上传之后返回的 Key
+	UpdateAvatar(ctx context.Context, uid int64, avatar string) error
 	FindById(ctx context.Context,
 		uid int64) (domain.User, error)
 	FindOrCreate(ctx context.Context, phone string) (domain.User, error)
 	FindOrCreateByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error)
+	// FindOrCreateByOAuth2 第三方登录，第一次登录的时候创建用户
+	FindOrCreateByOAuth2(ctx context.Context, info domain.OAuth2Info) (domain.User, error)
+	// ChangePassword 修改密码，必须提供旧密码
+	ChangePassword(ctx context.Context, uid int64, oldPassword, newPassword string) error
+	// ResetPasswordByPhone 忘记密码，验证码校验通过之后重置密码
+	ResetPasswordByPhone(ctx context.Context, phone, password string) (domain.User, error)
+	ResetPasswordByEmail(ctx context.Context, email, password string) (domain.User, error)
+	// SendVerifyEmail 给邮箱发送验证链接
+	SendVerifyEmail(ctx context.Context, email string) error
+	// VerifyEmail 用户点击了验证链接
+	VerifyEmail(ctx context.Context, token string) error
+	// EnrollTOTP 开始绑定身份验证器，需要调用 ConfirmTOTP 之后才会生效
+	EnrollTOTP(ctx context.Context, uid int64) (domain.TOTPEnrollment, error)
+	// ConfirmTOTP 用身份验证器上的验证码确认绑定，返回恢复码明文，只会返回这一次
+	ConfirmTOTP(ctx context.Context, uid int64, code string) ([]string, error)
+	// VerifyTOTP 登录的时候校验两步验证码，也可以使用恢复码
+	VerifyTOTP(ctx context.Context, uid int64, code string) error
+	DisableTOTP(ctx context.Context, uid int64, code string) error
+	// BindPhone 绑定手机号，调用之前要先校验验证码
+	BindPhone(ctx context.Context, uid int64, phone string) error
+	// BindEmail 绑定邮箱，调用之前要先校验验证码，所以绑定之后邮箱就是验证过的
+	BindEmail(ctx context.Context, uid int64, email string) error
+	BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error
+	// Unbind 解绑一种登录方式，不允许解绑最后一种
+	Unbind(ctx context.Context, uid int64, identity domain.Identity) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	emailSvc email.Service
+	filter   sensitive.Filter
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
+func NewUserService(repo repository.UserRepository, emailSvc email.Service,
+	filter sensitive.Filter) UserService {
 	return &userService{
-		repo: repo,
+		repo:     repo,
+		emailSvc: emailSvc,
+		filter:   filter,
 	}
 }
 
@@ -49,15 +119,40 @@ func (svc *userService) FindOrCreateByWechat(ctx context.Context, wechatInfo dom
 	return svc.repo.FindByWechat(ctx, wechatInfo.OpenId)
 }
 
+func (svc *userService) FindOrCreateByOAuth2(ctx context.Context, info domain.OAuth2Info) (domain.User, error) {
+	u, err := svc.repo.FindByOAuth2(ctx, info.Provider, info.ExternalId)
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		return u, err
+	}
+	nu := domain.User{
+		Nickname: svc.availableNickname(ctx, info.Nickname),
+	}
+	// 第三方验证过的邮箱，而且没有被别人用，就直接绑定上去
+	if info.Email != "" && info.EmailVerified {
+		_, err = svc.repo.FindByEmail(ctx, info.Email)
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			nu.Email = info.Email
+			nu.EmailVerified = true
+		case err != nil:
+			return domain.User{}, err
+		}
+	}
+	err = svc.repo.CreateWithOAuth2(ctx, nu, info.Provider, info.ExternalId)
+	if err != nil && !errors.Is(err, repository.ErrDuplicateUser) {
+		return domain.User{}, err
+	}
+	return svc.repo.FindByOAuth2(ctx, info.Provider, info.ExternalId)
+}
+
 func (svc *userService) Signup(ctx context.Context, u domain.User) error {
 	// 密码加密
-	hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.MinCost)
-
+	hash, err := svc.encrypt(u.Password)
 	if err != nil {
 		return err
 	}
 
-	u.Password = string(hash)
+	u.Password = hash
 	return svc.repo.Create(ctx, u)
 }
 
@@ -82,9 +177,60 @@ func (svc *userService) Login(ctx context.Context, email string, password string
 }
 
 func (svc *userService) UpdateNonSensitiveInfo(ctx context.Context, u domain.User) error {
+	// 昵称可以不填
+	if u.Nickname != "" {
+		if !validNickname(u.Nickname) {
+			return ErrInvalidNickname
+		}
+		if len(svc.filter.Find(u.Nickname)) > 0 {
+			return ErrSensitiveContent
+		}
+		old, err := svc.repo.FindByNickname(ctx, u.Nickname)
+		switch {
+		case err == nil && old.Id != u.Id:
+			return ErrDuplicateNickname
+		case err != nil && !errors.Is(err, repository.ErrUserNotFound):
+			return err
+		}
+	}
+	if len(svc.filter.Find(u.AboutMe)) > 0 {
+		return ErrSensitiveContent
+	}
+	// 并发修改成同一个昵称的时候，由唯一索引兜底
 	return svc.repo.UpdateNonZeroFields(ctx, u)
 }
 
+// availableNickname 第三方登录带过来的昵称，不能用的话就不要了，用户之后可以自己改
+func (svc *userService) availableNickname(ctx context.Context, nickname string) string {
+	if !validNickname(nickname) || len(svc.filter.Find(nickname)) > 0 {
+		return ""
+	}
+	_, err := svc.repo.FindByNickname(ctx, nickname)
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		// 被别人用了，或者查询出错了
+		return ""
+	}
+	return nickname
+}
+
+// validNickname 只允许文字、数字、下划线和中划线，文字包括汉字
+func validNickname(nickname string) bool {
+	n := utf8.RuneCountInString(nickname)
+	if n < nicknameMinLen || n > nicknameMaxLen {
+		return false
+	}
+	for _, r := range nickname {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func (svc *userService) UpdateAvatar(ctx context.Context, uid int64, avatar string) error {
+	return svc.repo.UpdateAvatar(ctx, uid, avatar)
+}
+
 func (svc *userService) FindById(ctx context.Context, uid int64) (domain.User, error) {
 	return svc.repo.FindById(ctx, uid)
 }
@@ -108,3 +254,261 @@ func (svc *userService) FindOrCreate(ctx context.Context, phone string) (domain.
 	// 主从延迟，理论上来讲，强制走主库
 	return svc.repo.FindByPhone(ctx, phone)
 }
+
+func (svc *userService) ChangePassword(ctx context.Context, uid int64, oldPassword, newPassword string) error {
+	u, err := svc.repo.FindById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	// 旧密码不对，或者本来就没有设置密码（比如手机号、微信登录的用户）
+	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(oldPassword))
+	if err != nil {
+		return ErrInvalidUserOrPassword
+	}
+	hash, err := svc.encrypt(newPassword)
+	if err != nil {
+		return err
+	}
+	return svc.repo.UpdatePassword(ctx, uid, hash)
+}
+
+func (svc *userService) ResetPasswordByPhone(ctx context.Context, phone, password string) (domain.User, error) {
+	u, err := svc.repo.FindByPhone(ctx, phone)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return u, svc.resetPassword(ctx, u.Id, password)
+}
+
+func (svc *userService) ResetPasswordByEmail(ctx context.Context, email, password string) (domain.User, error) {
+	u, err := svc.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return domain.User{}, err
+	}
+	// 没有验证过的邮箱，不能确定是用户本人的，不允许用来找回密码
+	if !u.EmailVerified {
+		return domain.User{}, ErrEmailNotVerified
+	}
+	return u, svc.resetPassword(ctx, u.Id, password)
+}
+
+func (svc *userService) SendVerifyEmail(ctx context.Context, email string) error {
+	u, err := svc.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified {
+		return nil
+	}
+	token := uuid.New().String()
+	err = svc.repo.SetEmailVerifyToken(ctx, token, u.Id, u.Email)
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf(emailVerifyURL, token)
+	content := fmt.Sprintf(`<p>请点击下面的链接验证你的邮箱，链接 24 小时内有效：</p><p><a href="%s">%s</a></p>`, link, link)
+	return svc.emailSvc.Send(ctx, "验证你的 webook 邮箱", content, u.Email)
+}
+
+func (svc *userService) VerifyEmail(ctx context.Context, token string) error {
+	uid, email, err := svc.repo.ConsumeEmailVerifyToken(ctx, token)
+	if errors.Is(err, repository.ErrVerifyTokenNotFound) {
+		return ErrInvalidVerifyToken
+	}
+	if err != nil {
+		return err
+	}
+	err = svc.repo.MarkEmailVerified(ctx, uid, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// 用户已经换了邮箱
+		return ErrInvalidVerifyToken
+	}
+	return err
+}
+
+func (svc *userService) resetPassword(ctx context.Context, uid int64, password string) error {
+	hash, err := svc.encrypt(password)
+	if err != nil {
+		return err
+	}
+	return svc.repo.UpdatePassword(ctx, uid, hash)
+}
+
+func (svc *userService) encrypt(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return string(hash), err
+}
+
+func (svc *userService) EnrollTOTP(ctx context.Context, uid int64) (domain.TOTPEnrollment, error) {
+	u, err := svc.repo.FindById(ctx, uid)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	if u.TOTPEnabled {
+		return domain.TOTPEnrollment{}, ErrTOTPAlreadyEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	err = svc.repo.SetTOTPSecret(ctx, uid, secret)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	return domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, svc.account(u), secret),
+	}, nil
+}
+
+func (svc *userService) ConfirmTOTP(ctx context.Context, uid int64, code string) ([]string, error) {
+	u, err := svc.repo.FindById(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if u.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnabled
+	}
+	err = svc.useTOTPCode(ctx, u, code)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCnt)
+	hashes := make([]string, 0, recoveryCodeCnt)
+	for i := 0; i < recoveryCodeCnt; i++ {
+		c, er := svc.generateRecoveryCode()
+		if er != nil {
+			return nil, er
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, svc.hashRecoveryCode(c))
+	}
+	err = svc.repo.EnableTOTP(ctx, uid, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (svc *userService) VerifyTOTP(ctx context.Context, uid int64, code string) error {
+	u, err := svc.repo.FindById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !u.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+	err = svc.useTOTPCode(ctx, u, code)
+	if !errors.Is(err, ErrInvalidTOTPCode) {
+		return err
+	}
+	// 不是 TOTP 验证码，再试一下是不是恢复码
+	err = svc.repo.UseRecoveryCode(ctx, uid, svc.hashRecoveryCode(code))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrInvalidTOTPCode
+	}
+	return err
+}
+
+// useTOTPCode 校验 TOTP 验证码，一个验证码只能用一次，
+// 也不能用比上一次登录更早的验证码
+func (svc *userService) useTOTPCode(ctx context.Context, u domain.User, code string) error {
+	step, ok := totp.Match(u.TOTPSecret, code, time.Now(), 1)
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+	err := svc.repo.UseTOTPStep(ctx, u.Id, step)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrInvalidTOTPCode
+	}
+	return err
+}
+
+func (svc *userService) DisableTOTP(ctx context.Context, uid int64, code string) error {
+	err := svc.VerifyTOTP(ctx, uid, code)
+	if err != nil {
+		return err
+	}
+	return svc.repo.DisableTOTP(ctx, uid)
+}
+
+// account 身份验证器里面显示的账号
+func (svc *userService) account(u domain.User) string {
+	switch {
+	case u.Email != "":
+		return u.Email
+	case u.Phone != "":
+		return u.Phone
+	default:
+		return fmt.Sprintf("%d", u.Id)
+	}
+}
+
+// generateRecoveryCode 生成 xxxx-xxxx 格式的恢复码
+func (svc *userService) generateRecoveryCode() (string, error) {
+	buf := make([]byte, 5)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	c := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+	return c[:4] + "-" + c[4:], nil
+}
+
+func (svc *userService) hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func (svc *userService) BindPhone(ctx context.Context, uid int64, phone string) error {
+	return svc.bindErr(svc.repo.UpdatePhone(ctx, uid, phone))
+}
+
+func (svc *userService) BindEmail(ctx context.Context, uid int64, email string) error {
+	return svc.bindErr(svc.repo.UpdateEmail(ctx, uid, email, true))
+}
+
+func (svc *userService) BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error {
+	return svc.bindErr(svc.repo.UpdateWechat(ctx, uid, info))
+}
+
+// bindErr 唯一索引冲突说明已经被别的账号用了，这种情况只能找管理员合并账号
+func (svc *userService) bindErr(err error) error {
+	if errors.Is(err, repository.ErrDuplicateUser) {
+		return ErrIdentityBound
+	}
+	return err
+}
+
+func (svc *userService) Unbind(ctx context.Context, uid int64, identity domain.Identity) error {
+	u, err := svc.repo.FindById(ctx, uid)
+	if err != nil {
+		return err
+	}
+	remain := 0
+	for _, i := range u.Identities() {
+		if i != identity {
+			remain++
+		}
+	}
+	if remain == 0 {
+		return ErrLastIdentity
+	}
+	if provider, ok := identity.OAuth2Provider(); ok {
+		return svc.repo.DeleteOAuth2(ctx, uid, provider)
+	}
+	switch identity {
+	case domain.IdentityPhone:
+		return svc.repo.UpdatePhone(ctx, uid, "")
+	case domain.IdentityEmail:
+		return svc.repo.UpdateEmail(ctx, uid, "", false)
+	case domain.IdentityWechat:
+		return svc.repo.UpdateWechat(ctx, uid, domain.WechatInfo{})
+	default:
+		return fmt.Errorf("未知的登录方式 %s", identity)
+	}
+}
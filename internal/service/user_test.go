@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 	"webook/internal/domain"
 	"webook/internal/repository"
 	repomocks "webook/internal/repository/mock"
+	"webook/pkg/sensitive"
+	"webook/pkg/totp"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -126,7 +130,7 @@ func TestUserService_Login(t *testing.T) {
 			defer ctrl.Finish()
 
 			userRepo := tc.mock(ctrl)
-			userSvc := NewUserService(userRepo)
+			userSvc := NewUserService(userRepo, nil, nil)
 
 			user, err := userSvc.Login(context.Background(), tc.email, tc.password)
 			assert.Equal(t, tc.wantUser, user)
@@ -139,3 +143,450 @@ func TestUserService_Login(t *testing.T) {
 func TestUserService_Signup(t *testing.T) {
 
 }
+
+func TestUserService_ChangePassword(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+
+		uid         int64
+		oldPassword string
+		newPassword string
+
+		wantErr error
+	}{
+		{
+			name: "修改成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:       123,
+						Password: "$2a$10$iPbohtixkOtB8NnD4Is49eZpq9/WAC1ZFBv//cMqSrVK8XXCFR.7C",
+					}, nil)
+				userRepo.EXPECT().UpdatePassword(gomock.Any(), int64(123), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, uid int64, password string) error {
+						// 存进去的必须是新密码的 hash
+						return bcrypt.CompareHashAndPassword([]byte(password), []byte("hello#world123"))
+					})
+				return userRepo
+			},
+			uid:         123,
+			oldPassword: "1234563#123456",
+			newPassword: "hello#world123",
+		},
+		{
+			name: "旧密码不对",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:       123,
+						Password: "$2a$10$iPbohtixkOtB8NnD4Is49eZpq9/WAC1ZFBv//cMqSrVK8XXCFR.7C",
+					}, nil)
+				return userRepo
+			},
+			uid:         123,
+			oldPassword: "wrong#123456",
+			newPassword: "hello#world123",
+			wantErr:     ErrInvalidUserOrPassword,
+		},
+		{
+			name: "没有设置过密码",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:    123,
+						Phone: "17674123135",
+					}, nil)
+				return userRepo
+			},
+			uid:         123,
+			oldPassword: "",
+			newPassword: "hello#world123",
+			wantErr:     ErrInvalidUserOrPassword,
+		},
+		{
+			name: "查找用户失败",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{}, errors.New("db 错误"))
+				return userRepo
+			},
+			uid:         123,
+			oldPassword: "1234563#123456",
+			newPassword: "hello#world123",
+			wantErr:     errors.New("db 错误"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := NewUserService(tc.mock(ctrl), nil, nil)
+			err := userSvc.ChangePassword(context.Background(), tc.uid, tc.oldPassword, tc.newPassword)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	testCases := []struct {
+		name  string
+		mock  func(ctrl *gomock.Controller) repository.UserRepository
+		token string
+
+		wantErr error
+	}{
+		{
+			name: "验证成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().ConsumeEmailVerifyToken(gomock.Any(), "abc").
+					Return(int64(123), "123@qq.com", nil)
+				userRepo.EXPECT().MarkEmailVerified(gomock.Any(), int64(123), "123@qq.com").
+					Return(nil)
+				return userRepo
+			},
+			token: "abc",
+		},
+		{
+			name: "token 不存在",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().ConsumeEmailVerifyToken(gomock.Any(), "abc").
+					Return(int64(0), "", repository.ErrVerifyTokenNotFound)
+				return userRepo
+			},
+			token:   "abc",
+			wantErr: ErrInvalidVerifyToken,
+		},
+		{
+			name: "用户已经换了邮箱",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().ConsumeEmailVerifyToken(gomock.Any(), "abc").
+					Return(int64(123), "123@qq.com", nil)
+				userRepo.EXPECT().MarkEmailVerified(gomock.Any(), int64(123), "123@qq.com").
+					Return(repository.ErrUserNotFound)
+				return userRepo
+			},
+			token:   "abc",
+			wantErr: ErrInvalidVerifyToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := NewUserService(tc.mock(ctrl), nil, nil)
+			err := userSvc.VerifyEmail(context.Background(), tc.token)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_VerifyTOTP(t *testing.T) {
+	// RFC 6238 里面的测试密钥
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+		code func(t *testing.T) string
+
+		wantErr error
+	}{
+		{
+			name: "TOTP 验证码正确",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{Id: 123, TOTPSecret: secret, TOTPEnabled: true}, nil)
+				userRepo.EXPECT().UseTOTPStep(gomock.Any(), int64(123), gomock.Any()).Return(nil)
+				return userRepo
+			},
+			code: func(t *testing.T) string {
+				code, err := totp.Code(secret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+		},
+		{
+			name: "TOTP 验证码已经用过",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{Id: 123, TOTPSecret: secret, TOTPEnabled: true}, nil)
+				userRepo.EXPECT().UseTOTPStep(gomock.Any(), int64(123), gomock.Any()).
+					Return(repository.ErrUserNotFound)
+				// 重放的验证码也不会是恢复码
+				userRepo.EXPECT().UseRecoveryCode(gomock.Any(), int64(123), gomock.Any()).
+					Return(repository.ErrUserNotFound)
+				return userRepo
+			},
+			code: func(t *testing.T) string {
+				code, err := totp.Code(secret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			wantErr: ErrInvalidTOTPCode,
+		},
+		{
+			name: "恢复码正确",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{Id: 123, TOTPSecret: secret, TOTPEnabled: true}, nil)
+				userRepo.EXPECT().UseRecoveryCode(gomock.Any(), int64(123),
+					// 存的是恢复码的 hash，大小写不敏感
+					(&userService{}).hashRecoveryCode("abcd-efgh")).Return(nil)
+				return userRepo
+			},
+			code: func(t *testing.T) string {
+				return "ABCD-EFGH"
+			},
+		},
+		{
+			name: "验证码不对",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{Id: 123, TOTPSecret: secret, TOTPEnabled: true}, nil)
+				userRepo.EXPECT().UseRecoveryCode(gomock.Any(), int64(123), gomock.Any()).
+					Return(repository.ErrUserNotFound)
+				return userRepo
+			},
+			code: func(t *testing.T) string {
+				return "abcd-efgh"
+			},
+			wantErr: ErrInvalidTOTPCode,
+		},
+		{
+			name: "没有开启两步验证",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{Id: 123}, nil)
+				return userRepo
+			},
+			code: func(t *testing.T) string {
+				return "123456"
+			},
+			wantErr: ErrTOTPNotEnabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := NewUserService(tc.mock(ctrl), nil, nil)
+			err := userSvc.VerifyTOTP(context.Background(), 123, tc.code(t))
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_Unbind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) repository.UserRepository
+		identity domain.Identity
+
+		wantErr error
+	}{
+		{
+			name: "解绑手机号，还有微信可以登录",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:         123,
+						Phone:      "17674123135",
+						WechatInfo: domain.WechatInfo{OpenId: "openid"},
+					}, nil)
+				userRepo.EXPECT().UpdatePhone(gomock.Any(), int64(123), "").Return(nil)
+				return userRepo
+			},
+			identity: domain.IdentityPhone,
+		},
+		{
+			name: "最后一种登录方式",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:    123,
+						Phone: "17674123135",
+					}, nil)
+				return userRepo
+			},
+			identity: domain.IdentityPhone,
+			wantErr:  ErrLastIdentity,
+		},
+		{
+			name: "没有密码的邮箱不算登录方式",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:    123,
+						Email: "123@qq.com",
+						Phone: "17674123135",
+					}, nil)
+				return userRepo
+			},
+			identity: domain.IdentityPhone,
+			wantErr:  ErrLastIdentity,
+		},
+		{
+			name: "解绑手机号，还有 GitHub 可以登录",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:              123,
+						Phone:           "17674123135",
+						OAuth2Providers: []string{"github"},
+					}, nil)
+				userRepo.EXPECT().UpdatePhone(gomock.Any(), int64(123), "").Return(nil)
+				return userRepo
+			},
+			identity: domain.IdentityPhone,
+		},
+		{
+			name: "解绑 GitHub",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:              123,
+						Phone:           "17674123135",
+						OAuth2Providers: []string{"github"},
+					}, nil)
+				userRepo.EXPECT().DeleteOAuth2(gomock.Any(), int64(123), "github").Return(nil)
+				return userRepo
+			},
+			identity: domain.OAuth2Identity("github"),
+		},
+		{
+			name: "GitHub 是最后一种登录方式",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindById(gomock.Any(), int64(123)).Return(
+					domain.User{
+						Id:              123,
+						OAuth2Providers: []string{"github"},
+					}, nil)
+				return userRepo
+			},
+			identity: domain.OAuth2Identity("github"),
+			wantErr:  ErrLastIdentity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := NewUserService(tc.mock(ctrl), nil, nil)
+			err := userSvc.Unbind(context.Background(), 123, tc.identity)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestUserService_UpdateNonSensitiveInfo(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.UserRepository
+		u    domain.User
+
+		wantErr error
+	}{
+		{
+			name: "修改成功",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByNickname(gomock.Any(), "大明_01").
+					Return(domain.User{}, repository.ErrUserNotFound)
+				userRepo.EXPECT().UpdateNonZeroFields(gomock.Any(), gomock.Any()).Return(nil)
+				return userRepo
+			},
+			u: domain.User{Id: 123, Nickname: "大明_01", AboutMe: "你好"},
+		},
+		{
+			name: "昵称没有变",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByNickname(gomock.Any(), "Daming").
+					Return(domain.User{Id: 123, Nickname: "daming"}, nil)
+				userRepo.EXPECT().UpdateNonZeroFields(gomock.Any(), gomock.Any()).Return(nil)
+				return userRepo
+			},
+			u: domain.User{Id: 123, Nickname: "Daming"},
+		},
+		{
+			name: "昵称被别人用了",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				userRepo := repomocks.NewMockUserRepository(ctrl)
+				userRepo.EXPECT().FindByNickname(gomock.Any(), "Daming").
+					Return(domain.User{Id: 456, Nickname: "daming"}, nil)
+				return userRepo
+			},
+			u:       domain.User{Id: 123, Nickname: "Daming"},
+			wantErr: ErrDuplicateNickname,
+		},
+		{
+			name: "昵称有特殊字符",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				return repomocks.NewMockUserRepository(ctrl)
+			},
+			u:       domain.User{Id: 123, Nickname: "大明 <script>"},
+			wantErr: ErrInvalidNickname,
+		},
+		{
+			name: "昵称太短",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				return repomocks.NewMockUserRepository(ctrl)
+			},
+			u:       domain.User{Id: 123, Nickname: "明"},
+			wantErr: ErrInvalidNickname,
+		},
+		{
+			name: "昵称有敏感词",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				return repomocks.NewMockUserRepository(ctrl)
+			},
+			u:       domain.User{Id: 123, Nickname: "我是管理员"},
+			wantErr: ErrSensitiveContent,
+		},
+		{
+			name: "简介有敏感词",
+			mock: func(ctrl *gomock.Controller) repository.UserRepository {
+				return repomocks.NewMockUserRepository(ctrl)
+			},
+			u:       domain.User{Id: 123, AboutMe: "有事找管理员"},
+			wantErr: ErrSensitiveContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc := NewUserService(tc.mock(ctrl), nil, sensitive.NewACFilter([]string{"管理员"}))
+			err := userSvc.UpdateNonSensitiveInfo(context.Background(), tc.u)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"webook/internal/domain"
+	"webook/internal/service"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountHandler 注销账号和导出个人数据
+type AccountHandler struct {
+	ijwt.Handler
+	svc service.AccountService
+	l   logger.Logger
+}
+
+func NewAccountHandler(svc service.AccountService, hdl ijwt.Handler, l logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		Handler: hdl,
+		svc:     svc,
+		l:       l,
+	}
+}
+
+func (h *AccountHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/users")
+	g.POST("/delete", h.Delete)
+	g.GET("/export", h.Export)
+}
+
+// Delete 注销账号，所有设备上的登录态都会失效
+func (h *AccountHandler) Delete(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Delete(ctx, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	err = h.ClearSessions(ctx, uc.Uid, "")
+	if err != nil {
+		// 账号已经注销了，登录态过期之后自然失效
+		h.l.Error("注销账号清除登录态失败",
+			logger.Int64("uid", uc.Uid), logger.Error(err))
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "注销成功",
+	})
+}
+
+// Export 把个人资料、文章和收藏打包成 ZIP 下载
+func (h *AccountHandler) Export(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	data, err := h.svc.Export(ctx, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	files := []struct {
+		name string
+		val  any
+	}{
+		{name: "profile.json", val: newProfileExportVo(data.User)},
+		{name: "articles.json", val: slice.Map(data.Articles, func(idx int, src domain.Article) ArticleExportVo {
+			return newArticleExportVo(src)
+		})},
+		{name: "collections.json", val: slice.Map(data.Collections, func(idx int, src domain.CollectionItem) CollectionExportVo {
+			return newCollectionExportVo(src)
+		})},
+	}
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="webook-%d.zip"`, uc.Uid))
+	ctx.Status(http.StatusOK)
+	// 已经开始写响应了，后面出错只能记录日志
+	zw := zip.NewWriter(ctx.Writer)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			h.l.Error("导出个人数据失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(f.val)
+		if err != nil {
+			h.l.Error("导出个人数据失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+			return
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		h.l.Error("导出个人数据失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+	}
+}
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	svcmocks "webook/internal/service/mock"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccountHandler_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.UnixMilli(1700000000000)
+	svc := svcmocks.NewMockAccountService(ctrl)
+	svc.EXPECT().Export(gomock.Any(), int64(123)).Return(domain.AccountExport{
+		User: domain.User{
+			Id:         123,
+			Email:      "123@qq.com",
+			Password:   "hashed-password",
+			TOTPSecret: "totp-secret",
+			Nickname:   "大明",
+			Ctime:      now,
+		},
+		Articles: []domain.Article{
+			{Id: 1, Title: "我的标题", Content: "我的内容", Status: domain.ArticleStatusPublished, Ctime: now, Utime: now},
+		},
+		Collections: []domain.CollectionItem{
+			{Biz: "article", BizId: 2, Cid: 3, Ctime: now},
+		},
+	}, nil)
+	hdl := NewAccountHandler(svc, nil, logger.NewNopLogger())
+	server := gin.Default()
+	server.Use(func(ctx *gin.Context) {
+		ctx.Set("user", ijwt.UserClaims{
+			Uid: 123,
+		})
+	})
+	hdl.RegisterRoutes(server)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/export", nil)
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+
+	body := resp.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	files := make(map[string][]byte, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(rc)
+		require.NoError(t, err)
+		_ = rc.Close()
+	}
+	require.Len(t, files, 3)
+
+	// 密码和两步验证密钥不能导出
+	assert.NotContains(t, string(files["profile.json"]), "hashed-password")
+	assert.NotContains(t, string(files["profile.json"]), "totp-secret")
+	var profile ProfileExportVo
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, int64(123), profile.Id)
+	assert.Equal(t, "大明", profile.Nickname)
+
+	var arts []ArticleExportVo
+	require.NoError(t, json.Unmarshal(files["articles.json"], &arts))
+	require.Len(t, arts, 1)
+	assert.Equal(t, "我的内容", arts[0].Content)
+
+	var cols []CollectionExportVo
+	require.NoError(t, json.Unmarshal(files["collections.json"], &cols))
+	assert.Equal(t, []CollectionExportVo{
+		{Biz: "article", BizId: 2, Cid: 3, Ctime: now.Format(time.DateTime)},
+	}, cols)
+}
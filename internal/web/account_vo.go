@@ -0,0 +1,71 @@
+package web
+
+import (
+	"time"
+	"webook/internal/domain"
+)
+
+// 导出个人数据用的 VO，不能带上密码、两步验证密钥这些敏感字段
+
+type ProfileExportVo struct {
+	Id            int64  `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	Phone         string `json:"phone"`
+	Nickname      string `json:"nickname"`
+	Birthday      string `json:"birthday"`
+	AboutMe       string `json:"aboutMe"`
+	Ctime         string `json:"ctime"`
+}
+
+type ArticleExportVo struct {
+	Id      int64  `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Status  uint8  `json:"status"`
+	Ctime   string `json:"ctime"`
+	Utime   string `json:"utime"`
+}
+
+type CollectionExportVo struct {
+	Biz   string `json:"biz"`
+	BizId int64  `json:"bizId"`
+	Cid   int64  `json:"cid"`
+	Ctime string `json:"ctime"`
+}
+
+func newProfileExportVo(u domain.User) ProfileExportVo {
+	vo := ProfileExportVo{
+		Id:            u.Id,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Phone:         u.Phone,
+		Nickname:      u.Nickname,
+		AboutMe:       u.AboutMe,
+		Ctime:         u.Ctime.Format(time.DateTime),
+	}
+	if !u.Birthday.IsZero() {
+		vo.Birthday = u.Birthday.Format(time.DateOnly)
+	}
+	return vo
+}
+
+func newArticleExportVo(art domain.Article) ArticleExportVo {
+	return ArticleExportVo{
+		Id:      art.Id,
+		Title:   art.Title,
+		Content: art.Content,
+		Status:  art.Status.ToUint8(),
+		Ctime:   art.Ctime.Format(time.DateTime),
+		Utime:   art.Utime.Format(time.DateTime),
+	}
+}
+
+func newCollectionExportVo(c domain.CollectionItem) CollectionExportVo {
+	return CollectionExportVo{
+		Biz:   c.Biz,
+		BizId: c.BizId,
+		Cid:   c.Cid,
+		Ctime: c.Ctime.Format(time.DateTime),
+	}
+}
@@ -0,0 +1,206 @@
+package web
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	ijwt "webook/internal/web/jwt"
+	"webook/internal/web/middleware"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+)
+
+// AdminHandler 管理后台的接口，只有配置里面的管理员可以访问
+type AdminHandler struct {
+	ijwt.Handler
+	accountSvc service.AccountService
+	guardSvc   service.LoginGuardService
+	reviewSvc  service.ArticleReviewService
+	adminUids  []int64
+	l          logger.Logger
+}
+
+func NewAdminHandler(accountSvc service.AccountService, guardSvc service.LoginGuardService,
+	reviewSvc service.ArticleReviewService, hdl ijwt.Handler, adminUids []int64, l logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		Handler:    hdl,
+		accountSvc: accountSvc,
+		guardSvc:   guardSvc,
+		reviewSvc:  reviewSvc,
+		adminUids:  adminUids,
+		l:          l,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/admin", middleware.NewAdminMiddlewareBuilder(h.adminUids).Build())
+	g.POST("/users/merge", h.MergeUsers)
+	g.POST("/users/unlock", h.UnlockUser)
+	g.POST("/reviews", h.PendingReviews)
+	g.POST("/reviews/approve", h.ApproveReview)
+	g.POST("/reviews/reject", h.RejectReview)
+}
+
+// MergeUsers 把 from 账号合并到 to 账号
+func (h *AdminHandler) MergeUsers(ctx *gin.Context) {
+	type Req struct {
+		From int64 `json:"from"`
+		To   int64 `json:"to"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	err := h.accountSvc.Merge(ctx, req.From, req.To)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrMergeSameUser):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "不能合并同一个账号",
+		})
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+		return
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	// from 已经没有东西了，让它的登录态都失效
+	err = h.ClearSessions(ctx, req.From, "")
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "合并成功",
+	})
+}
+
+// UnlockUser 解除登录失败太多次导致的锁定
+func (h *AdminHandler) UnlockUser(ctx *gin.Context) {
+	type Req struct {
+		Uid int64 `json:"uid"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	err := h.guardSvc.Unlock(ctx, req.Uid)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "解锁成功",
+		})
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// PendingReviews 待人工审核的文章，先提交的排在前面
+func (h *AdminHandler) PendingReviews(ctx *gin.Context) {
+	var page Page
+	if err := ctx.Bind(&page); err != nil {
+		return
+	}
+	rs, err := h.reviewSvc.Pending(ctx, page.Offset, page.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询审核队列失败",
+			logger.Int("offset", page.Offset),
+			logger.Int("limit", page.Limit),
+			logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map[domain.ArticleReview, ArticleReviewVo](rs,
+			func(idx int, src domain.ArticleReview) ArticleReviewVo {
+				return ArticleReviewVo{
+					Id:       src.Id,
+					Aid:      src.Aid,
+					AuthorId: src.AuthorId,
+					Title:    src.Title,
+					Content:  src.Content,
+					Reasons:  src.Reasons,
+					Ctime:    src.Ctime.Format(time.DateTime),
+				}
+			}),
+	})
+}
+
+func (h *AdminHandler) ApproveReview(ctx *gin.Context) {
+	type Req struct {
+		Id int64 `json:"id"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.reviewSvc.Approve(ctx, req.Id, uc.Uid)
+	h.reviewResult(ctx, req.Id, uc.Uid, err, "审核通过")
+}
+
+// RejectReview reason 会通过邮件发给作者
+func (h *AdminHandler) RejectReview(ctx *gin.Context) {
+	type Req struct {
+		Id     int64  `json:"id"`
+		Reason string `json:"reason"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.reviewSvc.Reject(ctx, req.Id, uc.Uid, req.Reason)
+	h.reviewResult(ctx, req.Id, uc.Uid, err, "已驳回")
+}
+
+func (h *AdminHandler) reviewResult(ctx *gin.Context, id, reviewer int64, err error, msg string) {
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: msg,
+		})
+	case errors.Is(err, service.ErrArticleReviewNotPending):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "审核任务不存在或者已经处理过了",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("处理审核任务失败",
+			logger.Int64("id", id),
+			logger.Int64("reviewer", reviewer),
+			logger.Error(err))
+	}
+}
@@ -1,8 +1,11 @@
 package web
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	"webook/internal/domain"
 	"webook/internal/service"
@@ -16,20 +19,32 @@ import (
 )
 
 type ArticleHandler struct {
-	svc     service.ArticleService
-	intrSvc service.InteractiveService
-	l       logger.Logger
-	biz     string
+	svc        service.ArticleService
+	intrSvc    service.InteractiveService
+	privacySvc service.PrivacyService
+	seriesSvc  service.SeriesService
+	historySvc service.ReadingHistoryService
+	rewardSvc  service.RewardService
+	l          logger.Logger
+	biz        string
 }
 
 func NewArticleHandler(l logger.Logger,
 	svc service.ArticleService,
-	intrSvc service.InteractiveService) *ArticleHandler {
+	intrSvc service.InteractiveService,
+	privacySvc service.PrivacyService,
+	seriesSvc service.SeriesService,
+	historySvc service.ReadingHistoryService,
+	rewardSvc service.RewardService) *ArticleHandler {
 	return &ArticleHandler{
-		l:       l,
-		intrSvc: intrSvc,
-		svc:     svc,
-		biz:     "article",
+		l:          l,
+		intrSvc:    intrSvc,
+		privacySvc: privacySvc,
+		seriesSvc:  seriesSvc,
+		historySvc: historySvc,
+		rewardSvc:  rewardSvc,
+		svc:        svc,
+		biz:        domain.BizArticle,
 	}
 }
 
@@ -38,8 +53,13 @@ func (h *ArticleHandler) RegisterRoutes(server *gin.Engine) {
 
 	//g.PUT("/", h.Edit)
 	g.POST("/edit", h.Edit)
+	g.POST("/autosave", h.Autosave)
 	g.POST("/publish", h.Publish)
 	g.POST("/withdraw", h.Withdraw)
+	g.POST("/delete", h.Delete)
+	g.POST("/restore", h.Restore)
+	// 回收站
+	g.POST("/trash", h.Trash)
 
 	// 创作者接口
 	g.GET("/detail/:id", h.Detail)
@@ -55,6 +75,12 @@ func (h *ArticleHandler) RegisterRoutes(server *gin.Engine) {
 
 	pub.POST("/collect", h.Collect)
 
+	// 上报阅读进度，下次打开的时候从这里继续
+	pub.POST("/progress", h.ReportProgress)
+
+	// 用积分打赏作者
+	pub.POST("/reward", h.Reward)
+
 }
 
 func (h *ArticleHandler) Like(c *gin.Context) {
@@ -70,12 +96,18 @@ func (h *ArticleHandler) Like(c *gin.Context) {
 	uc := c.MustGet("user").(jwt.UserClaims)
 	var err error
 	if req.Like {
-		// 点赞
+		// 点赞，文章不存在或者有拉黑关系的时候不能点赞
 		err = h.intrSvc.Like(c, h.biz, req.Id, uc.Uid)
 	} else {
 		// 取消点赞
 		err = h.intrSvc.CancelLike(c, h.biz, req.Id, uc.Uid)
 	}
+	if errors.Is(err, service.ErrInteractiveTargetNotFound) {
+		c.JSON(http.StatusOK, ginx.Result{
+			Code: 4, Msg: "文章不存在",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusOK, ginx.Result{
 			Code: 5, Msg: "系统错误",
@@ -117,26 +149,146 @@ func (h *ArticleHandler) Withdraw(ctx *gin.Context) {
 	})
 }
 
+// Delete 移到回收站，30 天之内可以恢复
+func (h *ArticleHandler) Delete(ctx *gin.Context) {
+	type Req struct {
+		Id int64 `json:"id"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.svc.Delete(ctx, uc.Uid, req.Id)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrArticleNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章不存在",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "系统错误",
+			Code: 5,
+		})
+		h.l.Error("删除文章失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("aid", req.Id),
+			logger.Error(err))
+	}
+}
+
+func (h *ArticleHandler) Restore(ctx *gin.Context) {
+	type Req struct {
+		Id int64 `json:"id"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.svc.Restore(ctx, uc.Uid, req.Id)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrArticleNotInTrash):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章不在回收站里面，或者已经过了恢复期限",
+		})
+	case errors.Is(err, service.ErrArticleRejected):
+		// 已经恢复成草稿了，作者修改之后可以重新发表
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  err.Error(),
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "系统错误",
+			Code: 5,
+		})
+		h.l.Error("恢复文章失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("aid", req.Id),
+			logger.Error(err))
+	}
+}
+
+func (h *ArticleHandler) Trash(ctx *gin.Context) {
+	var page Page
+	if err := ctx.Bind(&page); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	arts, err := h.svc.ListTrash(ctx, uc.Uid, page.Offset, page.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查找回收站失败",
+			logger.Error(err),
+			logger.Int("offset", page.Offset),
+			logger.Int("limit", page.Limit),
+			logger.Int64("uid", uc.Uid))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map[domain.Article, ArticleVo](arts, func(idx int, src domain.Article) ArticleVo {
+			return ArticleVo{
+				Id:       src.Id,
+				Title:    src.Title,
+				Abstract: src.Abstract(),
+				AuthorId: src.Author.Id,
+				Status:   src.Status.ToUint8(),
+				Ctime:    src.Ctime.Format(time.DateTime),
+				Utime:    src.Utime.Format(time.DateTime),
+				Dtime:    src.Dtime.Format(time.DateTime),
+			}
+		}),
+	})
+}
+
 // Edit 接收 Article 输入，返回一个 ID，文章的 ID
 func (h *ArticleHandler) Edit(ctx *gin.Context) {
 	type Req struct {
 		Id      int64
 		Title   string `json:"title"`
 		Content string `json:"content"`
+		// 0 是纯文本，1 是 Markdown
+		Format uint8 `json:"format"`
+		// 编辑之前拿到的版本号，新建的时候不用传。
+		// 返回的还是文章 ID，保存成功之后版本号一定是加一，新建的文章从 1 开始
+		Version int64 `json:"version"`
 	}
 	var req Req
 	if err := ctx.Bind(&req); err != nil {
 		return
 	}
+	if !h.validFormat(ctx, req.Format) {
+		return
+	}
 	uc := ctx.MustGet("user").(jwt.UserClaims)
 	id, err := h.svc.Save(ctx, domain.Article{
 		Id:      req.Id,
 		Title:   req.Title,
 		Content: req.Content,
+		Format:  domain.ArticleFormat(req.Format),
+		Version: req.Version,
 		Author: domain.Author{
 			Id: uc.Uid,
 		},
 	})
+	if errors.Is(err, service.ErrArticleConflict) {
+		h.conflict(ctx)
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Msg: "系统错误",
@@ -151,27 +303,115 @@ func (h *ArticleHandler) Edit(ctx *gin.Context) {
 	})
 }
 
+// Autosave 编辑过程中定时自动保存，只能保存已经存在的草稿
+func (h *ArticleHandler) Autosave(ctx *gin.Context) {
+	type Req struct {
+		Id      int64  `json:"id"`
+		Title   string `json:"title"`
+		Content string `json:"content"`
+		Format  uint8  `json:"format"`
+		Version int64  `json:"version"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	if req.Id <= 0 {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请先保存草稿",
+		})
+		return
+	}
+	if !h.validFormat(ctx, req.Format) {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.svc.Autosave(ctx, domain.Article{
+		Id:      req.Id,
+		Title:   req.Title,
+		Content: req.Content,
+		Format:  domain.ArticleFormat(req.Format),
+		Version: req.Version,
+		Author: domain.Author{
+			Id: uc.Uid,
+		},
+	})
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrArticleConflict):
+		h.conflict(ctx)
+	case errors.Is(err, service.ErrArticleNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章不存在",
+		})
+		h.l.Warn("自动保存别人的文章",
+			logger.Int64("id", req.Id),
+			logger.Int64("uid", uc.Uid))
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("自动保存文章失败",
+			logger.Int64("id", req.Id),
+			logger.Int64("uid", uc.Uid),
+			logger.Error(err))
+	}
+}
+
 func (h *ArticleHandler) Publish(ctx *gin.Context) {
 	type Req struct {
 		// 这个地方要兼容 mongo db
 		Id      int64  `json:"id"`
 		Title   string `json:"title"`
 		Content string `json:"content"`
+		Format  uint8  `json:"format"`
+		Version int64  `json:"version"`
 	}
 	var req Req
 	if err := ctx.Bind(&req); err != nil {
 		return
 	}
+	if !h.validFormat(ctx, req.Format) {
+		return
+	}
 
 	uc := ctx.MustGet("user").(jwt.UserClaims)
-	id, err := h.svc.Publish(ctx, domain.Article{
+	id, status, err := h.svc.Publish(ctx, domain.Article{
 		Id:      req.Id,
 		Title:   req.Title,
 		Content: req.Content,
+		Format:  domain.ArticleFormat(req.Format),
+		Version: req.Version,
 		Author: domain.Author{
 			Id: uc.Uid,
 		},
 	})
+	if errors.Is(err, service.ErrArticleConflict) {
+		h.conflict(ctx)
+		return
+	}
+	if errors.Is(err, service.ErrEmailNotVerified) {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "请先验证邮箱",
+			Code: 4,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrArticleRejected) {
+		// 已经保存成草稿了，把 id 带回去方便作者修改
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  err.Error(),
+			Code: 4,
+			Data: id,
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Msg:  "系统错误",
@@ -182,11 +422,25 @@ func (h *ArticleHandler) Publish(ctx *gin.Context) {
 			logger.Error(err))
 		return
 	}
+	if status == domain.ArticleStatusPendingReview {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "文章已提交审核，审核通过之后其他人才能看到",
+			Data: id,
+		})
+		return
+	}
 	ctx.JSON(http.StatusOK, ginx.Result{
 		Data: id,
 	})
 }
 
+func (h *ArticleHandler) conflict(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Code: codeArticleConflict,
+		Msg:  "文章已经在其他地方修改过了，请刷新之后再编辑",
+	})
+}
+
 func (h *ArticleHandler) Detail(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.ParseInt(idstr, 10, 64)
@@ -230,23 +484,45 @@ func (h *ArticleHandler) Detail(ctx *gin.Context) {
 		//Abstract: art.Abstract(),
 
 		Content:  art.Content,
+		Format:   art.Format.ToUint8(),
+		Tags:     art.Tags,
 		AuthorId: art.Author.Id,
 		// 列表，你不需要
-		Status: art.Status.ToUint8(),
-		Ctime:  art.Ctime.Format(time.DateTime),
-		Utime:  art.Utime.Format(time.DateTime),
+		Status:  art.Status.ToUint8(),
+		Version: art.Version,
+		Ctime:   art.Ctime.Format(time.DateTime),
+		Utime:   art.Utime.Format(time.DateTime),
 	}
 	ctx.JSON(http.StatusOK, ginx.Result{Data: vo})
 }
 
+// List 作者自己的文章列表，用游标翻页。
+// 第一页不传 cursor，后面每一页传上一页返回的 cursor，返回的 cursor 是空的说明没有了
 func (h *ArticleHandler) List(ctx *gin.Context) {
-	var page Page
-	if err := ctx.Bind(&page); err != nil {
+	type Req struct {
+		Cursor string `json:"cursor"`
+		Limit  int    `json:"limit"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
 		return
 	}
-	// 我要不要检测一下？ 不检测 正常用户不会碰到这种问题
+	cursor, err := decodeArticleCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "cursor 参数错误",
+		})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultPubListLimit
+	}
+	if req.Limit > maxPubListLimit {
+		req.Limit = maxPubListLimit
+	}
 	uc := ctx.MustGet("user").(jwt.UserClaims)
-	arts, err := h.svc.GetByAuthor(ctx, uc.Uid, page.Offset, page.Limit)
+	arts, err := h.svc.GetByAuthor(ctx, uc.Uid, cursor, req.Limit)
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Code: 5,
@@ -254,13 +530,13 @@ func (h *ArticleHandler) List(ctx *gin.Context) {
 		})
 		h.l.Error("查找文章列表失败",
 			logger.Error(err),
-			logger.Int("offset", page.Offset),
-			logger.Int("limit", page.Limit),
+			logger.String("cursor", req.Cursor),
+			logger.Int("limit", req.Limit),
 			logger.Int64("uid", uc.Uid))
 		return
 	}
-	ctx.JSON(http.StatusOK, ginx.Result{
-		Data: slice.Map[domain.Article, ArticleVo](arts, func(idx int, src domain.Article) ArticleVo {
+	res := ArticleListVo{
+		List: slice.Map[domain.Article, ArticleVo](arts, func(idx int, src domain.Article) ArticleVo {
 			return ArticleVo{
 				Id:       src.Id,
 				Title:    src.Title,
@@ -274,7 +550,44 @@ func (h *ArticleHandler) List(ctx *gin.Context) {
 				Utime:  src.Utime.Format(time.DateTime),
 			}
 		}),
-	})
+	}
+	fillInteractive(ctx, h.intrSvc, h.l, res.List, uc.Uid)
+	// 不满一页说明没有了
+	if len(arts) == req.Limit {
+		res.Cursor = encodeArticleCursor(arts[len(arts)-1].Cursor())
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{Data: res})
+}
+
+var errInvalidCursor = errors.New("cursor 格式不对")
+
+// encodeArticleCursor 对前端来说游标是不透明的，前端原样带回来就可以
+func encodeArticleCursor(c domain.ArticleCursor) string {
+	raw := strconv.FormatInt(c.Utime.UnixMilli(), 10) + "_" + strconv.FormatInt(c.Id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeArticleCursor(str string) (domain.ArticleCursor, error) {
+	if str == "" {
+		return domain.ArticleCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(str)
+	if err != nil {
+		return domain.ArticleCursor{}, err
+	}
+	utimeStr, idStr, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return domain.ArticleCursor{}, errInvalidCursor
+	}
+	utime, err := strconv.ParseInt(utimeStr, 10, 64)
+	if err != nil {
+		return domain.ArticleCursor{}, err
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		return domain.ArticleCursor{}, errInvalidCursor
+	}
+	return domain.ArticleCursor{Utime: time.UnixMilli(utime), Id: id}, nil
 }
 
 func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
@@ -308,6 +621,10 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 	eg.Go(func() error {
 		var er error
 		intr, er = h.intrSvc.Get(ctx, h.biz, id, uc.Uid)
+		if errors.Is(er, service.ErrInteractiveTargetNotFound) {
+			// 看不到的文章下面会返回文章不存在
+			return nil
+		}
 		return er
 	})
 
@@ -322,6 +639,34 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 			logger.Error(err))
 		return
 	}
+	// 撤回了的、还在审核的和被驳回的文章只有作者自己能看
+	if art.Status != domain.ArticleStatusPublished && art.Author.Id != uc.Uid {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "文章不存在",
+			Code: 4,
+		})
+		return
+	}
+	// 拉黑了的双方互相看不到对方的文章
+	ok, err := h.privacySvc.Allow(ctx, uc.Uid, art.Author.Id, domain.PrivacyActionView)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "系统错误",
+			Code: 5,
+		})
+		h.l.Error("查询拉黑关系失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("aid", art.Id),
+			logger.Error(err))
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "文章不存在",
+			Code: 4,
+		})
+		return
+	}
 
 	//// biz article  bizId art.Id
 	//go func() {
@@ -343,16 +688,29 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 			Id:    art.Id,
 			Title: art.Title,
 
-			Content:    art.Content,
+			Content: art.Content,
+			Format:  art.Format.ToUint8(),
+			HTML:    art.HTML,
+			TOC: slice.Map[domain.TOCItem, TOCItemVo](art.TOC, func(idx int, src domain.TOCItem) TOCItemVo {
+				return TOCItemVo{
+					Level:  src.Level,
+					Title:  src.Title,
+					Anchor: src.Anchor,
+				}
+			}),
 			AuthorId:   art.Author.Id,
 			AuthorName: art.Author.Name,
 
 			ReadCnt:    intr.ReadCnt,
 			CollectCnt: intr.CollectCnt,
 			LikeCnt:    intr.LikeCnt,
+			RewardCnt:  intr.RewardCnt,
 			Liked:      intr.Liked,
 			Collected:  intr.Collected,
 
+			Series:   h.seriesNav(ctx, art.Id),
+			Progress: h.readingProgress(ctx, uc.Uid, art.Id),
+
 			Status: art.Status.ToUint8(),
 			Ctime:  art.Ctime.Format(time.DateTime),
 			Utime:  art.Utime.Format(time.DateTime),
@@ -360,6 +718,176 @@ func (h *ArticleHandler) PubDetail(ctx *gin.Context) {
 	})
 }
 
+func (h *ArticleHandler) ReportProgress(ctx *gin.Context) {
+	type Req struct {
+		Id int64 `json:"id"`
+		// Progress 0 到 1
+		Progress float64 `json:"progress"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.historySvc.ReportProgress(ctx, uc.Uid, req.Id, req.Progress)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrInvalidReadingProgress):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "阅读进度不合法",
+		})
+	case errors.Is(err, service.ErrArticleNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章不存在",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("上报阅读进度失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("aid", req.Id),
+			logger.Error(err))
+	}
+}
+
+func (h *ArticleHandler) Reward(ctx *gin.Context) {
+	type Req struct {
+		Id     int64 `json:"id"`
+		Amount int64 `json:"amount"`
+		// Key 客户端生成的幂等键，重试的时候要用同一个
+		Key string `json:"key"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	if !h.allowInteract(ctx, uc.Uid, req.Id) {
+		return
+	}
+	txn, err := h.rewardSvc.Reward(ctx, uc.Uid, h.biz, req.Id, req.Amount, req.Key)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Data: txn.Id,
+		})
+	case errors.Is(err, service.ErrInsufficientBalance):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "余额不足",
+		})
+	case errors.Is(err, service.ErrInvalidRewardAmount):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "打赏金额不合法",
+		})
+	case errors.Is(err, service.ErrInvalidIdempotencyKey),
+		errors.Is(err, service.ErrIdempotencyKeyConflict):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "key 参数错误",
+		})
+	case errors.Is(err, service.ErrRewardSelf):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "不能打赏自己",
+		})
+	case errors.Is(err, service.ErrArticleNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章不存在",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("打赏失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("aid", req.Id),
+			logger.Int64("amount", req.Amount),
+			logger.Error(err))
+	}
+}
+
+// readingProgress 上次读到的位置，没读过或者查询失败都从头开始
+func (h *ArticleHandler) readingProgress(ctx *gin.Context, uid, aid int64) float64 {
+	if uid <= 0 {
+		return 0
+	}
+	res, err := h.historySvc.Get(ctx, uid, aid)
+	if errors.Is(err, service.ErrReadingHistoryNotFound) {
+		return 0
+	}
+	if err != nil {
+		h.l.Error("查询阅读进度失败",
+			logger.Int64("uid", uid),
+			logger.Int64("aid", aid),
+			logger.Error(err))
+		return 0
+	}
+	return res.Progress
+}
+
+// fillInteractive 列表页的计数一次查出来，查询失败了只是不展示计数
+func fillInteractive(ctx *gin.Context, svc service.InteractiveService, l logger.Logger,
+	vos []ArticleVo, uid int64) {
+	if len(vos) == 0 {
+		return
+	}
+	ids := slice.Map(vos, func(idx int, src ArticleVo) int64 {
+		return src.Id
+	})
+	intrs, err := svc.GetByIds(ctx, domain.BizArticle, ids, uid)
+	if err != nil {
+		l.Error("批量查询互动数据失败",
+			logger.Int64("uid", uid),
+			logger.Error(err))
+		return
+	}
+	for i := range vos {
+		intr := intrs[vos[i].Id]
+		vos[i].ReadCnt = intr.ReadCnt
+		vos[i].LikeCnt = intr.LikeCnt
+		vos[i].CollectCnt = intr.CollectCnt
+		vos[i].RewardCnt = intr.RewardCnt
+		vos[i].Liked = intr.Liked
+		vos[i].Collected = intr.Collected
+	}
+}
+
+// seriesNav 系列导航只是锦上添花，查询失败了也不影响看文章
+func (h *ArticleHandler) seriesNav(ctx *gin.Context, aid int64) *SeriesNavVo {
+	nav, err := h.seriesSvc.Nav(ctx, aid)
+	if errors.Is(err, service.ErrSeriesNotFound) {
+		return nil
+	}
+	if err != nil {
+		h.l.Error("查询系列导航失败",
+			logger.Int64("aid", aid),
+			logger.Error(err))
+		return nil
+	}
+	vo := &SeriesNavVo{
+		Id:    nav.Series.Id,
+		Title: nav.Series.Title,
+	}
+	if nav.Prev.Id > 0 {
+		vo.Prev = &SeriesArticleVo{Id: nav.Prev.Id, Title: nav.Prev.Title}
+	}
+	if nav.Next.Id > 0 {
+		vo.Next = &SeriesArticleVo{Id: nav.Next.Id, Title: nav.Next.Title}
+	}
+	return vo
+}
+
 func (h *ArticleHandler) Collect(ctx *gin.Context) {
 	type Req struct {
 		Id  int64 `json:"id"`
@@ -372,6 +900,12 @@ func (h *ArticleHandler) Collect(ctx *gin.Context) {
 	}
 	uc := ctx.MustGet("user").(jwt.UserClaims)
 	err := h.intrSvc.Collect(ctx, h.biz, req.Id, req.Cid, uc.Uid)
+	if errors.Is(err, service.ErrInteractiveTargetNotFound) {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4, Msg: "文章不存在",
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Code: 5, Msg: "系统错误",
@@ -388,4 +922,49 @@ func (h *ArticleHandler) Collect(ctx *gin.Context) {
 	})
 }
 
+// allowInteract 被作者拉黑了或者拉黑了作者，都不能打赏。
+// 不允许的时候已经写好了响应
+func (h *ArticleHandler) allowInteract(ctx *gin.Context, uid, aid int64) bool {
+	art, err := h.svc.GetById(ctx, aid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5, Msg: "系统错误",
+		})
+		h.l.Error("查询文章作者失败",
+			logger.Error(err),
+			logger.Int64("uid", uid),
+			logger.Int64("aid", aid))
+		return false
+	}
+	ok, err := h.privacySvc.Allow(ctx, uid, art.Author.Id, domain.PrivacyActionInteract)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5, Msg: "系统错误",
+		})
+		h.l.Error("查询拉黑关系失败",
+			logger.Error(err),
+			logger.Int64("uid", uid),
+			logger.Int64("aid", aid))
+		return false
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4, Msg: "文章不存在",
+		})
+	}
+	return ok
+}
+
 //TODO: 取消收藏
+
+// validFormat 不认识的格式直接拒绝，不然发表的时候会被当成纯文本
+func (h *ArticleHandler) validFormat(ctx *gin.Context, format uint8) bool {
+	if domain.ArticleFormat(format).Valid() {
+		return true
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Code: 4,
+		Msg:  "不支持的文章格式",
+	})
+	return false
+}
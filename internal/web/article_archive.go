@@ -0,0 +1,193 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportUpload 导入的 ZIP 最大这么大
+const maxImportUpload = 10 << 20
+
+// ArticleArchiveHandler 用 Markdown 文件批量导入导出文章
+type ArticleArchiveHandler struct {
+	svc service.ArticleArchiveService
+	l   logger.Logger
+}
+
+func NewArticleArchiveHandler(svc service.ArticleArchiveService, l logger.Logger) *ArticleArchiveHandler {
+	return &ArticleArchiveHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *ArticleArchiveHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/articles")
+	// 表单里面的 file 字段是 ZIP，返回任务 ID，用 /import/:id 查询进度
+	g.POST("/import", h.Import)
+	g.GET("/import/:id", h.ImportJob)
+	g.GET("/export", h.Export)
+}
+
+func (h *ArticleArchiveHandler) Import(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportUpload)
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 4,
+				Msg:  "文件太大",
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请选择要导入的 ZIP 文件",
+		})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	id, err := h.svc.Import(ctx, uc.Uid, data)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Data: id,
+		})
+	case errors.Is(err, service.ErrArticleImportInvalid),
+		errors.Is(err, service.ErrArticleImportTooLarge):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  err.Error(),
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("创建导入任务失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Error(err))
+	}
+}
+
+func (h *ArticleArchiveHandler) ImportJob(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "id 参数错误",
+		})
+		return
+	}
+	job, err := h.svc.ImportJob(ctx, uc.Uid, id)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Data: newArticleImportJobVo(job),
+		})
+	case errors.Is(err, service.ErrArticleImportJobNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "导入任务不存在或者已经过期",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询导入任务失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("job", id),
+			logger.Error(err))
+	}
+}
+
+// Export 所有的文章导出成带 front-matter 的 Markdown 文件，打包成 ZIP 下载
+func (h *ArticleArchiveHandler) Export(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="webook-articles-%d.zip"`, uc.Uid))
+	err := h.svc.Export(ctx, uc.Uid, ctx.Writer)
+	if err == nil {
+		return
+	}
+	h.l.Error("导出文章失败", logger.Int64("uid", uc.Uid), logger.Error(err))
+	if ctx.Writer.Written() {
+		// 已经开始写响应了，只能记录日志
+		return
+	}
+	ctx.Writer.Header().Del("Content-Type")
+	ctx.Writer.Header().Del("Content-Disposition")
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Code: 5,
+		Msg:  "系统错误",
+	})
+}
+
+func newArticleImportJobVo(job domain.ArticleImportJob) ArticleImportJobVo {
+	status := "running"
+	if job.Status == domain.ArticleImportJobStatusDone {
+		status = "done"
+	}
+	return ArticleImportJobVo{
+		Id:      job.Id,
+		Status:  status,
+		Total:   job.Total,
+		Created: job.Count(domain.ArticleImportFileStatusCreated),
+		Skipped: job.Count(domain.ArticleImportFileStatusSkipped),
+		Failed:  job.Count(domain.ArticleImportFileStatusFailed),
+		Files: slice.Map(job.Files, func(idx int, src domain.ArticleImportFile) ArticleImportFileVo {
+			return ArticleImportFileVo{
+				Name:      src.Name,
+				Status:    articleImportFileStatusName(src.Status),
+				ArticleId: src.ArticleId,
+				Error:     src.Error,
+			}
+		}),
+		Ctime: job.Ctime.Format(time.DateTime),
+		Utime: job.Utime.Format(time.DateTime),
+	}
+}
+
+func articleImportFileStatusName(status domain.ArticleImportFileStatus) string {
+	switch status {
+	case domain.ArticleImportFileStatusCreated:
+		return "created"
+	case domain.ArticleImportFileStatusSkipped:
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -37,7 +38,7 @@ func TestArticleHandler_Publish(t *testing.T) {
 					Author: domain.Author{
 						Id: 123,
 					},
-				}).Return(int64(1), nil)
+				}).Return(int64(1), domain.ArticleStatus(domain.ArticleStatusPublished), nil)
 				return svc
 			},
 			reqBody: `
@@ -61,17 +62,19 @@ func TestArticleHandler_Publish(t *testing.T) {
 					Id:      1,
 					Title:   "新的标题",
 					Content: "新的内容",
+					Version: 2,
 					Author: domain.Author{
 						Id: 123,
 					},
-				}).Return(int64(1), nil)
+				}).Return(int64(1), domain.ArticleStatus(domain.ArticleStatusPublished), nil)
 				return svc
 			},
 			reqBody: `
 {
 "id": 1,
  "title": "新的标题",
- "content": "新的内容"
+ "content": "新的内容",
+ "version": 2
 }
 `,
 			wantCode: 200,
@@ -81,6 +84,88 @@ func TestArticleHandler_Publish(t *testing.T) {
 				Data: float64(1),
 			},
 		},
+		{
+			name: "版本冲突",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().Publish(gomock.Any(), gomock.Any()).
+					Return(int64(0), domain.ArticleStatus(domain.ArticleStatusUnknown), service.ErrArticleConflict)
+				return svc
+			},
+			reqBody: `
+{
+"id": 1,
+ "title": "新的标题",
+ "content": "新的内容",
+ "version": 2
+}
+`,
+			wantCode: 200,
+			wantRes: ginx.Result{
+				Code: codeArticleConflict,
+				Msg:  "文章已经在其他地方修改过了，请刷新之后再编辑",
+			},
+		},
+		{
+			name: "转人工审核",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().Publish(gomock.Any(), gomock.Any()).
+					Return(int64(1), domain.ArticleStatus(domain.ArticleStatusPendingReview), nil)
+				return svc
+			},
+			reqBody: `
+{
+ "title": "我的标题",
+ "content": "我的内容"
+}
+`,
+			wantCode: 200,
+			wantRes: ginx.Result{
+				Msg:  "文章已提交审核，审核通过之后其他人才能看到",
+				Data: float64(1),
+			},
+		},
+		{
+			name: "审核不通过",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().Publish(gomock.Any(), gomock.Any()).
+					Return(int64(1), domain.ArticleStatus(domain.ArticleStatusUnpublished),
+						fmt.Errorf("%w：包含敏感词：赌博", service.ErrArticleRejected))
+				return svc
+			},
+			reqBody: `
+{
+ "title": "我的标题",
+ "content": "我的内容"
+}
+`,
+			wantCode: 200,
+			wantRes: ginx.Result{
+				Code: 4,
+				Msg:  service.ErrArticleRejected.Error() + "：包含敏感词：赌博",
+				Data: float64(1),
+			},
+		},
+		{
+			name: "不支持的格式",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				return svcmocks.NewMockArticleService(ctrl)
+			},
+			reqBody: `
+{
+ "title": "我的标题",
+ "content": "我的内容",
+ "format": 9
+}
+`,
+			wantCode: 200,
+			wantRes: ginx.Result{
+				Code: 4,
+				Msg:  "不支持的文章格式",
+			},
+		},
 		{
 			name: "输入有误",
 			mock: func(ctrl *gomock.Controller) service.ArticleService {
@@ -107,7 +192,7 @@ func TestArticleHandler_Publish(t *testing.T) {
 					Author: domain.Author{
 						Id: 123,
 					},
-				}).Return(int64(0), errors.New("mock error"))
+				}).Return(int64(0), domain.ArticleStatus(domain.ArticleStatusUnknown), errors.New("mock error"))
 				return svc
 			},
 			reqBody: `
@@ -134,7 +219,7 @@ func TestArticleHandler_Publish(t *testing.T) {
 
 			// 构造 handler
 			svc := tc.mock(ctrl)
-			hdl := NewArticleHandler(logger.NewNopLogger(), svc, nil)
+			hdl := NewArticleHandler(logger.NewNopLogger(), svc, nil, nil, nil, nil, nil)
 
 			// 准备服务器，注册路由
 			server := gin.Default()
@@ -1,19 +1,134 @@
 package web
 
 type ArticleVo struct {
-	Id         int64  `json:"id,omitempty"`
-	Title      string `json:"title,omitempty"`
-	Abstract   string `json:"abstract,omitempty"`
-	Content    string `json:"content,omitempty"`
-	AuthorId   int64  `json:"authorId,omitempty"`
-	AuthorName string `json:"authorName,omitempty"`
-	Status     uint8  `json:"status,omitempty"`
-	Ctime      string `json:"ctime,omitempty"`
-	Utime      string `json:"utime,omitempty"`
+	Id       int64  `json:"id,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Abstract string `json:"abstract,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Format   uint8  `json:"format,omitempty"`
+	// HTML 和 TOC 只有 Markdown 文章有，前端直接展示 HTML，不用再渲染 Content
+	HTML       string      `json:"html,omitempty"`
+	TOC        []TOCItemVo `json:"toc,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	AuthorId   int64       `json:"authorId,omitempty"`
+	AuthorName string      `json:"authorName,omitempty"`
+	Status     uint8       `json:"status,omitempty"`
+	// Version 编辑的时候原样带回来，用来检查冲突
+	Version int64  `json:"version,omitempty"`
+	Ctime   string `json:"ctime,omitempty"`
+	Utime   string `json:"utime,omitempty"`
+	// Dtime 回收站里面的文章才有，过了 30 天就彻底删除
+	Dtime string `json:"dtime,omitempty"`
 
 	ReadCnt    int64 `json:"readCnt"`
 	LikeCnt    int64 `json:"likeCnt"`
 	CollectCnt int64 `json:"collectCnt"`
+	RewardCnt  int64 `json:"rewardCnt"`
 	Liked      bool  `json:"liked"`
 	Collected  bool  `json:"collected"`
+
+	// Series 文章属于某个系列的时候才有
+	Series *SeriesNavVo `json:"series,omitempty"`
+	// Progress 上次读到的位置，0 到 1，没读过是 0
+	Progress float64 `json:"progress,omitempty"`
+}
+
+// ArticleListVo 游标翻页的列表，Cursor 是空的说明没有下一页了
+type ArticleListVo struct {
+	List   []ArticleVo `json:"list"`
+	Cursor string      `json:"cursor,omitempty"`
+}
+
+// TOCItemVo 目录，Anchor 对应 HTML 里面标题的 id
+type TOCItemVo struct {
+	Level  int    `json:"level"`
+	Title  string `json:"title"`
+	Anchor string `json:"anchor"`
+}
+
+// ArticleReviewVo 审核队列里面的任务
+type ArticleReviewVo struct {
+	Id       int64    `json:"id"`
+	Aid      int64    `json:"aid"`
+	AuthorId int64    `json:"authorId"`
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Reasons  []string `json:"reasons"`
+	Ctime    string   `json:"ctime"`
+}
+
+// SeriesVo 系列，Articles 只有查看系列详情的时候才有
+type SeriesVo struct {
+	Id          int64       `json:"id"`
+	AuthorId    int64       `json:"authorId"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	ArticleIds  []int64     `json:"articleIds,omitempty"`
+	Articles    []ArticleVo `json:"articles,omitempty"`
+	Ctime       string      `json:"ctime"`
+	Utime       string      `json:"utime"`
+}
+
+// SeriesNavVo 文章详情页里面的系列导航，没有上一篇或者下一篇的时候是 null
+type SeriesNavVo struct {
+	Id    int64            `json:"id"`
+	Title string           `json:"title"`
+	Prev  *SeriesArticleVo `json:"prev"`
+	Next  *SeriesArticleVo `json:"next"`
+}
+
+type SeriesArticleVo struct {
+	Id    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// ArticleImportJobVo 导入任务的进度，Files 是已经处理完的文件
+type ArticleImportJobVo struct {
+	Id int64 `json:"id"`
+	// running 或者 done
+	Status  string                `json:"status"`
+	Total   int                   `json:"total"`
+	Created int                   `json:"created"`
+	Skipped int                   `json:"skipped"`
+	Failed  int                   `json:"failed"`
+	Files   []ArticleImportFileVo `json:"files"`
+	Ctime   string                `json:"ctime"`
+	Utime   string                `json:"utime"`
+}
+
+type ArticleImportFileVo struct {
+	Name string `json:"name"`
+	// created 创建了草稿，skipped 之前已经导入过了，failed 失败，原因在 Error 里面
+	Status    string `json:"status"`
+	ArticleId int64  `json:"articleId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadingHistoryVo 阅读历史里面的一篇文章
+type ReadingHistoryVo struct {
+	Id         int64   `json:"id"`
+	Title      string  `json:"title"`
+	Abstract   string  `json:"abstract"`
+	AuthorId   int64   `json:"authorId"`
+	AuthorName string  `json:"authorName"`
+	Progress   float64 `json:"progress"`
+	// ReadTime 最后一次阅读的时间
+	ReadTime string `json:"readTime"`
+}
+
+// ReadingHistoryListVo Cursor 是空的说明没有下一页了
+type ReadingHistoryListVo struct {
+	List   []ReadingHistoryVo `json:"list"`
+	Cursor string             `json:"cursor,omitempty"`
+}
+
+// LedgerEntryVo 积分流水
+type LedgerEntryVo struct {
+	TxnId int64 `json:"txnId"`
+	// Type 1 是充值，2 是打赏
+	Type uint8 `json:"type"`
+	// Amount 入账是正数，出账是负数
+	Amount  int64  `json:"amount"`
+	Balance int64  `json:"balance"`
+	Ctime   string `json:"ctime"`
 }
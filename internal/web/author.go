@@ -0,0 +1,291 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultPubListLimit = 10
+	maxPubListLimit     = 100
+)
+
+// AuthorHandler 作者的公开主页，不需要登录就能看
+type AuthorHandler struct {
+	userSvc    service.UserService
+	artSvc     service.ArticleService
+	mediaSvc   service.MediaService
+	privacySvc service.PrivacyService
+	intrSvc    service.InteractiveService
+	l          logger.Logger
+}
+
+func NewAuthorHandler(userSvc service.UserService, artSvc service.ArticleService,
+	mediaSvc service.MediaService, privacySvc service.PrivacyService,
+	intrSvc service.InteractiveService, l logger.Logger) *AuthorHandler {
+	return &AuthorHandler{
+		userSvc:    userSvc,
+		artSvc:     artSvc,
+		mediaSvc:   mediaSvc,
+		privacySvc: privacySvc,
+		intrSvc:    intrSvc,
+		l:          l,
+	}
+}
+
+func (h *AuthorHandler) RegisterRoutes(server *gin.Engine) {
+	// /users/profile 这种静态路由 gin 会优先匹配
+	g := server.Group("/users/:id")
+	g.GET("", h.Profile)
+	// /users/:id/articles?offset=0&limit=10
+	g.GET("/articles", h.PubList)
+	// 这两个要看作者的隐私设置
+	g.GET("/likes", h.Likes)
+	g.GET("/collections", h.Collections)
+}
+
+// Profile 公开的个人主页，不能返回邮箱手机号这些隐私信息
+func (h *AuthorHandler) Profile(ctx *gin.Context) {
+	uid, ok := h.uid(ctx)
+	if !ok {
+		return
+	}
+	var (
+		eg  errgroup.Group
+		u   domain.User
+		cnt int64
+	)
+	eg.Go(func() error {
+		var er error
+		u, er = h.userSvc.FindById(ctx, uid)
+		return er
+	})
+	eg.Go(func() error {
+		var er error
+		cnt, er = h.artSvc.CountPub(ctx, uid)
+		return er
+	})
+	err := eg.Wait()
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		// 注销了的用户也是找不到
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询作者主页失败",
+			logger.Int64("uid", uid), logger.Error(err))
+		return
+	}
+	res := AuthorProfileVo{
+		Id:         u.Id,
+		Nickname:   u.Nickname,
+		AboutMe:    u.AboutMe,
+		ArticleCnt: cnt,
+	}
+	if u.Avatar != "" {
+		res.Avatar, err = h.mediaSvc.URL(ctx, u.Avatar, avatarSize)
+		if err != nil {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 5,
+				Msg:  "系统错误",
+			})
+			h.l.Error("生成头像地址失败",
+				logger.Int64("uid", uid), logger.Error(err))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: res,
+	})
+}
+
+// PubList 作者已经发表的文章，仅自己可见的不会出现在这里
+func (h *AuthorHandler) PubList(ctx *gin.Context) {
+	uid, ok := h.uid(ctx)
+	if !ok {
+		return
+	}
+	offset, limit := pageQuery(ctx)
+	arts, err := h.artSvc.ListPub(ctx, uid, offset, limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询作者文章列表失败",
+			logger.Int64("uid", uid),
+			logger.Int("offset", offset),
+			logger.Int("limit", limit),
+			logger.Error(err))
+		return
+	}
+	vos := slice.Map[domain.Article, ArticleVo](arts, func(idx int, src domain.Article) ArticleVo {
+		return ArticleVo{
+			Id:       src.Id,
+			Title:    src.Title,
+			Abstract: src.Abstract(),
+			AuthorId: src.Author.Id,
+			Ctime:    src.Ctime.Format(time.DateTime),
+			Utime:    src.Utime.Format(time.DateTime),
+		}
+	})
+	fillInteractive(ctx, h.intrSvc, h.l, vos, viewerUid(ctx))
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: vos,
+	})
+}
+
+// Likes 作者点赞过的内容，作者设置了不公开的时候别人看不到
+func (h *AuthorHandler) Likes(ctx *gin.Context) {
+	uid, ok := h.allow(ctx, domain.PrivacyActionViewLikes)
+	if !ok {
+		return
+	}
+	offset, limit := pageQuery(ctx)
+	items, err := h.intrSvc.ListLikes(ctx, uid, offset, limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询用户点赞列表失败",
+			logger.Int64("uid", uid), logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map[domain.LikeItem, InteractiveItemVo](items,
+			func(idx int, src domain.LikeItem) InteractiveItemVo {
+				return InteractiveItemVo{
+					Biz:   src.Biz,
+					BizId: src.BizId,
+					Ctime: src.Ctime.Format(time.DateTime),
+				}
+			}),
+	})
+}
+
+// Collections 作者的收藏，作者设置了不公开的时候别人看不到
+func (h *AuthorHandler) Collections(ctx *gin.Context) {
+	uid, ok := h.allow(ctx, domain.PrivacyActionViewCollections)
+	if !ok {
+		return
+	}
+	offset, limit := pageQuery(ctx)
+	items, err := h.intrSvc.ListCollections(ctx, uid, offset, limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询用户收藏列表失败",
+			logger.Int64("uid", uid), logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map[domain.CollectionItem, InteractiveItemVo](items,
+			func(idx int, src domain.CollectionItem) InteractiveItemVo {
+				return InteractiveItemVo{
+					Biz:   src.Biz,
+					BizId: src.BizId,
+					Ctime: src.Ctime.Format(time.DateTime),
+				}
+			}),
+	})
+}
+
+// allow 在 uid 的基础上再检查作者对 action 的隐私设置
+func (h *AuthorHandler) allow(ctx *gin.Context, action domain.PrivacyAction) (int64, bool) {
+	uid, ok := h.uid(ctx)
+	if !ok {
+		return 0, false
+	}
+	viewer := viewerUid(ctx)
+	ok, err := h.privacySvc.Allow(ctx, viewer, uid, action)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询隐私设置失败",
+			logger.Int64("uid", uid),
+			logger.Int64("viewer", viewer),
+			logger.Error(err))
+		return 0, false
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "作者设置了不公开",
+		})
+		return 0, false
+	}
+	return uid, true
+}
+
+// pageQuery 解析 offset 和 limit，不合法的时候用默认值
+func pageQuery(ctx *gin.Context) (int, int) {
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultPubListLimit
+	}
+	if limit > maxPubListLimit {
+		limit = maxPubListLimit
+	}
+	return offset, limit
+}
+
+// uid 路径里面的作者 ID。和作者之间有拉黑关系的时候当作用户不存在，
+// 不允许的时候已经写好了响应
+func (h *AuthorHandler) uid(ctx *gin.Context) (int64, bool) {
+	idstr := ctx.Param("id")
+	uid, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil || uid <= 0 {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "id 参数错误",
+		})
+		return 0, false
+	}
+	viewer := viewerUid(ctx)
+	ok, err := h.privacySvc.Allow(ctx, viewer, uid, domain.PrivacyActionView)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询拉黑关系失败",
+			logger.Int64("uid", uid),
+			logger.Int64("viewer", viewer),
+			logger.Error(err))
+		return 0, false
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+		return 0, false
+	}
+	return uid, true
+}
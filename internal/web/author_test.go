@@ -0,0 +1,199 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	svcmocks "webook/internal/service/mock"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthorHandler(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService)
+		url  string
+		// 和作者之间有拉黑关系
+		blocked bool
+		// 作者把点赞设置成了不公开
+		hideLikes bool
+
+		wantRes ginx.Result
+	}{
+		{
+			name: "个人主页",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().FindById(gomock.Any(), int64(123)).Return(domain.User{
+					Id:       123,
+					Email:    "123@qq.com",
+					Phone:    "15212345678",
+					Nickname: "大明",
+					AboutMe:  "自我介绍",
+					Avatar:   "media/ab/abc.png",
+				}, nil)
+				artSvc := svcmocks.NewMockArticleService(ctrl)
+				artSvc.EXPECT().CountPub(gomock.Any(), int64(123)).Return(int64(3), nil)
+				mediaSvc := svcmocks.NewMockMediaService(ctrl)
+				mediaSvc.EXPECT().URL(gomock.Any(), "media/ab/abc.png", avatarSize).
+					Return("/media/files/abc.png", nil)
+				return userSvc, artSvc, mediaSvc
+			},
+			url: "/users/123",
+			wantRes: ginx.Result{
+				Data: map[string]any{
+					"id":         float64(123),
+					"nickname":   "大明",
+					"aboutMe":    "自我介绍",
+					"avatar":     "/media/files/abc.png",
+					"articleCnt": float64(3),
+				},
+			},
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().FindById(gomock.Any(), int64(123)).
+					Return(domain.User{}, service.ErrUserNotFound)
+				artSvc := svcmocks.NewMockArticleService(ctrl)
+				artSvc.EXPECT().CountPub(gomock.Any(), int64(123)).Return(int64(0), nil)
+				return userSvc, artSvc, svcmocks.NewMockMediaService(ctrl)
+			},
+			url:     "/users/123",
+			wantRes: ginx.Result{Code: 4, Msg: "用户不存在"},
+		},
+		{
+			name: "拉黑了",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockArticleService(ctrl),
+					svcmocks.NewMockMediaService(ctrl)
+			},
+			url:     "/users/123/articles",
+			blocked: true,
+			wantRes: ginx.Result{Code: 4, Msg: "用户不存在"},
+		},
+		{
+			name: "id 不对",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockArticleService(ctrl),
+					svcmocks.NewMockMediaService(ctrl)
+			},
+			url:     "/users/abc/articles",
+			wantRes: ginx.Result{Code: 4, Msg: "id 参数错误"},
+		},
+		{
+			name: "文章列表，limit 太大",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				artSvc := svcmocks.NewMockArticleService(ctrl)
+				artSvc.EXPECT().ListPub(gomock.Any(), int64(123), 10, maxPubListLimit).
+					Return([]domain.Article{
+						{
+							Id:      1,
+							Title:   "标题",
+							Content: "内容",
+							Author:  domain.Author{Id: 123},
+							Status:  domain.ArticleStatusPublished,
+							Ctime:   now,
+							Utime:   now,
+						},
+					}, nil)
+				return svcmocks.NewMockUserService(ctrl), artSvc, svcmocks.NewMockMediaService(ctrl)
+			},
+			url: "/users/123/articles?offset=10&limit=1000",
+			wantRes: ginx.Result{
+				Data: []any{
+					map[string]any{
+						"id":         float64(1),
+						"title":      "标题",
+						"abstract":   "内容",
+						"authorId":   float64(123),
+						"ctime":      now.Format(time.DateTime),
+						"utime":      now.Format(time.DateTime),
+						"readCnt":    float64(5),
+						"likeCnt":    float64(2),
+						"collectCnt": float64(0),
+						"rewardCnt":  float64(0),
+						"liked":      false,
+						"collected":  false,
+					},
+				},
+			},
+		},
+		{
+			name: "点赞列表",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockArticleService(ctrl),
+					svcmocks.NewMockMediaService(ctrl)
+			},
+			url: "/users/123/likes",
+			wantRes: ginx.Result{
+				Data: []any{
+					map[string]any{
+						"biz":   domain.BizArticle,
+						"bizId": float64(1),
+						"ctime": now.Format(time.DateTime),
+					},
+				},
+			},
+		},
+		{
+			name: "点赞不公开",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.ArticleService, service.MediaService) {
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockArticleService(ctrl),
+					svcmocks.NewMockMediaService(ctrl)
+			},
+			url:       "/users/123/likes",
+			hideLikes: true,
+			wantRes:   ginx.Result{Code: 4, Msg: "作者设置了不公开"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, artSvc, mediaSvc := tc.mock(ctrl)
+			privacySvc := svcmocks.NewMockPrivacyService(ctrl)
+			privacySvc.EXPECT().Allow(gomock.Any(), int64(0), int64(123), domain.PrivacyActionView).
+				AnyTimes().Return(!tc.blocked, nil)
+			privacySvc.EXPECT().Allow(gomock.Any(), int64(0), int64(123), domain.PrivacyActionViewLikes).
+				AnyTimes().Return(!tc.hideLikes, nil)
+			// 列表页的计数一次查出来
+			intrSvc := svcmocks.NewMockInteractiveService(ctrl)
+			intrSvc.EXPECT().GetByIds(gomock.Any(), domain.BizArticle, []int64{1}, int64(0)).
+				AnyTimes().Return(map[int64]domain.Interactive{
+				1: {ReadCnt: 5, LikeCnt: 2},
+			}, nil)
+			intrSvc.EXPECT().ListLikes(gomock.Any(), int64(123), 0, defaultPubListLimit).
+				AnyTimes().Return([]domain.LikeItem{
+				{Biz: domain.BizArticle, BizId: 1, Ctime: now},
+			}, nil)
+			hdl := NewAuthorHandler(userSvc, artSvc, mediaSvc, privacySvc, intrSvc, logger.NewNopLogger())
+			server := gin.Default()
+			// 静态路由和 /users/:id 要能共存
+			server.GET("/users/profile", func(ctx *gin.Context) {})
+			hdl.RegisterRoutes(server)
+
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			var res ginx.Result
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeedHandler RSS、Atom 订阅源和 sitemap，给阅读器和搜索引擎用的，
+// 出错的时候只返回 HTTP 状态码
+type FeedHandler struct {
+	svc        service.FeedService
+	privacySvc service.PrivacyService
+	l          logger.Logger
+}
+
+func NewFeedHandler(svc service.FeedService, privacySvc service.PrivacyService, l logger.Logger) *FeedHandler {
+	return &FeedHandler{
+		svc:        svc,
+		privacySvc: privacySvc,
+		l:          l,
+	}
+}
+
+func (h *FeedHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/rss.xml", h.feed(domain.FeedFormatRSS))
+	server.GET("/atom.xml", h.feed(domain.FeedFormatAtom))
+	server.GET("/users/:id/rss.xml", h.authorFeed(domain.FeedFormatRSS))
+	server.GET("/users/:id/atom.xml", h.authorFeed(domain.FeedFormatAtom))
+	// /sitemap.xml 是索引，/sitemap.xml?page=1 是具体的一页
+	server.GET("/sitemap.xml", h.Sitemap)
+}
+
+// feed 全站的订阅源
+func (h *FeedHandler) feed(format domain.FeedFormat) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		h.writeFeed(ctx, 0, format)
+	}
+}
+
+// authorFeed 作者的订阅源，和作者主页一样，有拉黑关系的当作用户不存在
+func (h *FeedHandler) authorFeed(format domain.FeedFormat) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uid, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+		if err != nil || uid <= 0 {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		viewer := viewerUid(ctx)
+		ok, err := h.privacySvc.Allow(ctx, viewer, uid, domain.PrivacyActionView)
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			h.l.Error("查询拉黑关系失败",
+				logger.Int64("uid", uid),
+				logger.Int64("viewer", viewer),
+				logger.Error(err))
+			return
+		}
+		if !ok {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		h.writeFeed(ctx, uid, format)
+	}
+}
+
+func (h *FeedHandler) writeFeed(ctx *gin.Context, uid int64, format domain.FeedFormat) {
+	data, err := h.svc.Feed(ctx, uid, format)
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.Status(http.StatusNotFound)
+		return
+	case err != nil:
+		ctx.Status(http.StatusInternalServerError)
+		h.l.Error("生成订阅源失败",
+			logger.Int64("uid", uid),
+			logger.String("format", string(format)),
+			logger.Error(err))
+		return
+	}
+	contentType := "application/rss+xml; charset=utf-8"
+	if format == domain.FeedFormatAtom {
+		contentType = "application/atom+xml; charset=utf-8"
+	}
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+func (h *FeedHandler) Sitemap(ctx *gin.Context) {
+	var page int
+	if p := ctx.Query("page"); p != "" {
+		var err error
+		page, err = strconv.Atoi(p)
+		if err != nil || page <= 0 {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+	}
+	data, err := h.svc.Sitemap(ctx, page)
+	switch {
+	case errors.Is(err, service.ErrSitemapPageNotFound):
+		ctx.Status(http.StatusNotFound)
+		return
+	case err != nil:
+		ctx.Status(http.StatusInternalServerError)
+		h.l.Error("生成 sitemap 失败",
+			logger.Int("page", page),
+			logger.Error(err))
+		return
+	}
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
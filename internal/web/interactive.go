@@ -0,0 +1,164 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"webook/internal/service"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InteractiveHandler 通用的点赞、收藏、阅读接口，biz 是资源类型，比如 article、series
+type InteractiveHandler struct {
+	svc service.InteractiveService
+	l   logger.Logger
+}
+
+func NewInteractiveHandler(svc service.InteractiveService, l logger.Logger) *InteractiveHandler {
+	return &InteractiveHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *InteractiveHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/interactive/:biz/:id")
+	g.GET("", h.Get)
+	// 传入一个参数，true 就是点赞, false 就是不点赞
+	g.POST("/like", h.Like)
+	g.POST("/collect", h.Collect)
+	g.POST("/read", h.Read)
+}
+
+func (h *InteractiveHandler) Get(ctx *gin.Context) {
+	biz, id, ok := h.target(ctx)
+	if !ok {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	intr, err := h.svc.Get(ctx, biz, id, uc.Uid)
+	if err != nil {
+		h.handleErr(ctx, err, "查询互动数据失败", biz, id, uc.Uid)
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: InteractiveVo{
+			ReadCnt:    intr.ReadCnt,
+			LikeCnt:    intr.LikeCnt,
+			CollectCnt: intr.CollectCnt,
+			RewardCnt:  intr.RewardCnt,
+			Liked:      intr.Liked,
+			Collected:  intr.Collected,
+		},
+	})
+}
+
+func (h *InteractiveHandler) Like(ctx *gin.Context) {
+	type Req struct {
+		// true 是点赞，false 是不点赞
+		Like bool `json:"like"`
+	}
+	biz, id, ok := h.target(ctx)
+	if !ok {
+		return
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	var err error
+	if req.Like {
+		err = h.svc.Like(ctx, biz, id, uc.Uid)
+	} else {
+		err = h.svc.CancelLike(ctx, biz, id, uc.Uid)
+	}
+	if err != nil {
+		h.handleErr(ctx, err, "点赞/取消点赞失败", biz, id, uc.Uid)
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "OK",
+	})
+}
+
+func (h *InteractiveHandler) Collect(ctx *gin.Context) {
+	type Req struct {
+		Cid int64 `json:"cid"`
+	}
+	biz, id, ok := h.target(ctx)
+	if !ok {
+		return
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Collect(ctx, biz, id, req.Cid, uc.Uid)
+	if err != nil {
+		h.handleErr(ctx, err, "收藏失败", biz, id, uc.Uid)
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "OK",
+	})
+}
+
+func (h *InteractiveHandler) Read(ctx *gin.Context) {
+	biz, id, ok := h.target(ctx)
+	if !ok {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Read(ctx, biz, id, uc.Uid)
+	if err != nil {
+		h.handleErr(ctx, err, "记录阅读失败", biz, id, uc.Uid)
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "OK",
+	})
+}
+
+// target 解析路径里面的资源，格式不对的时候已经写好了响应
+func (h *InteractiveHandler) target(ctx *gin.Context) (string, int64, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "id 参数错误",
+		})
+		return "", 0, false
+	}
+	return ctx.Param("biz"), id, true
+}
+
+func (h *InteractiveHandler) handleErr(ctx *gin.Context, err error, msg string, biz string, id, uid int64) {
+	switch {
+	case errors.Is(err, service.ErrUnknownBiz):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "不支持的资源类型",
+		})
+	case errors.Is(err, service.ErrInteractiveTargetNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "资源不存在",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error(msg,
+			logger.String("biz", biz),
+			logger.Int64("bizId", id),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+}
@@ -0,0 +1,18 @@
+package web
+
+// InteractiveVo 任意资源的互动数据，Liked 和 Collected 是当前用户的状态
+type InteractiveVo struct {
+	ReadCnt    int64 `json:"readCnt"`
+	LikeCnt    int64 `json:"likeCnt"`
+	CollectCnt int64 `json:"collectCnt"`
+	RewardCnt  int64 `json:"rewardCnt"`
+	Liked      bool  `json:"liked"`
+	Collected  bool  `json:"collected"`
+}
+
+// InteractiveItemVo 用户点赞或者收藏的一个资源，详情由前端按照 biz 去查
+type InteractiveItemVo struct {
+	Biz   string `json:"biz"`
+	BizId int64  `json:"bizId"`
+	Ctime string `json:"ctime"`
+}
@@ -15,6 +15,8 @@ type RedisJWTHandler struct {
 	client        redis.Cmdable
 	signingMethod jwt.SigningMethod
 	rcExpiration  time.Duration
+	// 两步验证的 token 只需要很短的有效期
+	mfaExpiration time.Duration
 }
 
 var _ Handler = &RedisJWTHandler{}
@@ -24,11 +26,12 @@ func NewRedisJWTHandler(client redis.Cmdable) Handler {
 		client:        client,
 		signingMethod: jwt.SigningMethodHS512,
 		rcExpiration:  time.Hour * 24 * 7,
+		mfaExpiration: time.Minute * 5,
 	}
 }
 
 func (h *RedisJWTHandler) CheckSession(ctx *gin.Context, ssid string) error {
-	cnt, err := h.client.Exists(ctx, fmt.Sprintf("users:ssid:%s", ssid)).Result()
+	cnt, err := h.client.Exists(ctx, h.ssidKey(ssid)).Result()
 	if err != nil {
 		return err
 	}
@@ -44,11 +47,33 @@ func (h *RedisJWTHandler) ClearToken(ctx *gin.Context) error {
 	ctx.Header("x-refresh-token", "")
 	uc := ctx.MustGet("user").(UserClaims)
 
-	return h.client.Set(ctx,
-		fmt.Sprintf("users:ssid:%s", uc.Ssid),
+	return h.client.Set(ctx, h.ssidKey(uc.Ssid),
 		"", h.rcExpiration).Err()
 }
 
+func (h *RedisJWTHandler) ClearSessions(ctx *gin.Context, uid int64, keepSsid string) error {
+	key := h.userSsidsKey(uid)
+	ssids, err := h.client.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	for _, ssid := range ssids {
+		if ssid == keepSsid {
+			continue
+		}
+		// 和退出登录一样，把 ssid 标记为无效
+		err = h.client.Set(ctx, h.ssidKey(ssid), "", h.rcExpiration).Err()
+		if err != nil {
+			return err
+		}
+		err = h.client.SRem(ctx, key, ssid).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *RedisJWTHandler) ExtractToken(ctx *gin.Context) string {
 	authCode := ctx.GetHeader("Authorization")
 	if authCode == "" {
@@ -67,9 +92,32 @@ func (h *RedisJWTHandler) SetLoginToken(ctx *gin.Context, uid int64) error {
 	if err != nil {
 		return err
 	}
+	err = h.recordSession(ctx, uid, ssid)
+	if err != nil {
+		return err
+	}
 	return h.SetJWTToken(ctx, uid, ssid)
 }
 
+// recordSession 记录用户所有登录过的 ssid，方便修改密码之类的场景下让其它会话失效
+func (h *RedisJWTHandler) recordSession(ctx *gin.Context, uid int64, ssid string) error {
+	key := h.userSsidsKey(uid)
+	err := h.client.SAdd(ctx, key, ssid).Err()
+	if err != nil {
+		return err
+	}
+	// 长 token 过期了，ssid 也就没有意义了
+	return h.client.Expire(ctx, key, h.rcExpiration).Err()
+}
+
+func (h *RedisJWTHandler) ssidKey(ssid string) string {
+	return fmt.Sprintf("users:ssid:%s", ssid)
+}
+
+func (h *RedisJWTHandler) userSsidsKey(uid int64) string {
+	return fmt.Sprintf("users:ssids:%d", uid)
+}
+
 func (h *RedisJWTHandler) SetJWTToken(ctx *gin.Context, uid int64, ssid string) error {
 	uc := UserClaims{
 		Uid:       uid,
@@ -89,6 +137,41 @@ func (h *RedisJWTHandler) SetJWTToken(ctx *gin.Context, uid int64, ssid string)
 	return nil
 }
 
+func (h *RedisJWTHandler) SetMFAToken(ctx *gin.Context, uid int64) error {
+	mc := MFAClaims{
+		Uid:       uid,
+		UserAgent: ctx.GetHeader("User-Agent"),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(h.mfaExpiration)),
+		},
+	}
+	token := jwt.NewWithClaims(h.signingMethod, mc)
+	tokenStr, err := token.SignedString(MFAJWTKey)
+	if err != nil {
+		return err
+	}
+	ctx.Header("x-mfa-token", tokenStr)
+	return nil
+}
+
+func (h *RedisJWTHandler) ParseMFAToken(ctx *gin.Context) (MFAClaims, error) {
+	tokenStr := h.ExtractToken(ctx)
+	var mc MFAClaims
+	token, err := jwt.ParseWithClaims(tokenStr, &mc, func(token *jwt.Token) (interface{}, error) {
+		return MFAJWTKey, nil
+	})
+	if err != nil {
+		return MFAClaims{}, err
+	}
+	if token == nil || !token.Valid {
+		return MFAClaims{}, errors.New("token 无效")
+	}
+	if mc.UserAgent != ctx.GetHeader("User-Agent") {
+		return MFAClaims{}, errors.New("user-agent 不一致")
+	}
+	return mc, nil
+}
+
 func (h *RedisJWTHandler) setRefreshToken(ctx *gin.Context, uid int64, ssid string) error {
 	rc := RefreshClaims{
 		Uid:  uid,
@@ -108,6 +191,14 @@ func (h *RedisJWTHandler) setRefreshToken(ctx *gin.Context, uid int64, ssid stri
 
 var JWTKey = []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgK")
 var RCJWTKey = []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgA")
+var MFAJWTKey = []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgM")
+
+// MFAClaims 密码校验通过，但是还需要两步验证的中间状态
+type MFAClaims struct {
+	jwt.RegisteredClaims
+	Uid       int64
+	UserAgent string
+}
 
 type RefreshClaims struct {
 	jwt.RegisteredClaims
@@ -8,4 +8,10 @@ type Handler interface {
 	SetLoginToken(ctx *gin.Context, uid int64) error
 	SetJWTToken(ctx *gin.Context, uid int64, ssid string) error
 	CheckSession(ctx *gin.Context, ssid string) error
+	// ClearSessions 让用户除了 keepSsid 之外的所有会话失效
+	// keepSsid 为空就是全部失效
+	ClearSessions(ctx *gin.Context, uid int64, keepSsid string) error
+	// SetMFAToken 第一步登录成功之后，发一个短期的 token，用来完成两步验证
+	SetMFAToken(ctx *gin.Context, uid int64) error
+	ParseMFAToken(ctx *gin.Context) (MFAClaims, error)
 }
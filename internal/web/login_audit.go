@@ -0,0 +1,196 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// LoginAuditHandler 登录记录和异常登录的二次验证
+type LoginAuditHandler struct {
+	ijwt.Handler
+	svc service.LoginAuditService
+}
+
+func NewLoginAuditHandler(svc service.LoginAuditService, hdl ijwt.Handler) *LoginAuditHandler {
+	return &LoginAuditHandler{
+		Handler: hdl,
+		svc:     svc,
+	}
+}
+
+func (h *LoginAuditHandler) RegisterRoutes(server *gin.Engine) {
+	ug := server.Group("/users")
+	ug.POST("/login_history", h.History)
+	ug.POST("/login/step_up", h.StepUp)
+}
+
+func (h *LoginAuditHandler) History(ctx *gin.Context) {
+	var page Page
+	if err := ctx.Bind(&page); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	logs, err := h.svc.History(ctx, uc.Uid, page.Offset, page.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map(logs, func(idx int, src domain.LoginLog) LoginLogVo {
+			return LoginLogVo{
+				Method:    string(src.Method),
+				IP:        src.IP,
+				UserAgent: src.UserAgent,
+				Result:    src.Result.ToUint8(),
+				Ctime:     src.Ctime.Format(time.DateTime),
+			}
+		}),
+	})
+}
+
+// StepUp 异常登录的二次验证，和两步验证一样，前端在 Authorization 里面带上 x-mfa-token
+func (h *LoginAuditHandler) StepUp(ctx *gin.Context) {
+	type Req struct {
+		Code string `json:"code"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	mc, err := h.ParseMFAToken(ctx)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	l := newLoginLog(ctx, domain.LoginMethodStepUp, "")
+	l.Uid = mc.Uid
+	u, err := h.svc.VerifyStepUp(ctx, l, req.Code)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidStepUpCode):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码不对",
+		})
+		return
+	case errors.Is(err, service.ErrCodeVerifyTooMany):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证太频繁，请重新登录",
+		})
+		return
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	setLoginTokenOrMFA(ctx, h.Handler, u)
+}
+
+// newLoginLog 从请求里面拿到 IP 和 User-Agent
+func newLoginLog(ctx *gin.Context, method domain.LoginMethod, account string) domain.LoginLog {
+	return domain.LoginLog{
+		Account:   account,
+		Method:    method,
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.GetHeader("User-Agent"),
+	}
+}
+
+// loginSucceed 凭证校验通过之后，根据风险评估的结果决定直接登录、二次验证还是拒绝
+func loginSucceed(ctx *gin.Context, hdl ijwt.Handler, auditSvc service.LoginAuditService,
+	l domain.LoginLog, u domain.User) {
+	risk, err := auditSvc.Assess(ctx, u, l)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrCodeSendTooMany):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码发送太频繁，请稍后再试",
+		})
+		return
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	switch risk {
+	case domain.LoginRiskLocked:
+		writeLoginLocked(ctx, 0)
+	case domain.LoginRiskStepUp:
+		err = hdl.SetMFAToken(ctx, u.Id)
+		if err != nil {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 5,
+				Msg:  "系统错误",
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "检测到异常登录，验证码已经发送到你绑定的手机或者邮箱",
+			Data: StepUpRequiredVo{StepUpRequired: true},
+		})
+	default:
+		setLoginTokenOrMFA(ctx, hdl, u)
+	}
+}
+
+// checkLoginLock 校验凭证之前先看账号和 IP 有没有被锁定，返回 false 的时候已经写好了响应
+func checkLoginLock(ctx *gin.Context, guardSvc service.LoginGuardService, account string) bool {
+	ttl, err := guardSvc.Check(ctx, account, ctx.ClientIP())
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrLoginLocked):
+		writeLoginLocked(ctx, ttl)
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+	return false
+}
+
+// loginFailed 记录一次凭证不对的登录。
+// 返回 false 的时候这一次失败触发了锁定，已经写好了响应
+func loginFailed(ctx *gin.Context, auditSvc service.LoginAuditService,
+	guardSvc service.LoginGuardService, l domain.LoginLog) bool {
+	auditSvc.RecordFailure(ctx, l)
+	ttl, err := guardSvc.Fail(ctx, l.Account, l.IP)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, service.ErrLoginLocked):
+		writeLoginLocked(ctx, ttl)
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+	return false
+}
+
+func writeLoginLocked(ctx *gin.Context, ttl time.Duration) {
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Code: codeLoginLocked,
+		Msg:  "登录失败次数太多，请稍后再试",
+		Data: LoginLockedVo{RetryAfter: int64(ttl.Seconds())},
+	})
+}
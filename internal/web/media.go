@@ -0,0 +1,141 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/pkg/ginx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipart 还有一些额外的开销，真正的大小限制在 MediaService 里面
+const maxUploadBody = 10 << 20
+
+// MediaHandler 上传图片和访问图片
+type MediaHandler struct {
+	svc service.MediaService
+	// 存储自己不能对外提供访问的时候，比如本地文件系统，由这里提供下载
+	files http.Handler
+}
+
+func NewMediaHandler(svc service.MediaService, files http.Handler) *MediaHandler {
+	return &MediaHandler{
+		svc:   svc,
+		files: files,
+	}
+}
+
+func (h *MediaHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/media")
+	g.POST("/upload", h.Upload)
+	// 文章里面引用图片用这个地址，每次访问都会跳转到新签名的 URL，不会过期
+	g.GET("/view/*key", h.View)
+	if h.files != nil {
+		g.GET("/files/*key", gin.WrapH(http.StripPrefix("/media/files", h.files)))
+	}
+}
+
+func (h *MediaHandler) Upload(ctx *gin.Context) {
+	m, ok := uploadMedia(ctx, h.svc)
+	if !ok {
+		return
+	}
+	url, err := h.svc.URL(ctx, m.Key, 0)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: MediaVo{
+			Key:     m.Key,
+			URL:     url,
+			ViewURL: "/media/view/" + m.Key,
+			Width:   m.Width,
+			Height:  m.Height,
+		},
+	})
+}
+
+// View 跳转到签名的 URL，size 不为 0 的时候是缩略图
+func (h *MediaHandler) View(ctx *gin.Context) {
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	// 只能访问上传的图片
+	if !strings.HasPrefix(key, "media/") || strings.Contains(key, "..") {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	size, _ := strconv.Atoi(ctx.Query("size"))
+	url, err := h.svc.URL(ctx, key, size)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// uploadMedia 读取表单里面的 file 字段并上传，返回 false 的时候已经写好了响应
+func uploadMedia(ctx *gin.Context, svc service.MediaService) (domain.Media, bool) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxUploadBody)
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 4,
+				Msg:  "文件太大",
+			})
+			return domain.Media{}, false
+		}
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请选择要上传的文件",
+		})
+		return domain.Media{}, false
+	}
+	f, err := fh.Open()
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return domain.Media{}, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return domain.Media{}, false
+	}
+	m, err := svc.Upload(ctx, data)
+	switch {
+	case err == nil:
+		return m, true
+	case errors.Is(err, service.ErrMediaTooLarge):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文件太大",
+		})
+	case errors.Is(err, service.ErrUnsupportedMediaType):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "只支持 JPEG、PNG 和 GIF 格式的图片",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+	return domain.Media{}, false
+}
@@ -0,0 +1,11 @@
+package web
+
+type MediaVo struct {
+	Key string `json:"key"`
+	// 带签名的地址，会过期
+	URL string `json:"url"`
+	// 不会过期的地址，文章里面引用图片用这个
+	ViewURL string `json:"viewUrl"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
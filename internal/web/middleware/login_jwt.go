@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"net/http"
+	"strings"
 	ijwt "webook/internal/web/jwt"
 )
 
@@ -25,8 +26,15 @@ func (m *LoginJWTMiddlewareBuilder) CheckLogin() gin.HandlerFunc {
 			path == "/users/login" ||
 			path == "/users/login_sms/code/send" ||
 			path == "/users/login_sms" ||
-			path == "/oauth2/wechat/authurl" ||
-			path == "/oauth2/wechat/callback" {
+			path == "/users/login/mfa" ||
+			path == "/users/login/step_up" ||
+			path == "/users/password/reset/code/send" ||
+			path == "/users/password/reset" ||
+			path == "/users/email/verify" ||
+			isOAuth2LoginPath(path) ||
+			// 图片的访问地址自己带了签名
+			strings.HasPrefix(path, "/media/view/") ||
+			strings.HasPrefix(path, "/media/files/") {
 			// 不需要登录校验
 			return
 		}
@@ -63,3 +71,11 @@ func (m *LoginJWTMiddlewareBuilder) CheckLogin() gin.HandlerFunc {
 		ctx.Set("user", uc)
 	}
 }
+
+// isOAuth2LoginPath 第三方登录的 /oauth2/:provider/authurl 和 /oauth2/:provider/callback。
+// 绑定微信的 /oauth2/wechat/bind/authurl 需要登录，不在这里面
+func isOAuth2LoginPath(path string) bool {
+	segs := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	return len(segs) == 3 && segs[0] == "oauth2" &&
+		(segs[2] == "authurl" || segs[2] == "callback")
+}
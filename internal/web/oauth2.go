@@ -0,0 +1,156 @@
+package web
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	uuid "github.com/lithammer/shortuuid/v4"
+	"net/http"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/internal/service/oauth2"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+)
+
+// OAuth2Handler 通用的第三方登录，具体是哪个提供商由路径里面的 provider 决定
+type OAuth2Handler struct {
+	ijwt.Handler
+	registry        *oauth2.Registry
+	userSvc         service.UserService
+	auditSvc        service.LoginAuditService
+	key             []byte
+	stateCookieName string
+}
+
+func NewOAuth2Handler(registry *oauth2.Registry, userSvc service.UserService,
+	auditSvc service.LoginAuditService, hdl ijwt.Handler, stateKey []byte) *OAuth2Handler {
+	return &OAuth2Handler{
+		Handler:         hdl,
+		registry:        registry,
+		userSvc:         userSvc,
+		auditSvc:        auditSvc,
+		key:             stateKey,
+		stateCookieName: "jwt-state",
+	}
+}
+
+func (o *OAuth2Handler) RegisterRoutes(server *gin.Engine) {
+	// /oauth2/wechat 是静态路由，gin 会优先匹配 OAuth2WechatHandler
+	g := server.Group("/oauth2/:provider")
+	g.GET("/authurl", o.AuthURL)
+	g.Any("/callback", o.Callback)
+}
+
+func (o *OAuth2Handler) AuthURL(ctx *gin.Context) {
+	provider := ctx.Param("provider")
+	svc, ok := o.registry.Get(provider)
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "不支持的登录方式",
+		})
+		return
+	}
+	state := uuid.New()
+	val, err := svc.AuthURL(ctx, state)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "构造跳转URL失败",
+		})
+		return
+	}
+	err = o.setStateCookie(ctx, provider, state)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "服务器异常",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: val,
+	})
+}
+
+func (o *OAuth2Handler) Callback(ctx *gin.Context) {
+	provider := ctx.Param("provider")
+	svc, ok := o.registry.Get(provider)
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "不支持的登录方式",
+		})
+		return
+	}
+	err := o.verifyState(ctx, provider)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "非法请求",
+		})
+		return
+	}
+	info, err := svc.VerifyCode(ctx, ctx.Query("code"))
+	if err != nil {
+		o.auditSvc.RecordFailure(ctx, newLoginLog(ctx, domain.LoginMethod(provider), ""))
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "授权码有误",
+		})
+		return
+	}
+	u, err := o.userSvc.FindOrCreateByOAuth2(ctx, info)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	loginSucceed(ctx, o.Handler, o.auditSvc,
+		newLoginLog(ctx, domain.LoginMethod(provider), info.ExternalId), u)
+}
+
+func (o *OAuth2Handler) verifyState(ctx *gin.Context, provider string) error {
+	state := ctx.Query("state")
+	ck, err := ctx.Cookie(o.stateCookieName)
+	if err != nil {
+		return fmt.Errorf("无法获得 cookie %w", err)
+	}
+	var sc OAuth2StateClaims
+	_, err = jwt.ParseWithClaims(ck, &sc, func(token *jwt.Token) (interface{}, error) {
+		return o.key, nil
+	})
+	if err != nil {
+		return fmt.Errorf("解析 token 失败 %w", err)
+	}
+	// 防止把 A 提供商的 state 拿到 B 提供商的回调里面用
+	if state != sc.State || provider != sc.Provider {
+		return fmt.Errorf("state 不匹配")
+	}
+	return nil
+}
+
+func (o *OAuth2Handler) setStateCookie(ctx *gin.Context, provider, state string) error {
+	claims := OAuth2StateClaims{
+		State:    state,
+		Provider: provider,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	tokenStr, err := token.SignedString(o.key)
+	if err != nil {
+		return err
+	}
+	ctx.SetCookie(o.stateCookieName, tokenStr,
+		600, "/oauth2/"+provider+"/callback",
+		"", false, true)
+	return nil
+}
+
+type OAuth2StateClaims struct {
+	jwt.RegisteredClaims
+	State    string
+	Provider string
+}
@@ -0,0 +1,197 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"webook/internal/domain"
+	"webook/internal/service"
+	svcmocks "webook/internal/service/mock"
+	"webook/internal/service/oauth2"
+	"webook/internal/service/oauth2/oauth2test"
+	"webook/internal/service/oauth2/oidc"
+	ijwt "webook/internal/web/jwt"
+	jwtmocks "webook/internal/web/jwt/mock"
+	"webook/pkg/ginx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOAuth2Handler_Callback(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler)
+		// 回调的时候用的授权码
+		code string
+		// 篡改 state
+		state func(state string) string
+
+		wantResult ginx.Result
+	}{
+		{
+			name: "登录成功",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().FindOrCreateByOAuth2(gomock.Any(), domain.OAuth2Info{
+					Provider:      "test",
+					ExternalId:    "user-123",
+					Email:         "123@qq.com",
+					EmailVerified: true,
+					Nickname:      "大明",
+				}).Return(domain.User{Id: 123}, nil)
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().Assess(gomock.Any(), domain.User{Id: 123}, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, u domain.User, l domain.LoginLog) (domain.LoginRisk, error) {
+						assert.Equal(t, domain.LoginMethod("test"), l.Method)
+						assert.Equal(t, "user-123", l.Account)
+						return domain.LoginRiskNone, nil
+					})
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().SetLoginToken(gomock.Any(), int64(123)).Return(nil)
+				return userSvc, auditSvc, hdl
+			},
+			code:       oauth2test.GoodCode,
+			wantResult: ginx.Result{Msg: "登录成功"},
+		},
+		{
+			name: "异常登录要求二次验证",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().FindOrCreateByOAuth2(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 123}, nil)
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().Assess(gomock.Any(), domain.User{Id: 123}, gomock.Any()).
+					Return(domain.LoginRiskStepUp, nil)
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().SetMFAToken(gomock.Any(), int64(123)).Return(nil)
+				return userSvc, auditSvc, hdl
+			},
+			code: oauth2test.GoodCode,
+			wantResult: ginx.Result{
+				Msg:  "检测到异常登录，验证码已经发送到你绑定的手机或者邮箱",
+				Data: map[string]any{"stepUpRequired": true},
+			},
+		},
+		{
+			name: "失败次数太多",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().FindOrCreateByOAuth2(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 123}, nil)
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().Assess(gomock.Any(), domain.User{Id: 123}, gomock.Any()).
+					Return(domain.LoginRiskLocked, nil)
+				return userSvc, auditSvc, jwtmocks.NewMockHandler(ctrl)
+			},
+			code: oauth2test.GoodCode,
+			wantResult: ginx.Result{
+				Code: codeLoginLocked,
+				Msg:  "登录失败次数太多，请稍后再试",
+				Data: map[string]any{"retryAfter": float64(0)},
+			},
+		},
+		{
+			name: "开启了两步验证",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().FindOrCreateByOAuth2(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 123, TOTPEnabled: true}, nil)
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().Assess(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(domain.LoginRiskNone, nil)
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().SetMFAToken(gomock.Any(), int64(123)).Return(nil)
+				return userSvc, auditSvc, hdl
+			},
+			code: oauth2test.GoodCode,
+			wantResult: ginx.Result{
+				Msg:  "请输入两步验证码",
+				Data: map[string]any{"mfaRequired": true},
+			},
+		},
+		{
+			name: "state 不匹配",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler) {
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockLoginAuditService(ctrl),
+					jwtmocks.NewMockHandler(ctrl)
+			},
+			code: oauth2test.GoodCode,
+			state: func(state string) string {
+				return state + "x"
+			},
+			wantResult: ginx.Result{Code: 4, Msg: "非法请求"},
+		},
+		{
+			name: "授权码不对",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService, ijwt.Handler) {
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().RecordFailure(gomock.Any(), gomock.Any())
+				return svcmocks.NewMockUserService(ctrl), auditSvc, jwtmocks.NewMockHandler(ctrl)
+			},
+			code:       "bad-code",
+			wantResult: ginx.Result{Code: 4, Msg: "授权码有误"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fs := oauth2test.NewFakeOIDCServer(t)
+			registry := oauth2.NewRegistry(oidc.NewService("test", fs.URL,
+				oauth2test.ClientID, oauth2test.ClientSecret, "https://meoying.com/oauth2/test/callback"))
+			userSvc, auditSvc, jwtHdl := tc.mock(ctrl)
+			hdl := NewOAuth2Handler(registry, userSvc, auditSvc, jwtHdl, []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgB"))
+			server := gin.Default()
+			hdl.RegisterRoutes(server)
+
+			// 第一步，拿到跳转的 URL 和 state cookie
+			req := httptest.NewRequest(http.MethodGet, "/oauth2/test/authurl", nil)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			var authRes ginx.Result
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &authRes))
+			authURL, err := url.Parse(authRes.Data.(string))
+			require.NoError(t, err)
+			state := authURL.Query().Get("state")
+			if tc.state != nil {
+				state = tc.state(state)
+			}
+			cookies := resp.Result().Cookies()
+			require.Len(t, cookies, 1)
+
+			// 第二步，模拟授权服务器回调
+			req = httptest.NewRequest(http.MethodGet, "/oauth2/test/callback?"+url.Values{
+				"code":  []string{tc.code},
+				"state": []string{state},
+			}.Encode(), nil)
+			req.AddCookie(cookies[0])
+			resp = httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			var res ginx.Result
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+			assert.Equal(t, tc.wantResult, res)
+		})
+	}
+}
+
+func TestOAuth2Handler_UnknownProvider(t *testing.T) {
+	hdl := NewOAuth2Handler(oauth2.NewRegistry(), nil, nil, nil, []byte("key"))
+	server := gin.Default()
+	hdl.RegisterRoutes(server)
+	req := httptest.NewRequest(http.MethodGet, "/oauth2/unknown/authurl", nil)
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	var res ginx.Result
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	assert.Equal(t, ginx.Result{Code: 4, Msg: "不支持的登录方式"}, res)
+}
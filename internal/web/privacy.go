@@ -0,0 +1,184 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyHandler 隐私设置和黑名单
+type PrivacyHandler struct {
+	svc service.PrivacyService
+	l   logger.Logger
+}
+
+func NewPrivacyHandler(svc service.PrivacyService, l logger.Logger) *PrivacyHandler {
+	return &PrivacyHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *PrivacyHandler) RegisterRoutes(server *gin.Engine) {
+	ug := server.Group("/users")
+	ug.GET("/privacy", h.Settings)
+	ug.POST("/privacy", h.UpdateSettings)
+	ug.POST("/block", h.Block)
+	ug.POST("/unblock", h.Unblock)
+	ug.POST("/blocks", h.Blocks)
+}
+
+func (h *PrivacyHandler) Settings(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	s, err := h.svc.GetSettings(ctx, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: PrivacySettingsVo{
+			Likes:       s.Likes.ToUint8(),
+			Collections: s.Collections.ToUint8(),
+		},
+	})
+}
+
+func (h *PrivacyHandler) UpdateSettings(ctx *gin.Context) {
+	var req PrivacySettingsVo
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.UpdateSettings(ctx, domain.PrivacySettings{
+		Uid:         uc.Uid,
+		Likes:       domain.PrivacyScope(req.Likes),
+		Collections: domain.PrivacyScope(req.Collections),
+	})
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrInvalidPrivacyScope):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "隐私设置不合法",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+func (h *PrivacyHandler) Block(ctx *gin.Context) {
+	type Req struct {
+		Uid int64 `json:"uid"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Block(ctx, uc.Uid, req.Uid)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrBlockSelf):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "不能拉黑自己",
+		})
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("拉黑失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("target", req.Uid),
+			logger.Error(err))
+	}
+}
+
+func (h *PrivacyHandler) Unblock(ctx *gin.Context) {
+	type Req struct {
+		Uid int64 `json:"uid"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Unblock(ctx, uc.Uid, req.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("取消拉黑失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("target", req.Uid),
+			logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "OK",
+	})
+}
+
+func (h *PrivacyHandler) Blocks(ctx *gin.Context) {
+	var page Page
+	if err := ctx.Bind(&page); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	blocks, err := h.svc.Blocks(ctx, uc.Uid, page.Offset, page.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map(blocks, func(idx int, src domain.Block) BlockVo {
+			return BlockVo{
+				Uid:   src.BlockedUid,
+				Ctime: src.Ctime.Format(time.DateTime),
+			}
+		}),
+	})
+}
+
+// viewerUid 公开接口没有登录也能访问，这时候返回 0
+func viewerUid(ctx *gin.Context) int64 {
+	val, ok := ctx.Get("user")
+	if !ok {
+		return 0
+	}
+	uc, ok := val.(ijwt.UserClaims)
+	if !ok {
+		return 0
+	}
+	return uc.Uid
+}
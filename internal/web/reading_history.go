@@ -0,0 +1,107 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadingHistoryHandler 我的阅读历史
+type ReadingHistoryHandler struct {
+	svc service.ReadingHistoryService
+	l   logger.Logger
+}
+
+func NewReadingHistoryHandler(svc service.ReadingHistoryService, l logger.Logger) *ReadingHistoryHandler {
+	return &ReadingHistoryHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *ReadingHistoryHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/history")
+	// /history?cursor=?&limit=?
+	g.GET("", h.List)
+	g.POST("/clear", h.Clear)
+}
+
+func (h *ReadingHistoryHandler) List(ctx *gin.Context) {
+	cursorStr := ctx.Query("cursor")
+	cursor, err := decodeArticleCursor(cursorStr)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "cursor 参数错误",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if limit <= 0 {
+		limit = defaultPubListLimit
+	}
+	if limit > maxPubListLimit {
+		limit = maxPubListLimit
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	hs, err := h.svc.List(ctx, uc.Uid, cursor, limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询阅读历史失败",
+			logger.Error(err),
+			logger.String("cursor", cursorStr),
+			logger.Int64("uid", uc.Uid))
+		return
+	}
+	res := ReadingHistoryListVo{
+		List: make([]ReadingHistoryVo, 0, len(hs)),
+	}
+	for _, src := range hs {
+		// 已经删除、撤回或者设置成仅自己可见的文章不展示，但是游标照样往后走
+		if src.Article.Status != domain.ArticleStatusPublished && src.Article.Author.Id != uc.Uid {
+			continue
+		}
+		res.List = append(res.List, ReadingHistoryVo{
+			Id:         src.Aid,
+			Title:      src.Article.Title,
+			Abstract:   src.Article.Abstract(),
+			AuthorId:   src.Article.Author.Id,
+			AuthorName: src.Article.Author.Name,
+			Progress:   src.Progress,
+			ReadTime:   src.Utime.Format(time.DateTime),
+		})
+	}
+	// 不满一页说明没有了
+	if len(hs) == limit {
+		res.Cursor = encodeArticleCursor(hs[len(hs)-1].Cursor())
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{Data: res})
+}
+
+func (h *ReadingHistoryHandler) Clear(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Clear(ctx, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("清空阅读历史失败",
+			logger.Error(err),
+			logger.Int64("uid", uc.Uid))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "OK",
+	})
+}
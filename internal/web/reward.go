@@ -0,0 +1,139 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/internal/service/payment"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+// RewardHandler 积分钱包，打赏的接口在 ArticleHandler 里面
+type RewardHandler struct {
+	svc service.RewardService
+	l   logger.Logger
+}
+
+func NewRewardHandler(svc service.RewardService, l logger.Logger) *RewardHandler {
+	return &RewardHandler{
+		svc: svc,
+		l:   l,
+	}
+}
+
+func (h *RewardHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/wallet")
+	g.GET("", h.Balance)
+	g.POST("/topup", h.TopUp)
+	// /wallet/entries?offset=?&limit=?
+	g.GET("/entries", h.Entries)
+}
+
+func (h *RewardHandler) Balance(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	balance, err := h.svc.Balance(ctx, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询积分余额失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: balance,
+	})
+}
+
+func (h *RewardHandler) TopUp(ctx *gin.Context) {
+	type Req struct {
+		Amount int64 `json:"amount"`
+		// Key 客户端生成的幂等键，重试的时候要用同一个
+		Key string `json:"key"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	txn, err := h.svc.TopUp(ctx, uc.Uid, req.Amount, req.Key)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Data: txn.Id,
+		})
+	case errors.Is(err, service.ErrInvalidRewardAmount):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "充值金额不合法",
+		})
+	case errors.Is(err, service.ErrInvalidIdempotencyKey),
+		errors.Is(err, service.ErrIdempotencyKeyConflict):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "key 参数错误",
+		})
+	case errors.Is(err, payment.ErrChargeDeclined):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "支付失败",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("充值失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int64("amount", req.Amount),
+			logger.String("key", req.Key),
+			logger.Error(err))
+	}
+}
+
+func (h *RewardHandler) Entries(ctx *gin.Context) {
+	offset, _ := strconv.Atoi(ctx.Query("offset"))
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultPubListLimit
+	}
+	if limit > maxPubListLimit {
+		limit = maxPubListLimit
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	es, err := h.svc.Entries(ctx, uc.Uid, offset, limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询积分流水失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map(es, func(idx int, src domain.LedgerEntry) LedgerEntryVo {
+			return LedgerEntryVo{
+				TxnId:   src.TxnId,
+				Type:    src.Type.ToUint8(),
+				Amount:  src.Amount,
+				Balance: src.Balance,
+				Ctime:   src.Ctime.Format(time.DateTime),
+			}
+		}),
+	})
+}
@@ -0,0 +1,278 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	"webook/internal/domain"
+	"webook/internal/service"
+	"webook/internal/web/jwt"
+	"webook/pkg/ginx"
+	"webook/pkg/logger"
+
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxSeriesTitleLen       = 100
+	maxSeriesDescriptionLen = 1000
+)
+
+// SeriesHandler 系列，作者把多篇文章按顺序组织起来
+type SeriesHandler struct {
+	svc        service.SeriesService
+	privacySvc service.PrivacyService
+	l          logger.Logger
+}
+
+func NewSeriesHandler(svc service.SeriesService, privacySvc service.PrivacyService,
+	l logger.Logger) *SeriesHandler {
+	return &SeriesHandler{
+		svc:        svc,
+		privacySvc: privacySvc,
+		l:          l,
+	}
+}
+
+func (h *SeriesHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/series")
+	g.POST("/create", h.Create)
+	g.POST("/list", h.List)
+	g.POST("/add", h.AddArticle)
+	g.POST("/remove", h.RemoveArticle)
+	g.POST("/reorder", h.Reorder)
+	// 公开的系列页面，不需要登录
+	g.GET("/:id", h.PubDetail)
+}
+
+func (h *SeriesHandler) Create(ctx *gin.Context) {
+	type Req struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	if req.Title == "" || utf8.RuneCountInString(req.Title) > maxSeriesTitleLen ||
+		utf8.RuneCountInString(req.Description) > maxSeriesDescriptionLen {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "标题或者简介长度不对",
+		})
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	id, err := h.svc.Create(ctx, domain.Series{
+		AuthorId:    uc.Uid,
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("创建系列失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: id,
+	})
+}
+
+// List 自己的系列，编辑的时候用
+func (h *SeriesHandler) List(ctx *gin.Context) {
+	var page Page
+	if err := ctx.Bind(&page); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	ss, err := h.svc.List(ctx, uc.Uid, page.Offset, page.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询系列列表失败",
+			logger.Int64("uid", uc.Uid),
+			logger.Int("offset", page.Offset),
+			logger.Int("limit", page.Limit),
+			logger.Error(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: slice.Map[domain.Series, SeriesVo](ss, func(idx int, src domain.Series) SeriesVo {
+			return h.toVo(src)
+		}),
+	})
+}
+
+type seriesArticleReq struct {
+	Id  int64 `json:"id"`
+	Aid int64 `json:"aid"`
+}
+
+func (h *SeriesHandler) AddArticle(ctx *gin.Context) {
+	var req seriesArticleReq
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.svc.AddArticle(ctx, uc.Uid, req.Id, req.Aid)
+	h.handleErr(ctx, err, "添加系列文章失败", uc.Uid, req.Id)
+}
+
+func (h *SeriesHandler) RemoveArticle(ctx *gin.Context) {
+	var req seriesArticleReq
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.svc.RemoveArticle(ctx, uc.Uid, req.Id, req.Aid)
+	h.handleErr(ctx, err, "移除系列文章失败", uc.Uid, req.Id)
+}
+
+func (h *SeriesHandler) Reorder(ctx *gin.Context) {
+	type Req struct {
+		Id int64 `json:"id"`
+		// Aids 调整之后系列里面所有文章的顺序
+		Aids []int64 `json:"aids"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(jwt.UserClaims)
+	err := h.svc.Reorder(ctx, uc.Uid, req.Id, req.Aids)
+	h.handleErr(ctx, err, "调整系列顺序失败", uc.Uid, req.Id)
+}
+
+// handleErr 修改系列的几个接口错误处理都一样
+func (h *SeriesHandler) handleErr(ctx *gin.Context, err error, msg string, uid, id int64) {
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "OK",
+		})
+	case errors.Is(err, service.ErrSeriesNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "系列不存在",
+		})
+	case errors.Is(err, service.ErrArticleNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章不存在",
+		})
+	case errors.Is(err, service.ErrSeriesArticleDuplicate):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "文章已经在系列里面了",
+		})
+	case errors.Is(err, service.ErrSeriesFull):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "系列里面的文章太多了",
+		})
+	case errors.Is(err, service.ErrSeriesArticlesMismatch):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "系列里面的文章已经变了，请刷新之后再调整",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error(msg,
+			logger.Int64("uid", uid),
+			logger.Int64("id", id),
+			logger.Error(err))
+	}
+}
+
+// PubDetail 公开的系列页面，按顺序列出已经发表的文章
+func (h *SeriesHandler) PubDetail(ctx *gin.Context) {
+	idstr := ctx.Param("id")
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "id 参数错误",
+		})
+		return
+	}
+	s, arts, err := h.svc.PubDetail(ctx, id)
+	if errors.Is(err, service.ErrSeriesNotFound) {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "系列不存在",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询系列失败",
+			logger.Int64("id", id),
+			logger.Error(err))
+		return
+	}
+	// 拉黑了的双方互相看不到对方的系列
+	viewer := viewerUid(ctx)
+	ok, err := h.privacySvc.Allow(ctx, viewer, s.AuthorId, domain.PrivacyActionView)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		h.l.Error("查询拉黑关系失败",
+			logger.Int64("uid", s.AuthorId),
+			logger.Int64("viewer", viewer),
+			logger.Error(err))
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "系列不存在",
+		})
+		return
+	}
+	vo := h.toVo(s)
+	// 没有发表的文章的 ID 也不能给别人看
+	vo.ArticleIds = nil
+	vo.Articles = slice.Map[domain.Article, ArticleVo](arts, func(idx int, src domain.Article) ArticleVo {
+		return ArticleVo{
+			Id:       src.Id,
+			Title:    src.Title,
+			Abstract: src.Abstract(),
+			AuthorId: src.Author.Id,
+			Ctime:    src.Ctime.Format(time.DateTime),
+			Utime:    src.Utime.Format(time.DateTime),
+		}
+	})
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: vo,
+	})
+}
+
+func (h *SeriesHandler) toVo(s domain.Series) SeriesVo {
+	return SeriesVo{
+		Id:          s.Id,
+		AuthorId:    s.AuthorId,
+		Title:       s.Title,
+		Description: s.Description,
+		ArticleIds:  s.ArticleIds,
+		Ctime:       s.Ctime.Format(time.DateTime),
+		Utime:       s.Utime.Format(time.DateTime),
+	}
+}
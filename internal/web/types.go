@@ -1,6 +1,18 @@
 package web
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"webook/internal/domain"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/ginx"
+)
+
+// codeLoginLocked 登录失败次数太多被锁定，前端根据 retryAfter 提示还要等多久
+const codeLoginLocked = 429
+
+// codeArticleConflict 文章在别的地方修改过了，前端要提示作者刷新
+const codeArticleConflict = 409
 
 type Handler interface {
 	RegisterRoutes(server *gin.Engine)
@@ -10,3 +22,34 @@ type Page struct {
 	Limit  int
 	Offset int
 }
+
+// setLoginTokenOrMFA 各种登录方式最后都走这里。
+// 开启了两步验证的用户只拿到 mfa token，两步验证通过之后在 LoginMFA 里面才真正登录
+func setLoginTokenOrMFA(ctx *gin.Context, hdl ijwt.Handler, u domain.User) {
+	if u.TOTPEnabled {
+		err := hdl.SetMFAToken(ctx, u.Id)
+		if err != nil {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 5,
+				Msg:  "系统错误",
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "请输入两步验证码",
+			Data: MFARequiredVo{MFARequired: true},
+		})
+		return
+	}
+	err := hdl.SetLoginToken(ctx, u.Id)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "登录成功",
+	})
+}
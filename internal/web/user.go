@@ -20,6 +20,12 @@ const (
 	// 和上面比起来，用 ` 看起来就比较清爽
 	passwordRegexPattern = `^(?=.*[A-Za-z])(?=.*\d)(?=.*[$@$!%*#?&])[A-Za-z\d$@$!%*#?&]{8,72}$`
 	bizLogin             = "login"
+	// 忘记密码的时候重置密码
+	bizResetPassword = "reset_password"
+	// 给当前账号绑定手机号或者邮箱
+	bizBind = "bind"
+	// 头像用的缩略图尺寸
+	avatarSize = 256
 )
 
 type UserHandler struct {
@@ -28,15 +34,23 @@ type UserHandler struct {
 	passwordRexExp *regexp.Regexp
 	svc            service.UserService
 	codeSvc        service.CodeService
+	auditSvc       service.LoginAuditService
+	guardSvc       service.LoginGuardService
+	mediaSvc       service.MediaService
 }
 
-func NewUserHandler(svc service.UserService, codeSvc service.CodeService, hdl ijwt.Handler) *UserHandler {
+func NewUserHandler(svc service.UserService, codeSvc service.CodeService,
+	auditSvc service.LoginAuditService, guardSvc service.LoginGuardService,
+	mediaSvc service.MediaService, hdl ijwt.Handler) *UserHandler {
 	return &UserHandler{
 		Handler:        hdl,
 		emailRexExp:    regexp.MustCompile(emailRegexPattern, regexp.None),
 		passwordRexExp: regexp.MustCompile(passwordRegexPattern, regexp.None),
 		svc:            svc,
 		codeSvc:        codeSvc,
+		auditSvc:       auditSvc,
+		guardSvc:       guardSvc,
+		mediaSvc:       mediaSvc,
 	}
 }
 func (h *UserHandler) RegisterRoutes(server *gin.Engine) {
@@ -49,11 +63,32 @@ func (h *UserHandler) RegisterRoutes(server *gin.Engine) {
 	ug.POST("/signup", ginx.WrapBody(h.SignUp))
 
 	ug.POST("/edit", h.Edit)
+	ug.POST("/avatar", h.UploadAvatar)
 	ug.POST("/login_sms/code/send", h.SendSMSLoginCode)
 	ug.POST("/login_sms", h.LoginSMS)
 	ug.GET("/profile", h.Profile)
 	ug.POST("/logout", h.LogoutJWT)
 	ug.GET("/refresh_token", h.RefreshToken)
+
+	// 修改密码需要登录，重置密码不需要
+	ug.POST("/password/change", h.ChangePassword)
+	ug.POST("/password/reset/code/send", h.SendResetPasswordCode)
+	ug.POST("/password/reset", h.ResetPassword)
+
+	ug.POST("/email/verify/send", h.SendVerifyEmail)
+	// 邮件里面的链接，只能是 GET
+	ug.GET("/email/verify", h.VerifyEmail)
+
+	// 两步验证
+	ug.POST("/login/mfa", h.LoginMFA)
+	ug.POST("/totp/enroll", h.EnrollTOTP)
+	ug.POST("/totp/confirm", h.ConfirmTOTP)
+	ug.POST("/totp/disable", h.DisableTOTP)
+
+	// 绑定和解绑登录方式，微信的绑定在 OAuth2WechatHandler 里面
+	ug.POST("/bind/code/send", h.SendBindCode)
+	ug.POST("/bind", h.Bind)
+	ug.POST("/unbind", h.Unbind)
 }
 
 // 登录
@@ -147,6 +182,8 @@ func (h *UserHandler) SignUp(ctx *gin.Context, req SignUpReq) (ginx.Result, erro
 
 	switch {
 	case err == nil:
+		// 验证邮件发送失败不影响注册，用户可以登录之后重新发送
+		_ = h.svc.SendVerifyEmail(ctx, req.Email)
 		return ginx.Result{
 			Msg: "OK",
 		}, nil
@@ -168,7 +205,7 @@ func (h *UserHandler) Edit(ctx *gin.Context) {
 		// 邮箱 密码 手机号不允许在这个位置修改
 		Nickname string `json:"nickname"`
 		Birthday string `json:"birthday"`
-		// 最大长度限制是128字节
+		// 最大长度限制是128个字符
 		AboutMe string `json:"aboutme" binding:"max=128"`
 	}
 
@@ -195,12 +232,18 @@ func (h *UserHandler) Edit(ctx *gin.Context) {
 
 	err = h.svc.UpdateNonSensitiveInfo(ctx, u)
 
-	if err != nil {
+	switch {
+	case err == nil:
+		ctx.String(http.StatusOK, "修改信息成功")
+	case errors.Is(err, service.ErrInvalidNickname):
+		ctx.String(http.StatusOK, err.Error())
+	case errors.Is(err, service.ErrDuplicateNickname):
+		ctx.String(http.StatusOK, "昵称已经被使用")
+	case errors.Is(err, service.ErrSensitiveContent):
+		ctx.String(http.StatusOK, "昵称或者简介包含敏感词")
+	default:
 		ctx.String(http.StatusOK, "修改信息失败")
-		return
 	}
-
-	ctx.String(http.StatusOK, "修改信息成功")
 }
 
 func (h *UserHandler) RefreshToken(ctx *gin.Context) {
@@ -245,6 +288,35 @@ func (h *UserHandler) LogoutJWT(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, ginx.Result{Msg: "退出登录成功"})
 }
 
+// UploadAvatar 上传头像，返回头像的访问地址
+func (h *UserHandler) UploadAvatar(ctx *gin.Context) {
+	m, ok := uploadMedia(ctx, h.mediaSvc)
+	if !ok {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.UpdateAvatar(ctx, uc.Uid, m.Key)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	url, err := h.mediaSvc.URL(ctx, m.Key, avatarSize)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg:  "上传成功",
+		Data: url,
+	})
+}
+
 // 用户信息
 func (h *UserHandler) Profile(ctx *gin.Context) {
 	uc := ctx.MustGet("user").(ijwt.UserClaims)
@@ -257,17 +329,27 @@ func (h *UserHandler) Profile(ctx *gin.Context) {
 
 	//返回给前端的数据
 	type User struct {
-		Nickname string `json:"nickname"`
-		Email    string `json:"email"`
-		AboutMe  string `json:"aboutMe"`
-		Birthday string `json:"birthday"`
+		Nickname      string `json:"nickname"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"emailVerified"`
+		AboutMe       string `json:"aboutMe"`
+		Birthday      string `json:"birthday"`
+		Avatar        string `json:"avatar"`
 	}
 
 	resUser := User{
-		Nickname: u.Nickname,
-		Email:    u.Email,
-		AboutMe:  u.AboutMe,
-		Birthday: u.Birthday.Format(time.DateOnly),
+		Nickname:      u.Nickname,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		AboutMe:       u.AboutMe,
+		Birthday:      u.Birthday.Format(time.DateOnly),
+	}
+	if u.Avatar != "" {
+		resUser.Avatar, err = h.mediaSvc.URL(ctx, u.Avatar, avatarSize)
+		if err != nil {
+			ctx.String(http.StatusOK, "系统错误")
+			return
+		}
 	}
 
 	ctx.JSON(200, resUser)
@@ -285,17 +367,18 @@ func (h *UserHandler) LoginJWT(ctx *gin.Context) {
 		return
 	}
 
+	if !checkLoginLock(ctx, h.guardSvc, req.Email) {
+		return
+	}
 	u, err := h.svc.Login(ctx, req.Email, req.Password)
-
+	l := newLoginLog(ctx, domain.LoginMethodPassword, req.Email)
 	switch err {
 	case nil:
-
-		err = h.SetLoginToken(ctx, u.Id)
-		if err != nil {
-			ctx.String(http.StatusOK, "系统错误")
+		loginSucceed(ctx, h.Handler, h.auditSvc, l, u)
+	case service.ErrInvalidUserOrPassword:
+		if !loginFailed(ctx, h.auditSvc, h.guardSvc, l) {
 			return
 		}
-	case service.ErrInvalidUserOrPassword:
 		ctx.String(http.StatusOK, service.ErrInvalidUserOrPassword.Error())
 	default:
 		ctx.String(http.StatusOK, "系统错误")
@@ -350,6 +433,9 @@ func (h *UserHandler) LoginSMS(ctx *gin.Context) {
 	if err := ctx.Bind(&req); err != nil {
 		return
 	}
+	if !checkLoginLock(ctx, h.guardSvc, req.Phone) {
+		return
+	}
 	ok, err := h.codeSvc.Verify(ctx, bizLogin, req.Phone, req.Code)
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
@@ -358,7 +444,11 @@ func (h *UserHandler) LoginSMS(ctx *gin.Context) {
 		})
 		return
 	}
+	l := newLoginLog(ctx, domain.LoginMethodSMS, req.Phone)
 	if !ok {
+		if !loginFailed(ctx, h.auditSvc, h.guardSvc, l) {
+			return
+		}
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Code: 4,
 			Msg:  "验证码不对，请重新输入",
@@ -375,13 +465,593 @@ func (h *UserHandler) LoginSMS(ctx *gin.Context) {
 		})
 		return
 	}
-	err = h.SetLoginToken(ctx, u.Id)
+	loginSucceed(ctx, h.Handler, h.auditSvc, l, u)
+}
+
+// ChangePassword 修改密码，修改成功之后，其它设备上的登录态全部失效
+func (h *UserHandler) ChangePassword(ctx *gin.Context) {
+	type Req struct {
+		OldPassword     string `json:"oldPassword"`
+		NewPassword     string `json:"newPassword"`
+		ConfirmPassword string `json:"confirmPassword"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	if res, ok := h.checkNewPassword(req.NewPassword, req.ConfirmPassword); !ok {
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.ChangePassword(ctx, uc.Uid, req.OldPassword, req.NewPassword)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidUserOrPassword):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "旧密码不对",
+		})
+		return
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	err = h.ClearSessions(ctx, uc.Uid, uc.Ssid)
 	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
+		// 密码已经改了，这里失败了也只能让用户重新操作一下
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "修改密码成功",
+	})
+}
+
+// SendResetPasswordCode 忘记密码，发送验证码。手机号和邮箱二选一
+func (h *UserHandler) SendResetPasswordCode(ctx *gin.Context) {
+	type Req struct {
+		Phone string `json:"phone"`
+		Email string `json:"email"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+
+	var err error
+	switch {
+	case req.Phone != "":
+		err = h.codeSvc.Send(ctx, bizResetPassword, req.Phone)
+	case req.Email != "":
+		isEmail, er := h.emailRexExp.MatchString(req.Email)
+		if er != nil || !isEmail {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 4,
+				Msg:  "非法邮箱格式",
+			})
+			return
+		}
+		err = h.codeSvc.SendByEmail(ctx, bizResetPassword, req.Email)
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请输入手机号码或者邮箱",
+		})
+		return
+	}
+
+	switch err {
+	case nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "发送成功",
+		})
+	case service.ErrCodeSendTooMany:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// ResetPassword 校验验证码，然后重置密码，所有的登录态都会失效
+func (h *UserHandler) ResetPassword(ctx *gin.Context) {
+	type Req struct {
+		Phone           string `json:"phone"`
+		Email           string `json:"email"`
+		Code            string `json:"code"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirmPassword"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	if res, ok := h.checkNewPassword(req.Password, req.ConfirmPassword); !ok {
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+
+	var (
+		ok  bool
+		err error
+	)
+	switch {
+	case req.Phone != "":
+		ok, err = h.codeSvc.Verify(ctx, bizResetPassword, req.Phone, req.Code)
+	case req.Email != "":
+		ok, err = h.codeSvc.VerifyByEmail(ctx, bizResetPassword, req.Email, req.Code)
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请输入手机号码或者邮箱",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统异常",
+		})
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码不对，请重新输入",
+		})
+		return
+	}
+
+	var u domain.User
+	if req.Phone != "" {
+		u, err = h.svc.ResetPasswordByPhone(ctx, req.Phone, req.Password)
+	} else {
+		u, err = h.svc.ResetPasswordByEmail(ctx, req.Email, req.Password)
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		})
+		return
+	case errors.Is(err, service.ErrEmailNotVerified):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "邮箱还没有验证，请使用手机号找回密码",
+		})
+		return
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+
+	err = h.ClearSessions(ctx, u.Id, "")
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "重置密码成功",
+	})
+}
+
+// SendVerifyEmail 重新发送邮箱验证链接
+func (h *UserHandler) SendVerifyEmail(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	u, err := h.svc.FindById(ctx, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	if u.Email == "" {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "还没有绑定邮箱",
+		})
+		return
+	}
+	if u.EmailVerified {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "邮箱已经验证过了",
+		})
+		return
+	}
+	err = h.svc.SendVerifyEmail(ctx, u.Email)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Msg: "发送成功",
+	})
+}
+
+// VerifyEmail 用户点击邮件里面的验证链接
+func (h *UserHandler) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证链接无效",
+		})
+		return
+	}
+	err := h.svc.VerifyEmail(ctx, token)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "邮箱验证成功",
+		})
+	case errors.Is(err, service.ErrInvalidVerifyToken):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证链接无效或者已经过期",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// LoginMFA 两步验证的第二步，前端在 Authorization 里面带上 x-mfa-token
+func (h *UserHandler) LoginMFA(ctx *gin.Context) {
+	type Req struct {
+		// TOTP 验证码或者恢复码
+		Code string `json:"code"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	mc, err := h.ParseMFAToken(ctx)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	// MFA token 有效期内可以一直提交，所以输错验证码也要计数
+	account := service.MFAGuardAccount(mc.Uid)
+	if !checkLoginLock(ctx, h.guardSvc, account) {
+		return
+	}
+	err = h.svc.VerifyTOTP(ctx, mc.Uid, req.Code)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidTOTPCode):
+		l := newLoginLog(ctx, domain.LoginMethodTOTP, account)
+		l.Uid = mc.Uid
+		if !loginFailed(ctx, h.auditSvc, h.guardSvc, l) {
+			return
+		}
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码不对",
+		})
+		return
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	err = h.SetLoginToken(ctx, mc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
 		return
 	}
 	ctx.JSON(http.StatusOK, ginx.Result{
 		Msg: "登录成功",
 	})
+}
+
+func (h *UserHandler) EnrollTOTP(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	e, err := h.svc.EnrollTOTP(ctx, uc.Uid)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Data: TOTPEnrollmentVo{
+				Secret: e.Secret,
+				URI:    e.URI,
+			},
+		})
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "已经开启了两步验证",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+func (h *UserHandler) ConfirmTOTP(ctx *gin.Context) {
+	type Req struct {
+		Code string `json:"code"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	codes, err := h.svc.ConfirmTOTP(ctx, uc.Uid, req.Code)
+	switch {
+	case err == nil:
+		// 恢复码只会返回这一次，前端要提示用户保存好
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "开启两步验证成功",
+			Data: codes,
+		})
+	case errors.Is(err, service.ErrInvalidTOTPCode):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码不对",
+		})
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "已经开启了两步验证",
+		})
+	case errors.Is(err, service.ErrTOTPNotEnabled):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请先绑定身份验证器",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+func (h *UserHandler) DisableTOTP(ctx *gin.Context) {
+	type Req struct {
+		Code string `json:"code"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.DisableTOTP(ctx, uc.Uid, req.Code)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "已关闭两步验证",
+		})
+	case errors.Is(err, service.ErrInvalidTOTPCode):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码不对",
+		})
+	case errors.Is(err, service.ErrTOTPNotEnabled):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "没有开启两步验证",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+func (h *UserHandler) SendBindCode(ctx *gin.Context) {
+	type Req struct {
+		Phone string `json:"phone"`
+		Email string `json:"email"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+
+	var err error
+	switch {
+	case req.Phone != "":
+		err = h.codeSvc.Send(ctx, bizBind, req.Phone)
+	case req.Email != "":
+		isEmail, er := h.emailRexExp.MatchString(req.Email)
+		if er != nil || !isEmail {
+			ctx.JSON(http.StatusOK, ginx.Result{
+				Code: 4,
+				Msg:  "非法邮箱格式",
+			})
+			return
+		}
+		err = h.codeSvc.SendByEmail(ctx, bizBind, req.Email)
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请输入手机号码或者邮箱",
+		})
+		return
+	}
+
+	switch err {
+	case nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "发送成功",
+		})
+	case service.ErrCodeSendTooMany:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码发送太频繁，请稍后再试",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+// Bind 校验验证码，然后把手机号或者邮箱绑定到当前账号
+func (h *UserHandler) Bind(ctx *gin.Context) {
+	type Req struct {
+		Phone string `json:"phone"`
+		Email string `json:"email"`
+		Code  string `json:"code"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+
+	var (
+		ok  bool
+		err error
+	)
+	switch {
+	case req.Phone != "":
+		ok, err = h.codeSvc.Verify(ctx, bizBind, req.Phone, req.Code)
+	case req.Email != "":
+		ok, err = h.codeSvc.VerifyByEmail(ctx, bizBind, req.Email, req.Code)
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "请输入手机号码或者邮箱",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统异常",
+		})
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "验证码不对，请重新输入",
+		})
+		return
+	}
+
+	if req.Phone != "" {
+		err = h.svc.BindPhone(ctx, uc.Uid, req.Phone)
+	} else {
+		err = h.svc.BindEmail(ctx, uc.Uid, req.Email)
+	}
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "绑定成功",
+		})
+	case errors.Is(err, service.ErrIdentityBound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "已经绑定了别的账号，请联系管理员合并账号",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
+
+func (h *UserHandler) Unbind(ctx *gin.Context) {
+	type Req struct {
+		// phone, email, wechat 或者 oauth2:github 这种第三方账号
+		Identity string `json:"identity"`
+	}
+	var req Req
+	if err := ctx.Bind(&req); err != nil {
+		return
+	}
+	identity := domain.Identity(req.Identity)
+	_, isOAuth2 := identity.OAuth2Provider()
+	switch {
+	case isOAuth2:
+	case identity == domain.IdentityPhone, identity == domain.IdentityEmail, identity == domain.IdentityWechat:
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "未知的登录方式",
+		})
+		return
+	}
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	err := h.svc.Unbind(ctx, uc.Uid, identity)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "解绑成功",
+		})
+	case errors.Is(err, service.ErrLastIdentity):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "至少要保留一种登录方式",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+	}
+}
 
+// checkNewPassword 校验新密码的格式，第二个返回值为 false 的时候直接把 Result 返回给前端
+func (h *UserHandler) checkNewPassword(password, confirmPassword string) (ginx.Result, bool) {
+	if password != confirmPassword {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "两次输入的密码不相等",
+		}, false
+	}
+	isPassword, err := h.passwordRexExp.MatchString(password)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, false
+	}
+	if !isPassword {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "密码必须包含字母、数字、特殊字符",
+		}, false
+	}
+	return ginx.Result{}, true
 }
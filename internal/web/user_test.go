@@ -8,9 +8,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"webook/internal/domain"
 	"webook/internal/service"
 	svcmocks "webook/internal/service/mock"
+	ijwt "webook/internal/web/jwt"
+	jwtmocks "webook/internal/web/jwt/mock"
 )
 
 func TestUserHandler_SignUp(t *testing.T) {
@@ -33,6 +36,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 					Email:    "123@qq.com",
 					Password: "hello#world123",
 				}).Return(nil)
+				userSvc.EXPECT().SendVerifyEmail(gomock.Any(), "123@qq.com").Return(nil)
 				return userSvc, nil
 			},
 			req: func(t *testing.T) *http.Request {
@@ -168,7 +172,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 
 			// 构造handler
 			userSvc, codeSvc := tc.mock(ctrl)
-			hdl := NewUserHandler(userSvc, codeSvc, nil)
+			hdl := NewUserHandler(userSvc, codeSvc, nil, nil, nil, nil)
 
 			//启动服务,注册路由
 			server := gin.Default()
@@ -189,3 +193,90 @@ func TestUserHandler_SignUp(t *testing.T) {
 		})
 	}
 }
+
+func TestUserHandler_LoginMFA(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService,
+			service.LoginGuardService, ijwt.Handler)
+
+		wantBody string
+	}{
+		{
+			name: "验证码不对，记一次失败",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService,
+				service.LoginGuardService, ijwt.Handler) {
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().ParseMFAToken(gomock.Any()).Return(ijwt.MFAClaims{Uid: 123}, nil)
+				guardSvc := svcmocks.NewMockLoginGuardService(ctrl)
+				guardSvc.EXPECT().Check(gomock.Any(), "mfa:123", gomock.Any()).Return(time.Duration(0), nil)
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().VerifyTOTP(gomock.Any(), int64(123), "654321").
+					Return(service.ErrInvalidTOTPCode)
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().RecordFailure(gomock.Any(), gomock.Any()).
+					Do(func(_ any, l domain.LoginLog) {
+						assert.Equal(t, int64(123), l.Uid)
+						assert.Equal(t, domain.LoginMethodTOTP, l.Method)
+					})
+				guardSvc.EXPECT().Fail(gomock.Any(), "mfa:123", gomock.Any()).Return(time.Duration(0), nil)
+				return userSvc, auditSvc, guardSvc, hdl
+			},
+			wantBody: `{"code":4,"msg":"验证码不对","data":null}`,
+		},
+		{
+			name: "验证码错太多次，锁定",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService,
+				service.LoginGuardService, ijwt.Handler) {
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().ParseMFAToken(gomock.Any()).Return(ijwt.MFAClaims{Uid: 123}, nil)
+				guardSvc := svcmocks.NewMockLoginGuardService(ctrl)
+				guardSvc.EXPECT().Check(gomock.Any(), "mfa:123", gomock.Any()).Return(time.Duration(0), nil)
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().VerifyTOTP(gomock.Any(), int64(123), "654321").
+					Return(service.ErrInvalidTOTPCode)
+				auditSvc := svcmocks.NewMockLoginAuditService(ctrl)
+				auditSvc.EXPECT().RecordFailure(gomock.Any(), gomock.Any())
+				guardSvc.EXPECT().Fail(gomock.Any(), "mfa:123", gomock.Any()).
+					Return(time.Minute*5, service.ErrLoginLocked)
+				return userSvc, auditSvc, guardSvc, hdl
+			},
+			wantBody: `{"code":429,"msg":"登录失败次数太多，请稍后再试","data":{"retryAfter":300}}`,
+		},
+		{
+			name: "已经锁定，不校验验证码",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.LoginAuditService,
+				service.LoginGuardService, ijwt.Handler) {
+				hdl := jwtmocks.NewMockHandler(ctrl)
+				hdl.EXPECT().ParseMFAToken(gomock.Any()).Return(ijwt.MFAClaims{Uid: 123}, nil)
+				guardSvc := svcmocks.NewMockLoginGuardService(ctrl)
+				guardSvc.EXPECT().Check(gomock.Any(), "mfa:123", gomock.Any()).
+					Return(time.Minute, service.ErrLoginLocked)
+				return svcmocks.NewMockUserService(ctrl), svcmocks.NewMockLoginAuditService(ctrl), guardSvc, hdl
+			},
+			wantBody: `{"code":429,"msg":"登录失败次数太多，请稍后再试","data":{"retryAfter":60}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userSvc, auditSvc, guardSvc, jwtHdl := tc.mock(ctrl)
+			hdl := NewUserHandler(userSvc, nil, auditSvc, guardSvc, nil, jwtHdl)
+			server := gin.Default()
+			hdl.RegisterRoutes(server)
+
+			req, err := http.NewRequest(http.MethodPost, "/users/login/mfa",
+				bytes.NewReader([]byte(`{"code":"654321"}`)))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tc.wantBody, resp.Body.String())
+		})
+	}
+}
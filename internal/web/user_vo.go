@@ -5,3 +5,52 @@ type SignUpReq struct {
 	Password        string `json:"password"`
 	ConfirmPassword string `json:"confirmPassword"`
 }
+
+type TOTPEnrollmentVo struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFARequiredVo 登录的时候需要两步验证
+type MFARequiredVo struct {
+	MFARequired bool `json:"mfaRequired"`
+}
+
+// StepUpRequiredVo 异常登录，需要输入发送到手机或者邮箱的验证码
+type StepUpRequiredVo struct {
+	StepUpRequired bool `json:"stepUpRequired"`
+}
+
+type LoginLogVo struct {
+	Method    string `json:"method"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	Result    uint8  `json:"result"`
+	Ctime     string `json:"ctime"`
+}
+
+// LoginLockedVo 登录被锁定
+type LoginLockedVo struct {
+	// 还要等多少秒，不知道的时候为 0
+	RetryAfter int64 `json:"retryAfter"`
+}
+
+// AuthorProfileVo 公开的个人主页
+type AuthorProfileVo struct {
+	Id         int64  `json:"id"`
+	Nickname   string `json:"nickname"`
+	AboutMe    string `json:"aboutMe"`
+	Avatar     string `json:"avatar"`
+	ArticleCnt int64  `json:"articleCnt"`
+}
+
+// PrivacySettingsVo 取值是 domain.PrivacyScope，0 所有人，1 只有自己
+type PrivacySettingsVo struct {
+	Likes       uint8 `json:"likes"`
+	Collections uint8 `json:"collections"`
+}
+
+type BlockVo struct {
+	Uid   int64  `json:"uid"`
+	Ctime string `json:"ctime"`
+}
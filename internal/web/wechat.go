@@ -1,11 +1,13 @@
 package web
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	uuid "github.com/lithammer/shortuuid/v4"
 	"net/http"
+	"webook/internal/domain"
 	"webook/internal/service"
 	"webook/internal/service/oauth2/wechat"
 	ijwt "webook/internal/web/jwt"
@@ -13,18 +15,21 @@ import (
 )
 
 type OAuth2WechatHandler struct {
-	svc     wechat.Service
-	userSvc service.UserService
+	svc      wechat.Service
+	userSvc  service.UserService
+	auditSvc service.LoginAuditService
 	ijwt.Handler
 	key             []byte
 	stateCookieName string
 }
 
-func NewOAuth2WechatHandler(svc wechat.Service, userSvc service.UserService, hdl ijwt.Handler) *OAuth2WechatHandler {
+func NewOAuth2WechatHandler(svc wechat.Service, userSvc service.UserService,
+	auditSvc service.LoginAuditService, hdl ijwt.Handler) *OAuth2WechatHandler {
 	return &OAuth2WechatHandler{
 		Handler:         hdl,
 		svc:             svc,
 		userSvc:         userSvc,
+		auditSvc:        auditSvc,
 		key:             []byte("k6CswdUm77WKcbM68UQUuxVsHSpTCwgB"),
 		stateCookieName: "jwt-state",
 	}
@@ -33,6 +38,8 @@ func NewOAuth2WechatHandler(svc wechat.Service, userSvc service.UserService, hdl
 func (o *OAuth2WechatHandler) RegisterRoutes(server *gin.Engine) {
 	g := server.Group("/oauth2/wechat")
 	g.GET("/authurl", o.Auth2URL)
+	// 已经登录的用户绑定微信，回调还是走 /callback
+	g.GET("/bind/authurl", o.BindAuth2URL)
 	g.Any("/callback", o.Callback)
 }
 
@@ -46,7 +53,7 @@ func (o *OAuth2WechatHandler) Auth2URL(ctx *gin.Context) {
 		})
 		return
 	}
-	err = o.setStateCookie(ctx, state)
+	err = o.setStateCookie(ctx, state, 0)
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Msg:  "服务器异常",
@@ -58,8 +65,33 @@ func (o *OAuth2WechatHandler) Auth2URL(ctx *gin.Context) {
 	})
 }
 
+func (o *OAuth2WechatHandler) BindAuth2URL(ctx *gin.Context) {
+	uc := ctx.MustGet("user").(ijwt.UserClaims)
+	state := uuid.New()
+	val, err := o.svc.AuthURL(ctx, state)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "构造跳转URL失败",
+			Code: 5,
+		})
+		return
+	}
+	// 回调的时候没有登录态，要绑定的用户记在 state 里面
+	err = o.setStateCookie(ctx, state, uc.Uid)
+	if err != nil {
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg:  "服务器异常",
+			Code: 5,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, ginx.Result{
+		Data: val,
+	})
+}
+
 func (o *OAuth2WechatHandler) Callback(ctx *gin.Context) {
-	err := o.verifyState(ctx)
+	sc, err := o.verifyState(ctx)
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Msg:  "非法请求",
@@ -72,12 +104,19 @@ func (o *OAuth2WechatHandler) Callback(ctx *gin.Context) {
 	// state := ctx.Query("state")
 	wechatInfo, err := o.svc.VerifyCode(ctx, code)
 	if err != nil {
+		if sc.Uid == 0 {
+			o.auditSvc.RecordFailure(ctx, newLoginLog(ctx, domain.LoginMethodWechat, ""))
+		}
 		ctx.JSON(http.StatusOK, ginx.Result{
 			Msg:  "授权码有误",
 			Code: 4,
 		})
 		return
 	}
+	if sc.Uid > 0 {
+		o.bind(ctx, sc.Uid, wechatInfo)
+		return
+	}
 	u, err := o.userSvc.FindOrCreateByWechat(ctx, wechatInfo)
 	if err != nil {
 		ctx.JSON(http.StatusOK, ginx.Result{
@@ -86,41 +125,55 @@ func (o *OAuth2WechatHandler) Callback(ctx *gin.Context) {
 		})
 		return
 	}
-	err = o.SetLoginToken(ctx, u.Id)
-	if err != nil {
-		ctx.String(http.StatusOK, "系统错误")
-		return
+	loginSucceed(ctx, o.Handler, o.auditSvc,
+		newLoginLog(ctx, domain.LoginMethodWechat, wechatInfo.OpenId), u)
+}
+
+func (o *OAuth2WechatHandler) bind(ctx *gin.Context, uid int64, info domain.WechatInfo) {
+	err := o.userSvc.BindWechat(ctx, uid, info)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Msg: "绑定成功",
+		})
+	case errors.Is(err, service.ErrIdentityBound):
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 4,
+			Msg:  "这个微信已经绑定了别的账号，请联系管理员合并账号",
+		})
+	default:
+		ctx.JSON(http.StatusOK, ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
 	}
-	ctx.JSON(http.StatusOK, ginx.Result{
-		Msg: "OK",
-	})
-	return
 }
 
-func (o *OAuth2WechatHandler) verifyState(ctx *gin.Context) error {
+func (o *OAuth2WechatHandler) verifyState(ctx *gin.Context) (StateClaims, error) {
 	state := ctx.Query("state")
 	ck, err := ctx.Cookie(o.stateCookieName)
 	if err != nil {
-		return fmt.Errorf("无法获得 cookie %w", err)
+		return StateClaims{}, fmt.Errorf("无法获得 cookie %w", err)
 	}
 	var sc StateClaims
 	_, err = jwt.ParseWithClaims(ck, &sc, func(token *jwt.Token) (interface{}, error) {
 		return o.key, nil
 	})
 	if err != nil {
-		return fmt.Errorf("解析 token 失败 %w", err)
+		return StateClaims{}, fmt.Errorf("解析 token 失败 %w", err)
 	}
 	if state != sc.State {
 		// state 不匹配，有人搞你
-		return fmt.Errorf("state 不匹配")
+		return StateClaims{}, fmt.Errorf("state 不匹配")
 	}
-	return nil
+	return sc, nil
 }
 
 func (o *OAuth2WechatHandler) setStateCookie(ctx *gin.Context,
-	state string) error {
+	state string, uid int64) error {
 	claims := StateClaims{
 		State: state,
+		Uid:   uid,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
 	tokenStr, err := token.SignedString(o.key)
@@ -137,4 +190,6 @@ func (o *OAuth2WechatHandler) setStateCookie(ctx *gin.Context,
 type StateClaims struct {
 	jwt.RegisteredClaims
 	State string
+	// 绑定微信的时候才有，登录的时候是 0
+	Uid int64
 }
@@ -0,0 +1,24 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webook/internal/service"
+	"webook/internal/web"
+	ijwt "webook/internal/web/jwt"
+	"webook/pkg/logger"
+)
+
+func InitAdminHandler(accountSvc service.AccountService,
+	guardSvc service.LoginGuardService, reviewSvc service.ArticleReviewService,
+	hdl ijwt.Handler, l logger.Logger) *web.AdminHandler {
+	type Config struct {
+		// 管理员的用户 ID
+		Uids []int64 `yaml:"uids"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("admin", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return web.NewAdminHandler(accountSvc, guardSvc, reviewSvc, hdl, cfg.Uids, l)
+}
@@ -0,0 +1,28 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webook/internal/service/email"
+	"webook/internal/service/email/localemail"
+	"webook/internal/service/email/smtp"
+)
+
+func InitEmailService() email.Service {
+	type Config struct {
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		From     string `yaml:"from"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("email", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	// 没有配置 SMTP 服务器，就只打印日志
+	if cfg.Host == "" {
+		return localemail.NewService()
+	}
+	return smtp.NewService(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
+}
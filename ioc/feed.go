@@ -0,0 +1,32 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webook/internal/repository"
+	"webook/internal/service"
+	"webook/pkg/logger"
+)
+
+func InitFeedService(repo repository.FeedRepository, artRepo repository.ArticleRepository,
+	userRepo repository.UserRepository, l logger.Logger) service.FeedService {
+	type Config struct {
+		SiteURL     string `yaml:"siteURL"`
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+	}
+	cfg := Config{
+		Title: "webook",
+	}
+	err := viper.UnmarshalKey("feed", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.SiteURL == "" {
+		panic("没有配置 feed.siteURL")
+	}
+	return service.NewFeedService(repo, artRepo, userRepo, service.FeedConfig{
+		SiteURL:     cfg.SiteURL,
+		Title:       cfg.Title,
+		Description: cfg.Description,
+	}, l)
+}
@@ -0,0 +1,29 @@
+package ioc
+
+import (
+	"webook/internal/repository"
+	"webook/internal/repository/cache"
+	"webook/internal/service/interactive"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// InitInteractiveRegistry 新的资源要支持点赞收藏，在这里注册
+func InitInteractiveRegistry(artRepo repository.ArticleRepository,
+	seriesRepo repository.SeriesRepository) *interactive.Registry {
+	return interactive.NewRegistry(
+		interactive.NewArticleBiz(artRepo),
+		interactive.NewSeriesBiz(seriesRepo),
+	)
+}
+
+// InitInteractiveStateCache 点赞收藏状态缓存，按 kind 统计命中和未命中的次数
+func InitInteractiveStateCache(client redis.Cmdable) cache.InteractiveStateCache {
+	return cache.NewInteractiveStateRedisCache(client, prometheus.CounterOpts{
+		Namespace: "webook",
+		Subsystem: "interactive",
+		Name:      "state_cache_lookups_total",
+		Help:      "点赞收藏状态缓存的查询次数，按是否命中区分",
+	})
+}
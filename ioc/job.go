@@ -0,0 +1,17 @@
+package ioc
+
+import (
+	"time"
+	"webook/internal/job"
+	"webook/internal/service"
+	"webook/pkg/logger"
+)
+
+func InitScheduler(l logger.Logger, accountSvc service.AccountService,
+	articleSvc service.ArticleService) *job.Scheduler {
+	s := job.NewScheduler(l)
+	s.Register(job.NewPurgeDeletedUsersJob(accountSvc, l), time.Hour, time.Minute*10)
+	s.Register(job.NewFlushArticleAutosaveJob(articleSvc, l), time.Second*30, time.Second*20)
+	s.Register(job.NewPurgeTrashedArticlesJob(articleSvc, l), time.Hour, time.Minute*10)
+	return s
+}
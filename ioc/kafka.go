@@ -39,6 +39,7 @@ func InitSyncProducer(c sarama.Client) sarama.SyncProducer {
 	return p
 }
 
-func InitConsumers(cl *article.InteractiveReadEventConsumer, like *article.InteractiveLikeEventConsumer, unlike *article.InteractiveUnLikeEventConsumer) []events.Consumer {
-	return []events.Consumer{cl, like, unlike}
+func InitConsumers(cl *article.InteractiveReadEventConsumer, like *article.InteractiveLikeEventConsumer, unlike *article.InteractiveUnLikeEventConsumer,
+	history *article.ReadingHistoryConsumer) []events.Consumer {
+	return []events.Consumer{cl, like, unlike, history}
 }
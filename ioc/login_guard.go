@@ -0,0 +1,18 @@
+package ioc
+
+import (
+	"github.com/redis/go-redis/v9"
+	"time"
+	"webook/internal/repository"
+	"webook/internal/service"
+	"webook/pkg/limiter"
+)
+
+func InitLoginGuardService(redisClient redis.Cmdable,
+	repo repository.LoginLockRepository,
+	userRepo repository.UserRepository) service.LoginGuardService {
+	// 一个账号 15 分钟内最多错 5 次，一个 IP 最多错 50 次
+	accountLimiter := limiter.NewRedisSlidingWindowLimiter(redisClient, time.Minute*15, 5)
+	ipLimiter := limiter.NewRedisSlidingWindowLimiter(redisClient, time.Minute*15, 50)
+	return service.NewLoginGuardService(repo, userRepo, accountLimiter, ipLimiter)
+}
@@ -0,0 +1,57 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"net/http"
+	"webook/internal/service"
+	"webook/internal/service/storage"
+	"webook/internal/service/storage/local"
+	"webook/internal/service/storage/s3"
+	"webook/internal/web"
+)
+
+func InitMediaStorage() storage.Storage {
+	type LocalConfig struct {
+		Root    string `yaml:"root"`
+		BaseURL string `yaml:"baseURL"`
+		SignKey string `yaml:"signKey"`
+	}
+	type S3Config struct {
+		Endpoint  string `yaml:"endpoint"`
+		Region    string `yaml:"region"`
+		Bucket    string `yaml:"bucket"`
+		AccessKey string `yaml:"accessKey"`
+		SecretKey string `yaml:"secretKey"`
+	}
+	type Config struct {
+		// local 或者 s3
+		Type  string      `yaml:"type"`
+		Local LocalConfig `yaml:"local"`
+		S3    S3Config    `yaml:"s3"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("media", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	switch cfg.Type {
+	case "s3":
+		s, err := s3.NewStorage(cfg.S3.Endpoint, cfg.S3.Region, cfg.S3.Bucket,
+			cfg.S3.AccessKey, cfg.S3.SecretKey)
+		if err != nil {
+			panic(err)
+		}
+		return s
+	default:
+		if cfg.Local.SignKey == "" {
+			panic("没有配置 media.local.signKey")
+		}
+		return local.NewStorage(cfg.Local.Root, cfg.Local.BaseURL, []byte(cfg.Local.SignKey))
+	}
+}
+
+func InitMediaHandler(svc service.MediaService, store storage.Storage) *web.MediaHandler {
+	// 本地文件系统没办法直接对外提供访问，由 MediaHandler 提供下载
+	files, _ := store.(http.Handler)
+	return web.NewMediaHandler(svc, files)
+}
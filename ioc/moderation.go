@@ -0,0 +1,37 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webook/internal/service/moderation"
+	"webook/pkg/sensitive"
+)
+
+// InitModerationChecker 发表文章的时候的机审，敏感词和昵称共用一份词库
+func InitModerationChecker(filter sensitive.Filter) moderation.Checker {
+	type Config struct {
+		MaxTitle   int `yaml:"maxTitle"`
+		MaxContent int `yaml:"maxContent"`
+		// 外链超过这个数量转人工
+		MaxLinks int `yaml:"maxLinks"`
+		// 同一行重复出现超过 MaxRepeatLines 次，或者同一个字连续出现超过 MaxRepeatRunes 次，转人工
+		MaxRepeatLines int `yaml:"maxRepeatLines"`
+		MaxRepeatRunes int `yaml:"maxRepeatRunes"`
+	}
+	cfg := Config{
+		MaxTitle:       100,
+		MaxContent:     100000,
+		MaxLinks:       10,
+		MaxRepeatLines: 5,
+		MaxRepeatRunes: 30,
+	}
+	err := viper.UnmarshalKey("moderation", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	return moderation.NewChain(
+		moderation.NewLengthChecker(cfg.MaxTitle, cfg.MaxContent),
+		moderation.NewSensitiveChecker(filter),
+		moderation.NewLinkChecker(cfg.MaxLinks),
+		moderation.NewSpamChecker(cfg.MaxRepeatLines, cfg.MaxRepeatRunes),
+	)
+}
@@ -0,0 +1,53 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webook/internal/service"
+	"webook/internal/service/oauth2"
+	"webook/internal/service/oauth2/github"
+	"webook/internal/service/oauth2/oidc"
+	"webook/internal/web"
+	ijwt "webook/internal/web/jwt"
+)
+
+func InitOAuth2Registry() *oauth2.Registry {
+	type Provider struct {
+		Name string `yaml:"name"`
+		// github 或者 oidc
+		Type         string `yaml:"type"`
+		Issuer       string `yaml:"issuer"`
+		ClientId     string `yaml:"clientId"`
+		ClientSecret string `yaml:"clientSecret"`
+		RedirectURL  string `yaml:"redirectURL"`
+	}
+	var providers []Provider
+	err := viper.UnmarshalKey("oauth2.providers", &providers)
+	if err != nil {
+		panic(err)
+	}
+	registry := oauth2.NewRegistry()
+	for _, p := range providers {
+		// 没有配置 client id 的就当做没有开启
+		if p.ClientId == "" {
+			continue
+		}
+		switch p.Type {
+		case "github":
+			registry.Register(github.NewService(p.ClientId, p.ClientSecret, p.RedirectURL))
+		case "oidc":
+			registry.Register(oidc.NewService(p.Name, p.Issuer, p.ClientId, p.ClientSecret, p.RedirectURL))
+		default:
+			panic("未知的第三方登录类型 " + p.Type)
+		}
+	}
+	return registry
+}
+
+func InitOAuth2Handler(registry *oauth2.Registry, userSvc service.UserService,
+	auditSvc service.LoginAuditService, hdl ijwt.Handler) *web.OAuth2Handler {
+	key := viper.GetString("oauth2.stateKey")
+	if key == "" {
+		panic("没有配置 oauth2.stateKey")
+	}
+	return web.NewOAuth2Handler(registry, userSvc, auditSvc, hdl, []byte(key))
+}
@@ -0,0 +1,11 @@
+package ioc
+
+import (
+	"webook/internal/service/payment"
+	"webook/internal/service/payment/localpay"
+)
+
+// InitPaymentGateway 现在只有本地的假网关，接入真的支付渠道之后在这里按照配置切换
+func InitPaymentGateway() payment.Gateway {
+	return localpay.NewGateway()
+}
@@ -0,0 +1,31 @@
+package ioc
+
+import (
+	"github.com/spf13/viper"
+	"webook/pkg/logger"
+	"webook/pkg/sensitive"
+)
+
+func InitSensitiveFilter(l logger.Logger) sensitive.Filter {
+	type Config struct {
+		// 一行一个敏感词，修改之后自动重新加载，不配置就不过滤
+		WordsFile string `yaml:"wordsFile"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("sensitive", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.WordsFile == "" {
+		return sensitive.NewACFilter(nil)
+	}
+	f, err := sensitive.NewFileFilter(cfg.WordsFile, l)
+	if err != nil {
+		panic(err)
+	}
+	err = f.Watch()
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
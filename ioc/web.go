@@ -19,12 +19,38 @@ import (
 func InitWebServer(mdls []gin.HandlerFunc,
 	userHdl *web.UserHandler,
 	artHdl *web.ArticleHandler,
-	wechatHdl *web.OAuth2WechatHandler) *gin.Engine {
+	wechatHdl *web.OAuth2WechatHandler,
+	adminHdl *web.AdminHandler,
+	oauth2Hdl *web.OAuth2Handler,
+	accountHdl *web.AccountHandler,
+	loginAuditHdl *web.LoginAuditHandler,
+	mediaHdl *web.MediaHandler,
+	authorHdl *web.AuthorHandler,
+	privacyHdl *web.PrivacyHandler,
+	seriesHdl *web.SeriesHandler,
+	feedHdl *web.FeedHandler,
+	archiveHdl *web.ArticleArchiveHandler,
+	historyHdl *web.ReadingHistoryHandler,
+	rewardHdl *web.RewardHandler,
+	intrHdl *web.InteractiveHandler) *gin.Engine {
 	server := gin.Default()
 	server.Use(mdls...)
 	userHdl.RegisterRoutes(server)
 	wechatHdl.RegisterRoutes(server)
+	oauth2Hdl.RegisterRoutes(server)
 	artHdl.RegisterRoutes(server)
+	adminHdl.RegisterRoutes(server)
+	accountHdl.RegisterRoutes(server)
+	loginAuditHdl.RegisterRoutes(server)
+	mediaHdl.RegisterRoutes(server)
+	authorHdl.RegisterRoutes(server)
+	privacyHdl.RegisterRoutes(server)
+	seriesHdl.RegisterRoutes(server)
+	feedHdl.RegisterRoutes(server)
+	archiveHdl.RegisterRoutes(server)
+	historyHdl.RegisterRoutes(server)
+	rewardHdl.RegisterRoutes(server)
+	intrHdl.RegisterRoutes(server)
 	return server
 }
 
@@ -42,7 +68,7 @@ func InitGinMiddlewares(redisClient redis.Cmdable, hdl ijwt.Handler, l logger.Lo
 			// 业务业务请求中可以带上的头
 			AllowHeaders: []string{"Content-Type", "Authorization"},
 			//允许前端访问自定义返回的token
-			ExposeHeaders: []string{"x-jwt-token", "x-refresh-token"},
+			ExposeHeaders: []string{"x-jwt-token", "x-refresh-token", "x-mfa-token"},
 			//哪些来源是允许的
 			AllowOriginFunc: func(origin string) bool {
 				if strings.Contains(origin, "localhost") {
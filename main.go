@@ -26,6 +26,9 @@ func main() {
 			panic(err)
 		}
 	}
+	// 启动定时任务
+	app.scheduler.Start()
+	defer app.scheduler.Stop()
 	server := app.server
 	server.GET("/hello", func(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "hello，启动成功了！")
@@ -0,0 +1,42 @@
+package imagex
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnail(t *testing.T) {
+	testCases := []struct {
+		name  string
+		w, h  int
+		size  int
+		wantW int
+		wantH int
+	}{
+		{name: "横图", w: 400, h: 200, size: 100, wantW: 100, wantH: 50},
+		{name: "竖图", w: 300, h: 600, size: 64, wantW: 32, wantH: 64},
+		{name: "小图不放大", w: 50, h: 40, size: 64, wantW: 50, wantH: 40},
+		{name: "特别窄的图", w: 1000, h: 2, size: 100, wantW: 100, wantH: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tc.w, tc.h))
+			res := Thumbnail(src, tc.size)
+			assert.Equal(t, tc.wantW, res.Bounds().Dx())
+			assert.Equal(t, tc.wantH, res.Bounds().Dy())
+		})
+	}
+}
+
+func TestThumbnail_Average(t *testing.T) {
+	// 左半边黑，右半边白，缩成 1 个像素之后是灰色，结果只有 8 位精度
+	src := image.NewGray(image.Rect(0, 0, 2, 2))
+	src.SetGray(1, 0, color.Gray{Y: 255})
+	src.SetGray(1, 1, color.Gray{Y: 255})
+	res := Thumbnail(src, 1)
+	r, g, b, a := res.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{0x7f7f, 0x7f7f, 0x7f7f, 0xffff}, []uint32{r, g, b, a})
+}
@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -package=limitermocks -destination=./mock/limiter.mock.go -source=./types.go
+//	mockgen -source=./types.go -destination=./mocks/limiter.mock.go -package=limitermocks
 //
 
 // Package limitermocks is a generated GoMock package.
@@ -39,7 +39,7 @@ func (m *MockLimiter) EXPECT() *MockLimiterMockRecorder {
 	return m.recorder
 }
 
-// Limit mock base method.
+// Limit mocks base method.
 func (m *MockLimiter) Limit(ctx context.Context, key string) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Limit", ctx, key)
@@ -53,3 +53,17 @@ func (mr *MockLimiterMockRecorder) Limit(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Limit", reflect.TypeOf((*MockLimiter)(nil).Limit), ctx, key)
 }
+
+// Reset mocks base method.
+func (m *MockLimiter) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockLimiterMockRecorder) Reset(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockLimiter)(nil).Reset), ctx, key)
+}
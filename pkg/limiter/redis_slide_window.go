@@ -29,3 +29,7 @@ func (b *RedisSlidingWindowLimiter) Limit(ctx context.Context, key string) (bool
 	return b.cmd.Eval(ctx, luaScript, []string{key},
 		b.interval.Milliseconds(), b.rate, time.Now().UnixMilli()).Bool()
 }
+
+func (b *RedisSlidingWindowLimiter) Reset(ctx context.Context, key string) error {
+	return b.cmd.Del(ctx, key).Err()
+}
@@ -6,4 +6,6 @@ type Limiter interface {
 	// Limit 是否触发限流
 	// 返回 true，就是触发限流
 	Limit(ctx context.Context, key string) (bool, error)
+	// Reset 清空 key 的计数，比如管理员手动解锁之后
+	Reset(ctx context.Context, key string) error
 }
@@ -0,0 +1,401 @@
+package markdown
+
+import (
+	"strings"
+)
+
+type blockKind uint8
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockCode
+	blockQuote
+	blockList
+	blockRule
+)
+
+type block struct {
+	kind blockKind
+	// 段落和标题是还没有解析的行内文本，代码块是原样的代码
+	text string
+	// 标题级别
+	level int
+	// 代码块的语言
+	lang string
+	// 引用里面的内容
+	children []block
+	// 列表
+	ordered bool
+	start   int
+	items   [][]block
+	// 列表项之间或者里面有空行，段落要用 <p> 包起来
+	loose bool
+}
+
+func splitLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	return strings.Split(src, "\n")
+}
+
+func parseBlocks(lines []string, depth int) []block {
+	var res []block
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlank(line) {
+			i++
+			continue
+		}
+		if b, n, ok := parseFence(lines[i:]); ok {
+			res = append(res, b)
+			i += n
+			continue
+		}
+		if isRule(line) {
+			res = append(res, block{kind: blockRule})
+			i++
+			continue
+		}
+		if level, text, ok := parseHeading(line); ok {
+			res = append(res, block{kind: blockHeading, level: level, text: text})
+			i++
+			continue
+		}
+		if depth < maxDepth {
+			if _, ok := stripQuote(line); ok {
+				b, n := parseQuote(lines[i:], depth)
+				res = append(res, b)
+				i += n
+				continue
+			}
+			if m, ok := parseListMarker(line); ok {
+				b, n := parseList(lines[i:], m, depth)
+				res = append(res, b)
+				i += n
+				continue
+			}
+		}
+		if indent(line) >= 4 {
+			b, n := parseIndentedCode(lines[i:])
+			res = append(res, b)
+			i += n
+			continue
+		}
+		b, n := parseParagraph(lines[i:])
+		res = append(res, b)
+		i += n
+	}
+	return res
+}
+
+// parseFence ``` 或者 ~~~ 包起来的代码块，没有结束标记的时候一直到最后
+func parseFence(lines []string) (block, int, bool) {
+	ind := indent(lines[0])
+	if ind > 3 {
+		return block{}, 0, false
+	}
+	first := lines[0][ind:]
+	if len(first) < 3 || (first[0] != '`' && first[0] != '~') {
+		return block{}, 0, false
+	}
+	ch := first[0]
+	n := countPrefix(first, ch)
+	if n < 3 {
+		return block{}, 0, false
+	}
+	info := strings.TrimSpace(first[n:])
+	if ch == '`' && strings.IndexByte(info, '`') >= 0 {
+		return block{}, 0, false
+	}
+	var code []string
+	i := 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if ci := indent(line); ci <= 3 {
+			rest := line[ci:]
+			if cn := countPrefix(rest, ch); cn >= n && isBlank(rest[cn:]) {
+				i++
+				break
+			}
+		}
+		code = append(code, trimIndent(line, ind))
+	}
+	text := strings.Join(code, "\n")
+	if len(code) > 0 {
+		text += "\n"
+	}
+	return block{kind: blockCode, text: text, lang: codeLang(info)}, i, true
+}
+
+// codeLang 语言会拼进 class 里面，只保留安全的字符
+func codeLang(info string) string {
+	if fields := strings.Fields(info); len(fields) > 0 {
+		info = fields[0]
+	}
+	return strings.Map(func(c rune) rune {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '+' || c == '#' || c == '.' {
+			return c
+		}
+		return -1
+	}, info)
+}
+
+func parseIndentedCode(lines []string) (block, int) {
+	var code []string
+	i := 0
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if !isBlank(line) && indent(line) < 4 {
+			break
+		}
+		code = append(code, trimIndent(line, 4))
+	}
+	// 结尾的空行不算代码
+	for len(code) > 0 && isBlank(code[len(code)-1]) {
+		code = code[:len(code)-1]
+	}
+	return block{kind: blockCode, text: strings.Join(code, "\n") + "\n"}, i
+}
+
+func parseQuote(lines []string, depth int) (block, int) {
+	var inner []string
+	i := 0
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if rest, ok := stripQuote(line); ok {
+			inner = append(inner, rest)
+			continue
+		}
+		// 段落的延续行可以不带 >
+		if isBlank(line) || len(inner) == 0 || isBlank(inner[len(inner)-1]) || startsBlock(line) {
+			break
+		}
+		inner = append(inner, line)
+	}
+	return block{kind: blockQuote, children: parseBlocks(inner, depth+1)}, i
+}
+
+func parseParagraph(lines []string) (block, int) {
+	var text []string
+	i := 0
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) || (i > 0 && startsBlock(line)) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+	return block{kind: blockParagraph, text: strings.TrimRight(strings.Join(text, "\n"), " ")}, i
+}
+
+type listMarker struct {
+	ordered bool
+	// 无序列表的 - * +，有序列表的 . )
+	ch    byte
+	start int
+	// 内容从第几列开始，后面的行缩进这么多才算是这一项的内容
+	width int
+	empty bool
+}
+
+func parseListMarker(line string) (listMarker, bool) {
+	ind := indent(line)
+	if ind > 3 {
+		return listMarker{}, false
+	}
+	rest := line[ind:]
+	if rest == "" {
+		return listMarker{}, false
+	}
+	m := listMarker{}
+	var n int
+	switch rest[0] {
+	case '-', '*', '+':
+		m.ch = rest[0]
+		n = 1
+	default:
+		for n < len(rest) && n < 9 && rest[n] >= '0' && rest[n] <= '9' {
+			m.start = m.start*10 + int(rest[n]-'0')
+			n++
+		}
+		if n == 0 || n >= len(rest) || (rest[n] != '.' && rest[n] != ')') {
+			return listMarker{}, false
+		}
+		m.ordered = true
+		m.ch = rest[n]
+		n++
+	}
+	if n < len(rest) && rest[n] != ' ' {
+		return listMarker{}, false
+	}
+	m.width = ind + n + 1
+	m.empty = isBlank(rest[n:])
+	return m, true
+}
+
+func (m listMarker) sameList(other listMarker) bool {
+	return m.ordered == other.ordered && m.ch == other.ch
+}
+
+func parseList(lines []string, first listMarker, depth int) (block, int) {
+	res := block{kind: blockList, ordered: first.ordered, start: first.start}
+	var (
+		items [][]string
+		cur   []string
+		width = first.width
+	)
+	i := 0
+	for i < len(lines) {
+		line := lines[i]
+		if m, ok := parseListMarker(line); ok && m.sameList(first) && (i == 0 || indent(line) < width) {
+			if i > 0 {
+				items = append(items, cur)
+			}
+			width = m.width
+			cur = []string{""}
+			if width < len(line) {
+				cur[0] = line[width:]
+			}
+			i++
+			continue
+		}
+		if isBlank(line) {
+			j := i + 1
+			for j < len(lines) && isBlank(lines[j]) {
+				j++
+			}
+			if j == len(lines) {
+				break
+			}
+			next := lines[j]
+			m, ok := parseListMarker(next)
+			if indent(next) >= width || (ok && m.sameList(first)) {
+				res.loose = true
+				for ; i < j; i++ {
+					cur = append(cur, "")
+				}
+				continue
+			}
+			break
+		}
+		if indent(line) >= width {
+			cur = append(cur, trimIndent(line, width))
+			i++
+			continue
+		}
+		// 段落的延续行可以不缩进
+		if len(cur) > 0 && !isBlank(cur[len(cur)-1]) && !startsBlock(line) {
+			cur = append(cur, strings.TrimLeft(line, " "))
+			i++
+			continue
+		}
+		break
+	}
+	items = append(items, cur)
+	for _, item := range items {
+		res.items = append(res.items, parseBlocks(item, depth+1))
+	}
+	return res, i
+}
+
+func parseHeading(line string) (int, string, bool) {
+	ind := indent(line)
+	if ind > 3 {
+		return 0, "", false
+	}
+	rest := line[ind:]
+	level := countPrefix(rest, '#')
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest = rest[level:]
+	if rest != "" && rest[0] != ' ' {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(rest)
+	// 去掉结尾的 ###
+	if trimmed := strings.TrimRight(text, "#"); trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		text = strings.TrimSpace(trimmed)
+	}
+	return level, text, true
+}
+
+func isRule(line string) bool {
+	if indent(line) > 3 {
+		return false
+	}
+	var (
+		ch  byte
+		cnt int
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ':
+		case ch == 0 && (c == '-' || c == '*' || c == '_'):
+			ch = c
+			cnt++
+		case c == ch:
+			cnt++
+		default:
+			return false
+		}
+	}
+	return cnt >= 3
+}
+
+func stripQuote(line string) (string, bool) {
+	ind := indent(line)
+	if ind > 3 || ind >= len(line) || line[ind] != '>' {
+		return "", false
+	}
+	rest := line[ind+1:]
+	return strings.TrimPrefix(rest, " "), true
+}
+
+// startsBlock 这一行会打断正在解析的段落
+func startsBlock(line string) bool {
+	if _, _, ok := parseFence([]string{line}); ok {
+		return true
+	}
+	if isRule(line) {
+		return true
+	}
+	if _, _, ok := parseHeading(line); ok {
+		return true
+	}
+	if _, ok := stripQuote(line); ok {
+		return true
+	}
+	// 有序列表只有从 1 开始才能打断段落，不然 "2024. 年" 这种句子会被当成列表
+	m, ok := parseListMarker(line)
+	return ok && !m.empty && (!m.ordered || m.start == 1)
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func indent(line string) int {
+	return countPrefix(line, ' ')
+}
+
+func countPrefix(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// trimIndent 最多去掉 n 个前导空格
+func trimIndent(line string, n int) string {
+	ind := indent(line)
+	if ind > n {
+		ind = n
+	}
+	return line[ind:]
+}
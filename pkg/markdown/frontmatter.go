@@ -0,0 +1,54 @@
+package markdown
+
+import "strings"
+
+const frontMatterDelim = "---"
+
+// SplitFrontMatter 把开头用 --- 包起来的 YAML front-matter 和正文分开。
+// 没有 front-matter，或者找不到结束的 --- 的时候 meta 是空的，body 是原文
+func SplitFrontMatter(src string) (meta string, body string) {
+	s := strings.TrimPrefix(src, "\ufeff")
+	first, rest, ok := cutLine(s)
+	if !ok || strings.TrimSpace(first) != frontMatterDelim {
+		return "", src
+	}
+	var lines []string
+	for {
+		var line string
+		line, rest, ok = cutLine(rest)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == frontMatterDelim || trimmed == "..." {
+			// 正文前面的空行不要
+			return strings.Join(lines, "\n"), strings.TrimLeft(rest, "\r\n")
+		}
+		if !ok {
+			return "", src
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+	}
+}
+
+// JoinFrontMatter SplitFrontMatter 的逆操作，meta 是空的时候只有正文
+func JoinFrontMatter(meta string, body string) string {
+	if meta == "" {
+		return body
+	}
+	var sb strings.Builder
+	sb.WriteString(frontMatterDelim + "\n")
+	sb.WriteString(meta)
+	if !strings.HasSuffix(meta, "\n") {
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(frontMatterDelim + "\n\n")
+	sb.WriteString(body)
+	return sb.String()
+}
+
+// cutLine 切出第一行，没有换行的时候 ok 是 false，line 是剩下的全部内容
+func cutLine(s string) (line string, rest string, ok bool) {
+	idx := strings.IndexByte(s, '\n')
+	if idx < 0 {
+		return s, "", false
+	}
+	return s[:idx], s[idx+1:], true
+}
@@ -0,0 +1,63 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+
+		wantMeta string
+		wantBody string
+	}{
+		{
+			name:     "有 front-matter",
+			src:      "---\ntitle: 标题\ntags: [a, b]\n---\n\n# 正文\n",
+			wantMeta: "title: 标题\ntags: [a, b]",
+			wantBody: "# 正文\n",
+		},
+		{
+			name:     "Windows 换行和 BOM",
+			src:      "\ufeff---\r\ntitle: 标题\r\n---\r\n正文",
+			wantMeta: "title: 标题",
+			wantBody: "正文",
+		},
+		{
+			name:     "只有 front-matter",
+			src:      "---\ntitle: 标题\n---",
+			wantMeta: "title: 标题",
+			wantBody: "",
+		},
+		{
+			name:     "没有 front-matter",
+			src:      "# 正文\n---\n",
+			wantBody: "# 正文\n---\n",
+		},
+		{
+			name:     "没有结束",
+			src:      "---\ntitle: 标题\n正文",
+			wantBody: "---\ntitle: 标题\n正文",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta, body := SplitFrontMatter(tc.src)
+			assert.Equal(t, tc.wantMeta, meta)
+			assert.Equal(t, tc.wantBody, body)
+		})
+	}
+}
+
+func TestJoinFrontMatter(t *testing.T) {
+	src := JoinFrontMatter("title: 标题\n", "# 正文\n")
+	assert.Equal(t, "---\ntitle: 标题\n---\n\n# 正文\n", src)
+	meta, body := SplitFrontMatter(src)
+	assert.Equal(t, "title: 标题", meta)
+	assert.Equal(t, "# 正文\n", body)
+	assert.Equal(t, "正文", JoinFrontMatter("", "正文"))
+}
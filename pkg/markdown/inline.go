@@ -0,0 +1,440 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxLinkText 链接文字最多这么长，找不到 ] 的时候不用一直扫到结尾
+const maxLinkText = 1000
+
+// renderInline 渲染行内元素，同时返回纯文本
+func renderInline(src string) (string, string) {
+	p := &inlineParser{}
+	p.parse(src, 0, false)
+	return p.html.String(), p.text.String()
+}
+
+type inlineParser struct {
+	html strings.Builder
+	text strings.Builder
+}
+
+// parse inLink 是在链接文字里面，不能再嵌套链接
+func (p *inlineParser) parse(s string, depth int, inLink bool) {
+	// 记住找不到结束标记的情况，避免 "*a *b *c ..." 这种输入每次都扫到结尾
+	noCloser := make(map[string]bool)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '\\':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				p.hardBreak()
+				i += 2
+				continue
+			}
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				p.writeEscaped(s[i+1 : i+2])
+				i += 2
+				continue
+			}
+		case '`':
+			n := countPrefix(s[i:], '`')
+			key := s[i : i+n]
+			if !noCloser[key] {
+				if end := findCodeClose(s, i+n, n); end >= 0 {
+					p.codeSpan(s[i+n : end])
+					i = end + n
+					continue
+				}
+				noCloser[key] = true
+			}
+			p.writeEscaped(key)
+			i += n
+			continue
+		case '*', '_':
+			n := countPrefix(s[i:], c)
+			if depth < maxDepth && p.canOpen(s, i, n) {
+				k := min(n, 3)
+				key := s[i : i+k]
+				if !noCloser[key] {
+					if end := findEmphasisClose(s, i+n, c, k); end >= 0 {
+						// 多出来的标记原样输出
+						p.writeEscaped(s[i : i+n-k])
+						p.emphasis(s[i+n:end], k, depth)
+						i = end + k
+						continue
+					}
+					noCloser[key] = true
+				}
+			}
+			p.writeEscaped(s[i : i+n])
+			i += n
+			continue
+		case '!':
+			if i+1 < len(s) && s[i+1] == '[' && depth < maxDepth {
+				if text, dest, title, end, ok := parseLink(s, i+1); ok {
+					p.image(text, dest, title)
+					i = end
+					continue
+				}
+			}
+		case '[':
+			if depth < maxDepth {
+				if text, dest, title, end, ok := parseLink(s, i); ok {
+					p.link(text, dest, title, depth, inLink)
+					i = end
+					continue
+				}
+			}
+		case '<':
+			if !inLink {
+				if end := strings.IndexByte(s[i:], '>'); end > 0 {
+					if u := s[i+1 : i+end]; isAutolink(u) {
+						p.openLink(u, "")
+						p.writeEscaped(u)
+						p.html.WriteString("</a>")
+						i += end + 1
+						continue
+					}
+				}
+			}
+		case ' ':
+			// 行尾两个以上的空格是硬换行，少于两个就丢掉
+			n := countPrefix(s[i:], ' ')
+			if i+n < len(s) && s[i+n] == '\n' {
+				if n >= 2 {
+					p.hardBreak()
+				} else {
+					p.softBreak()
+				}
+				i += n + 1
+				continue
+			}
+			p.html.WriteString(s[i : i+n])
+			p.text.WriteString(s[i : i+n])
+			i += n
+			continue
+		case '\n':
+			p.softBreak()
+			i++
+			continue
+		}
+		p.writeEscaped(s[i : i+1])
+		i++
+	}
+}
+
+func (p *inlineParser) writeEscaped(s string) {
+	p.html.WriteString(html.EscapeString(s))
+	p.text.WriteString(s)
+}
+
+func (p *inlineParser) softBreak() {
+	p.html.WriteByte('\n')
+	p.text.WriteByte(' ')
+}
+
+func (p *inlineParser) hardBreak() {
+	p.html.WriteString("<br>\n")
+	p.text.WriteByte('\n')
+}
+
+func (p *inlineParser) codeSpan(code string) {
+	code = strings.ReplaceAll(code, "\n", " ")
+	if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+		code = code[1 : len(code)-1]
+	}
+	p.html.WriteString("<code>")
+	p.writeEscaped(code)
+	p.html.WriteString("</code>")
+}
+
+func (p *inlineParser) emphasis(inner string, k int, depth int) {
+	switch k {
+	case 1:
+		p.html.WriteString("<em>")
+		p.parse(inner, depth+1, false)
+		p.html.WriteString("</em>")
+	case 2:
+		p.html.WriteString("<strong>")
+		p.parse(inner, depth+1, false)
+		p.html.WriteString("</strong>")
+	default:
+		p.html.WriteString("<em><strong>")
+		p.parse(inner, depth+1, false)
+		p.html.WriteString("</strong></em>")
+	}
+}
+
+func (p *inlineParser) link(text, dest, title string, depth int, inLink bool) {
+	u, ok := safeURL(dest, false)
+	// 不安全的地址和嵌套的链接只保留文字
+	if !ok || inLink {
+		p.parse(text, depth+1, true)
+		return
+	}
+	p.openLink(u, title)
+	p.parse(text, depth+1, true)
+	p.html.WriteString("</a>")
+}
+
+func (p *inlineParser) openLink(u, title string) {
+	p.html.WriteString(`<a href="`)
+	p.html.WriteString(html.EscapeString(u))
+	p.html.WriteString(`"`)
+	if title != "" {
+		p.html.WriteString(` title="`)
+		p.html.WriteString(html.EscapeString(title))
+		p.html.WriteString(`"`)
+	}
+	if isAbsolute(u) {
+		p.html.WriteString(` rel="nofollow noopener noreferrer" target="_blank"`)
+	}
+	p.html.WriteString(">")
+}
+
+func (p *inlineParser) image(alt, dest, title string) {
+	// alt 只要纯文字
+	sub := &inlineParser{}
+	sub.parse(alt, maxDepth, true)
+	alt = sub.text.String()
+	p.text.WriteString(alt)
+	u, ok := safeURL(dest, true)
+	if !ok {
+		p.html.WriteString(html.EscapeString(alt))
+		return
+	}
+	p.html.WriteString(`<img src="`)
+	p.html.WriteString(html.EscapeString(u))
+	p.html.WriteString(`" alt="`)
+	p.html.WriteString(html.EscapeString(alt))
+	p.html.WriteString(`"`)
+	if title != "" {
+		p.html.WriteString(` title="`)
+		p.html.WriteString(html.EscapeString(title))
+		p.html.WriteString(`"`)
+	}
+	p.html.WriteString(">")
+}
+
+// canOpen 开始标记后面不能是空白；下划线不能在单词中间
+func (p *inlineParser) canOpen(s string, i, n int) bool {
+	if i+n >= len(s) {
+		return false
+	}
+	next, _ := utf8.DecodeRuneInString(s[i+n:])
+	if unicode.IsSpace(next) {
+		return false
+	}
+	if s[i] == '_' && i > 0 {
+		prev, _ := utf8.DecodeLastRuneInString(s[:i])
+		if isWordRune(prev) {
+			return false
+		}
+	}
+	return true
+}
+
+// findEmphasisClose 找长度为 k 的结束标记，结束标记前面不能是空白。
+// 长度不一样的标记整段跳过，它们要么是嵌套的强调，要么是普通文字
+func findEmphasisClose(s string, from int, c byte, k int) int {
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			n := countPrefix(s[j:], '`')
+			if end := findCodeClose(s, j+n, n); end >= 0 {
+				j = end + n
+			} else {
+				j += n
+			}
+			continue
+		case c:
+			n := countPrefix(s[j:], c)
+			if (n == k || (k == 3 && n > 3)) && j > from {
+				prev, _ := utf8.DecodeLastRuneInString(s[:j])
+				after := j + n
+				ok := !unicode.IsSpace(prev)
+				if ok && c == '_' && after < len(s) {
+					next, _ := utf8.DecodeRuneInString(s[after:])
+					ok = !isWordRune(next)
+				}
+				if ok {
+					return j
+				}
+			}
+			j += n
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// findCodeClose 找长度正好是 n 的反引号
+func findCodeClose(s string, from, n int) int {
+	for j := from; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := countPrefix(s[j:], '`')
+		if m == n {
+			return j
+		}
+		j += m
+	}
+	return -1
+}
+
+// parseLink 解析 [text](dest "title")，i 是 [ 的位置，end 是 ) 后面的位置
+func parseLink(s string, i int) (text, dest, title string, end int, ok bool) {
+	depth := 0
+	closeIdx := -1
+	limit := min(len(s), i+maxLinkText)
+	for j := i; j < limit && closeIdx < 0; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeIdx = j
+			}
+		}
+	}
+	if closeIdx < 0 || closeIdx+1 >= len(s) || s[closeIdx+1] != '(' {
+		return "", "", "", 0, false
+	}
+	text = s[i+1 : closeIdx]
+	j := skipSpaces(s, closeIdx+2)
+	// 地址，可以用 <> 包起来
+	if j < len(s) && s[j] == '<' {
+		e := strings.IndexAny(s[j+1:], ">\n")
+		if e < 0 || s[j+1+e] != '>' {
+			return "", "", "", 0, false
+		}
+		dest = s[j+1 : j+1+e]
+		j = j + e + 2
+	} else {
+		start := j
+		parens := 0
+	loop:
+		for ; j < len(s); j++ {
+			switch s[j] {
+			case '\\':
+				j++
+			case '(':
+				parens++
+			case ')':
+				if parens == 0 {
+					break loop
+				}
+				parens--
+			case ' ', '\n':
+				break loop
+			}
+		}
+		if j > len(s) {
+			j = len(s)
+		}
+		dest = s[start:j]
+	}
+	j = skipSpaces(s, j)
+	// 标题
+	if j < len(s) && (s[j] == '"' || s[j] == '\'') {
+		q := s[j]
+		e := strings.IndexByte(s[j+1:], q)
+		if e < 0 {
+			return "", "", "", 0, false
+		}
+		title = s[j+1 : j+1+e]
+		j = skipSpaces(s, j+e+2)
+	}
+	if j >= len(s) || s[j] != ')' {
+		return "", "", "", 0, false
+	}
+	return text, unescape(dest), unescape(title), j + 1, true
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func isAutolink(s string) bool {
+	if strings.ContainsAny(s, " <>\n") {
+		return false
+	}
+	lower := strings.ToLower(s)
+	return strings.HasPrefix(lower, "http://") ||
+		strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "mailto:")
+}
+
+// safeURL 只允许 http、https、mailto 和本站的相对路径，图片不允许 mailto。
+// javascript:、data: 之类的一律拒绝
+func safeURL(raw string, image bool) (string, bool) {
+	u := strings.TrimSpace(raw)
+	// 浏览器会忽略 scheme 里面的控制字符，java\tscript: 也能执行
+	if strings.IndexFunc(u, func(c rune) bool {
+		return c < 0x20 || c == 0x7f
+	}) >= 0 {
+		return "", false
+	}
+	// 浏览器会把 \ 当成 /，//evil.com、/\evil.com 都是协议相对地址，会跳到别的站点
+	if len(u) >= 2 && (u[0] == '/' || u[0] == '\\') && (u[1] == '/' || u[1] == '\\') {
+		return "", false
+	}
+	colon := strings.IndexByte(u, ':')
+	if colon < 0 {
+		return u, true
+	}
+	// 冒号出现在路径、查询或者锚点里面，还是相对路径
+	if sep := strings.IndexAny(u, "/?#"); sep >= 0 && sep < colon {
+		return u, true
+	}
+	switch strings.ToLower(u[:colon]) {
+	case "http", "https":
+		return u, true
+	case "mailto":
+		return u, !image
+	}
+	return "", false
+}
+
+func isAbsolute(u string) bool {
+	lower := strings.ToLower(u)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+func isASCIIPunct(c byte) bool {
+	return c >= '!' && c <= '/' || c >= ':' && c <= '@' || c >= '[' && c <= '`' || c >= '{' && c <= '~'
+}
+
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,169 @@
+// Package markdown 把 Markdown 渲染成 HTML。
+// 支持的是 CommonMark 的一个常用子集：标题、段落、引用、列表、代码块、分割线，
+// 以及行内的强调、代码、链接、图片和自动链接。
+//
+// 输出天然就是安全的：原始 HTML 一律转义，只会生成白名单里面的标签，
+// 链接和图片地址只允许 http、https、mailto（图片不允许）和相对路径。
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxDepth 引用、列表和行内元素最多嵌套这么多层，防止恶意输入把栈撑爆
+const maxDepth = 32
+
+// Heading 文章里面的标题，用来生成目录
+type Heading struct {
+	Level int
+	Text  string
+	// Anchor 同时也是渲染出来的标题的 id
+	Anchor string
+}
+
+// Document 渲染结果
+type Document struct {
+	HTML string
+	// Text 去掉了所有标记的纯文本，块之间用换行分隔
+	Text     string
+	Headings []Heading
+}
+
+// Render 渲染 Markdown
+func Render(src string) Document {
+	r := &renderer{anchors: make(map[string]int)}
+	r.blocks(parseBlocks(splitLines(src), 0), false)
+	return Document{
+		HTML:     r.html.String(),
+		Text:     strings.TrimSpace(r.text.String()),
+		Headings: r.headings,
+	}
+}
+
+// PlainText 只要纯文本的时候用，例如生成摘要
+func PlainText(src string) string {
+	return Render(src).Text
+}
+
+type renderer struct {
+	html     strings.Builder
+	text     strings.Builder
+	headings []Heading
+	// 同名的标题加上序号
+	anchors map[string]int
+}
+
+// blocks tight 是紧凑列表里面的内容，段落不用 <p> 包起来
+func (r *renderer) blocks(bs []block, tight bool) {
+	for _, b := range bs {
+		r.block(b, tight)
+	}
+}
+
+func (r *renderer) block(b block, tight bool) {
+	switch b.kind {
+	case blockParagraph:
+		h, t := renderInline(b.text)
+		if tight {
+			r.html.WriteString(h)
+		} else {
+			r.html.WriteString("<p>")
+			r.html.WriteString(h)
+			r.html.WriteString("</p>\n")
+		}
+		r.writeText(t)
+	case blockHeading:
+		h, t := renderInline(b.text)
+		anchor := r.anchor(t)
+		_, _ = fmt.Fprintf(&r.html, "<h%d id=\"%s\">%s</h%d>\n",
+			b.level, html.EscapeString(anchor), h, b.level)
+		r.headings = append(r.headings, Heading{
+			Level:  b.level,
+			Text:   t,
+			Anchor: anchor,
+		})
+		r.writeText(t)
+	case blockCode:
+		r.html.WriteString("<pre><code")
+		if b.lang != "" {
+			r.html.WriteString(` class="language-`)
+			r.html.WriteString(b.lang)
+			r.html.WriteString(`"`)
+		}
+		r.html.WriteString(">")
+		r.html.WriteString(html.EscapeString(b.text))
+		r.html.WriteString("</code></pre>\n")
+		r.writeText(strings.TrimRight(b.text, "\n"))
+	case blockQuote:
+		r.html.WriteString("<blockquote>\n")
+		r.blocks(b.children, false)
+		r.html.WriteString("</blockquote>\n")
+	case blockList:
+		tag := "ul"
+		if b.ordered {
+			tag = "ol"
+		}
+		r.html.WriteString("<")
+		r.html.WriteString(tag)
+		if b.ordered && b.start != 1 {
+			r.html.WriteString(` start="`)
+			r.html.WriteString(strconv.Itoa(b.start))
+			r.html.WriteString(`"`)
+		}
+		r.html.WriteString(">\n")
+		for _, item := range b.items {
+			r.html.WriteString("<li>")
+			r.blocks(item, !b.loose)
+			r.html.WriteString("</li>\n")
+		}
+		r.html.WriteString("</")
+		r.html.WriteString(tag)
+		r.html.WriteString(">\n")
+	case blockRule:
+		r.html.WriteString("<hr>\n")
+	}
+}
+
+func (r *renderer) writeText(t string) {
+	if t == "" {
+		return
+	}
+	r.text.WriteString(t)
+	r.text.WriteByte('\n')
+}
+
+func (r *renderer) anchor(text string) string {
+	slug := slugify(text)
+	if slug == "" {
+		slug = "section"
+	}
+	n := r.anchors[slug]
+	r.anchors[slug] = n + 1
+	if n > 0 {
+		return slug + "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+// slugify 保留字母和数字（包括中文），空白和连字符变成一个连字符，其余的丢掉
+func slugify(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_':
+			if dash {
+				sb.WriteByte('-')
+				dash = false
+			}
+			sb.WriteRune(c)
+		case unicode.IsSpace(c) || c == '-':
+			dash = sb.Len() > 0
+		}
+	}
+	return sb.String()
+}
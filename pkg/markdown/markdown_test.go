@@ -0,0 +1,137 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_HTML(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+
+		want string
+	}{
+		{
+			name: "标题和段落",
+			src:  "# 标题\n\n第一行\n第二行",
+			want: "<h1 id=\"标题\">标题</h1>\n<p>第一行\n第二行</p>\n",
+		},
+		{
+			name: "强调和代码",
+			src:  "**粗体** *斜体* ***都有*** `a <b>` snake_case_name",
+			want: "<p><strong>粗体</strong> <em>斜体</em> <em><strong>都有</strong></em> " +
+				"<code>a &lt;b&gt;</code> snake_case_name</p>\n",
+		},
+		{
+			name: "嵌套强调",
+			src:  "*a **b** c*",
+			want: "<p><em>a <strong>b</strong> c</em></p>\n",
+		},
+		{
+			name: "没有闭合的标记原样输出",
+			src:  "2 * 3 = 6, **没闭合",
+			want: "<p>2 * 3 = 6, **没闭合</p>\n",
+		},
+		{
+			name: "紧凑列表",
+			src:  "- a\n- b\n  - c\n\n1. x\n2. y",
+			want: "<ul>\n<li>a</li>\n<li>b<ul>\n<li>c</li>\n</ul>\n</li>\n</ul>\n" +
+				"<ol>\n<li>x</li>\n<li>y</li>\n</ol>\n",
+		},
+		{
+			name: "松散列表",
+			src:  "3. x\n\n4. y",
+			want: "<ol start=\"3\">\n<li><p>x</p>\n</li>\n<li><p>y</p>\n</li>\n</ol>\n",
+		},
+		{
+			name: "引用",
+			src:  "> 引用\n延续\n\n正文",
+			want: "<blockquote>\n<p>引用\n延续</p>\n</blockquote>\n<p>正文</p>\n",
+		},
+		{
+			name: "代码块",
+			src:  "```go\nfmt.Println(\"<hi>\")\n```\n\n    indented",
+			want: "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>\n" +
+				"<pre><code>indented\n</code></pre>\n",
+		},
+		{
+			name: "代码块的语言不能逃逸",
+			src:  "```\"><script>\ncode\n```",
+			want: "<pre><code class=\"language-script\">code\n</code></pre>\n",
+		},
+		{
+			name: "链接和图片",
+			src:  "[站内](/articles/1) [外链](https://a.com \"标题\") ![图](/a.png) <https://b.com>",
+			want: "<p><a href=\"/articles/1\">站内</a> " +
+				"<a href=\"https://a.com\" title=\"标题\" rel=\"nofollow noopener noreferrer\" target=\"_blank\">外链</a> " +
+				"<img src=\"/a.png\" alt=\"图\"> " +
+				"<a href=\"https://b.com\" rel=\"nofollow noopener noreferrer\" target=\"_blank\">https://b.com</a></p>\n",
+		},
+		{
+			name: "原始 HTML 转义",
+			src:  "<script>alert(1)</script>\n<img src=x onerror=alert(1)>",
+			want: "<p>&lt;script&gt;alert(1)&lt;/script&gt;\n&lt;img src=x onerror=alert(1)&gt;</p>\n",
+		},
+		{
+			name: "危险链接只保留文字",
+			src:  "[a](javascript:alert(1)) [b](JAVASCRIPT:alert(1)) [c](java\u0001script:x) ![d](data:image/png;base64,xx)",
+			want: "<p>a b c d</p>\n",
+		},
+		{
+			name: "协议相对地址只保留文字",
+			src:  "[a](//evil.com) [b](/\\evil.com) [c](\\\\\\\\evil.com) ![d](\\\\/evil.com/x.png) [e](/ok)",
+			want: "<p>a b c d <a href=\"/ok\">e</a></p>\n",
+		},
+		{
+			name: "属性里面的引号",
+			src:  "[a](/x\"onclick=\"alert(1))",
+			want: "<p><a href=\"/x&#34;onclick=&#34;alert(1)\">a</a></p>\n",
+		},
+		{
+			name: "链接里面不能嵌套链接",
+			src:  "[外 [内](/b)](/a)",
+			want: "<p><a href=\"/a\">外 内</a></p>\n",
+		},
+		{
+			name: "分割线和硬换行",
+			src:  "a  \nb\\\nc\n\n***",
+			want: "<p>a<br>\nb<br>\nc</p>\n<hr>\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Render(tc.src).HTML)
+		})
+	}
+}
+
+func TestRender_TextAndHeadings(t *testing.T) {
+	doc := Render("# 入门 *指南*\n\n正文 [链接](/a) `code`\n\n## 安装\n\n## 安装\n\n### Hello, World!")
+	assert.Equal(t, "入门 指南\n正文 链接 code\n安装\n安装\nHello, World!", doc.Text)
+	assert.Equal(t, []Heading{
+		{Level: 1, Text: "入门 指南", Anchor: "入门-指南"},
+		{Level: 2, Text: "安装", Anchor: "安装"},
+		{Level: 2, Text: "安装", Anchor: "安装-1"},
+		{Level: 3, Text: "Hello, World!", Anchor: "hello-world"},
+	}, doc.Headings)
+}
+
+func TestRender_Pathological(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("*a ", 50000),
+		strings.Repeat("[", 50000),
+		strings.Repeat("`", 50000) + "a",
+		strings.Repeat(">", 50000) + "a",
+		strings.Repeat("- ", 50000) + "a",
+	}
+	for _, src := range inputs {
+		start := time.Now()
+		Render(src)
+		assert.Less(t, time.Since(start), time.Second)
+	}
+}
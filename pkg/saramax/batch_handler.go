@@ -44,6 +44,7 @@ func (b BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 					cancel()
 					return nil
 				}
+				// 反序列化失败的也要提交
 				batch = append(batch, msg)
 				var t T
 				err := json.Unmarshal(msg.Value, &t)
@@ -55,7 +56,6 @@ func (b BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 						logger.Error(err))
 					continue
 				}
-				batch = append(batch, msg)
 				ts = append(ts, t)
 			}
 		}
@@ -0,0 +1,146 @@
+package sensitive
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"webook/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// LoadWords 从文件里面读取敏感词，一行一个，# 开头的是注释
+func LoadWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// FileFilter 从文件加载敏感词，文件修改之后自动重新加载
+type FileFilter struct {
+	*ACFilter
+	path    string
+	watcher *fsnotify.Watcher
+	// 上一次加载的时候文件的状态，只在 loop 里面读写
+	last fileState
+	l    logger.Logger
+}
+
+// fileState 用来判断文件是不是真的变了。
+// real 是解析完软链接之后的路径，ConfigMap 更新的时候内容会换到新的目录里面
+type fileState struct {
+	real    string
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) (fileState, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{real: real, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+func NewFileFilter(path string, l logger.Logger) (*FileFilter, error) {
+	st, err := statFile(path)
+	if err != nil {
+		return nil, err
+	}
+	words, err := LoadWords(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileFilter{
+		ACFilter: NewACFilter(words),
+		path:     path,
+		last:     st,
+		l:        l,
+	}, nil
+}
+
+// Watch 开始监听文件变化。
+// 监听的是目录，因为很多编辑器都是替换文件，而不是原地修改。
+// k8s 的 ConfigMap 是切换目录里面的 ..data 软链接，事件里面的文件名不是敏感词文件，
+// 所以目录里面有任何变化都重新 stat 一下文件，变了才加载
+func (f *FileFilter) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	err = watcher.Add(filepath.Dir(f.path))
+	if err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	f.watcher = watcher
+	go f.loop()
+	return nil
+}
+
+func (f *FileFilter) loop() {
+	for {
+		select {
+		case evt, ok := <-f.watcher.Events:
+			if !ok {
+				return
+			}
+			if evt.Op == fsnotify.Chmod {
+				continue
+			}
+			f.reload()
+		case err, ok := <-f.watcher.Errors:
+			if !ok {
+				return
+			}
+			f.l.Error("监听敏感词文件失败", logger.Error(err))
+		}
+	}
+}
+
+// reload 文件没变的时候什么都不做，加载失败的时候保留原来的词库
+func (f *FileFilter) reload() {
+	st, err := statFile(f.path)
+	if err != nil {
+		// 替换文件的过程中可能暂时不存在，等下一个事件
+		return
+	}
+	if st == f.last {
+		return
+	}
+	words, err := LoadWords(f.path)
+	if err != nil {
+		f.l.Error("重新加载敏感词失败",
+			logger.String("path", f.path), logger.Error(err))
+		return
+	}
+	f.last = st
+	f.Reload(words)
+	f.l.Info("重新加载敏感词",
+		logger.String("path", f.path), logger.Int("cnt", len(words)))
+}
+
+func (f *FileFilter) Close() error {
+	if f.watcher == nil {
+		return nil
+	}
+	return f.watcher.Close()
+}
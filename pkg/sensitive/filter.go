@@ -0,0 +1,31 @@
+package sensitive
+
+import (
+	"sync/atomic"
+)
+
+// Filter 敏感词过滤，可以替换成调用第三方内容审核服务的实现
+type Filter interface {
+	// Find 返回文本里面命中的敏感词，没有命中返回空
+	Find(text string) []string
+}
+
+// ACFilter 基于 Matcher 的实现，Reload 的时候整个替换掉 Matcher，
+// 不影响正在进行的匹配
+type ACFilter struct {
+	matcher atomic.Pointer[Matcher]
+}
+
+func NewACFilter(words []string) *ACFilter {
+	f := &ACFilter{}
+	f.Reload(words)
+	return f
+}
+
+func (f *ACFilter) Find(text string) []string {
+	return f.matcher.Load().Find(text)
+}
+
+func (f *ACFilter) Reload(words []string) {
+	f.matcher.Store(NewMatcher(words))
+}
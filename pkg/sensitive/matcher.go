@@ -0,0 +1,134 @@
+package sensitive
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Matcher Aho-Corasick 自动机，一次扫描就能找出文本里面所有的敏感词。
+// 匹配不区分大小写，构建之后是只读的，可以并发使用
+type Matcher struct {
+	nodes []node
+}
+
+type node struct {
+	next map[rune]int32
+	// 失配的时候跳转到的节点，也就是当前前缀的最长真后缀
+	fail int32
+	// 以这个节点结尾的词，包括通过 fail 链可以到达的，下标是 words 的
+	outputs []string
+}
+
+// NewMatcher 空白的词会被忽略
+func NewMatcher(words []string) *Matcher {
+	m := &Matcher{nodes: []node{{}}}
+	for _, w := range words {
+		w = normalize(strings.TrimSpace(w))
+		if w == "" {
+			continue
+		}
+		m.insert(w)
+	}
+	m.build()
+	return m
+}
+
+func (m *Matcher) insert(word string) {
+	var cur int32
+	for _, r := range word {
+		nxt, ok := m.nodes[cur].next[r]
+		if !ok {
+			if m.nodes[cur].next == nil {
+				m.nodes[cur].next = make(map[rune]int32)
+			}
+			m.nodes = append(m.nodes, node{})
+			nxt = int32(len(m.nodes) - 1)
+			m.nodes[cur].next[r] = nxt
+		}
+		cur = nxt
+	}
+	for _, o := range m.nodes[cur].outputs {
+		if o == word {
+			return
+		}
+	}
+	m.nodes[cur].outputs = append(m.nodes[cur].outputs, word)
+}
+
+// build 按照 BFS 的顺序计算 fail 指针，父节点的 fail 一定先算好
+func (m *Matcher) build() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			f := m.nodes[cur].fail
+			for f > 0 {
+				if _, ok := m.nodes[f].next[r]; ok {
+					break
+				}
+				f = m.nodes[f].fail
+			}
+			if nxt, ok := m.nodes[f].next[r]; ok && nxt != child {
+				m.nodes[child].fail = nxt
+			}
+			fail := m.nodes[child].fail
+			m.nodes[child].outputs = append(m.nodes[child].outputs, m.nodes[fail].outputs...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Find 找出文本里面出现的所有敏感词，每个词只返回一次，顺序是第一次出现的位置
+func (m *Matcher) Find(text string) []string {
+	var (
+		res  []string
+		seen map[string]struct{}
+		cur  int32
+	)
+	for _, r := range normalize(text) {
+		cur = m.step(cur, r)
+		for _, o := range m.nodes[cur].outputs {
+			if seen == nil {
+				seen = make(map[string]struct{})
+			}
+			if _, ok := seen[o]; ok {
+				continue
+			}
+			seen[o] = struct{}{}
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// Contains 有敏感词就提前返回，比 Find 快
+func (m *Matcher) Contains(text string) bool {
+	var cur int32
+	for _, r := range normalize(text) {
+		cur = m.step(cur, r)
+		if len(m.nodes[cur].outputs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Matcher) step(cur int32, r rune) int32 {
+	for {
+		if nxt, ok := m.nodes[cur].next[r]; ok {
+			return nxt
+		}
+		if cur == 0 {
+			return 0
+		}
+		cur = m.nodes[cur].fail
+	}
+}
+
+func normalize(s string) string {
+	return strings.Map(unicode.ToLower, s)
+}
@@ -0,0 +1,113 @@
+package sensitive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"webook/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_Find(t *testing.T) {
+	testCases := []struct {
+		name  string
+		words []string
+		text  string
+
+		want []string
+	}{
+		{
+			name:  "经典例子",
+			words: []string{"he", "she", "his", "hers"},
+			text:  "ushers",
+			want:  []string{"she", "he", "hers"},
+		},
+		{
+			name:  "中文",
+			words: []string{"管理员", "官方", "官方客服"},
+			text:  "我是官方客服，不是管理员",
+			want:  []string{"官方", "官方客服", "管理员"},
+		},
+		{
+			name:  "不区分大小写",
+			words: []string{"Admin"},
+			text:  "ADMIN_01",
+			want:  []string{"admin"},
+		},
+		{
+			name:  "重复出现只返回一次",
+			words: []string{"ab"},
+			text:  "abab",
+			want:  []string{"ab"},
+		},
+		{
+			name:  "失配之后回退",
+			words: []string{"abcd", "bc"},
+			text:  "abce",
+			want:  []string{"bc"},
+		},
+		{
+			name:  "没有命中",
+			words: []string{"abc", ""},
+			text:  "ab",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMatcher(tc.words)
+			assert.Equal(t, tc.want, m.Find(tc.text))
+			assert.Equal(t, len(tc.want) > 0, m.Contains(tc.text))
+		})
+	}
+}
+
+func TestFileFilter_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# 注释\n管理员\n"), 0644))
+	f, err := NewFileFilter(path, logger.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, f.Watch())
+	defer f.Close()
+	assert.Equal(t, []string{"管理员"}, f.Find("我是管理员"))
+
+	// 模拟编辑器先写临时文件再替换
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, []byte("官方\n"), 0644))
+	require.NoError(t, os.Rename(tmp, path))
+	assert.Eventually(t, func() bool {
+		return len(f.Find("官方")) == 1
+	}, time.Second*3, time.Millisecond*10)
+	assert.Empty(t, f.Find("我是管理员"))
+}
+
+func TestFileFilter_WatchConfigMap(t *testing.T) {
+	// k8s 挂载 ConfigMap 的目录结构：
+	// words.txt -> ..data/words.txt，..data -> ..v1，更新的时候把 ..data 换成指向 ..v2
+	dir := t.TempDir()
+	writeVersion := func(version, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, "words.txt"), []byte(content), 0644))
+	}
+	writeVersion("..v1", "管理员\n")
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	path := filepath.Join(dir, "words.txt")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "words.txt"), path))
+
+	f, err := NewFileFilter(path, logger.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, f.Watch())
+	defer f.Close()
+	assert.Equal(t, []string{"管理员"}, f.Find("我是管理员"))
+
+	writeVersion("..v2", "官方\n")
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	assert.Eventually(t, func() bool {
+		return len(f.Find("官方")) == 1
+	}, time.Second*3, time.Millisecond*10)
+	assert.Empty(t, f.Find("我是管理员"))
+}
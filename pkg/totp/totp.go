@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 按照 RFC 6238 实现的 TOTP，参数和 Google Authenticator 之类的 App 默认值保持一致
+const (
+	digits = 6
+	period = 30
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret 生成一个 base32 编码的密钥
+func GenerateSecret() (string, error) {
+	// RFC 4226 推荐 160 位
+	key := make([]byte, 20)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(key), nil
+}
+
+// Code 计算 t 时刻的验证码
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, counter(t), digits), nil
+}
+
+// Validate 校验验证码，skew 是允许前后偏差的时间窗口数量，用来容忍客户端的时钟误差
+func Validate(secret, code string, t time.Time, skew int) bool {
+	_, ok := Match(secret, code, t, skew)
+	return ok
+}
+
+// Match 和 Validate 一样，同时返回验证码所在的时间窗口。
+// 调用方记下用过的最大窗口，拒绝不大于它的验证码，防止同一个验证码被重放
+func Match(secret, code string, t time.Time, skew int) (int64, bool) {
+	if len(code) != digits {
+		return 0, false
+	}
+	key, err := decode(secret)
+	if err != nil {
+		return 0, false
+	}
+	c := int64(counter(t))
+	for i := -skew; i <= skew; i++ {
+		step := c + int64(i)
+		expected := hotp(key, uint64(step), digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// URI 生成 otpauth:// 格式的链接，前端把它转成二维码给 App 扫
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", digits))
+	params.Set("period", fmt.Sprintf("%d", period))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+func decode(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return b32.DecodeString(strings.TrimRight(secret, "="))
+}
+
+func counter(t time.Time) uint64 {
+	return uint64(t.Unix() / period)
+}
+
+// hotp RFC 4226
+func hotp(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, bin%mod)
+}
@@ -0,0 +1,83 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 附录 B 里面 SHA1 的测试数据
+func TestHOTP_RFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	testCases := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "94287082"},
+		{unix: 1111111109, want: "07081804"},
+		{unix: 1111111111, want: "14050471"},
+		{unix: 1234567890, want: "89005924"},
+		{unix: 2000000000, want: "69279037"},
+		{unix: 20000000000, want: "65353130"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			assert.Equal(t, tc.want, hotp(key, counter(time.Unix(tc.unix, 0)), 8))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	assert.Len(t, code, 6)
+	assert.True(t, Validate(secret, code, now, 1))
+
+	// 上一个窗口的验证码，允许一个窗口的误差
+	prev, err := Code(secret, now.Add(-time.Second*period))
+	require.NoError(t, err)
+	assert.True(t, Validate(secret, prev, now, 1))
+	assert.False(t, Validate(secret, prev, now, 0) && prev != code)
+
+	// 很久以前的验证码
+	old, err := Code(secret, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, Validate(secret, old, now, 1) && old != code)
+
+	assert.False(t, Validate(secret, "12345", now, 1))
+	assert.False(t, Validate("not base32!", "123456", now, 1))
+}
+
+func TestMatch(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	step, ok := Match(secret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/period, step)
+
+	// 下一个窗口里面还能通过，但是窗口还是原来的，调用方靠它拒绝重放
+	later, ok := Match(secret, code, now.Add(time.Second*period), 1)
+	assert.True(t, ok)
+	assert.Equal(t, step, later)
+
+	_, ok = Match(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("webook", "123@qq.com", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/webook:123@qq.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=webook")
+}
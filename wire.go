@@ -26,10 +26,12 @@ func InitWebServer() *App {
 		ioc.InitSyncProducer,
 		ioc.InitConsumers,
 		ioc.InitKafkaPrometheus,
+		ioc.InitScheduler,
 
 		article.NewInteractiveReadEventConsumer,
 		article.NewInteractiveLikeEventConsumer,
 		article.NewInteractiveUnLikeEventConsumer,
+		article.NewReadingHistoryConsumer,
 
 		// article.NewInteractiveReadEventBatchConsumer,
 		article.NewSaramaSyncProducer,
@@ -39,31 +41,85 @@ func InitWebServer() *App {
 		//dao.NewArticleGORMDAO,
 		dao.NewMongoDBArticleDAO,
 		dao.NewGORMInteractiveDAO,
+		dao.NewGORMLoginLogDAO,
+		dao.NewGORMPrivacyDAO,
+		dao.NewGORMArticleReviewDAO,
+		//dao.NewGORMSeriesDAO,
+		dao.NewMongoDBSeriesDAO,
+		dao.NewGORMArticleImportDAO,
+		dao.NewGORMReadingHistoryDAO,
+		dao.NewGORMLedgerDAO,
 
 		// cache 部分
 		cache.NewCodeCache, cache.NewUserCache,
 		cache.NewArticleRedisCache,
+		cache.NewArticleAutosaveCache,
 		cache.NewInteractiveRedisCache,
+		ioc.InitInteractiveStateCache,
+		cache.NewLoginLockCache,
+		cache.NewPrivacyCache,
+		cache.NewFeedCache,
+		cache.NewArticleImportCache,
 
 		// repository 部分
 		repository.NewCachedUserRepository,
 		repository.NewCodeRepository,
 		repository.NewCachedArticleRepository,
 		repository.NewCachedInteractiveRepository,
+		repository.NewLoginLogRepository,
+		repository.NewLoginLockRepository,
+		repository.NewCachedPrivacyRepository,
+		repository.NewArticleReviewRepository,
+		repository.NewSeriesRepository,
+		repository.NewCachedFeedRepository,
+		repository.NewCachedArticleImportRepository,
+		repository.NewCachedReadingHistoryRepository,
+		repository.NewLedgerRepository,
 
 		// Service 部分
 		ioc.InitSMSService,
+		ioc.InitEmailService,
 		ioc.InitWechatService,
+		ioc.InitOAuth2Registry,
+		ioc.InitMediaStorage,
+		ioc.InitSensitiveFilter,
+		ioc.InitModerationChecker,
+		ioc.InitPaymentGateway,
+		ioc.InitInteractiveRegistry,
 		service.NewUserService,
 		service.NewCodeService,
 		service.NewArticleService,
 		service.NewInteractiveService,
+		service.NewAccountService,
+		service.NewLoginAuditService,
+		ioc.InitLoginGuardService,
+		service.NewMediaService,
+		service.NewPrivacyService,
+		service.NewArticleReviewService,
+		service.NewSeriesService,
+		ioc.InitFeedService,
+		service.NewArticleArchiveService,
+		service.NewReadingHistoryService,
+		service.NewRewardService,
 
 		// handler 部分
 		web.NewUserHandler,
 		web.NewArticleHandler,
 		ijwt.NewRedisJWTHandler,
 		web.NewOAuth2WechatHandler,
+		ioc.InitAdminHandler,
+		ioc.InitOAuth2Handler,
+		web.NewAccountHandler,
+		web.NewLoginAuditHandler,
+		ioc.InitMediaHandler,
+		web.NewAuthorHandler,
+		web.NewPrivacyHandler,
+		web.NewSeriesHandler,
+		web.NewFeedHandler,
+		web.NewArticleArchiveHandler,
+		web.NewReadingHistoryHandler,
+		web.NewRewardHandler,
+		web.NewInteractiveHandler,
 		ioc.InitGinMiddlewares,
 		ioc.InitWebServer,
 
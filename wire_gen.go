@@ -28,38 +28,94 @@ func InitWebServer() *App {
 	userDAO := dao.NewUserDAO(db)
 	userCache := cache.NewUserCache(cmdable)
 	userRepository := repository.NewCachedUserRepository(userDAO, userCache)
-	userService := service.NewUserService(userRepository)
+	emailService := ioc.InitEmailService()
+	filter := ioc.InitSensitiveFilter(logger)
+	userService := service.NewUserService(userRepository, emailService, filter)
 	codeCache := cache.NewCodeCache(cmdable)
 	codeRepository := repository.NewCodeRepository(codeCache)
 	smsService := ioc.InitSMSService()
-	codeService := service.NewCodeService(codeRepository, smsService)
-	userHandler := web.NewUserHandler(userService, codeService, handler)
+	codeService := service.NewCodeService(codeRepository, smsService, emailService)
+	loginLogDAO := dao.NewGORMLoginLogDAO(db)
+	loginLogRepository := repository.NewLoginLogRepository(loginLogDAO)
+	loginAuditService := service.NewLoginAuditService(loginLogRepository, userRepository, codeService, logger)
+	loginLockCache := cache.NewLoginLockCache(cmdable)
+	loginLockRepository := repository.NewLoginLockRepository(loginLockCache)
+	loginGuardService := ioc.InitLoginGuardService(cmdable, loginLockRepository, userRepository)
+	storageStorage := ioc.InitMediaStorage()
+	mediaService := service.NewMediaService(storageStorage)
+	userHandler := web.NewUserHandler(userService, codeService, loginAuditService, loginGuardService, mediaService, handler)
 	database := ioc.InitMongoDB()
 	node := ioc.InitSnowFlake()
 	articleDAO := dao.NewMongoDBArticleDAO(database, node)
 	articleCache := cache.NewArticleRedisCache(cmdable)
-	articleRepository := repository.NewCachedArticleRepository(articleDAO, userRepository, articleCache)
+	articleAutosaveCache := cache.NewArticleAutosaveCache(cmdable)
+	feedCache := cache.NewFeedCache(cmdable)
+	articleRepository := repository.NewCachedArticleRepository(articleDAO, userRepository, articleCache, articleAutosaveCache, feedCache)
 	client := ioc.InitSaramaClient()
 	syncProducer := ioc.InitSyncProducer(client)
 	producer := article.NewSaramaSyncProducer(syncProducer)
-	articleService := service.NewArticleService(articleRepository, producer)
+	articleReviewDAO := dao.NewGORMArticleReviewDAO(db)
+	articleReviewRepository := repository.NewArticleReviewRepository(articleReviewDAO)
+	checker := ioc.InitModerationChecker(filter)
 	interactiveDAO := dao.NewGORMInteractiveDAO(db)
 	interactiveCache := cache.NewInteractiveRedisCache(cmdable)
-	interactiveRepository := repository.NewCachedInteractiveRepository(interactiveDAO, logger, interactiveCache)
-	interactiveService := service.NewInteractiveService(interactiveRepository, producer, logger)
-	articleHandler := web.NewArticleHandler(logger, articleService, interactiveService)
+	interactiveStateCache := ioc.InitInteractiveStateCache(cmdable)
+	interactiveRepository := repository.NewCachedInteractiveRepository(interactiveDAO, logger, interactiveCache, interactiveStateCache)
+	articleImportDAO := dao.NewGORMArticleImportDAO(db)
+	articleImportCache := cache.NewArticleImportCache(cmdable)
+	articleImportRepository := repository.NewCachedArticleImportRepository(articleImportDAO, articleImportCache)
+	articleService := service.NewArticleService(articleRepository, userRepository, articleReviewRepository, interactiveRepository, articleImportRepository, checker, producer, logger)
+	privacyDAO := dao.NewGORMPrivacyDAO(db)
+	privacyCache := cache.NewPrivacyCache(cmdable)
+	privacyRepository := repository.NewCachedPrivacyRepository(privacyDAO, privacyCache)
+	privacyService := service.NewPrivacyService(privacyRepository, userRepository)
+	seriesDAO := dao.NewMongoDBSeriesDAO(database, node)
+	seriesRepository := repository.NewSeriesRepository(seriesDAO)
+	registry := ioc.InitInteractiveRegistry(articleRepository, seriesRepository)
+	interactiveService := service.NewInteractiveService(interactiveRepository, producer, registry, privacyService, logger)
+	seriesService := service.NewSeriesService(seriesRepository, articleRepository, logger)
+	readingHistoryDAO := dao.NewGORMReadingHistoryDAO(db)
+	readingHistoryRepository := repository.NewCachedReadingHistoryRepository(readingHistoryDAO)
+	readingHistoryService := service.NewReadingHistoryService(readingHistoryRepository, articleRepository, producer, logger)
+	ledgerDAO := dao.NewGORMLedgerDAO(db)
+	ledgerRepository := repository.NewLedgerRepository(ledgerDAO, interactiveCache, logger)
+	gateway := ioc.InitPaymentGateway()
+	rewardService := service.NewRewardService(ledgerRepository, articleRepository, gateway, logger)
+	articleHandler := web.NewArticleHandler(logger, articleService, interactiveService, privacyService, seriesService, readingHistoryService, rewardService)
 	wechatService := ioc.InitWechatService()
-	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, userService, handler)
-	engine := ioc.InitWebServer(v, userHandler, articleHandler, oAuth2WechatHandler)
+	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, userService, loginAuditService, handler)
+	accountService := service.NewAccountService(userRepository, articleRepository, interactiveRepository, readingHistoryRepository)
+	articleReviewService := service.NewArticleReviewService(articleReviewRepository, articleRepository, userRepository, emailService, logger)
+	adminHandler := ioc.InitAdminHandler(accountService, loginGuardService, articleReviewService, handler, logger)
+	oauth2Registry := ioc.InitOAuth2Registry()
+	oAuth2Handler := ioc.InitOAuth2Handler(oauth2Registry, userService, loginAuditService, handler)
+	accountHandler := web.NewAccountHandler(accountService, handler, logger)
+	loginAuditHandler := web.NewLoginAuditHandler(loginAuditService, handler)
+	mediaHandler := ioc.InitMediaHandler(mediaService, storageStorage)
+	authorHandler := web.NewAuthorHandler(userService, articleService, mediaService, privacyService, interactiveService, logger)
+	privacyHandler := web.NewPrivacyHandler(privacyService, logger)
+	seriesHandler := web.NewSeriesHandler(seriesService, privacyService, logger)
+	feedRepository := repository.NewCachedFeedRepository(feedCache)
+	feedService := ioc.InitFeedService(feedRepository, articleRepository, userRepository, logger)
+	feedHandler := web.NewFeedHandler(feedService, privacyService, logger)
+	articleArchiveService := service.NewArticleArchiveService(articleImportRepository, articleRepository, articleService, logger)
+	articleArchiveHandler := web.NewArticleArchiveHandler(articleArchiveService, logger)
+	readingHistoryHandler := web.NewReadingHistoryHandler(readingHistoryService, logger)
+	rewardHandler := web.NewRewardHandler(rewardService, logger)
+	interactiveHandler := web.NewInteractiveHandler(interactiveService, logger)
+	engine := ioc.InitWebServer(v, userHandler, articleHandler, oAuth2WechatHandler, adminHandler, oAuth2Handler, accountHandler, loginAuditHandler, mediaHandler, authorHandler, privacyHandler, seriesHandler, feedHandler, articleArchiveHandler, readingHistoryHandler, rewardHandler, interactiveHandler)
 	interactiveReadEventConsumer := article.NewInteractiveReadEventConsumer(interactiveRepository, client, logger)
 	interactiveLikeEventConsumer := article.NewInteractiveLikeEventConsumer(interactiveRepository, client, logger)
 	interactiveUnLikeEventConsumer := article.NewInteractiveUnLikeEventConsumer(interactiveRepository, client, logger)
-	v2 := ioc.InitConsumers(interactiveReadEventConsumer, interactiveLikeEventConsumer, interactiveUnLikeEventConsumer)
+	readingHistoryConsumer := article.NewReadingHistoryConsumer(readingHistoryRepository, client, logger)
+	v2 := ioc.InitConsumers(interactiveReadEventConsumer, interactiveLikeEventConsumer, interactiveUnLikeEventConsumer, readingHistoryConsumer)
 	monitorMessage := ioc.InitKafkaPrometheus(client)
+	scheduler := ioc.InitScheduler(logger, accountService, articleService)
 	app := &App{
 		server:       engine,
 		consumers:    v2,
 		kafkaMonitor: monitorMessage,
+		scheduler:    scheduler,
 	}
 	return app
 }